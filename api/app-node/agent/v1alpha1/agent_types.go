@@ -31,14 +31,28 @@ type AgentSpec struct {
 	AgentConfig `json:",inline"`
 }
 
+const (
+	// AgentTypeZeroShot parses the ReAct style text output of llm to decide which tool to use
+	AgentTypeZeroShot = "zeroShot"
+	// AgentTypeConversational is a ReAct agent which is optimized for conversation, the chat history is used as memory
+	AgentTypeConversational = "conversational"
+	// AgentTypePlanAndExecute makes a plan of steps first, then runs each step with a zero-shot agent
+	AgentTypePlanAndExecute = "planAndExecute"
+	// AgentTypeToolCalling uses the native tool(function) calling of llm, the llm must support it
+	AgentTypeToolCalling = "toolCalling"
+)
+
 type AgentConfig struct {
-	// type, can be zeroShot or conversational
+	// type, can be zeroShot, conversational, planAndExecute or toolCalling
 	//+kubebuilder:default="zeroShot"
+	//+kubebuilder:validation:Enum=zeroShot;conversational;planAndExecute;toolCalling
 	Type string `json:"type,omitempty"`
 	// Prompt used to instruct the LLM of agent
 	Prompt string `json:"prompt,omitempty"`
 	// list of allowed tools for this agent
-	AllowedTools []Tool `json:"allowedTools,omitempty"`
+	AllowedTools []AllowedTool `json:"allowedTools,omitempty"`
+	// MCPServers are the Model Context Protocol servers, their tools are used alongside the allowed tools
+	MCPServers []MCPServer `json:"mcpServers,omitempty"`
 	// http action like get/post
 	Options Options `json:"options,omitempty"`
 }
@@ -52,6 +66,9 @@ type Options struct {
 	// +kubebuilder:validation:Maximum=10
 	// +kubebuilder:default=5
 	MaxIterations int `json:"maxIterations,omitempty"`
+	// GiveUpMessage is the answer when the agent fails or doesn't finish in MaxIterations,
+	// the last result of tools is appended to it as a partial answer
+	GiveUpMessage string `json:"giveUpMessage,omitempty"`
 	// Memory for chain memory
 	Memory chainv1alpha1.Memory `json:"memory,omitempty"`
 	// The options below might be used later
@@ -64,12 +81,43 @@ type Options struct {
 	// memory schema.Memory
 }
 
-// Tool/Capability that this agent will use
-type Tool struct {
-	// Name of the tool
+// AllowedTool is a tool/capability that this agent will use
+type AllowedTool struct {
+	// Name of the tool, it is the type of tool implementation when Ref is not set
 	Name string `json:"name,omitempty"`
 	// Map of key/value that will be passed to the tool
 	Params map[string]string `json:"params,omitempty"`
+	// Ref references a Tool CR, Name and Params are ignored if it is set.
+	// The namespace of agent is used if the namespace of reference is empty.
+	Ref *v1alpha1.TypedObjectReference `json:"ref,omitempty"`
+	// RequiresApproval pauses the agent before calling the tool until the user approves or rejects the call,
+	// it should be set for the tools which change something, like creating tickets or sending POST requests
+	RequiresApproval bool `json:"requiresApproval,omitempty"`
+}
+
+// MCPServer is a Model Context Protocol server, one of URL and Command must be set
+type MCPServer struct {
+	// Name of the server, it is the prefix of the tool names
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_-]+$`
+	Name string `json:"name"`
+	// URL of the streamable HTTP endpoint, like http://mcp-server.default:8080/mcp
+	URL string `json:"url,omitempty"`
+	// Command starts the server with stdio transport,
+	// the binary is usually provided by a sidecar or an init container through a shared volume
+	Command []string `json:"command,omitempty"`
+	// Env of the command
+	Env map[string]string `json:"env,omitempty"`
+	// AuthSecret is the secret for auth, every key/value is sent as a http header with the URL,
+	// or added to the environment of the command
+	AuthSecret *v1alpha1.TypedObjectReference `json:"authSecret,omitempty"`
+	// Tools is the allowlist of tools, all tools of the server are used if it is empty
+	Tools []string `json:"tools,omitempty"`
+	// RequiresApproval pauses the agent before calling the tools of the server until the user approves or rejects the call
+	RequiresApproval bool `json:"requiresApproval,omitempty"`
+	// TimeoutSeconds limits connecting to the server and each tool call
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=30
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 // AgentStatus defines the observed state of Agent
@@ -22,6 +22,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	basev1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -57,7 +58,14 @@ func (in *AgentConfig) DeepCopyInto(out *AgentConfig) {
 	*out = *in
 	if in.AllowedTools != nil {
 		in, out := &in.AllowedTools, &out.AllowedTools
-		*out = make([]Tool, len(*in))
+		*out = make([]AllowedTool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MCPServers != nil {
+		in, out := &in.MCPServers, &out.MCPServers
+		*out = make([]MCPServer, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -141,39 +149,81 @@ func (in *AgentStatus) DeepCopy() *AgentStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Options) DeepCopyInto(out *Options) {
+func (in *AllowedTool) DeepCopyInto(out *AllowedTool) {
 	*out = *in
-	in.Memory.DeepCopyInto(&out.Memory)
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Ref != nil {
+		in, out := &in.Ref, &out.Ref
+		*out = new(basev1alpha1.TypedObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Options.
-func (in *Options) DeepCopy() *Options {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedTool.
+func (in *AllowedTool) DeepCopy() *AllowedTool {
 	if in == nil {
 		return nil
 	}
-	out := new(Options)
+	out := new(AllowedTool)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Tool) DeepCopyInto(out *Tool) {
+func (in *MCPServer) DeepCopyInto(out *MCPServer) {
 	*out = *in
-	if in.Params != nil {
-		in, out := &in.Params, &out.Params
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
+	if in.AuthSecret != nil {
+		in, out := &in.AuthSecret, &out.AuthSecret
+		*out = new(basev1alpha1.TypedObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Tool.
-func (in *Tool) DeepCopy() *Tool {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServer.
+func (in *MCPServer) DeepCopy() *MCPServer {
 	if in == nil {
 		return nil
 	}
-	out := new(Tool)
+	out := new(MCPServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Options) DeepCopyInto(out *Options) {
+	*out = *in
+	in.Memory.DeepCopyInto(&out.Memory)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Options.
+func (in *Options) DeepCopy() *Options {
+	if in == nil {
+		return nil
+	}
+	out := new(Options)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -78,6 +78,17 @@ type Memory struct {
 	// +kubebuilder:validation:Maximum=30
 	// +kubebuilder:default=5
 	ConversionWindowSize *int `json:"conversionWindowSize,omitempty"`
+	// SummaryBuffer summarizes the older rounds by the llm and keeps the summary with the recent rounds.
+	// The summary is saved with the conversation and updated incrementally. It is used instead of MaxTokenLimit and ConversionWindowSize.
+	SummaryBuffer *SummaryBuffer `json:"summaryBuffer,omitempty"`
+}
+
+type SummaryBuffer struct {
+	// RecentRounds is the number of the latest conversation rounds kept as they are, the earlier ones are summarized.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +kubebuilder:default=5
+	RecentRounds int `json:"recentRounds,omitempty"`
 }
 
 // LLMChainStatus defines the observed state of LLMChain
@@ -258,6 +258,11 @@ func (in *Memory) DeepCopyInto(out *Memory) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.SummaryBuffer != nil {
+		in, out := &in.SummaryBuffer, &out.SummaryBuffer
+		*out = new(SummaryBuffer)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Memory.
@@ -361,3 +366,18 @@ func (in *RetrievalQAChainStatus) DeepCopy() *RetrievalQAChainStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SummaryBuffer) DeepCopyInto(out *SummaryBuffer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SummaryBuffer.
+func (in *SummaryBuffer) DeepCopy() *SummaryBuffer {
+	if in == nil {
+		return nil
+	}
+	out := new(SummaryBuffer)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const DefaultRefusalMessage = "Sorry, I can not answer this question."
+
+type CommonGuardrailConfig struct {
+	// RefusalMessage is the answer when the guardrail refuses, like docNullReturn of the application,
+	// the chain is not called if the question is refused.
+	// +kubebuilder:default:="Sorry, I can not answer this question."
+	RefusalMessage string `json:"refusalMessage,omitempty"`
+	// Blocklist refuses the text containing any of the keywords or matching any of the patterns
+	Blocklist *Blocklist `json:"blocklist,omitempty"`
+}
+
+type Blocklist struct {
+	// Keywords are matched case-insensitively
+	Keywords []string `json:"keywords,omitempty"`
+	// Patterns are regular expressions in RE2 syntax, such as (?i)password\s*[:=]
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// Compile compiles the patterns of the blocklist
+func (b *Blocklist) Compile() ([]*regexp.Regexp, error) {
+	if b == nil {
+		return nil, nil
+	}
+	res := make([]*regexp.Regexp, 0, len(b.Patterns))
+	for _, p := range b.Patterns {
+		r, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocklist pattern %q: %w", p, err)
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// GetRefusalMessage returns the refusal message, or the default one if it is empty
+func (c CommonGuardrailConfig) GetRefusalMessage() string {
+	if c.RefusalMessage == "" {
+		return DefaultRefusalMessage
+	}
+	return c.RefusalMessage
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	node "github.com/kubeagi/arcadia/api/app-node"
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+)
+
+// InputGuardrailSpec defines the desired state of InputGuardrail
+type InputGuardrailSpec struct {
+	v1alpha1.CommonSpec   `json:",inline"`
+	CommonGuardrailConfig `json:",inline"`
+	// TopicAllowlist refuses the questions not about any of the topics, which are classified by a llm
+	TopicAllowlist *TopicAllowlist `json:"topicAllowlist,omitempty"`
+}
+
+type TopicAllowlist struct {
+	// Topics are the allowed topics described in natural language, such as "travel reimbursement"
+	// +kubebuilder:validation:MinItems=1
+	Topics []string `json:"topics"`
+	// LLM classifies the questions, a small and fast model is enough
+	// +kubebuilder:validation:Required
+	LLM *v1alpha1.TypedObjectReference `json:"llm"`
+	// Model is the model of the llm, the first model of the llm is used if it is empty
+	Model string `json:"model,omitempty"`
+}
+
+// InputGuardrailStatus defines the observed state of InputGuardrail
+type InputGuardrailStatus struct {
+	// ObservedGeneration is the last observed generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ConditionedStatus is the current status
+	v1alpha1.ConditionedStatus `json:",inline"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// InputGuardrail is the Schema for the InputGuardrail API, it checks the questions before the chain
+type InputGuardrail struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   InputGuardrailSpec   `json:"spec,omitempty"`
+	Status InputGuardrailStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// InputGuardrailList contains a list of InputGuardrail
+type InputGuardrailList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []InputGuardrail `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&InputGuardrail{}, &InputGuardrailList{})
+}
+
+var _ node.Node = (*InputGuardrail)(nil)
+
+func (c *InputGuardrail) SetRef() {
+	annotations := node.SetRefAnnotations(c.GetAnnotations(), []node.Ref{node.InputRef.Len(1)}, []node.Ref{node.CommonRef.Len(1)})
+	if c.GetAnnotations() == nil {
+		c.SetAnnotations(annotations)
+	}
+	for k, v := range annotations {
+		c.Annotations[k] = v
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	node "github.com/kubeagi/arcadia/api/app-node"
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+)
+
+// OutputGuardrailSpec defines the desired state of OutputGuardrail
+type OutputGuardrailSpec struct {
+	v1alpha1.CommonSpec   `json:",inline"`
+	CommonGuardrailConfig `json:",inline"`
+	// Grounding refuses the answers not supported by the references of the retrievers
+	Grounding *Grounding `json:"grounding,omitempty"`
+}
+
+type Grounding struct {
+	// LLM judges whether the answer is supported by the references.
+	// If it is empty, the words of the answer are looked up in the references instead.
+	LLM *v1alpha1.TypedObjectReference `json:"llm,omitempty"`
+	// Model is the model of the llm, the first model of the llm is used if it is empty
+	Model string `json:"model,omitempty"`
+	// MinScore is the minimum ratio of the words of the answer found in the references, only used without llm
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	// +kubebuilder:default:=0.5
+	MinScore float64 `json:"minScore,omitempty"`
+}
+
+// OutputGuardrailStatus defines the observed state of OutputGuardrail
+type OutputGuardrailStatus struct {
+	// ObservedGeneration is the last observed generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ConditionedStatus is the current status
+	v1alpha1.ConditionedStatus `json:",inline"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// OutputGuardrail is the Schema for the OutputGuardrail API, it checks the answers of the chain.
+// The answer is streamed after it passes the check, instead of chunk by chunk, so a refused answer is never shown.
+type OutputGuardrail struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OutputGuardrailSpec   `json:"spec,omitempty"`
+	Status OutputGuardrailStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// OutputGuardrailList contains a list of OutputGuardrail
+type OutputGuardrailList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OutputGuardrail `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OutputGuardrail{}, &OutputGuardrailList{})
+}
+
+var _ node.Node = (*OutputGuardrail)(nil)
+
+func (c *OutputGuardrail) SetRef() {
+	annotations := node.SetRefAnnotations(c.GetAnnotations(), []node.Ref{node.ChainRef.Len(1)}, []node.Ref{node.OutputRef.Len(1)})
+	if c.GetAnnotations() == nil {
+		c.SetAnnotations(annotations)
+	}
+	for k, v := range annotations {
+		c.Annotations[k] = v
+	}
+}
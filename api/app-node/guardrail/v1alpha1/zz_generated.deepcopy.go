@@ -0,0 +1,311 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2023 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	basev1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Blocklist) DeepCopyInto(out *Blocklist) {
+	*out = *in
+	if in.Keywords != nil {
+		in, out := &in.Keywords, &out.Keywords
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Blocklist.
+func (in *Blocklist) DeepCopy() *Blocklist {
+	if in == nil {
+		return nil
+	}
+	out := new(Blocklist)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CommonGuardrailConfig) DeepCopyInto(out *CommonGuardrailConfig) {
+	*out = *in
+	if in.Blocklist != nil {
+		in, out := &in.Blocklist, &out.Blocklist
+		*out = new(Blocklist)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonGuardrailConfig.
+func (in *CommonGuardrailConfig) DeepCopy() *CommonGuardrailConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CommonGuardrailConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Grounding) DeepCopyInto(out *Grounding) {
+	*out = *in
+	if in.LLM != nil {
+		in, out := &in.LLM, &out.LLM
+		*out = new(basev1alpha1.TypedObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Grounding.
+func (in *Grounding) DeepCopy() *Grounding {
+	if in == nil {
+		return nil
+	}
+	out := new(Grounding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputGuardrail) DeepCopyInto(out *InputGuardrail) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputGuardrail.
+func (in *InputGuardrail) DeepCopy() *InputGuardrail {
+	if in == nil {
+		return nil
+	}
+	out := new(InputGuardrail)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InputGuardrail) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputGuardrailList) DeepCopyInto(out *InputGuardrailList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]InputGuardrail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputGuardrailList.
+func (in *InputGuardrailList) DeepCopy() *InputGuardrailList {
+	if in == nil {
+		return nil
+	}
+	out := new(InputGuardrailList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InputGuardrailList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputGuardrailSpec) DeepCopyInto(out *InputGuardrailSpec) {
+	*out = *in
+	out.CommonSpec = in.CommonSpec
+	in.CommonGuardrailConfig.DeepCopyInto(&out.CommonGuardrailConfig)
+	if in.TopicAllowlist != nil {
+		in, out := &in.TopicAllowlist, &out.TopicAllowlist
+		*out = new(TopicAllowlist)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputGuardrailSpec.
+func (in *InputGuardrailSpec) DeepCopy() *InputGuardrailSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InputGuardrailSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputGuardrailStatus) DeepCopyInto(out *InputGuardrailStatus) {
+	*out = *in
+	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputGuardrailStatus.
+func (in *InputGuardrailStatus) DeepCopy() *InputGuardrailStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InputGuardrailStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputGuardrail) DeepCopyInto(out *OutputGuardrail) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputGuardrail.
+func (in *OutputGuardrail) DeepCopy() *OutputGuardrail {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputGuardrail)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OutputGuardrail) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputGuardrailList) DeepCopyInto(out *OutputGuardrailList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OutputGuardrail, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputGuardrailList.
+func (in *OutputGuardrailList) DeepCopy() *OutputGuardrailList {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputGuardrailList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OutputGuardrailList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputGuardrailSpec) DeepCopyInto(out *OutputGuardrailSpec) {
+	*out = *in
+	out.CommonSpec = in.CommonSpec
+	in.CommonGuardrailConfig.DeepCopyInto(&out.CommonGuardrailConfig)
+	if in.Grounding != nil {
+		in, out := &in.Grounding, &out.Grounding
+		*out = new(Grounding)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputGuardrailSpec.
+func (in *OutputGuardrailSpec) DeepCopy() *OutputGuardrailSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputGuardrailSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputGuardrailStatus) DeepCopyInto(out *OutputGuardrailStatus) {
+	*out = *in
+	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputGuardrailStatus.
+func (in *OutputGuardrailStatus) DeepCopy() *OutputGuardrailStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputGuardrailStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopicAllowlist) DeepCopyInto(out *TopicAllowlist) {
+	*out = *in
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LLM != nil {
+		in, out := &in.LLM, &out.LLM
+		*out = new(basev1alpha1.TypedObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopicAllowlist.
+func (in *TopicAllowlist) DeepCopy() *TopicAllowlist {
+	if in == nil {
+		return nil
+	}
+	out := new(TopicAllowlist)
+	in.DeepCopyInto(out)
+	return out
+}
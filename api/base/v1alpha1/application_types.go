@@ -54,6 +54,42 @@ type ApplicationSpec struct {
 	// +kubebuilder:validation:Minimum:=1
 	// +kubebuilder:default:=60
 	ChatTimeoutSecond float64 `json:"chatTimeoutSecond,omitempty"`
+	// ChatRetentionDays is how many days the conversations of the application are kept after their last update,
+	// they are deleted permanently with the uploaded files then. It overrides the chat retention in system config,
+	// 0 means using the retention of the namespace in system config.
+	// +kubebuilder:validation:Minimum:=0
+	ChatRetentionDays int `json:"chatRetentionDays,omitempty"`
+	// PIIGuard detects the personally identifiable information in the questions and answers of the chats,
+	// before the questions are sent to the llms and before the chats are stored. No detection if it is empty.
+	// Note: the answers streamed to the clients are not guarded, only the stored and the blocking ones are.
+	PIIGuard *PIIGuard `json:"piiGuard,omitempty"`
+}
+
+type PIIGuardMode string
+
+const (
+	// PIIGuardModeMask replaces the detected information with asterisks
+	PIIGuardModeMask PIIGuardMode = "mask"
+	// PIIGuardModeBlock rejects the questions, and hides the answers, with the detected information
+	PIIGuardModeBlock PIIGuardMode = "block"
+	// PIIGuardModeAudit keeps the detected information and records an audit
+	PIIGuardModeAudit PIIGuardMode = "audit"
+)
+
+// PIIGuard is the configuration of the personally identifiable information detection of an application
+type PIIGuard struct {
+	// Mode is what to do with the detected information, every detection is audited in all modes
+	// +kubebuilder:validation:Enum=mask;block;audit
+	// +kubebuilder:default:=mask
+	Mode PIIGuardMode `json:"mode,omitempty"`
+	// Detectors are the regex detectors enabled, all of them are enabled if it is empty
+	// +kubebuilder:validation:items:Enum=idcard;phone;email;bankcard
+	Detectors []string `json:"detectors,omitempty"`
+	// LLM detects the information not matched by the regex detectors, such as names and addresses.
+	// The questions are sent to it before guarded, so it should be a trusted one, like a local model.
+	LLM *TypedObjectReference `json:"llm,omitempty"`
+	// Model is the model of the llm, the first model of the llm is used if it is empty
+	Model string `json:"model,omitempty"`
 }
 
 // WebConfig is the configuration for web interface
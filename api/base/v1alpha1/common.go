@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -170,6 +171,74 @@ func (endpoint Endpoint) SchemeInternalURL() string {
 	return fmt.Sprintf("%s://%s", prefix, endpoint.InternalURL)
 }
 
+// Probe defines the periodic synthetic probe on a model service, like a cheap completion or embedding call
+type Probe struct {
+	// IntervalSeconds between two probes
+	// +kubebuilder:validation:Minimum=10
+	// +kubebuilder:default=300
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+
+	// TimeoutSeconds of a single probe
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=30
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed probes before the service is marked as not ready
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+
+	// Window is the number of latest probes used to calculate latency percentiles and error rate
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=20
+	Window int `json:"window,omitempty"`
+
+	// LatencySLOMilliseconds is the expected p95 latency, 0 means no latency SLO
+	// +kubebuilder:validation:Minimum=0
+	LatencySLOMilliseconds int64 `json:"latencySLOMilliseconds,omitempty"`
+}
+
+// ProbeSample is the result of a single probe
+type ProbeSample struct {
+	Time                metav1.Time `json:"time"`
+	LatencyMilliseconds int64       `json:"latencyMilliseconds"`
+	Success             bool        `json:"success"`
+}
+
+// HealthStatus is the observed health of a model service by probes
+type HealthStatus struct {
+	// ObservedGeneration is the generation of the spec which was validated before probing
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastProbeTime is the time of the latest probe
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty"`
+
+	// P50LatencyMilliseconds of the successful probes in window
+	P50LatencyMilliseconds int64 `json:"p50LatencyMilliseconds,omitempty"`
+
+	// P95LatencyMilliseconds of the successful probes in window
+	P95LatencyMilliseconds int64 `json:"p95LatencyMilliseconds,omitempty"`
+
+	// ErrorRate of the probes in window, from 0 to 1
+	ErrorRate float64 `json:"errorRate,omitempty"`
+
+	// SLOViolated is true when p95 latency exceeds the latency SLO
+	SLOViolated bool `json:"sloViolated,omitempty"`
+
+	// ConsecutiveFailures is the number of failed probes since the last successful one
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+
+	// LastFailureTime is the time of the latest failed probe
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+
+	// LastFailureReason is the error of the latest failed probe
+	LastFailureReason string `json:"lastFailureReason,omitempty"`
+
+	// Samples are the latest probe results in window
+	Samples []ProbeSample `json:"samples,omitempty"`
+}
+
 type CommonSpec struct {
 	// Creator defines datasource creator (AUTO-FILLED by webhook)
 	Creator string `json:"creator,omitempty"`
@@ -199,3 +268,12 @@ func (endpoint Endpoint) AuthAPIKey(ctx context.Context, ns string, c client.Cli
 	}
 	return string(data["apiKey"]), nil
 }
+
+// AuthSecretKey returns the secretKey in auth secret, which is required besides apiKey by some providers like qianfan
+func (endpoint Endpoint) AuthSecretKey(ctx context.Context, ns string, c client.Client) (string, error) {
+	data, err := endpoint.AuthData(ctx, ns, c)
+	if err != nil {
+		return "", err
+	}
+	return string(data["secretKey"]), nil
+}
@@ -42,6 +42,13 @@ func (e Embedder) AuthAPIKey(ctx context.Context, c client.Client) (string, erro
 	return e.Spec.Endpoint.AuthAPIKey(ctx, e.GetNamespace(), c)
 }
 
+func (e Embedder) AuthSecretKey(ctx context.Context, c client.Client) (string, error) {
+	if e.Spec.Endpoint == nil {
+		return "", nil
+	}
+	return e.Spec.Endpoint.AuthSecretKey(ctx, e.GetNamespace(), c)
+}
+
 // GetEmbedderBaseUrl returns the embedder's url
 func (e Embedder) Get3rdPartyEmbedderBaseURL() string {
 	return e.Spec.Endpoint.URL
@@ -88,6 +95,10 @@ func (e Embedder) Get3rdPartyModels() []string {
 		return embeddings.OpenAIModels
 	case embeddings.Gemini:
 		return embeddings.GeminiModels
+	case embeddings.Qianfan:
+		return embeddings.QianfanModels
+	case embeddings.DashScope:
+		return embeddings.DashScopeModels
 	}
 
 	return []string{}
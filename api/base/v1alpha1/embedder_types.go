@@ -38,6 +38,10 @@ type EmbedderSpec struct {
 	// Models provided by this LLM
 	// If not set,we will use default model list based on LLMType
 	Models []string `json:"models,omitempty"`
+
+	// Probe defines the periodic health probe of this embedder
+	// If not set, the embedder is only validated when spec changes
+	Probe *Probe `json:"probe,omitempty"`
 }
 
 // EmbeddingsStatus defines the observed state of Embedder
@@ -46,6 +50,9 @@ type EmbedderStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
 	// ConditionedStatus is the current status
 	ConditionedStatus `json:",inline"`
+
+	// Health is the observed health by probes
+	Health *HealthStatus `json:"health,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -33,6 +33,13 @@ func (llm LLM) AuthAPIKey(ctx context.Context, c client.Client) (string, error)
 	return llm.Spec.Endpoint.AuthAPIKey(ctx, llm.GetNamespace(), c)
 }
 
+func (llm LLM) AuthSecretKey(ctx context.Context, c client.Client) (string, error) {
+	if llm.Spec.Endpoint == nil {
+		return "", nil
+	}
+	return llm.Spec.Endpoint.AuthSecretKey(ctx, llm.GetNamespace(), c)
+}
+
 func (llmStatus LLMStatus) LLMReady() (string, bool) {
 	if len(llmStatus.Conditions) == 0 {
 		return "No conditions yet", false
@@ -89,6 +96,14 @@ func (llm LLM) Get3rdPartyModels() []string {
 		return llms.OpenAIModels
 	case llms.Gemini:
 		return llms.GeminiModels
+	case llms.Anthropic:
+		return llms.AnthropicModels
+	case llms.Qianfan:
+		return llms.QianfanModels
+	case llms.Moonshot:
+		return llms.MoonshotModels
+	case llms.DashScope:
+		return llms.DashScopeModels
 	}
 	return []string{}
 }
@@ -35,12 +35,63 @@ type LLMSpec struct {
 	// Models provided by this LLM
 	// If not set,we will use default model list based on LLMType
 	Models []string `json:"models,omitempty"`
+
+	// Fallback defines how to retry this llm on failure and which llms to fall back to
+	// If not set, a failed call will fail the whole request
+	Fallback *LLMFallback `json:"fallback,omitempty"`
+
+	// Probe defines the periodic health probe of this llm
+	// If not set, the llm is only validated when spec changes
+	Probe *Probe `json:"probe,omitempty"`
+}
+
+// LLMFallback defines the fallback chain of a llm
+type LLMFallback struct {
+	// LLMs to fall back to in order when this llm still fails after retries
+	LLMs []TypedObjectReference `json:"llms,omitempty"`
+
+	// Retry defines the retry policy applied to this llm and each fallback llm
+	Retry *RetryPolicy `json:"retry,omitempty"`
+
+	// TimeoutSeconds is the timeout of a single call to a llm, 0 means no timeout
+	// +kubebuilder:validation:Minimum=0
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// RetryPolicy defines the retry with exponential backoff on llm calls
+type RetryPolicy struct {
+	// MaxRetries is the max retry times of a single llm, 0 means no retry
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=2
+	MaxRetries *int `json:"maxRetries,omitempty"`
+
+	// InitialBackoffMilliseconds is the wait time before the first retry
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=500
+	InitialBackoffMilliseconds int `json:"initialBackoffMilliseconds,omitempty"`
+
+	// MaxBackoffMilliseconds is the max wait time between two retries
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=10000
+	MaxBackoffMilliseconds int `json:"maxBackoffMilliseconds,omitempty"`
+
+	// Multiplier of the backoff after each retry
+	// +kubebuilder:default=2
+	Multiplier float64 `json:"multiplier,omitempty"`
+
+	// RetryOn defines the error classes which will be retried.
+	// Other errors fall back to the next llm directly.
+	// If not set, timeout, rateLimit, server and network errors will be retried
+	RetryOn []llms.ErrorClass `json:"retryOn,omitempty"`
 }
 
 // LLMStatus defines the observed state of LLM
 type LLMStatus struct {
 	// ConditionedStatus is the current status
 	ConditionedStatus `json:",inline"`
+
+	// Health is the observed health by probes
+	Health *HealthStatus `json:"health,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const ToolKind = "Tool"
+
+func (t Tool) TypedObjectReference() *TypedObjectReference {
+	return &TypedObjectReference{
+		APIGroup:  &GroupVersion.Group,
+		Kind:      ToolKind,
+		Name:      t.Name,
+		Namespace: &t.Namespace,
+	}
+}
+
+// ResolveParameters returns the values of parameters, values from secrets are read with the client
+func (t Tool) ResolveParameters(ctx context.Context, c client.Client) (map[string]string, error) {
+	params := make(map[string]string, len(t.Spec.Parameters))
+	secrets := make(map[string]*corev1.Secret)
+	for _, p := range t.Spec.Parameters {
+		if _, ok := params[p.Name]; ok {
+			return nil, fmt.Errorf("duplicate parameter %s", p.Name)
+		}
+		if p.ValueFrom == nil {
+			params[p.Name] = p.Value
+			continue
+		}
+		secret, ok := secrets[p.ValueFrom.Name]
+		if !ok {
+			secret = &corev1.Secret{}
+			if err := c.Get(ctx, types.NamespacedName{Namespace: t.Namespace, Name: p.ValueFrom.Name}, secret); err != nil {
+				return nil, fmt.Errorf("failed to get secret %s of parameter %s: %w", p.ValueFrom.Name, p.Name, err)
+			}
+			secrets[p.ValueFrom.Name] = secret
+		}
+		value, ok := secret.Data[p.ValueFrom.Key]
+		if !ok {
+			return nil, fmt.Errorf("secret %s has no key %s for parameter %s", p.ValueFrom.Name, p.ValueFrom.Key, p.Name)
+		}
+		params[p.Name] = string(value)
+	}
+	return params, nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ToolSpec defines the desired state of Tool
+type ToolSpec struct {
+	// CommonSpec.Description is shown to the llm to decide when to use this tool,
+	// the default description of the implementation is used if it is empty
+	CommonSpec `json:",inline"`
+
+	// Type is the implementation of this tool, like `Bing Search API`, `OpenAPI` or `SQL Database`
+	// +kubebuilder:validation:Required
+	Type string `json:"type"`
+
+	// Parameters passed to the implementation, they are validated against the parameters declared by the implementation
+	Parameters []ToolParameter `json:"parameters,omitempty"`
+}
+
+// ToolParameter is a parameter of a tool, the value is set directly or read from a secret
+type ToolParameter struct {
+	// Name of the parameter
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Value of the parameter
+	Value string `json:"value,omitempty"`
+
+	// ValueFrom reads the value from a key of secret in the namespace of the tool, used for credentials like api keys
+	ValueFrom *corev1.SecretKeySelector `json:"valueFrom,omitempty"`
+}
+
+// ToolStatus defines the observed state of Tool
+type ToolStatus struct {
+	// ObservedGeneration is the last observed generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ConditionedStatus is the current status
+	ConditionedStatus `json:",inline"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="display-name",type=string,JSONPath=`.spec.displayName`
+//+kubebuilder:printcolumn:name="type",type=string,JSONPath=`.spec.type`
+
+// Tool is the Schema for the tools API, it is referenced by agents
+type Tool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ToolSpec   `json:"spec,omitempty"`
+	Status ToolStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ToolList contains a list of Tool
+type ToolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Tool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Tool{}, &ToolList{})
+}
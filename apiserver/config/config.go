@@ -18,6 +18,7 @@ package config
 import (
 	"flag"
 	"os"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -56,6 +57,15 @@ type ServerConfig struct {
 
 	// DataProcessURL is the URL of the data process service
 	DataProcessURL string
+
+	// PDFFont is the path of a ttf font used by exporting conversations as pdf, which should cover the language of the chats
+	PDFFont string
+
+	// ShareSecret is the key to sign the links of shared conversations, it should be the same for all replicas
+	ShareSecret string
+
+	// ChatPurgeInterval is the interval to purge the conversations exceeding the retention, 0 disables the purge
+	ChatPurgeInterval time.Duration
 }
 
 func NewServerFlags() ServerConfig {
@@ -71,6 +81,9 @@ func NewServerFlags() ServerConfig {
 	flag.StringVar(&s.ClientID, "client-id", "", "oidc client id(required when enable odic)")
 	flag.StringVar(&s.ClientSecret, "client-secret", "", "oidc client secret(required when enable odic)")
 	flag.StringVar(&s.DataProcessURL, "data-processing-url", "http://127.0.0.1:28888", "url to access data processing server")
+	flag.StringVar(&s.PDFFont, "pdf-font", os.Getenv("PDF_FONT"), "path of the ttf font to export conversations as pdf, such as a Noto Sans CJK font for chinese chats. Only latin characters are supported if it is empty")
+	flag.StringVar(&s.ShareSecret, "share-secret", os.Getenv("SHARE_SECRET"), "key to sign the links of shared conversations. A random key is used if it is empty, so the links are invalid after restart")
+	flag.DurationVar(&s.ChatPurgeInterval, "chat-purge-interval", time.Hour, "interval to purge the conversations exceeding the retention in system config and applications, 0 disables the purge")
 	flag.BoolVar(&s.Debug, "debug", false, "debug model for apiserver")
 
 	klog.InitFlags(nil)
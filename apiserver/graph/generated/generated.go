@@ -78,6 +78,7 @@ type DirectiveRoot struct {
 type ComplexityRoot struct {
 	Application struct {
 		BatchSize            func(childComplexity int) int
+		ChatRetentionDays    func(childComplexity int) int
 		ChatTimeout          func(childComplexity int) int
 		ChunkOverlap         func(childComplexity int) int
 		ChunkSize            func(childComplexity int) int
@@ -105,6 +106,11 @@ type ComplexityRoot struct {
 		UserPrompt           func(childComplexity int) int
 	}
 
+	ApplicationFeedbackStats struct {
+		Intervals func(childComplexity int) int
+		Total     func(childComplexity int) int
+	}
+
 	ApplicationMetadata struct {
 		Annotations        func(childComplexity int) int
 		Category           func(childComplexity int) int
@@ -132,8 +138,9 @@ type ComplexityRoot struct {
 	}
 
 	ApplicationQuery struct {
-		GetApplication          func(childComplexity int, name string, namespace string) int
-		ListApplicationMetadata func(childComplexity int, input ListCommonInput) int
+		GetApplication              func(childComplexity int, name string, namespace string) int
+		GetApplicationFeedbackStats func(childComplexity int, input ApplicationFeedbackStatsInput) int
+		ListApplicationMetadata     func(childComplexity int, input ListCommonInput) int
 	}
 
 	CountDataProcessItem struct {
@@ -371,6 +378,14 @@ type ComplexityRoot struct {
 		Versions          func(childComplexity int) int
 	}
 
+	FeedbackStat struct {
+		Corrections      func(childComplexity int) int
+		Dislikes         func(childComplexity int) int
+		Likes            func(childComplexity int) int
+		SatisfactionRate func(childComplexity int) int
+		StartTime        func(childComplexity int) int
+	}
+
 	FileDetails struct {
 		EndTime   func(childComplexity int) int
 		FileName  func(childComplexity int) int
@@ -845,6 +860,7 @@ type ApplicationMutationResolver interface {
 type ApplicationQueryResolver interface {
 	GetApplication(ctx context.Context, obj *ApplicationQuery, name string, namespace string) (*Application, error)
 	ListApplicationMetadata(ctx context.Context, obj *ApplicationQuery, input ListCommonInput) (*PaginatedResult, error)
+	GetApplicationFeedbackStats(ctx context.Context, obj *ApplicationQuery, input ApplicationFeedbackStatsInput) (*ApplicationFeedbackStats, error)
 }
 type DataProcessMutationResolver interface {
 	CreateDataProcessTask(ctx context.Context, obj *DataProcessMutation, input *AddDataProcessInput) (*DataProcessResponse, error)
@@ -1034,6 +1050,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Application.BatchSize(childComplexity), true
 
+	case "Application.chatRetentionDays":
+		if e.complexity.Application.ChatRetentionDays == nil {
+			break
+		}
+
+		return e.complexity.Application.ChatRetentionDays(childComplexity), true
+
 	case "Application.chatTimeout":
 		if e.complexity.Application.ChatTimeout == nil {
 			break
@@ -1209,6 +1232,20 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Application.UserPrompt(childComplexity), true
 
+	case "ApplicationFeedbackStats.intervals":
+		if e.complexity.ApplicationFeedbackStats.Intervals == nil {
+			break
+		}
+
+		return e.complexity.ApplicationFeedbackStats.Intervals(childComplexity), true
+
+	case "ApplicationFeedbackStats.total":
+		if e.complexity.ApplicationFeedbackStats.Total == nil {
+			break
+		}
+
+		return e.complexity.ApplicationFeedbackStats.Total(childComplexity), true
+
 	case "ApplicationMetadata.annotations":
 		if e.complexity.ApplicationMetadata.Annotations == nil {
 			break
@@ -1381,6 +1418,18 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ApplicationQuery.GetApplication(childComplexity, args["name"].(string), args["namespace"].(string)), true
 
+	case "ApplicationQuery.getApplicationFeedbackStats":
+		if e.complexity.ApplicationQuery.GetApplicationFeedbackStats == nil {
+			break
+		}
+
+		args, err := ec.field_ApplicationQuery_getApplicationFeedbackStats_args(context.TODO(), rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.ApplicationQuery.GetApplicationFeedbackStats(childComplexity, args["input"].(ApplicationFeedbackStatsInput)), true
+
 	case "ApplicationQuery.listApplicationMetadata":
 		if e.complexity.ApplicationQuery.ListApplicationMetadata == nil {
 			break
@@ -2601,6 +2650,41 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.F.Versions(childComplexity), true
 
+	case "FeedbackStat.corrections":
+		if e.complexity.FeedbackStat.Corrections == nil {
+			break
+		}
+
+		return e.complexity.FeedbackStat.Corrections(childComplexity), true
+
+	case "FeedbackStat.dislikes":
+		if e.complexity.FeedbackStat.Dislikes == nil {
+			break
+		}
+
+		return e.complexity.FeedbackStat.Dislikes(childComplexity), true
+
+	case "FeedbackStat.likes":
+		if e.complexity.FeedbackStat.Likes == nil {
+			break
+		}
+
+		return e.complexity.FeedbackStat.Likes(childComplexity), true
+
+	case "FeedbackStat.satisfactionRate":
+		if e.complexity.FeedbackStat.SatisfactionRate == nil {
+			break
+		}
+
+		return e.complexity.FeedbackStat.SatisfactionRate(childComplexity), true
+
+	case "FeedbackStat.startTime":
+		if e.complexity.FeedbackStat.StartTime == nil {
+			break
+		}
+
+		return e.complexity.FeedbackStat.StartTime(childComplexity), true
+
 	case "FileDetails.end_time":
 		if e.complexity.FileDetails.EndTime == nil {
 			break
@@ -4957,6 +5041,7 @@ func (e *executableSchema) Exec(ctx context.Context) graphql.ResponseHandler {
 		ec.unmarshalInputAddDataProcessInput,
 		ec.unmarshalInputAllDataProcessListByCountInput,
 		ec.unmarshalInputAllDataProcessListByPageInput,
+		ec.unmarshalInputApplicationFeedbackStatsInput,
 		ec.unmarshalInputCheckDataProcessTaskNameInput,
 		ec.unmarshalInputCreateApplicationMetadataInput,
 		ec.unmarshalInputCreateDatasetInput,
@@ -5122,6 +5207,7 @@ var sources = []*ast.Source{
 	{Name: "../schema/application.graphqls", Input: `type ApplicationQuery {
     getApplication(name: String!, namespace: String!): Application!
     listApplicationMetadata(input: ListCommonInput!): PaginatedResult!
+    getApplicationFeedbackStats(input: ApplicationFeedbackStatsInput!): ApplicationFeedbackStats!
 }
 
 type ApplicationMutation {
@@ -5240,6 +5326,10 @@ type Application {
     """
     chatTimeout: Float
     """
+    chatRetentionDays 对话保留天数，超过后对话及上传的文档将被永久删除，为 0 时使用系统配置中命名空间的保留天数
+    """
+    chatRetentionDays: Int
+    """
     enableUploadFile 是否开启对话上传文档功能
     """
     enableUploadFile: Boolean
@@ -5561,6 +5651,10 @@ input UpdateApplicationConfigInput {
     """
     chatTimeout: Float
     """
+    chatRetentionDays 对话保留天数，超过后对话及上传的文档将被永久删除，为 0 时使用系统配置中命名空间的保留天数
+    """
+    chatRetentionDays: Int
+    """
     enableUploadFile 是否开启对话上传文档功能
     """
     enableUploadFile: Boolean
@@ -5577,6 +5671,56 @@ input UpdateApplicationConfigInput {
     """
     batchSize: Int
 }
+
+input ApplicationFeedbackStatsInput {
+    """应用名称"""
+    name: String!
+    """应用所在的命名空间"""
+    namespace: String!
+    """
+    统计的开始时间
+    规则: 默认为7天前
+    """
+    startTime: Time
+    """
+    统计的结束时间
+    规则: 默认为当前时间
+    """
+    endTime: Time
+    """
+    每个统计区间的长度，单位为小时
+    规则: 默认为24
+    """
+    intervalHours: Int
+}
+
+"""
+FeedbackStat
+一个统计区间内的用户反馈
+"""
+type FeedbackStat {
+    """区间的开始时间"""
+    startTime: Time!
+    """点赞数"""
+    likes: Int!
+    """点踩数"""
+    dislikes: Int!
+    """提供了修正答案的反馈数"""
+    corrections: Int!
+    """满意度，点赞数占所有评价的比例，没有评价时为0"""
+    satisfactionRate: Float!
+}
+
+"""
+ApplicationFeedbackStats
+应用的用户反馈统计
+"""
+type ApplicationFeedbackStats {
+    """统计时间内的总计"""
+    total: FeedbackStat!
+    """每个统计区间的反馈，按时间排序"""
+    intervals: [FeedbackStat!]!
+}
 `, BuiltIn: false},
 	{Name: "../schema/dataprocessing.graphqls", Input: `# 数据处理 Mutation
 type DataProcessMutation {
@@ -6345,7 +6489,7 @@ input CreateEmbedderInput {
 
     """
     向量化模型服务接口类型
-    规则:  目前支持 zhipuai,openai两种接口类型
+    规则:  目前支持 zhipuai,openai,azureopenai,qianfan,dashscope等接口类型
     """
     type: String
 
@@ -6376,7 +6520,7 @@ input UpdateEmbedderInput {
 
     """
     向量化模型服务接口类型
-    规则:  目前支持 zhipuai,openai两种接口类型
+    规则:  目前支持 zhipuai,openai,azureopenai,qianfan,dashscope等接口类型
     """
     type: String
 
@@ -6416,7 +6560,7 @@ type Mutation{
 input EndpointInput {
     """地址(必填)"""
     url: String!
-    """secret验证密码"""
+    """secret验证密码,如 apiKey,千帆(qianfan)还需要 secretKey"""
     auth: Map
     """默认true"""
     insecure: Boolean
@@ -7097,7 +7241,7 @@ input CreateLLMInput {
 
     """
     模型服务接口类型
-    规则:  目前支持 zhipuai,openai两种接口类型
+    规则:  目前支持 zhipuai,openai,anthropic,azureopenai,qianfan,moonshot,dashscope等接口类型
     """
     type: String
 
@@ -7127,7 +7271,7 @@ input UpdateLLMInput {
 
     """
     模型服务接口类型
-    规则:  目前支持 zhipuai,openai两种接口类型
+    规则:  目前支持 zhipuai,openai,anthropic,azureopenai,qianfan,moonshot,dashscope等接口类型
     """
     type: String
 
@@ -7402,7 +7546,7 @@ extend type Query {
 
     """
     模型服务 API 类型
-    规则：支持 openai, zhipuai 两种类型
+    规则：支持 openai, zhipuai, anthropic, azureopenai, qianfan, moonshot, dashscope 等类型
     """
     apiType: String
 
@@ -7463,7 +7607,7 @@ input CreateModelServiceInput {
 
     """
     模型服务 API 类型
-    规则：支持 openai, zhipuai 两种类型
+    规则：支持 openai, zhipuai, anthropic, azureopenai, qianfan, moonshot, dashscope 等类型
     """
     apiType: String
 
@@ -7509,7 +7653,7 @@ input UpdateModelServiceInput {
 
     """
     模型服务 API 类型
-    规则：支持 openai, zhipuai 两种类型
+    规则：支持 openai, zhipuai, anthropic, azureopenai, qianfan, moonshot, dashscope 等类型
     """
     apiType: String
 
@@ -8441,82 +8585,13 @@ func (ec *executionContext) field_ApplicationMutation_updateApplication_args(ctx
 	return args, nil
 }
 
-func (ec *executionContext) field_ApplicationQuery_getApplication_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 string
-	if tmp, ok := rawArgs["name"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("name"))
-		arg0, err = ec.unmarshalNString2string(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["name"] = arg0
-	var arg1 string
-	if tmp, ok := rawArgs["namespace"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("namespace"))
-		arg1, err = ec.unmarshalNString2string(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["namespace"] = arg1
-	return args, nil
-}
-
-func (ec *executionContext) field_ApplicationQuery_listApplicationMetadata_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 ListCommonInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalNListCommonInput2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐListCommonInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DataProcessMutation_createDataProcessTask_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *AddDataProcessInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalOAddDataProcessInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐAddDataProcessInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DataProcessMutation_deleteDataProcessTask_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *DeleteDataProcessInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalODeleteDataProcessInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDeleteDataProcessInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DataProcessQuery_allDataProcessListByCount_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+func (ec *executionContext) field_ApplicationQuery_getApplicationFeedbackStats_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
 	var err error
 	args := map[string]interface{}{}
-	var arg0 *AllDataProcessListByCountInput
+	var arg0 ApplicationFeedbackStatsInput
 	if tmp, ok := rawArgs["input"]; ok {
 		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalOAllDataProcessListByCountInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐAllDataProcessListByCountInput(ctx, tmp)
+		arg0, err = ec.unmarshalNApplicationFeedbackStatsInput2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐApplicationFeedbackStatsInput(ctx, tmp)
 		if err != nil {
 			return nil, err
 		}
@@ -8525,142 +8600,226 @@ func (ec *executionContext) field_DataProcessQuery_allDataProcessListByCount_arg
 	return args, nil
 }
 
-func (ec *executionContext) field_DataProcessQuery_allDataProcessListByPage_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *AllDataProcessListByPageInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalOAllDataProcessListByPageInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐAllDataProcessListByPageInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DataProcessQuery_checkDataProcessTaskName_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *CheckDataProcessTaskNameInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalOCheckDataProcessTaskNameInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐCheckDataProcessTaskNameInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DataProcessQuery_dataProcessDetails_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *DataProcessDetailsInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalODataProcessDetailsInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDataProcessDetailsInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DataProcessQuery_dataProcessLogInfoByFileName_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *DataProcessFileLogInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalODataProcessFileLogInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDataProcessFileLogInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DataProcessQuery_dataProcessRetry_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *DataProcessRetryInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalODataProcessRetryInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDataProcessRetryInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DataProcessQuery_getLogInfo_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *DataProcessDetailsInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalODataProcessDetailsInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDataProcessDetailsInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DatasetMutation_createDataset_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *CreateDatasetInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalOCreateDatasetInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐCreateDatasetInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DatasetMutation_deleteDatasets_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *DeleteCommonInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalODeleteCommonInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDeleteCommonInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DatasetMutation_updateDataset_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
-	var err error
-	args := map[string]interface{}{}
-	var arg0 *UpdateDatasetInput
-	if tmp, ok := rawArgs["input"]; ok {
-		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
-		arg0, err = ec.unmarshalOUpdateDatasetInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐUpdateDatasetInput(ctx, tmp)
-		if err != nil {
-			return nil, err
-		}
-	}
-	args["input"] = arg0
-	return args, nil
-}
-
-func (ec *executionContext) field_DatasetQuery_getDataset_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+func (ec *executionContext) field_ApplicationQuery_getApplication_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 string
+	if tmp, ok := rawArgs["name"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("name"))
+		arg0, err = ec.unmarshalNString2string(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["name"] = arg0
+	var arg1 string
+	if tmp, ok := rawArgs["namespace"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("namespace"))
+		arg1, err = ec.unmarshalNString2string(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["namespace"] = arg1
+	return args, nil
+}
+
+func (ec *executionContext) field_ApplicationQuery_listApplicationMetadata_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 ListCommonInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalNListCommonInput2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐListCommonInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessMutation_createDataProcessTask_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *AddDataProcessInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalOAddDataProcessInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐAddDataProcessInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessMutation_deleteDataProcessTask_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *DeleteDataProcessInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalODeleteDataProcessInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDeleteDataProcessInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessQuery_allDataProcessListByCount_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *AllDataProcessListByCountInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalOAllDataProcessListByCountInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐAllDataProcessListByCountInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessQuery_allDataProcessListByPage_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *AllDataProcessListByPageInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalOAllDataProcessListByPageInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐAllDataProcessListByPageInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessQuery_checkDataProcessTaskName_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *CheckDataProcessTaskNameInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalOCheckDataProcessTaskNameInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐCheckDataProcessTaskNameInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessQuery_dataProcessDetails_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *DataProcessDetailsInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalODataProcessDetailsInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDataProcessDetailsInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessQuery_dataProcessLogInfoByFileName_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *DataProcessFileLogInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalODataProcessFileLogInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDataProcessFileLogInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessQuery_dataProcessRetry_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *DataProcessRetryInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalODataProcessRetryInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDataProcessRetryInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DataProcessQuery_getLogInfo_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *DataProcessDetailsInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalODataProcessDetailsInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDataProcessDetailsInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DatasetMutation_createDataset_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *CreateDatasetInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalOCreateDatasetInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐCreateDatasetInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DatasetMutation_deleteDatasets_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *DeleteCommonInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalODeleteCommonInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐDeleteCommonInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DatasetMutation_updateDataset_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 *UpdateDatasetInput
+	if tmp, ok := rawArgs["input"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("input"))
+		arg0, err = ec.unmarshalOUpdateDatasetInput2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐUpdateDatasetInput(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["input"] = arg0
+	return args, nil
+}
+
+func (ec *executionContext) field_DatasetQuery_getDataset_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
 	var err error
 	args := map[string]interface{}{}
 	var arg0 string
@@ -10588,6 +10747,47 @@ func (ec *executionContext) fieldContext_Application_chatTimeout(ctx context.Con
 	return fc, nil
 }
 
+func (ec *executionContext) _Application_chatRetentionDays(ctx context.Context, field graphql.CollectedField, obj *Application) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Application_chatRetentionDays(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.ChatRetentionDays, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*int)
+	fc.Result = res
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Application_chatRetentionDays(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Application",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _Application_enableUploadFile(ctx context.Context, field graphql.CollectedField, obj *Application) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_Application_enableUploadFile(ctx, field)
 	if err != nil {
@@ -10752,6 +10952,118 @@ func (ec *executionContext) fieldContext_Application_batchSize(ctx context.Conte
 	return fc, nil
 }
 
+func (ec *executionContext) _ApplicationFeedbackStats_total(ctx context.Context, field graphql.CollectedField, obj *ApplicationFeedbackStats) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ApplicationFeedbackStats_total(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Total, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(FeedbackStat)
+	fc.Result = res
+	return ec.marshalNFeedbackStat2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐFeedbackStat(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ApplicationFeedbackStats_total(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ApplicationFeedbackStats",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "startTime":
+				return ec.fieldContext_FeedbackStat_startTime(ctx, field)
+			case "likes":
+				return ec.fieldContext_FeedbackStat_likes(ctx, field)
+			case "dislikes":
+				return ec.fieldContext_FeedbackStat_dislikes(ctx, field)
+			case "corrections":
+				return ec.fieldContext_FeedbackStat_corrections(ctx, field)
+			case "satisfactionRate":
+				return ec.fieldContext_FeedbackStat_satisfactionRate(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type FeedbackStat", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ApplicationFeedbackStats_intervals(ctx context.Context, field graphql.CollectedField, obj *ApplicationFeedbackStats) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ApplicationFeedbackStats_intervals(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Intervals, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]*FeedbackStat)
+	fc.Result = res
+	return ec.marshalNFeedbackStat2ᚕᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐFeedbackStatᚄ(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ApplicationFeedbackStats_intervals(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ApplicationFeedbackStats",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "startTime":
+				return ec.fieldContext_FeedbackStat_startTime(ctx, field)
+			case "likes":
+				return ec.fieldContext_FeedbackStat_likes(ctx, field)
+			case "dislikes":
+				return ec.fieldContext_FeedbackStat_dislikes(ctx, field)
+			case "corrections":
+				return ec.fieldContext_FeedbackStat_corrections(ctx, field)
+			case "satisfactionRate":
+				return ec.fieldContext_FeedbackStat_satisfactionRate(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type FeedbackStat", field.Name)
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _ApplicationMetadata_name(ctx context.Context, field graphql.CollectedField, obj *ApplicationMetadata) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_ApplicationMetadata_name(ctx, field)
 	if err != nil {
@@ -11727,6 +12039,8 @@ func (ec *executionContext) fieldContext_ApplicationMutation_updateApplicationCo
 				return ec.fieldContext_Application_enableMultiQuery(ctx, field)
 			case "chatTimeout":
 				return ec.fieldContext_Application_chatTimeout(ctx, field)
+			case "chatRetentionDays":
+				return ec.fieldContext_Application_chatRetentionDays(ctx, field)
 			case "enableUploadFile":
 				return ec.fieldContext_Application_enableUploadFile(ctx, field)
 			case "chunkSize":
@@ -11836,6 +12150,8 @@ func (ec *executionContext) fieldContext_ApplicationQuery_getApplication(ctx con
 				return ec.fieldContext_Application_enableMultiQuery(ctx, field)
 			case "chatTimeout":
 				return ec.fieldContext_Application_chatTimeout(ctx, field)
+			case "chatRetentionDays":
+				return ec.fieldContext_Application_chatRetentionDays(ctx, field)
 			case "enableUploadFile":
 				return ec.fieldContext_Application_enableUploadFile(ctx, field)
 			case "chunkSize":
@@ -11929,6 +12245,67 @@ func (ec *executionContext) fieldContext_ApplicationQuery_listApplicationMetadat
 	return fc, nil
 }
 
+func (ec *executionContext) _ApplicationQuery_getApplicationFeedbackStats(ctx context.Context, field graphql.CollectedField, obj *ApplicationQuery) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ApplicationQuery_getApplicationFeedbackStats(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.ApplicationQuery().GetApplicationFeedbackStats(rctx, obj, fc.Args["input"].(ApplicationFeedbackStatsInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*ApplicationFeedbackStats)
+	fc.Result = res
+	return ec.marshalNApplicationFeedbackStats2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐApplicationFeedbackStats(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ApplicationQuery_getApplicationFeedbackStats(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ApplicationQuery",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "total":
+				return ec.fieldContext_ApplicationFeedbackStats_total(ctx, field)
+			case "intervals":
+				return ec.fieldContext_ApplicationFeedbackStats_intervals(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type ApplicationFeedbackStats", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_ApplicationQuery_getApplicationFeedbackStats_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _CountDataProcessItem_status(ctx context.Context, field graphql.CollectedField, obj *CountDataProcessItem) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_CountDataProcessItem_status(ctx, field)
 	if err != nil {
@@ -19394,6 +19771,226 @@ func (ec *executionContext) fieldContext_F_latestVersion(ctx context.Context, fi
 	return fc, nil
 }
 
+func (ec *executionContext) _FeedbackStat_startTime(ctx context.Context, field graphql.CollectedField, obj *FeedbackStat) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_FeedbackStat_startTime(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.StartTime, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(time.Time)
+	fc.Result = res
+	return ec.marshalNTime2timeᚐTime(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_FeedbackStat_startTime(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "FeedbackStat",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Time does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _FeedbackStat_likes(ctx context.Context, field graphql.CollectedField, obj *FeedbackStat) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_FeedbackStat_likes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Likes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_FeedbackStat_likes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "FeedbackStat",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _FeedbackStat_dislikes(ctx context.Context, field graphql.CollectedField, obj *FeedbackStat) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_FeedbackStat_dislikes(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Dislikes, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_FeedbackStat_dislikes(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "FeedbackStat",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _FeedbackStat_corrections(ctx context.Context, field graphql.CollectedField, obj *FeedbackStat) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_FeedbackStat_corrections(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Corrections, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(int)
+	fc.Result = res
+	return ec.marshalNInt2int(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_FeedbackStat_corrections(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "FeedbackStat",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _FeedbackStat_satisfactionRate(ctx context.Context, field graphql.CollectedField, obj *FeedbackStat) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_FeedbackStat_satisfactionRate(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.SatisfactionRate, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(float64)
+	fc.Result = res
+	return ec.marshalNFloat2float64(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_FeedbackStat_satisfactionRate(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "FeedbackStat",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Float does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _FileDetails_file_name(ctx context.Context, field graphql.CollectedField, obj *FileDetails) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_FileDetails_file_name(ctx, field)
 	if err != nil {
@@ -27650,6 +28247,8 @@ func (ec *executionContext) fieldContext_Query_Application(ctx context.Context,
 				return ec.fieldContext_ApplicationQuery_getApplication(ctx, field)
 			case "listApplicationMetadata":
 				return ec.fieldContext_ApplicationQuery_listApplicationMetadata(ctx, field)
+			case "getApplicationFeedbackStats":
+				return ec.fieldContext_ApplicationQuery_getApplicationFeedbackStats(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ApplicationQuery", field.Name)
 		},
@@ -28918,6 +29517,8 @@ func (ec *executionContext) fieldContext_RAG_application(ctx context.Context, fi
 				return ec.fieldContext_Application_enableMultiQuery(ctx, field)
 			case "chatTimeout":
 				return ec.fieldContext_Application_chatTimeout(ctx, field)
+			case "chatRetentionDays":
+				return ec.fieldContext_Application_chatRetentionDays(ctx, field)
 			case "enableUploadFile":
 				return ec.fieldContext_Application_enableUploadFile(ctx, field)
 			case "chunkSize":
@@ -36321,6 +36922,61 @@ func (ec *executionContext) unmarshalInputAllDataProcessListByPageInput(ctx cont
 	return it, nil
 }
 
+func (ec *executionContext) unmarshalInputApplicationFeedbackStatsInput(ctx context.Context, obj interface{}) (ApplicationFeedbackStatsInput, error) {
+	var it ApplicationFeedbackStatsInput
+	asMap := map[string]interface{}{}
+	for k, v := range obj.(map[string]interface{}) {
+		asMap[k] = v
+	}
+
+	fieldsInOrder := [...]string{"name", "namespace", "startTime", "endTime", "intervalHours"}
+	for _, k := range fieldsInOrder {
+		v, ok := asMap[k]
+		if !ok {
+			continue
+		}
+		switch k {
+		case "name":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("name"))
+			data, err := ec.unmarshalNString2string(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.Name = data
+		case "namespace":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("namespace"))
+			data, err := ec.unmarshalNString2string(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.Namespace = data
+		case "startTime":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("startTime"))
+			data, err := ec.unmarshalOTime2ᚖtimeᚐTime(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.StartTime = data
+		case "endTime":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("endTime"))
+			data, err := ec.unmarshalOTime2ᚖtimeᚐTime(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.EndTime = data
+		case "intervalHours":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("intervalHours"))
+			data, err := ec.unmarshalOInt2ᚖint(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.IntervalHours = data
+		}
+	}
+
+	return it, nil
+}
+
 func (ec *executionContext) unmarshalInputCheckDataProcessTaskNameInput(ctx context.Context, obj interface{}) (CheckDataProcessTaskNameInput, error) {
 	var it CheckDataProcessTaskNameInput
 	asMap := map[string]interface{}{}
@@ -39224,7 +39880,7 @@ func (ec *executionContext) unmarshalInputUpdateApplicationConfigInput(ctx conte
 		asMap[k] = v
 	}
 
-	fieldsInOrder := [...]string{"name", "namespace", "prologue", "model", "llm", "temperature", "maxLength", "maxTokens", "conversionWindowSize", "knowledgebase", "scoreThreshold", "numDocuments", "docNullReturn", "userPrompt", "systemPrompt", "showRespInfo", "showRetrievalInfo", "showNextGuide", "tools", "enableRerank", "rerankModel", "enableMultiQuery", "chatTimeout", "enableUploadFile", "chunkSize", "chunkOverlap", "batchSize"}
+	fieldsInOrder := [...]string{"name", "namespace", "prologue", "model", "llm", "temperature", "maxLength", "maxTokens", "conversionWindowSize", "knowledgebase", "scoreThreshold", "numDocuments", "docNullReturn", "userPrompt", "systemPrompt", "showRespInfo", "showRetrievalInfo", "showNextGuide", "tools", "enableRerank", "rerankModel", "enableMultiQuery", "chatTimeout", "chatRetentionDays", "enableUploadFile", "chunkSize", "chunkOverlap", "batchSize"}
 	for _, k := range fieldsInOrder {
 		v, ok := asMap[k]
 		if !ok {
@@ -39392,6 +40048,13 @@ func (ec *executionContext) unmarshalInputUpdateApplicationConfigInput(ctx conte
 				return it, err
 			}
 			it.ChatTimeout = data
+		case "chatRetentionDays":
+			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("chatRetentionDays"))
+			data, err := ec.unmarshalOInt2ᚖint(ctx, v)
+			if err != nil {
+				return it, err
+			}
+			it.ChatRetentionDays = data
 		case "enableUploadFile":
 			ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("enableUploadFile"))
 			data, err := ec.unmarshalOBoolean2ᚖbool(ctx, v)
@@ -40650,6 +41313,8 @@ func (ec *executionContext) _Application(ctx context.Context, sel ast.SelectionS
 			out.Values[i] = ec._Application_enableMultiQuery(ctx, field, obj)
 		case "chatTimeout":
 			out.Values[i] = ec._Application_chatTimeout(ctx, field, obj)
+		case "chatRetentionDays":
+			out.Values[i] = ec._Application_chatRetentionDays(ctx, field, obj)
 		case "enableUploadFile":
 			out.Values[i] = ec._Application_enableUploadFile(ctx, field, obj)
 		case "chunkSize":
@@ -40681,6 +41346,50 @@ func (ec *executionContext) _Application(ctx context.Context, sel ast.SelectionS
 	return out
 }
 
+var applicationFeedbackStatsImplementors = []string{"ApplicationFeedbackStats"}
+
+func (ec *executionContext) _ApplicationFeedbackStats(ctx context.Context, sel ast.SelectionSet, obj *ApplicationFeedbackStats) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, applicationFeedbackStatsImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("ApplicationFeedbackStats")
+		case "total":
+			out.Values[i] = ec._ApplicationFeedbackStats_total(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "intervals":
+			out.Values[i] = ec._ApplicationFeedbackStats_intervals(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
 var applicationMetadataImplementors = []string{"ApplicationMetadata", "PageNode"}
 
 func (ec *executionContext) _ApplicationMetadata(ctx context.Context, sel ast.SelectionSet, obj *ApplicationMetadata) graphql.Marshaler {
@@ -40800,76 +41509,146 @@ func (ec *executionContext) _ApplicationMutation(ctx context.Context, sel ast.Se
 			}
 
 			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
-		case "updateApplication":
-			field := field
-
-			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
-				defer func() {
-					if r := recover(); r != nil {
-						ec.Error(ctx, ec.Recover(ctx, r))
-					}
-				}()
-				res = ec._ApplicationMutation_updateApplication(ctx, field, obj)
-				if res == graphql.Null {
-					atomic.AddUint32(&fs.Invalids, 1)
-				}
-				return res
-			}
-
-			if field.Deferrable != nil {
-				dfs, ok := deferred[field.Deferrable.Label]
-				di := 0
-				if ok {
-					dfs.AddField(field)
-					di = len(dfs.Values) - 1
-				} else {
-					dfs = graphql.NewFieldSet([]graphql.CollectedField{field})
-					deferred[field.Deferrable.Label] = dfs
-				}
-				dfs.Concurrently(di, func(ctx context.Context) graphql.Marshaler {
-					return innerFunc(ctx, dfs)
-				})
+		case "updateApplication":
+			field := field
+
+			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._ApplicationMutation_updateApplication(ctx, field, obj)
+				if res == graphql.Null {
+					atomic.AddUint32(&fs.Invalids, 1)
+				}
+				return res
+			}
+
+			if field.Deferrable != nil {
+				dfs, ok := deferred[field.Deferrable.Label]
+				di := 0
+				if ok {
+					dfs.AddField(field)
+					di = len(dfs.Values) - 1
+				} else {
+					dfs = graphql.NewFieldSet([]graphql.CollectedField{field})
+					deferred[field.Deferrable.Label] = dfs
+				}
+				dfs.Concurrently(di, func(ctx context.Context) graphql.Marshaler {
+					return innerFunc(ctx, dfs)
+				})
+
+				// don't run the out.Concurrently() call below
+				out.Values[i] = graphql.Null
+				continue
+			}
+
+			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
+		case "deleteApplication":
+			field := field
+
+			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._ApplicationMutation_deleteApplication(ctx, field, obj)
+				return res
+			}
+
+			if field.Deferrable != nil {
+				dfs, ok := deferred[field.Deferrable.Label]
+				di := 0
+				if ok {
+					dfs.AddField(field)
+					di = len(dfs.Values) - 1
+				} else {
+					dfs = graphql.NewFieldSet([]graphql.CollectedField{field})
+					deferred[field.Deferrable.Label] = dfs
+				}
+				dfs.Concurrently(di, func(ctx context.Context) graphql.Marshaler {
+					return innerFunc(ctx, dfs)
+				})
+
+				// don't run the out.Concurrently() call below
+				out.Values[i] = graphql.Null
+				continue
+			}
+
+			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
+		case "updateApplicationConfig":
+			field := field
+
+			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._ApplicationMutation_updateApplicationConfig(ctx, field, obj)
+				if res == graphql.Null {
+					atomic.AddUint32(&fs.Invalids, 1)
+				}
+				return res
+			}
+
+			if field.Deferrable != nil {
+				dfs, ok := deferred[field.Deferrable.Label]
+				di := 0
+				if ok {
+					dfs.AddField(field)
+					di = len(dfs.Values) - 1
+				} else {
+					dfs = graphql.NewFieldSet([]graphql.CollectedField{field})
+					deferred[field.Deferrable.Label] = dfs
+				}
+				dfs.Concurrently(di, func(ctx context.Context) graphql.Marshaler {
+					return innerFunc(ctx, dfs)
+				})
+
+				// don't run the out.Concurrently() call below
+				out.Values[i] = graphql.Null
+				continue
+			}
+
+			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
 
-				// don't run the out.Concurrently() call below
-				out.Values[i] = graphql.Null
-				continue
-			}
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
 
-			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
-		case "deleteApplication":
-			field := field
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
 
-			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
-				defer func() {
-					if r := recover(); r != nil {
-						ec.Error(ctx, ec.Recover(ctx, r))
-					}
-				}()
-				res = ec._ApplicationMutation_deleteApplication(ctx, field, obj)
-				return res
-			}
+	return out
+}
 
-			if field.Deferrable != nil {
-				dfs, ok := deferred[field.Deferrable.Label]
-				di := 0
-				if ok {
-					dfs.AddField(field)
-					di = len(dfs.Values) - 1
-				} else {
-					dfs = graphql.NewFieldSet([]graphql.CollectedField{field})
-					deferred[field.Deferrable.Label] = dfs
-				}
-				dfs.Concurrently(di, func(ctx context.Context) graphql.Marshaler {
-					return innerFunc(ctx, dfs)
-				})
+var applicationQueryImplementors = []string{"ApplicationQuery"}
 
-				// don't run the out.Concurrently() call below
-				out.Values[i] = graphql.Null
-				continue
-			}
+func (ec *executionContext) _ApplicationQuery(ctx context.Context, sel ast.SelectionSet, obj *ApplicationQuery) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, applicationQueryImplementors)
 
-			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
-		case "updateApplicationConfig":
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("ApplicationQuery")
+		case "getApplication":
 			field := field
 
 			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
@@ -40878,7 +41657,7 @@ func (ec *executionContext) _ApplicationMutation(ctx context.Context, sel ast.Se
 						ec.Error(ctx, ec.Recover(ctx, r))
 					}
 				}()
-				res = ec._ApplicationMutation_updateApplicationConfig(ctx, field, obj)
+				res = ec._ApplicationQuery_getApplication(ctx, field, obj)
 				if res == graphql.Null {
 					atomic.AddUint32(&fs.Invalids, 1)
 				}
@@ -40905,41 +41684,7 @@ func (ec *executionContext) _ApplicationMutation(ctx context.Context, sel ast.Se
 			}
 
 			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
-		default:
-			panic("unknown field " + strconv.Quote(field.Name))
-		}
-	}
-	out.Dispatch(ctx)
-	if out.Invalids > 0 {
-		return graphql.Null
-	}
-
-	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
-
-	for label, dfs := range deferred {
-		ec.processDeferredGroup(graphql.DeferredGroup{
-			Label:    label,
-			Path:     graphql.GetPath(ctx),
-			FieldSet: dfs,
-			Context:  ctx,
-		})
-	}
-
-	return out
-}
-
-var applicationQueryImplementors = []string{"ApplicationQuery"}
-
-func (ec *executionContext) _ApplicationQuery(ctx context.Context, sel ast.SelectionSet, obj *ApplicationQuery) graphql.Marshaler {
-	fields := graphql.CollectFields(ec.OperationContext, sel, applicationQueryImplementors)
-
-	out := graphql.NewFieldSet(fields)
-	deferred := make(map[string]*graphql.FieldSet)
-	for i, field := range fields {
-		switch field.Name {
-		case "__typename":
-			out.Values[i] = graphql.MarshalString("ApplicationQuery")
-		case "getApplication":
+		case "listApplicationMetadata":
 			field := field
 
 			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
@@ -40948,7 +41693,7 @@ func (ec *executionContext) _ApplicationQuery(ctx context.Context, sel ast.Selec
 						ec.Error(ctx, ec.Recover(ctx, r))
 					}
 				}()
-				res = ec._ApplicationQuery_getApplication(ctx, field, obj)
+				res = ec._ApplicationQuery_listApplicationMetadata(ctx, field, obj)
 				if res == graphql.Null {
 					atomic.AddUint32(&fs.Invalids, 1)
 				}
@@ -40975,7 +41720,7 @@ func (ec *executionContext) _ApplicationQuery(ctx context.Context, sel ast.Selec
 			}
 
 			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
-		case "listApplicationMetadata":
+		case "getApplicationFeedbackStats":
 			field := field
 
 			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
@@ -40984,7 +41729,7 @@ func (ec *executionContext) _ApplicationQuery(ctx context.Context, sel ast.Selec
 						ec.Error(ctx, ec.Recover(ctx, r))
 					}
 				}()
-				res = ec._ApplicationQuery_listApplicationMetadata(ctx, field, obj)
+				res = ec._ApplicationQuery_getApplicationFeedbackStats(ctx, field, obj)
 				if res == graphql.Null {
 					atomic.AddUint32(&fs.Invalids, 1)
 				}
@@ -43298,6 +44043,65 @@ func (ec *executionContext) _F(ctx context.Context, sel ast.SelectionSet, obj *F
 	return out
 }
 
+var feedbackStatImplementors = []string{"FeedbackStat"}
+
+func (ec *executionContext) _FeedbackStat(ctx context.Context, sel ast.SelectionSet, obj *FeedbackStat) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, feedbackStatImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("FeedbackStat")
+		case "startTime":
+			out.Values[i] = ec._FeedbackStat_startTime(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "likes":
+			out.Values[i] = ec._FeedbackStat_likes(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "dislikes":
+			out.Values[i] = ec._FeedbackStat_dislikes(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "corrections":
+			out.Values[i] = ec._FeedbackStat_corrections(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		case "satisfactionRate":
+			out.Values[i] = ec._FeedbackStat_satisfactionRate(ctx, field, obj)
+			if out.Values[i] == graphql.Null {
+				out.Invalids++
+			}
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
 var fileDetailsImplementors = []string{"FileDetails"}
 
 func (ec *executionContext) _FileDetails(ctx context.Context, sel ast.SelectionSet, obj *FileDetails) graphql.Marshaler {
@@ -48117,6 +48921,25 @@ func (ec *executionContext) marshalNApplication2ᚖgithubᚗcomᚋkubeagiᚋarca
 	return ec._Application(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalNApplicationFeedbackStats2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐApplicationFeedbackStats(ctx context.Context, sel ast.SelectionSet, v ApplicationFeedbackStats) graphql.Marshaler {
+	return ec._ApplicationFeedbackStats(ctx, sel, &v)
+}
+
+func (ec *executionContext) marshalNApplicationFeedbackStats2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐApplicationFeedbackStats(ctx context.Context, sel ast.SelectionSet, v *ApplicationFeedbackStats) graphql.Marshaler {
+	if v == nil {
+		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
+			ec.Errorf(ctx, "the requested element is null which the schema does not allow")
+		}
+		return graphql.Null
+	}
+	return ec._ApplicationFeedbackStats(ctx, sel, v)
+}
+
+func (ec *executionContext) unmarshalNApplicationFeedbackStatsInput2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐApplicationFeedbackStatsInput(ctx context.Context, v interface{}) (ApplicationFeedbackStatsInput, error) {
+	res, err := ec.unmarshalInputApplicationFeedbackStatsInput(ctx, v)
+	return res, graphql.ErrorOnPath(ctx, err)
+}
+
 func (ec *executionContext) marshalNApplicationMetadata2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐApplicationMetadata(ctx context.Context, sel ast.SelectionSet, v ApplicationMetadata) graphql.Marshaler {
 	return ec._ApplicationMetadata(ctx, sel, &v)
 }
@@ -48327,6 +49150,64 @@ func (ec *executionContext) marshalNF2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapis
 	return ec._F(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalNFeedbackStat2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐFeedbackStat(ctx context.Context, sel ast.SelectionSet, v FeedbackStat) graphql.Marshaler {
+	return ec._FeedbackStat(ctx, sel, &v)
+}
+
+func (ec *executionContext) marshalNFeedbackStat2ᚕᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐFeedbackStatᚄ(ctx context.Context, sel ast.SelectionSet, v []*FeedbackStat) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalNFeedbackStat2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐFeedbackStat(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	for _, e := range ret {
+		if e == graphql.Null {
+			return graphql.Null
+		}
+	}
+
+	return ret
+}
+
+func (ec *executionContext) marshalNFeedbackStat2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐFeedbackStat(ctx context.Context, sel ast.SelectionSet, v *FeedbackStat) graphql.Marshaler {
+	if v == nil {
+		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
+			ec.Errorf(ctx, "the requested element is null which the schema does not allow")
+		}
+		return graphql.Null
+	}
+	return ec._FeedbackStat(ctx, sel, v)
+}
+
 func (ec *executionContext) marshalNFileDetails2ᚖgithubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐFileDetails(ctx context.Context, sel ast.SelectionSet, v *FileDetails) graphql.Marshaler {
 	if v == nil {
 		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
@@ -48362,6 +49243,21 @@ func (ec *executionContext) unmarshalNFileWithVersionInput2ᚖgithubᚗcomᚋkub
 	return &res, graphql.ErrorOnPath(ctx, err)
 }
 
+func (ec *executionContext) unmarshalNFloat2float64(ctx context.Context, v interface{}) (float64, error) {
+	res, err := graphql.UnmarshalFloatContext(ctx, v)
+	return res, graphql.ErrorOnPath(ctx, err)
+}
+
+func (ec *executionContext) marshalNFloat2float64(ctx context.Context, sel ast.SelectionSet, v float64) graphql.Marshaler {
+	res := graphql.MarshalFloatContext(v)
+	if res == graphql.Null {
+		if !graphql.HasFieldError(ctx, graphql.GetFieldContext(ctx)) {
+			ec.Errorf(ctx, "the requested element is null which the schema does not allow")
+		}
+	}
+	return graphql.WrapContextMarshaler(ctx, res)
+}
+
 func (ec *executionContext) marshalNGPT2githubᚗcomᚋkubeagiᚋarcadiaᚋapiserverᚋgraphᚋgeneratedᚐGpt(ctx context.Context, sel ast.SelectionSet, v Gpt) graphql.Marshaler {
 	return ec._GPT(ctx, sel, &v)
 }
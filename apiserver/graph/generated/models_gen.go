@@ -82,6 +82,8 @@ type Application struct {
 	EnableMultiQuery *bool `json:"enableMultiQuery,omitempty"`
 	// chatTimeout 对话超时，单位秒，不填为默认 60s
 	ChatTimeout *float64 `json:"chatTimeout,omitempty"`
+	// chatRetentionDays 对话保留天数，超过后对话及上传的文档将被永久删除，为 0 时使用系统配置中命名空间的保留天数
+	ChatRetentionDays *int `json:"chatRetentionDays,omitempty"`
 	// enableUploadFile 是否开启对话上传文档功能
 	EnableUploadFile *bool `json:"enableUploadFile,omitempty"`
 	// chunkSize 上传文档做文档拆分时的块大小
@@ -92,6 +94,31 @@ type Application struct {
 	BatchSize *int `json:"batchSize,omitempty"`
 }
 
+// ApplicationFeedbackStats
+// 应用的用户反馈统计
+type ApplicationFeedbackStats struct {
+	// 统计时间内的总计
+	Total FeedbackStat `json:"total"`
+	// 每个统计区间的反馈，按时间排序
+	Intervals []*FeedbackStat `json:"intervals"`
+}
+
+type ApplicationFeedbackStatsInput struct {
+	// 应用名称
+	Name string `json:"name"`
+	// 应用所在的命名空间
+	Namespace string `json:"namespace"`
+	// 统计的开始时间
+	// 规则: 默认为7天前
+	StartTime *time.Time `json:"startTime,omitempty"`
+	// 统计的结束时间
+	// 规则: 默认为当前时间
+	EndTime *time.Time `json:"endTime,omitempty"`
+	// 每个统计区间的长度，单位为小时
+	// 规则: 默认为24
+	IntervalHours *int `json:"intervalHours,omitempty"`
+}
+
 // Application
 // 应用 Metadata
 type ApplicationMetadata struct {
@@ -149,8 +176,9 @@ type ApplicationMutation struct {
 }
 
 type ApplicationQuery struct {
-	GetApplication          Application     `json:"getApplication"`
-	ListApplicationMetadata PaginatedResult `json:"listApplicationMetadata"`
+	GetApplication              Application              `json:"getApplication"`
+	ListApplicationMetadata     PaginatedResult          `json:"listApplicationMetadata"`
+	GetApplicationFeedbackStats ApplicationFeedbackStats `json:"getApplicationFeedbackStats"`
 }
 
 type CheckDataProcessTaskNameInput struct {
@@ -256,7 +284,7 @@ type CreateEmbedderInput struct {
 	// 模型服务访问信息(必填)
 	Endpointinput EndpointInput `json:"endpointinput"`
 	// 向量化模型服务接口类型
-	// 规则:  目前支持 zhipuai,openai两种接口类型
+	// 规则:  目前支持 zhipuai,openai,azureopenai,qianfan,dashscope等接口类型
 	Type *string `json:"type,omitempty"`
 	// 此Embedder支持调用的模型列表
 	Models []string `json:"models,omitempty"`
@@ -306,7 +334,7 @@ type CreateLLMInput struct {
 	// 模型服务访问信息(必填)
 	Endpointinput EndpointInput `json:"endpointinput"`
 	// 模型服务接口类型
-	// 规则:  目前支持 zhipuai,openai两种接口类型
+	// 规则:  目前支持 zhipuai,openai,anthropic,azureopenai,qianfan,moonshot,dashscope等接口类型
 	Type *string `json:"type,omitempty"`
 	// 此LLM支持调用的模型列表
 	Models []string `json:"models,omitempty"`
@@ -354,7 +382,7 @@ type CreateModelServiceInput struct {
 	// 规则: 如果该模型支持多种模型类型，则可多选。多选后组成的字段通过逗号隔开。如 "llm,embedding"
 	Types *string `json:"types,omitempty"`
 	// 模型服务 API 类型
-	// 规则：支持 openai, zhipuai 两种类型
+	// 规则：支持 openai, zhipuai, anthropic, azureopenai, qianfan, moonshot, dashscope 等类型
 	APIType *string `json:"apiType,omitempty"`
 	// 模型服务终端输入
 	Endpoint EndpointInput `json:"endpoint"`
@@ -797,7 +825,7 @@ type Endpoint struct {
 type EndpointInput struct {
 	// 地址(必填)
 	URL string `json:"url"`
-	// secret验证密码
+	// secret验证密码,如 apiKey,千帆(qianfan)还需要 secretKey
 	Auth map[string]interface{} `json:"auth,omitempty"`
 	// 默认true
 	Insecure *bool `json:"insecure,omitempty"`
@@ -826,6 +854,21 @@ type F struct {
 
 func (F) IsPageNode() {}
 
+// FeedbackStat
+// 一个统计区间内的用户反馈
+type FeedbackStat struct {
+	// 区间的开始时间
+	StartTime time.Time `json:"startTime"`
+	// 点赞数
+	Likes int `json:"likes"`
+	// 点踩数
+	Dislikes int `json:"dislikes"`
+	// 提供了修正答案的反馈数
+	Corrections int `json:"corrections"`
+	// 满意度，点赞数占所有评价的比例，没有评价时为0
+	SatisfactionRate float64 `json:"satisfactionRate"`
+}
+
 type FileDetails struct {
 	FileName  string `json:"file_name"`
 	Status    string `json:"status"`
@@ -1320,7 +1363,7 @@ type ModelService struct {
 	// 规则: 如果该模型支持多种模型类型，则可多选。多选后组成的字段通过逗号隔开。如 "llm,embedding"
 	Types *string `json:"types,omitempty"`
 	// 模型服务 API 类型
-	// 规则：支持 openai, zhipuai 两种类型
+	// 规则：支持 openai, zhipuai, anthropic, azureopenai, qianfan, moonshot, dashscope 等类型
 	APIType *string `json:"apiType,omitempty"`
 	// 模型服务的大语言模型列表
 	// 规则；如果不填或者为空，则按照模型的API类型获取默认的模型列表
@@ -1735,6 +1778,8 @@ type UpdateApplicationConfigInput struct {
 	EnableMultiQuery *bool `json:"enableMultiQuery,omitempty"`
 	// chatTimeout 对话超时，单位秒，不填为默认 60s
 	ChatTimeout *float64 `json:"chatTimeout,omitempty"`
+	// chatRetentionDays 对话保留天数，超过后对话及上传的文档将被永久删除，为 0 时使用系统配置中命名空间的保留天数
+	ChatRetentionDays *int `json:"chatRetentionDays,omitempty"`
 	// enableUploadFile 是否开启对话上传文档功能
 	EnableUploadFile *bool `json:"enableUploadFile,omitempty"`
 	// chunkSize 上传文档做文档拆分时的块大小
@@ -1830,7 +1875,7 @@ type UpdateEmbedderInput struct {
 	// 模型服务访问信息
 	Endpointinput *EndpointInput `json:"endpointinput,omitempty"`
 	// 向量化模型服务接口类型
-	// 规则:  目前支持 zhipuai,openai两种接口类型
+	// 规则:  目前支持 zhipuai,openai,azureopenai,qianfan,dashscope等接口类型
 	Type *string `json:"type,omitempty"`
 	// 此Embedder支持调用的模型列表
 	Models []string `json:"models,omitempty"`
@@ -1876,7 +1921,7 @@ type UpdateLLMInput struct {
 	// 模型服务访问信息
 	Endpointinput *EndpointInput `json:"endpointinput,omitempty"`
 	// 模型服务接口类型
-	// 规则:  目前支持 zhipuai,openai两种接口类型
+	// 规则:  目前支持 zhipuai,openai,anthropic,azureopenai,qianfan,moonshot,dashscope等接口类型
 	Type *string `json:"type,omitempty"`
 	// 此LLM支持调用的模型列表
 	Models []string `json:"models,omitempty"`
@@ -1928,7 +1973,7 @@ type UpdateModelServiceInput struct {
 	// 规则: 如果该模型支持多种模型类型，则可多选。多选后组成的字段通过逗号隔开。如 "llm,embedding"
 	Types *string `json:"types,omitempty"`
 	// 模型服务 API 类型
-	// 规则：支持 openai, zhipuai 两种类型
+	// 规则：支持 openai, zhipuai, anthropic, azureopenai, qianfan, moonshot, dashscope 等类型
 	APIType *string `json:"apiType,omitempty"`
 	// 模型服务终端输入
 	Endpoint EndpointInput `json:"endpoint"`
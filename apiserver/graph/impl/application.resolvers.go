@@ -65,6 +65,15 @@ func (r *applicationQueryResolver) ListApplicationMetadata(ctx context.Context,
 	return application.ListApplicationMeatadatas(ctx, c, input)
 }
 
+// GetApplicationFeedbackStats is the resolver for the getApplicationFeedbackStats field.
+func (r *applicationQueryResolver) GetApplicationFeedbackStats(ctx context.Context, obj *generated.ApplicationQuery, input generated.ApplicationFeedbackStatsInput) (*generated.ApplicationFeedbackStats, error) {
+	c, err := getClientFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return application.GetApplicationFeedbackStats(ctx, c, input)
+}
+
 // Application is the resolver for the Application field.
 func (r *mutationResolver) Application(ctx context.Context) (*generated.ApplicationMutation, error) {
 	return &generated.ApplicationMutation{}, nil
@@ -107,6 +107,7 @@ func cr2app(prompt *apiprompt.Prompt, chainConfig *apichain.CommonChainConfig, r
 		ShowRetrievalInfo: pointer.Bool(app.Spec.ShowRetrievalInfo),
 		DocNullReturn:     pointer.String(app.Spec.DocNullReturn),
 		ChatTimeout:       pointer.Float64(app.Spec.ChatTimeoutSecond),
+		ChatRetentionDays: pointer.Int(app.Spec.ChatRetentionDays),
 		EnableUploadFile:  app.Spec.EnableUploadFile,
 	}
 	if prompt != nil {
@@ -730,9 +731,9 @@ func UpdateApplicationConfig(ctx context.Context, c client.Client, input generat
 			},
 		}
 		if _, err = controllerutil.CreateOrUpdate(ctx, c, agent, func() error {
-			agent.Spec.AgentConfig.AllowedTools = []apiagent.Tool{}
+			agent.Spec.AgentConfig.AllowedTools = []apiagent.AllowedTool{}
 			for _, v := range input.Tools {
-				agent.Spec.AllowedTools = append(agent.Spec.AllowedTools, apiagent.Tool{
+				agent.Spec.AllowedTools = append(agent.Spec.AllowedTools, apiagent.AllowedTool{
 					Name:   v.Name,
 					Params: utils.MapAny2Str(v.Params),
 				})
@@ -772,6 +773,7 @@ func mutateApp(app *v1alpha1.Application, input generated.UpdateApplicationConfi
 	app.Spec.ShowNextGuide = pointer.BoolDeref(input.ShowNextGuide, app.Spec.ShowNextGuide)
 	app.Spec.DocNullReturn = pointer.StringDeref(input.DocNullReturn, app.Spec.DocNullReturn)
 	app.Spec.ChatTimeoutSecond = pointer.Float64Deref(input.ChatTimeout, v1alpha1.DefaultChatTimeoutSeconds)
+	app.Spec.ChatRetentionDays = pointer.IntDeref(input.ChatRetentionDays, app.Spec.ChatRetentionDays)
 	if input.EnableUploadFile != nil {
 		app.Spec.EnableUploadFile = input.EnableUploadFile
 	}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package application
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/apiserver/graph/generated"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+	pkgclient "github.com/kubeagi/arcadia/apiserver/pkg/client"
+)
+
+const (
+	defaultFeedbackStatsDays          = 7
+	defaultFeedbackStatsIntervalHours = 24
+	// maxFeedbackStatsIntervals limits the number of intervals in one query
+	maxFeedbackStatsIntervals = 1000
+)
+
+var (
+	once        sync.Once
+	chatStorage storage.Storage
+)
+
+func getChatStorage() (storage.Storage, error) {
+	var err error
+	once.Do(func() {
+		var cli client.Client
+		// the storage is configured by the system datasource, which may not be visible to the user
+		cli, err = pkgclient.GetClient(nil)
+		if err != nil {
+			return
+		}
+		chatStorage = chat.NewChatServer(cli, false).Storage()
+	})
+	if chatStorage == nil {
+		return nil, errors.New("chat storage is not available")
+	}
+	return chatStorage, err
+}
+
+// GetApplicationFeedbackStats counts the feedbacks of users to the answers of the application in each interval
+func GetApplicationFeedbackStats(ctx context.Context, c client.Client, input generated.ApplicationFeedbackStatsInput) (*generated.ApplicationFeedbackStats, error) {
+	// make sure the user can get the application
+	app := &v1alpha1.Application{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: input.Namespace, Name: input.Name}, app); err != nil {
+		return nil, err
+	}
+	until := time.Now()
+	if input.EndTime != nil {
+		until = *input.EndTime
+	}
+	since := until.AddDate(0, 0, -defaultFeedbackStatsDays)
+	if input.StartTime != nil {
+		since = *input.StartTime
+	}
+	interval := defaultFeedbackStatsIntervalHours * time.Hour
+	if input.IntervalHours != nil {
+		interval = time.Duration(*input.IntervalHours) * time.Hour
+	}
+	if interval <= 0 || !since.Before(until) {
+		return nil, errors.New("startTime should be before endTime and intervalHours should be positive")
+	}
+	if until.Sub(since)/interval >= maxFeedbackStatsIntervals {
+		return nil, errors.New("too many intervals, please use a larger intervalHours")
+	}
+
+	s, err := getChatStorage()
+	if err != nil {
+		return nil, err
+	}
+	feedbacks, err := s.ListFeedbacks(storage.WithAppName(app.Name), storage.WithAppNamespace(app.Namespace), storage.WithTimeRange(since, until))
+	if err != nil {
+		return nil, err
+	}
+	total, stats := chat.SummarizeFeedbacks(feedbacks, since, until, interval)
+	res := &generated.ApplicationFeedbackStats{
+		Total:     feedbackStat2model(total),
+		Intervals: make([]*generated.FeedbackStat, len(stats)),
+	}
+	for i := range stats {
+		stat := feedbackStat2model(stats[i])
+		res.Intervals[i] = &stat
+	}
+	return res, nil
+}
+
+func feedbackStat2model(stat chat.FeedbackStat) generated.FeedbackStat {
+	return generated.FeedbackStat{
+		StartTime:        stat.Start,
+		Likes:            stat.Likes,
+		Dislikes:         stat.Dislikes,
+		Corrections:      stat.Corrections,
+		SatisfactionRate: stat.SatisfactionRate(),
+	}
+}
@@ -136,10 +136,13 @@ func (cs *ChatServer) ReceiveConversationFile(ctx context.Context, messageID str
 		Answer:    "DONE",
 		Latency:   int64(time.Since(req.StartTime).Milliseconds()),
 		Documents: []storage.Document{document},
+		ParentID:  conversation.LastMessageID(),
+		CreatedAt: req.StartTime,
 	}
 
 	// update conversat ion
 	conversation.Messages = append(conversation.Messages, message)
+	conversation.CurrentMessageID = message.ID
 	conversation.UpdatedAt = time.Now()
 	// update the conversation with new message
 	if err := cs.Storage().UpdateConversation(conversation); err != nil {
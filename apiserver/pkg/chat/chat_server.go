@@ -42,6 +42,7 @@ import (
 	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
 	"github.com/kubeagi/arcadia/apiserver/pkg/common"
 	"github.com/kubeagi/arcadia/pkg/appruntime"
+	"github.com/kubeagi/arcadia/pkg/appruntime/agent"
 	"github.com/kubeagi/arcadia/pkg/appruntime/base"
 	appruntimechain "github.com/kubeagi/arcadia/pkg/appruntime/chain"
 	"github.com/kubeagi/arcadia/pkg/appruntime/knowledgebase"
@@ -50,6 +51,7 @@ import (
 	pkgconfig "github.com/kubeagi/arcadia/pkg/config"
 	"github.com/kubeagi/arcadia/pkg/datasource"
 	"github.com/kubeagi/arcadia/pkg/documentloaders"
+	"github.com/kubeagi/arcadia/pkg/pii"
 )
 
 type ChatServer struct {
@@ -57,6 +59,8 @@ type ChatServer struct {
 	storage   storage.Storage
 	once      sync.Once
 	isGpts    bool
+	// liveRuns are the answers generated in background by message id, see LiveRun
+	liveRuns sync.Map
 }
 
 func NewChatServer(cli runtimeclient.Client, isGpts bool) *ChatServer {
@@ -112,7 +116,6 @@ func (cs *ChatServer) AppRun(ctx context.Context, req ChatReqBody, respStream ch
 	}
 	*timeout = app.Spec.ChatTimeoutSecond
 	var conversation *storage.Conversation
-	history := memory.NewChatMessageHistory()
 	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
 	if !req.NewChat {
 		search := []storage.SearchOption{
@@ -127,10 +130,6 @@ func (cs *ChatServer) AppRun(ctx context.Context, req ChatReqBody, respStream ch
 		if err != nil {
 			return nil, err
 		}
-		for _, v := range conversation.Messages {
-			_ = history.AddUserMessage(ctx, v.Query)
-			_ = history.AddAIMessage(ctx, v.Answer)
-		}
 	} else {
 		conversation = &storage.Conversation{
 			ID:           req.ConversationID,
@@ -141,57 +140,210 @@ func (cs *ChatServer) AppRun(ctx context.Context, req ChatReqBody, respStream ch
 			User:         currentUser,
 			Debug:        req.Debug,
 		}
+	}
+	message := storage.Message{
+		ID:        messageID,
+		Action:    "CHAT",
+		Query:     req.Query,
+		Answer:    "",
+		ParentID:  conversation.LastMessageID(),
+		CreatedAt: req.StartTime,
+	}
+	if req.Files != nil && len(req.Files) > 0 {
+		message.RawFiles = strings.Join(req.Files, ",")
+	}
+	switch {
+	case req.RegenerateMessageID != "":
+		// the regenerated message is a sibling of the original one
+		index := conversation.IndexOf(req.RegenerateMessageID)
+		if index < 0 {
+			return nil, storage.ErrMessageNotFound
+		}
+		message.ParentID = conversation.ParentIDOf(index)
+		message.Query = conversation.Messages[index].Query
+		message.RawFiles = conversation.Messages[index].RawFiles
+	case req.ParentMessageID != "":
+		message.ParentID = req.ParentMessageID
+	}
+	guard, err := cs.newPIIGuard(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	// the question is guarded before it is sent to the llms and stored
+	if guard != nil {
+		query, blocked, err := cs.guardPII(ctx, guard, app, storage.PIIAudit{ConversationID: conversation.ID, MessageID: message.ID, User: currentUser, Stage: PIIStageInput}, message.Query)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrPIIBlocked
+		}
+		message.Query = query
+	}
+	if req.NewChat {
 		// create before do AppRun
 		if err := cs.Storage().UpdateConversation(conversation); err != nil {
 			return nil, err
 		}
 	}
-	conversation.Messages = append(conversation.Messages, storage.Message{
-		ID:     messageID,
-		Action: "CHAT",
-		Query:  req.Query,
-		Answer: "",
-	})
+	// the history is the branch the new message follows
+	branch, err := conversation.Branch(message.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	history, summary := historyOf(ctx, conversation, branch)
+	var files []string
+	if message.RawFiles != "" {
+		files = strings.Split(message.RawFiles, ",")
+	}
+	conversation.Messages = append(conversation.Messages, message)
+	conversation.CurrentMessageID = message.ID
+	input := appruntime.Input{Question: message.Query, Files: files, NeedStream: req.ResponseMode.IsStreaming(), History: history, Summary: summary, ConversationID: req.ConversationID, Events: req.Events}
+	return cs.runApp(ctx, app, guard, conversation, len(conversation.Messages)-1, input, respStream, req.StartTime)
+}
+
+// runApp runs the application with the input and saves the result into the message at index of the conversation.
+// The answer is guarded by the pii guard if it is not nil, but the chunks already streamed are not.
+func (cs *ChatServer) runApp(ctx context.Context, app *v1alpha1.Application, guard *pii.Guard, conversation *storage.Conversation, index int, input appruntime.Input, respStream chan string, startTime time.Time) (*ChatRespBody, error) {
 	// since authenticattion already passed by http handler,we should use chatserver's client which is also the system client to new/ini appruntime
 	appRun, err := appruntime.NewAppOrGetFromCache(ctx, cs.systemCli, app)
 	if err != nil {
 		return nil, err
 	}
-	klog.FromContext(ctx).Info("begin to run application", "appName", req.APPName, "appNamespace", req.AppNamespace)
-	out, err := appRun.Run(ctx, cs.systemCli, respStream, appruntime.Input{Question: req.Query, Files: req.Files, NeedStream: req.ResponseMode.IsStreaming(), History: history, ConversationID: req.ConversationID})
+	klog.FromContext(ctx).Info("begin to run application", "appName", app.Name, "appNamespace", app.Namespace)
+	out, err := appRun.Run(ctx, cs.systemCli, respStream, input)
 	if err != nil {
 		return nil, err
 	}
 
-	conversation.UpdatedAt = req.StartTime
-	conversation.Messages[len(conversation.Messages)-1].Answer = out.Answer
-	conversation.Messages[len(conversation.Messages)-1].References = out.References
-	conversation.Messages[len(conversation.Messages)-1].Latency = time.Since(req.StartTime).Milliseconds()
-	if req.Files != nil && len(req.Files) > 0 {
-		conversation.Messages[len(conversation.Messages)-1].RawFiles = strings.Join(req.Files, ",")
+	message := &conversation.Messages[index]
+	answer := out.Answer
+	if guard != nil {
+		currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+		var blocked bool
+		answer, blocked, err = cs.guardPII(ctx, guard, app, storage.PIIAudit{ConversationID: conversation.ID, MessageID: message.ID, User: currentUser, Stage: PIIStageOutput}, out.Answer)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			answer = PIIBlockedAnswer
+		}
 	}
+	if out.Summary.Rounds > 0 {
+		// the summary covers the beginning of the branch the message follows
+		if branch, err := conversation.Branch(conversation.ParentIDOf(index)); err == nil {
+			conversation.SetSummary(branch, out.Summary.Text, out.Summary.Rounds)
+		}
+	}
+	conversation.UpdatedAt = startTime
+	message.Answer = answer
+	message.References = out.References
+	message.FailureReason = out.FailureReason
+	message.Approval = (*storage.Approval)(out.PendingApproval)
+	message.Latency = time.Since(startTime).Milliseconds()
 
 	if err := cs.Storage().UpdateConversation(conversation); err != nil {
 		return nil, err
 	}
-	return &ChatRespBody{
+	resp := &ChatRespBody{
+		ConversationID:    conversation.ID,
+		MessageID:         message.ID,
+		ParentID:          conversation.ParentIDOf(index),
+		Action:            "CHAT",
+		Message:           answer,
+		CreatedAt:         time.Now(),
+		References:        out.References,
+		Plan:              out.Plan,
+		IntermediateSteps: out.IntermediateSteps,
+		FailureReason:     out.FailureReason,
+	}
+	if out.PendingApproval != nil {
+		resp.PendingApproval = out.PendingApproval.Pending
+	}
+	return resp, nil
+}
+
+// historyOf returns the history of the branch and the saved summary of its older messages
+func historyOf(ctx context.Context, conversation *storage.Conversation, branch []storage.Message) (*memory.ChatMessageHistory, appruntimechain.Summary) {
+	history := memory.NewChatMessageHistory()
+	for _, v := range branch {
+		_ = history.AddUserMessage(ctx, v.Query)
+		_ = history.AddAIMessage(ctx, v.Answer)
+	}
+	text, count := conversation.SummaryOf(branch)
+	return history, appruntimechain.Summary{Text: text, Rounds: count}
+}
+
+// ResumeAppRun runs the application again with the decision of user on the tool call waiting for approval,
+// the answer is saved into the paused message.
+func (cs *ChatServer) ResumeAppRun(ctx context.Context, req ApprovalReqBody, respStream chan string, timeout *float64) (*ChatRespBody, error) {
+	app, err := cs.GetApp(ctx, req.APPName, req.AppNamespace)
+	if err != nil {
+		return nil, err
+	}
+	*timeout = app.Spec.ChatTimeoutSecond
+	search := []storage.SearchOption{
+		storage.WithAppName(req.APPName),
+		storage.WithAppNamespace(req.AppNamespace),
+	}
+	if currentUser, _ := ctx.Value(auth.UserNameContextKey).(string); currentUser != "" {
+		search = append(search, storage.WithUser(currentUser))
+	}
+	conversation, err := cs.Storage().FindExistingConversation(req.ConversationID, search...)
+	if err != nil {
+		return nil, err
+	}
+	index := conversation.IndexOf(req.MessageID)
+	if index < 0 {
+		return nil, storage.ErrMessageNotFound
+	}
+	branch, err := conversation.Branch(conversation.ParentIDOf(index))
+	if err != nil {
+		return nil, err
+	}
+	history, summary := historyOf(ctx, conversation, branch)
+	message := conversation.Messages[index]
+	approval := (*agent.Approval)(message.Approval)
+	if err := approval.Decide(req.Approved, req.Reason); err != nil {
+		return nil, err
+	}
+	var files []string
+	if message.RawFiles != "" {
+		files = strings.Split(message.RawFiles, ",")
+	}
+	input := appruntime.Input{
+		Question:       message.Query,
+		Files:          files,
+		NeedStream:     req.ResponseMode.IsStreaming(),
+		History:        history,
+		Summary:        summary,
 		ConversationID: conversation.ID,
-		MessageID:      messageID,
-		Action:         "CHAT",
-		Message:        out.Answer,
-		CreatedAt:      time.Now(),
-		References:     out.References,
-	}, nil
+		Approval:       approval,
+		Events:         req.Events,
+	}
+	guard, err := cs.newPIIGuard(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	return cs.runApp(ctx, app, guard, conversation, index, input, respStream, req.StartTime)
 }
 
 func (cs *ChatServer) ListConversations(ctx context.Context, req APPMetadata) ([]storage.Conversation, error) {
 	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
-	return cs.Storage().ListConversations(storage.WithAppNamespace(req.AppNamespace), storage.WithAppName(req.APPName), storage.WithUser(currentUser))
+	conversations, err := cs.Storage().ListConversations(storage.WithAppNamespace(req.AppNamespace), storage.WithAppName(req.APPName), storage.WithUser(currentUser))
+	if err != nil {
+		return nil, err
+	}
+	for i := range conversations {
+		conversations[i].BuildTree()
+	}
+	return conversations, nil
 }
 
 func (cs *ChatServer) DeleteConversation(ctx context.Context, conversationID string) error {
 	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
 	// Note: in pg table, this data is marked as deleted, deleted_at column is not null. the pdf in minio is not deleted. we only delete the conversation knowledgebase.
+	// The conversation and the pdf are deleted permanently by the purger after the retention days, see PurgeExpiredConversations.
 	// delete conversation knowledgebase if it exists
 	// when delete is successful, it means currentuser is the creator of this conversation
 	err := cs.Storage().Delete(storage.WithConversationID(conversationID), storage.WithUser(currentUser))
@@ -220,6 +372,7 @@ func (cs *ChatServer) ListMessages(ctx context.Context, req ConversationReqBody)
 		return storage.Conversation{}, err
 	}
 	if c != nil {
+		c.BuildTree()
 		return *c, nil
 	}
 	return storage.Conversation{}, errors.New("conversation is not found")
@@ -0,0 +1,333 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	"github.com/kubeagi/arcadia/apiserver/config"
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+)
+
+const (
+	ExportFormatJSON     = "json"
+	ExportFormatMarkdown = "markdown"
+	ExportFormatPDF      = "pdf"
+
+	// ConversationExportVersion is the version of the json format, it is increased when the format changes incompatibly
+	ConversationExportVersion = 1
+
+	exportTimeLayout = "2006-01-02 15:04:05 MST"
+	// pdfFontFamily is the family name of the font set by --pdf-font
+	pdfFontFamily = "export"
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format, should be one of json, markdown and pdf")
+
+// ConversationExport is a conversation exported as json, which can be imported to another app or environment
+type ConversationExport struct {
+	Version      int                  `json:"version" example:"1"`
+	ExportedAt   time.Time            `json:"exported_at" example:"2023-12-22T10:21:06.389359092+08:00"`
+	Conversation storage.Conversation `json:"conversation"`
+}
+
+// ExportConversation writes the conversation of current user in the format
+func (cs *ChatServer) ExportConversation(ctx context.Context, req ConversationExportReqBody, w io.Writer) error {
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	c, err := cs.Storage().FindExistingConversation(req.ConversationID, storage.WithAppName(req.APPName), storage.WithAppNamespace(req.AppNamespace), storage.WithUser(currentUser))
+	if err != nil {
+		return err
+	}
+	c.BuildTree()
+	export := ConversationExport{Version: ConversationExportVersion, ExportedAt: time.Now(), Conversation: *c}
+	switch req.Format {
+	case "", ExportFormatJSON:
+		return WriteConversationJSON(w, export)
+	case ExportFormatMarkdown:
+		return WriteConversationMarkdown(w, export)
+	case ExportFormatPDF:
+		return WriteConversationPDF(w, export, config.GetConfig().PDFFont)
+	default:
+		return ErrUnsupportedExportFormat
+	}
+}
+
+// ImportConversation recreates an exported conversation in the app for current user.
+// New ids are generated, so the same export can be imported more than once.
+// The uploaded documents are kept as records, but the conversation knowledgebase is not rebuilt.
+func (cs *ChatServer) ImportConversation(ctx context.Context, req ConversationImportReqBody) (*storage.Conversation, error) {
+	if _, err := cs.GetApp(ctx, req.APPName, req.AppNamespace); err != nil {
+		return nil, err
+	}
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	conversation, err := ConversationFromExport(req.Conversation, req.APPName, req.AppNamespace, currentUser)
+	if err != nil {
+		return nil, err
+	}
+	if err := cs.Storage().UpdateConversation(conversation); err != nil {
+		return nil, err
+	}
+	conversation.BuildTree()
+	return conversation, nil
+}
+
+// ConversationFromExport builds a new conversation of the app and user from the export, the branches of messages are kept.
+// The feedbacks are not imported, since they are the ratings of the app in the source environment.
+func ConversationFromExport(export ConversationExport, appName, appNamespace, user string) (*storage.Conversation, error) {
+	if export.Version != ConversationExportVersion {
+		return nil, fmt.Errorf("unsupported export version %d, should be %d", export.Version, ConversationExportVersion)
+	}
+	src := &export.Conversation
+	ids := map[string]string{src.ID: string(uuid.NewUUID())}
+	for _, m := range src.Messages {
+		if _, ok := ids[m.ID]; ok || m.ID == "" {
+			return nil, fmt.Errorf("message id %q is empty or duplicated", m.ID)
+		}
+		ids[m.ID] = string(uuid.NewUUID())
+	}
+	conversation := &storage.Conversation{
+		ID:           ids[src.ID],
+		AppName:      appName,
+		AppNamespace: appNamespace,
+		User:         user,
+		StartedAt:    src.StartedAt,
+		UpdatedAt:    src.UpdatedAt,
+		Messages:     make([]storage.Message, 0, len(src.Messages)),
+	}
+	for i, m := range src.Messages {
+		parentID, ok := ids[src.ParentIDOf(i)]
+		if !ok {
+			return nil, fmt.Errorf("message %s follows an unknown message %s", m.ID, src.ParentIDOf(i))
+		}
+		message := storage.Message{
+			ID:             ids[m.ID],
+			ConversationID: conversation.ID,
+			Latency:        m.Latency,
+			ParentID:       parentID,
+			Action:         m.Action,
+			Query:          m.Query,
+			RawFiles:       strings.Join(nonEmpty(m.Files), ","),
+			Answer:         m.Answer,
+			References:     m.References,
+			FailureReason:  m.FailureReason,
+			Approval:       m.Approval,
+			CreatedAt:      m.CreatedAt,
+		}
+		// the documents of other messages are found by the files when they are read
+		if m.Action == "UPLOAD" {
+			for _, d := range m.Documents {
+				message.Documents = append(message.Documents, storage.Document{
+					ID:             string(uuid.NewUUID()),
+					Name:           d.Name,
+					Object:         d.Object,
+					ConversationID: conversation.ID,
+					MessageID:      message.ID,
+					Summary:        d.Summary,
+				})
+			}
+		}
+		conversation.Messages = append(conversation.Messages, message)
+	}
+	conversation.CurrentMessageID = ids[src.CurrentMessageID]
+	return conversation, nil
+}
+
+// WriteConversationJSON writes the conversation as json, which can be imported by ImportConversation
+func WriteConversationJSON(w io.Writer, export ConversationExport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// WriteConversationMarkdown writes the conversation as a markdown transcript
+func WriteConversationMarkdown(w io.Writer, export ConversationExport) error {
+	t := newTranscript(export)
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# %s\n\n", t.title)
+	for _, line := range t.meta {
+		fmt.Fprintf(b, "- %s\n", line)
+	}
+	for _, m := range t.messages {
+		fmt.Fprintf(b, "\n## %s\n\n", m.title)
+		for _, line := range m.meta {
+			fmt.Fprintf(b, "- %s\n", line)
+		}
+		for _, s := range m.sections {
+			fmt.Fprintf(b, "\n**%s**\n\n%s\n", s.label, s.text)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteConversationPDF writes the conversation as a pdf transcript.
+// The font should be a ttf font covering the language of the chat, the built-in font which only supports latin characters is used if it is empty.
+func WriteConversationPDF(w io.Writer, export ConversationExport, font string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	family, translate := "Helvetica", pdf.UnicodeTranslatorFromDescriptor("")
+	if font != "" {
+		fontBytes, err := os.ReadFile(font)
+		if err != nil {
+			return fmt.Errorf("failed to read pdf font: %w", err)
+		}
+		pdf.AddUTF8FontFromBytes(pdfFontFamily, "", fontBytes)
+		family, translate = pdfFontFamily, func(s string) string { return s }
+	}
+	write := func(size float64, gray bool, text string) {
+		if gray {
+			pdf.SetTextColor(100, 100, 100)
+		} else {
+			pdf.SetTextColor(0, 0, 0)
+		}
+		pdf.SetFont(family, "", size)
+		// the line height is about 1.4 times of the font size, in mm
+		pdf.MultiCell(0, size*0.5, translate(text), "", "L", false)
+	}
+
+	t := newTranscript(export)
+	pdf.SetTitle(t.title, true)
+	pdf.AddPage()
+	write(18, false, t.title)
+	pdf.Ln(2)
+	for _, line := range t.meta {
+		write(9, true, line)
+	}
+	for _, m := range t.messages {
+		pdf.Ln(6)
+		write(14, false, m.title)
+		for _, line := range m.meta {
+			write(9, true, line)
+		}
+		for _, s := range m.sections {
+			pdf.Ln(2)
+			write(10, true, s.label)
+			write(11, false, s.text)
+		}
+	}
+	return pdf.Output(w)
+}
+
+// transcript is the readable content of a conversation shared by markdown and pdf
+type transcript struct {
+	title    string
+	meta     []string
+	messages []transcriptMessage
+}
+
+type transcriptMessage struct {
+	title    string
+	meta     []string
+	sections []transcriptSection
+}
+
+type transcriptSection struct {
+	label string
+	text  string
+}
+
+func newTranscript(export ConversationExport) transcript {
+	c := &export.Conversation
+	t := transcript{
+		title: "Conversation " + c.ID,
+		meta: []string{
+			fmt.Sprintf("App: %s/%s", c.AppNamespace, c.AppName),
+			"Started at: " + c.StartedAt.Format(exportTimeLayout),
+			"Exported at: " + export.ExportedAt.Format(exportTimeLayout),
+		},
+	}
+	for i, m := range c.Messages {
+		tm := transcriptMessage{
+			title: fmt.Sprintf("Message %d", i+1),
+			meta:  []string{"ID: " + m.ID},
+		}
+		if !m.CreatedAt.IsZero() {
+			tm.meta = append(tm.meta, "Time: "+m.CreatedAt.Format(exportTimeLayout))
+		}
+		// mark the messages of other branches, such as the regenerated answers
+		if parent := c.IndexOf(c.ParentIDOf(i)); parent >= 0 && parent != i-1 {
+			tm.meta = append(tm.meta, fmt.Sprintf("Reply to: Message %d", parent+1))
+		}
+		if m.Action == "UPLOAD" {
+			tm.sections = append(tm.sections, transcriptSection{label: "Uploaded documents", text: strings.Join(documentNames(m.Documents), ", ")})
+			t.messages = append(t.messages, tm)
+			continue
+		}
+		tm.sections = append(tm.sections, transcriptSection{label: "Question", text: m.Query})
+		files := documentNames(m.Documents)
+		if len(files) == 0 {
+			files = nonEmpty(m.Files)
+		}
+		if len(files) > 0 {
+			tm.sections = append(tm.sections, transcriptSection{label: "Documents", text: strings.Join(files, ", ")})
+		}
+		tm.sections = append(tm.sections, transcriptSection{label: "Answer", text: m.Answer})
+		if m.FailureReason != "" {
+			tm.sections = append(tm.sections, transcriptSection{label: "Failure reason", text: m.FailureReason})
+		}
+		if len(m.References) > 0 {
+			refs := make([]string, len(m.References))
+			for j, r := range m.References {
+				refs[j] = fmt.Sprintf("%d. %s", j+1, referenceText(r.Title, r.URL, r.FileName, r.PageNumber, r.Content))
+			}
+			tm.sections = append(tm.sections, transcriptSection{label: "References", text: strings.Join(refs, "\n")})
+		}
+		t.messages = append(t.messages, tm)
+	}
+	return t
+}
+
+func referenceText(title, url, fileName string, page int, content string) string {
+	source := fileName
+	if url != "" {
+		source = strings.TrimSpace(title + " " + url)
+	} else if page > 0 {
+		source = fmt.Sprintf("%s, page %d", fileName, page)
+	}
+	if content == "" {
+		return source
+	}
+	return source + ": " + strings.Join(strings.Fields(content), " ")
+}
+
+func documentNames(documents []storage.Document) []string {
+	names := make([]string, 0, len(documents))
+	for _, d := range documents {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+func nonEmpty(s []string) []string {
+	res := make([]string, 0, len(s))
+	for _, v := range s {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
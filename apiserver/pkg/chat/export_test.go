@@ -0,0 +1,105 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+)
+
+func TestExportConversation(t *testing.T) {
+	cs := &ChatServer{storage: storage.NewMemoryStorage()}
+	created := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, cs.Storage().UpdateConversation(&storage.Conversation{
+		ID:           "conversation",
+		AppName:      "app",
+		AppNamespace: "default",
+		User:         "alice",
+		StartedAt:    created,
+		Messages: []storage.Message{
+			{ID: "m1", ConversationID: "conversation", Action: "UPLOAD", Query: "UPLOAD", Answer: "DONE", CreatedAt: created,
+				Documents: []storage.Document{{ID: "d1", Name: "kaoqin.pdf", Object: "obj/kaoqin.pdf"}}},
+			{ID: "m2", ConversationID: "conversation", Query: "旷工最小计算单位为多少天？", Answer: "0.5 day", Files: []string{"obj/kaoqin.pdf"}, CreatedAt: created.Add(time.Minute),
+				References: storage.References{{FileName: "kaoqin.pdf", PageNumber: 2, Content: "旷工最小计算单位为0.5天"}},
+				Feedback:   &storage.Feedback{MessageID: "m2", Rating: storage.RatingLike}},
+			// the answer is regenerated
+			{ID: "m3", ConversationID: "conversation", ParentID: "m1", Query: "旷工最小计算单位为多少天？", Answer: "half a day", CreatedAt: created.Add(2 * time.Minute)},
+		},
+	}))
+	ctx := context.WithValue(context.Background(), auth.UserNameContextKey, "alice")
+	req := ConversationExportReqBody{ConversationID: "conversation"}
+	req.APPName, req.AppNamespace = "app", "default"
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, cs.ExportConversation(ctx, req, buf))
+	export := ConversationExport{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &export))
+	require.Equal(t, ConversationExportVersion, export.Version)
+	require.Len(t, export.Conversation.Messages, 3)
+	require.Equal(t, "m3", export.Conversation.CurrentMessageID)
+
+	imported, err := ConversationFromExport(export, "other", "default", "bob")
+	require.NoError(t, err)
+	require.NotEqual(t, "conversation", imported.ID)
+	require.Equal(t, "other", imported.AppName)
+	require.Equal(t, "bob", imported.User)
+	require.Len(t, imported.Messages, 3)
+	m1, m2, m3 := imported.Messages[0], imported.Messages[1], imported.Messages[2]
+	require.Equal(t, imported.ID, m1.ParentID)
+	require.Equal(t, m1.ID, m2.ParentID)
+	require.Equal(t, m1.ID, m3.ParentID)
+	require.Equal(t, m3.ID, imported.CurrentMessageID)
+	require.Equal(t, "obj/kaoqin.pdf", m2.RawFiles)
+	require.Equal(t, created.Add(time.Minute), m2.CreatedAt.UTC())
+	require.Equal(t, []retriever.Reference(export.Conversation.Messages[1].References), []retriever.Reference(m2.References))
+	require.Nil(t, m2.Feedback)
+	require.Len(t, m1.Documents, 1)
+	require.Equal(t, m1.ID, m1.Documents[0].MessageID)
+	require.Equal(t, "kaoqin.pdf", m1.Documents[0].Name)
+
+	export.Version = 0
+	_, err = ConversationFromExport(export, "other", "default", "bob")
+	require.Error(t, err)
+
+	buf.Reset()
+	req.Format = ExportFormatMarkdown
+	require.NoError(t, cs.ExportConversation(ctx, req, buf))
+	markdown := buf.String()
+	require.Contains(t, markdown, "# Conversation conversation\n")
+	require.Contains(t, markdown, "- App: default/app\n")
+	require.Contains(t, markdown, "**Uploaded documents**\n\nkaoqin.pdf\n")
+	require.Contains(t, markdown, "- Time: 2024-01-01 08:01:00 UTC\n")
+	require.Contains(t, markdown, "**References**\n\n1. kaoqin.pdf, page 2: 旷工最小计算单位为0.5天\n")
+	require.Contains(t, markdown, "## Message 3\n\n- ID: m3\n- Time: 2024-01-01 08:02:00 UTC\n- Reply to: Message 1\n")
+
+	buf.Reset()
+	req.Format = ExportFormatPDF
+	require.NoError(t, cs.ExportConversation(ctx, req, buf))
+	require.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+
+	req.Format = "doc"
+	require.ErrorIs(t, cs.ExportConversation(ctx, req, buf), ErrUnsupportedExportFormat)
+}
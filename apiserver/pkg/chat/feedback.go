@@ -0,0 +1,149 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+)
+
+const (
+	// the columns of the exported dataset, same as the default columns of the QA csv used by rag evaluation
+	datasetQuestionColumn = "q"
+	datasetAnswerColumn   = "a"
+)
+
+// SaveFeedback creates or updates the feedback of current user to a message
+func (cs *ChatServer) SaveFeedback(ctx context.Context, req FeedbackReqBody) (*storage.Feedback, error) {
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	conversation, err := cs.Storage().FindExistingConversation(req.ConversationID, storage.WithAppName(req.APPName), storage.WithAppNamespace(req.AppNamespace), storage.WithUser(currentUser))
+	if err != nil {
+		return nil, err
+	}
+	var message *storage.Message
+	for i := range conversation.Messages {
+		if conversation.Messages[i].ID == req.MessageID {
+			message = &conversation.Messages[i]
+			break
+		}
+	}
+	if message == nil {
+		return nil, storage.ErrMessageNotFound
+	}
+	feedback := &storage.Feedback{
+		MessageID:       message.ID,
+		ConversationID:  conversation.ID,
+		AppName:         conversation.AppName,
+		AppNamespace:    conversation.AppNamespace,
+		User:            currentUser,
+		Rating:          req.Rating,
+		Comment:         req.Comment,
+		CorrectedAnswer: strings.TrimSpace(req.CorrectedAnswer),
+		Query:           message.Query,
+		Answer:          message.Answer,
+	}
+	if err := cs.Storage().UpdateFeedback(feedback); err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// ExportFeedbackDataset writes the feedbacks of the app as a QA csv which can be used as the test data of rag evaluation
+func (cs *ChatServer) ExportFeedbackDataset(ctx context.Context, req FeedbackExportReqBody, w io.Writer) error {
+	feedbacks, err := cs.Storage().ListFeedbacks(storage.WithAppName(req.APPName), storage.WithAppNamespace(req.AppNamespace), storage.WithTimeRange(req.Since, req.Until))
+	if err != nil {
+		return err
+	}
+	return WriteFeedbackDataset(w, feedbacks)
+}
+
+// WriteFeedbackDataset writes the question and the expected answer of the feedbacks in csv.
+// The corrected answer is expected if there is one, otherwise only the liked answers are written.
+func WriteFeedbackDataset(w io.Writer, feedbacks []storage.Feedback) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{datasetQuestionColumn, datasetAnswerColumn}); err != nil {
+		return err
+	}
+	for _, f := range feedbacks {
+		answer := f.CorrectedAnswer
+		if answer == "" && f.Rating == storage.RatingLike {
+			answer = f.Answer
+		}
+		if f.Query == "" || answer == "" {
+			continue
+		}
+		if err := writer.Write([]string{f.Query, answer}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// FeedbackStat is the count of feedbacks given in a period
+type FeedbackStat struct {
+	Start       time.Time
+	Likes       int
+	Dislikes    int
+	Corrections int
+}
+
+func (s *FeedbackStat) add(f storage.Feedback) {
+	switch f.Rating {
+	case storage.RatingLike:
+		s.Likes++
+	case storage.RatingDislike:
+		s.Dislikes++
+	}
+	if f.CorrectedAnswer != "" {
+		s.Corrections++
+	}
+}
+
+// SatisfactionRate is the ratio of likes in all ratings, 0 if there is no rating
+func (s FeedbackStat) SatisfactionRate() float64 {
+	if s.Likes+s.Dislikes == 0 {
+		return 0
+	}
+	return float64(s.Likes) / float64(s.Likes+s.Dislikes)
+}
+
+// SummarizeFeedbacks counts the feedbacks in total and in each period from since to until,
+// feedbacks out of the range are ignored.
+func SummarizeFeedbacks(feedbacks []storage.Feedback, since, until time.Time, period time.Duration) (total FeedbackStat, stats []FeedbackStat) {
+	total.Start = since
+	if period <= 0 {
+		period = until.Sub(since)
+	}
+	for start := since; start.Before(until); start = start.Add(period) {
+		stats = append(stats, FeedbackStat{Start: start})
+	}
+	for _, f := range feedbacks {
+		if f.CreatedAt.Before(since) || !f.CreatedAt.Before(until) {
+			continue
+		}
+		total.add(f)
+		stats[int(f.CreatedAt.Sub(since)/period)].add(f)
+	}
+	return total, stats
+}
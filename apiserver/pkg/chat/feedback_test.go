@@ -0,0 +1,103 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+)
+
+func TestFeedback(t *testing.T) {
+	cs := &ChatServer{storage: storage.NewMemoryStorage()}
+	require.NoError(t, cs.Storage().UpdateConversation(&storage.Conversation{
+		ID:           "conversation",
+		AppName:      "app",
+		AppNamespace: "default",
+		User:         "alice",
+		Messages: []storage.Message{
+			{ID: "m1", ConversationID: "conversation", Query: "how many days?", Answer: "0.5 day"},
+			{ID: "m2", ConversationID: "conversation", Query: "who are you?", Answer: "a bot"},
+			{ID: "m3", ConversationID: "conversation", Query: "what is the weather?", Answer: "no idea"},
+		},
+	}))
+	ctx := context.WithValue(context.Background(), auth.UserNameContextKey, "alice")
+	req := func(messageID string, rating int, corrected string) FeedbackReqBody {
+		r := FeedbackReqBody{Rating: rating, CorrectedAnswer: corrected}
+		r.APPName, r.AppNamespace, r.ConversationID, r.MessageID = "app", "default", "conversation", messageID
+		return r
+	}
+
+	_, err := cs.SaveFeedback(ctx, req("m1", storage.RatingLike, ""))
+	require.NoError(t, err)
+	// the feedback given before is replaced
+	_, err = cs.SaveFeedback(ctx, req("m1", storage.RatingDislike, " 1 day "))
+	require.NoError(t, err)
+	_, err = cs.SaveFeedback(ctx, req("m2", storage.RatingLike, ""))
+	require.NoError(t, err)
+	_, err = cs.SaveFeedback(ctx, req("m3", storage.RatingDislike, ""))
+	require.NoError(t, err)
+	_, err = cs.SaveFeedback(ctx, req("not-exist", storage.RatingLike, ""))
+	require.ErrorIs(t, err, storage.ErrMessageNotFound)
+	// only the user of the conversation can give feedback
+	_, err = cs.SaveFeedback(context.WithValue(context.Background(), auth.UserNameContextKey, "bob"), req("m2", storage.RatingDislike, ""))
+	require.Error(t, err)
+
+	message, err := cs.Storage().FindExistingMessage("conversation", "m1")
+	require.NoError(t, err)
+	require.Equal(t, storage.RatingDislike, message.Feedback.Rating)
+	require.Equal(t, "1 day", message.Feedback.CorrectedAnswer)
+
+	feedbacks, err := cs.Storage().ListFeedbacks(storage.WithAppName("app"), storage.WithAppNamespace("default"))
+	require.NoError(t, err)
+	require.Len(t, feedbacks, 3)
+	feedbacks, err = cs.Storage().ListFeedbacks(storage.WithAppName("app"), storage.WithTimeRange(time.Now().Add(time.Hour), time.Time{}))
+	require.NoError(t, err)
+	require.Empty(t, feedbacks)
+
+	export := FeedbackExportReqBody{}
+	export.APPName, export.AppNamespace = "app", "default"
+	buf := &bytes.Buffer{}
+	require.NoError(t, cs.ExportFeedbackDataset(ctx, export, buf))
+	require.Equal(t, "q,a\nhow many days?,1 day\nwho are you?,a bot\n", buf.String())
+}
+
+func TestSummarizeFeedbacks(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(3 * 24 * time.Hour)
+	feedbacks := []storage.Feedback{
+		{Rating: storage.RatingLike, CreatedAt: since.Add(-time.Hour)},
+		{Rating: storage.RatingLike, CreatedAt: since},
+		{Rating: storage.RatingDislike, CorrectedAnswer: "a", CreatedAt: since.Add(time.Hour)},
+		{Rating: storage.RatingLike, CreatedAt: since.Add(50 * time.Hour)},
+		{Rating: storage.RatingLike, CreatedAt: until},
+	}
+	total, stats := SummarizeFeedbacks(feedbacks, since, until, 24*time.Hour)
+	require.Equal(t, FeedbackStat{Start: since, Likes: 2, Dislikes: 1, Corrections: 1}, total)
+	require.InDelta(t, 2.0/3, total.SatisfactionRate(), 0.0001)
+	require.Len(t, stats, 3)
+	require.Equal(t, 0.5, stats[0].SatisfactionRate())
+	require.Equal(t, since.Add(24*time.Hour), stats[1].Start)
+	require.Zero(t, stats[1].SatisfactionRate())
+	require.Equal(t, 1, stats[2].Likes)
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+)
+
+var (
+	// ErrAnswerRunning means the answer of the message is being generated
+	ErrAnswerRunning = errors.New("the answer of the message is being generated")
+	// ErrAnswerCancelled means the answer is stopped by the user
+	ErrAnswerCancelled = errors.New("the answer is cancelled")
+	// ErrAnswerTimeout means no data is received from the llm for a long time
+	ErrAnswerTimeout = errors.New("no data from llm for a long time")
+)
+
+const (
+	// liveRunRetention is how long a finished answer is kept for the clients reconnecting
+	liveRunRetention = time.Minute
+	// defaultLiveRunIdleTimeout stops the answer if no data is received for the time and the application doesn't set chatTimeoutSecond
+	defaultLiveRunIdleTimeout = 120 * time.Second
+	// liveRunSubscriberBuffer is the number of events buffered for a subscriber, a slower subscriber has to subscribe again
+	liveRunSubscriberBuffer = 64
+)
+
+// LiveRun is an answer generated in background, it goes on when the client disconnects until it is finished or cancelled.
+// The clients follow it by Subscribe, and reconnect with the seq of the last event received.
+// The live runs are kept in the memory of this apiserver, so the client has to reconnect to the same one.
+type LiveRun struct {
+	ConversationID string
+	MessageID      string
+	// User and AppNamespace are of the request starting the answer, only the same user can follow or cancel it
+	User         string
+	AppNamespace string
+
+	cancel      context.CancelCauseFunc
+	mu          sync.Mutex
+	events      []ChatEvent
+	subscribers map[<-chan ChatEvent]chan ChatEvent
+	finished    bool
+}
+
+// Cancel stops generating the answer, it ends with an error event
+func (r *LiveRun) Cancel() {
+	r.cancel(ErrAnswerCancelled)
+}
+
+// Finished returns whether the final event is sent
+func (r *LiveRun) Finished() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.finished
+}
+
+// Subscribe returns the events after the seq and a channel of the following events.
+// The channel is closed after the final event, or when the subscriber is too slow, then it should subscribe again with the last seq.
+func (r *LiveRun) Subscribe(afterSeq int) ([]ChatEvent, <-chan ChatEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	afterSeq = max(0, min(afterSeq, len(r.events)))
+	past := append([]ChatEvent(nil), r.events[afterSeq:]...)
+	ch := make(chan ChatEvent, liveRunSubscriberBuffer)
+	if r.finished {
+		close(ch)
+	} else {
+		r.subscribers[ch] = ch
+	}
+	return past, ch
+}
+
+// Unsubscribe stops sending events to the channel
+func (r *LiveRun) Unsubscribe(ch <-chan ChatEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.subscribers[ch]; ok {
+		delete(r.subscribers, ch)
+		close(c)
+	}
+}
+
+func (r *LiveRun) publish(event ChatEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finished {
+		return
+	}
+	event.Seq = len(r.events) + 1
+	event.ConversationID = r.ConversationID
+	event.MessageID = r.MessageID
+	event.CreatedAt = time.Now()
+	r.events = append(r.events, event)
+	for k, ch := range r.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(r.subscribers, k)
+			close(ch)
+		}
+	}
+	if event.IsFinal() {
+		r.finished = true
+		for k, ch := range r.subscribers {
+			delete(r.subscribers, k)
+			close(ch)
+		}
+	}
+}
+
+// run publishes the tokens and events until the answer is returned, the answer is cancelled if there is no data for the idle timeout
+func (r *LiveRun) run(ctx context.Context, idleTimeout time.Duration, run func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error)) (response *ChatRespBody, err error) {
+	respStream := make(chan string, 1)
+	events := make(chan base.StreamEvent, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if e := recover(); e != nil {
+				err = fmt.Errorf("a panic occurred when generating the answer: %v", e)
+			}
+		}()
+		response, err = run(ctx, respStream, events)
+	}()
+	idle := time.NewTimer(idleTimeout)
+	defer idle.Stop()
+	touch := func() {
+		if !idle.Stop() {
+			select {
+			case <-idle.C:
+			default:
+			}
+		}
+		idle.Reset(idleTimeout)
+	}
+	for {
+		select {
+		case msg := <-respStream:
+			r.publish(ChatEvent{Type: ChatEventToken, Message: msg})
+			touch()
+		case e := <-events:
+			// the stream event types are the same as the chat event types
+			r.publish(ChatEvent{Type: ChatEventType(e.Type), Message: e.Content})
+			touch()
+		case <-idle.C:
+			r.cancel(ErrAnswerTimeout)
+		case <-done:
+			// the tokens sent just before the answer is returned, like the message of DocNullReturn
+			for {
+				select {
+				case msg := <-respStream:
+					r.publish(ChatEvent{Type: ChatEventToken, Message: msg})
+				case e := <-events:
+					r.publish(ChatEvent{Type: ChatEventType(e.Type), Message: e.Content})
+				default:
+					return response, err
+				}
+			}
+		}
+	}
+}
+
+// StartAppRun starts to answer the chat request in background in streaming mode, see LiveRun
+func (cs *ChatServer) StartAppRun(ctx context.Context, req ChatReqBody, messageID string) (*LiveRun, error) {
+	app, err := cs.GetApp(ctx, req.APPName, req.AppNamespace)
+	if err != nil {
+		return nil, err
+	}
+	return cs.startLiveRun(ctx, app, req.ConversationID, messageID, func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error) {
+		req.ResponseMode = Streaming
+		req.Events = events
+		// the idle timeout is handled by the live run
+		var timeout float64
+		return cs.AppRun(ctx, req, respStream, messageID, &timeout)
+	})
+}
+
+// StartResumeAppRun resumes the answer paused for approval in background in streaming mode, see LiveRun
+func (cs *ChatServer) StartResumeAppRun(ctx context.Context, req ApprovalReqBody) (*LiveRun, error) {
+	app, err := cs.GetApp(ctx, req.APPName, req.AppNamespace)
+	if err != nil {
+		return nil, err
+	}
+	return cs.startLiveRun(ctx, app, req.ConversationID, req.MessageID, func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error) {
+		req.ResponseMode = Streaming
+		req.Events = events
+		var timeout float64
+		return cs.ResumeAppRun(ctx, req, respStream, &timeout)
+	})
+}
+
+// GetLiveRun returns the answer of the message being generated or finished recently, nil if it is not found or started by another user
+func (cs *ChatServer) GetLiveRun(ctx context.Context, appNamespace, messageID string) *LiveRun {
+	v, ok := cs.liveRuns.Load(messageID)
+	if !ok {
+		return nil
+	}
+	r := v.(*LiveRun)
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	if r.User != currentUser || r.AppNamespace != appNamespace {
+		return nil
+	}
+	return r
+}
+
+// SavedAnswer returns the saved answer of the message as the final event, for the clients reconnecting after the live run is removed
+func (cs *ChatServer) SavedAnswer(ctx context.Context, appNamespace, conversationID, messageID string) (ChatEvent, error) {
+	search := []storage.SearchOption{storage.WithAppNamespace(appNamespace)}
+	if currentUser, _ := ctx.Value(auth.UserNameContextKey).(string); currentUser != "" {
+		search = append(search, storage.WithUser(currentUser))
+	}
+	message, err := cs.Storage().FindExistingMessage(conversationID, messageID, search...)
+	if err != nil {
+		return ChatEvent{}, err
+	}
+	if message == nil {
+		return ChatEvent{}, storage.ErrMessageNotFound
+	}
+	resp := &ChatRespBody{
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		Action:         message.Action,
+		Message:        message.Answer,
+		CreatedAt:      message.CreatedAt,
+		References:     message.References,
+		FailureReason:  message.FailureReason,
+		Latency:        message.Latency,
+	}
+	event := ChatEvent{Type: ChatEventDone, ConversationID: conversationID, MessageID: messageID, Response: resp, CreatedAt: time.Now()}
+	if message.Approval != nil && message.Approval.Pending != nil {
+		resp.PendingApproval = message.Approval.Pending
+		event.Type = ChatEventApproval
+	}
+	return event, nil
+}
+
+// startLiveRun runs the answer of the message in background, it is not stopped by the cancellation of ctx but by LiveRun.Cancel
+func (cs *ChatServer) startLiveRun(ctx context.Context, app *v1alpha1.Application, conversationID, messageID string, run func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error)) (*LiveRun, error) {
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	runCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	r := &LiveRun{
+		ConversationID: conversationID,
+		MessageID:      messageID,
+		User:           currentUser,
+		AppNamespace:   app.Namespace,
+		cancel:         cancel,
+		subscribers:    make(map[<-chan ChatEvent]chan ChatEvent),
+	}
+	if old, loaded := cs.liveRuns.LoadOrStore(messageID, r); loaded {
+		// the finished answer kept for reconnecting is replaced, like the answer paused for approval
+		if !old.(*LiveRun).Finished() || !cs.liveRuns.CompareAndSwap(messageID, old, r) {
+			cancel(nil)
+			return nil, ErrAnswerRunning
+		}
+	}
+	r.publish(ChatEvent{Type: ChatEventStart})
+
+	idleTimeout := defaultLiveRunIdleTimeout
+	if app.Spec.ChatTimeoutSecond > 0 {
+		idleTimeout = time.Duration(app.Spec.ChatTimeoutSecond * float64(time.Second))
+	}
+	go func() {
+		defer func() {
+			cancel(nil)
+			time.AfterFunc(liveRunRetention, func() {
+				cs.liveRuns.CompareAndDelete(messageID, r)
+			})
+		}()
+		logger := klog.FromContext(runCtx)
+		response, err := r.run(runCtx, idleTimeout, run)
+		if err != nil {
+			// report why the answer is stopped instead of the context error
+			if cause := context.Cause(runCtx); cause != nil {
+				err = cause
+			}
+			logger.Error(err, "failed to generate the answer", "messageID", messageID)
+			r.publish(ChatEvent{Type: ChatEventError, Message: err.Error()})
+			return
+		}
+		if response == nil {
+			response = &ChatRespBody{ConversationID: conversationID, MessageID: messageID, CreatedAt: time.Now()}
+		}
+		if len(response.References) > 0 {
+			r.publish(ChatEvent{Type: ChatEventReference, References: response.References})
+		}
+		if response.PendingApproval != nil {
+			logger.Info("agent is waiting for approval", "messageID", messageID)
+			r.publish(ChatEvent{Type: ChatEventApproval, Response: response})
+			return
+		}
+		r.publish(ChatEvent{Type: ChatEventDone, Response: response})
+	}()
+	return r, nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+	"github.com/kubeagi/arcadia/pkg/appruntime/agent"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+)
+
+// waitFinal returns the events after the seq until the final one
+func waitFinal(t *testing.T, r *LiveRun, afterSeq int) []ChatEvent {
+	past, ch := r.Subscribe(afterSeq)
+	defer r.Unsubscribe(ch)
+	events := past
+	for len(events) == 0 || !events[len(events)-1].IsFinal() {
+		select {
+		case e, ok := <-ch:
+			require.True(t, ok, "the subscription is closed before the final event")
+			events = append(events, e)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the final event")
+		}
+	}
+	return events
+}
+
+func eventTypes(events []ChatEvent) []ChatEventType {
+	res := make([]ChatEventType, 0, len(events))
+	for _, e := range events {
+		res = append(res, e.Type)
+	}
+	return res
+}
+
+func TestLiveRun(t *testing.T) {
+	cs := &ChatServer{storage: storage.NewMemoryStorage()}
+	app := &v1alpha1.Application{ObjectMeta: v1.ObjectMeta{Name: "app", Namespace: "default"}}
+	ctx := context.WithValue(context.Background(), auth.UserNameContextKey, "alice")
+
+	t.Run("events", func(t *testing.T) {
+		release := make(chan struct{})
+		r, err := cs.startLiveRun(ctx, app, "c1", "m1", func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error) {
+			<-release
+			events <- base.StreamEvent{Type: base.StreamEventToolAction, Content: "Action: search"}
+			respStream <- "旷工最小"
+			respStream <- "计算单位"
+			return &ChatRespBody{ConversationID: "c1", MessageID: "m1", Message: "旷工最小计算单位", References: []retriever.Reference{{Answer: "0.5 天"}}}, nil
+		})
+		require.NoError(t, err)
+		_, err = cs.startLiveRun(ctx, app, "c1", "m1", nil)
+		require.ErrorIs(t, err, ErrAnswerRunning)
+		close(release)
+
+		events := waitFinal(t, r, 0)
+		require.Equal(t, []ChatEventType{ChatEventStart, ChatEventToolAction, ChatEventToken, ChatEventToken, ChatEventReference, ChatEventDone}, eventTypes(events))
+		for i, e := range events {
+			require.Equal(t, i+1, e.Seq)
+			require.Equal(t, "c1", e.ConversationID)
+			require.Equal(t, "m1", e.MessageID)
+		}
+		require.Equal(t, "计算单位", events[3].Message)
+		require.Equal(t, "旷工最小计算单位", events[5].Response.Message)
+
+		// reconnecting replays the events after the last received one
+		require.Equal(t, []ChatEventType{ChatEventToken, ChatEventReference, ChatEventDone}, eventTypes(waitFinal(t, r, 3)))
+		require.Same(t, r, cs.GetLiveRun(ctx, "default", "m1"))
+		require.Nil(t, cs.GetLiveRun(context.WithValue(context.Background(), auth.UserNameContextKey, "bob"), "default", "m1"))
+		require.Nil(t, cs.GetLiveRun(ctx, "other", "m1"))
+
+		// the finished answer is replaced by the resumed one
+		r2, err := cs.startLiveRun(ctx, app, "c1", "m1", func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error) {
+			return &ChatRespBody{Message: "resumed"}, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "resumed", waitFinal(t, r2, 0)[1].Response.Message)
+		require.Same(t, r2, cs.GetLiveRun(ctx, "default", "m1"))
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(ctx)
+		r, err := cs.startLiveRun(parent, app, "c1", "m2", func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error) {
+			respStream <- "partial"
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		require.NoError(t, err)
+		// the answer goes on when the connection is closed
+		cancelParent()
+		past, ch := r.Subscribe(0)
+		if len(past) < 2 {
+			<-ch
+		}
+		r.Unsubscribe(ch)
+		require.False(t, r.Finished())
+
+		r.Cancel()
+		events := waitFinal(t, r, 0)
+		require.Equal(t, []ChatEventType{ChatEventStart, ChatEventToken, ChatEventError}, eventTypes(events))
+		require.Equal(t, ErrAnswerCancelled.Error(), events[2].Message)
+	})
+
+	t.Run("idle timeout", func(t *testing.T) {
+		app := app.DeepCopy()
+		app.Spec.ChatTimeoutSecond = 0.05
+		r, err := cs.startLiveRun(ctx, app, "c1", "m3", func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		require.NoError(t, err)
+		events := waitFinal(t, r, 0)
+		require.Equal(t, ErrAnswerTimeout.Error(), events[len(events)-1].Message)
+	})
+
+	t.Run("approval", func(t *testing.T) {
+		r, err := cs.startLiveRun(ctx, app, "c1", "m4", func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error) {
+			return &ChatRespBody{PendingApproval: &agent.ToolCall{Tool: "delete_ticket"}}, nil
+		})
+		require.NoError(t, err)
+		events := waitFinal(t, r, 0)
+		require.Equal(t, ChatEventApproval, events[1].Type)
+		require.Equal(t, "delete_ticket", events[1].Response.PendingApproval.Tool)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		r, err := cs.startLiveRun(ctx, app, "c1", "m5", func(ctx context.Context, respStream chan string, events chan base.StreamEvent) (*ChatRespBody, error) {
+			panic("unexpected")
+		})
+		require.NoError(t, err)
+		require.Equal(t, ChatEventError, waitFinal(t, r, 0)[1].Type)
+	})
+}
+
+func TestLiveRunSlowSubscriber(t *testing.T) {
+	r := &LiveRun{subscribers: make(map[<-chan ChatEvent]chan ChatEvent)}
+	_, ch := r.Subscribe(0)
+	for i := 0; i < liveRunSubscriberBuffer+1; i++ {
+		r.publish(ChatEvent{Type: ChatEventToken})
+	}
+	received := 0
+	for range ch {
+		received++
+	}
+	require.Equal(t, liveRunSubscriberBuffer, received)
+	// the slow subscriber subscribes again for the rest events
+	past, ch := r.Subscribe(received)
+	require.Len(t, past, 1)
+	r.publish(ChatEvent{Type: ChatEventDone})
+	e, ok := <-ch
+	require.True(t, ok)
+	require.Equal(t, ChatEventDone, e.Type)
+	_, ok = <-ch
+	require.False(t, ok)
+}
+
+func TestSavedAnswer(t *testing.T) {
+	cs := &ChatServer{storage: storage.NewMemoryStorage()}
+	ctx := context.WithValue(context.Background(), auth.UserNameContextKey, "alice")
+	require.NoError(t, cs.Storage().UpdateConversation(&storage.Conversation{
+		ID: "c1", AppName: "app", AppNamespace: "default", User: "alice",
+		Messages: []storage.Message{{ID: "m1", ConversationID: "c1", Answer: "旷工最小计算单位为0.5天。"}},
+	}))
+	event, err := cs.SavedAnswer(ctx, "default", "c1", "m1")
+	require.NoError(t, err)
+	require.Equal(t, ChatEventDone, event.Type)
+	require.Equal(t, "旷工最小计算单位为0.5天。", event.Response.Message)
+
+	_, err = cs.SavedAnswer(ctx, "default", "c1", "unknown")
+	require.ErrorIs(t, err, storage.ErrMessageNotFound)
+}
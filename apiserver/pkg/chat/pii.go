@@ -0,0 +1,106 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+	"github.com/kubeagi/arcadia/pkg/langchainwrap"
+	"github.com/kubeagi/arcadia/pkg/pii"
+)
+
+const (
+	// PIIStageInput and PIIStageOutput are the stages recorded in the pii audits
+	PIIStageInput  = "input"
+	PIIStageOutput = "output"
+
+	// PIIBlockedAnswer replaces the answers containing pii in block mode
+	PIIBlockedAnswer = "The answer is hidden since it contains personally identifiable information."
+)
+
+var ErrPIIBlocked = errors.New("the question contains personally identifiable information, which is not allowed in this application")
+
+// newPIIGuard returns the pii guard of the app, or nil if the app does not enable it
+func (cs *ChatServer) newPIIGuard(ctx context.Context, app *v1alpha1.Application) (*pii.Guard, error) {
+	spec := app.Spec.PIIGuard
+	if spec == nil {
+		return nil, nil
+	}
+	var model langchainllms.Model
+	if spec.LLM != nil {
+		llm := &v1alpha1.LLM{}
+		if err := cs.systemCli.Get(ctx, types.NamespacedName{Namespace: spec.LLM.GetNamespace(app.Namespace), Name: spec.LLM.Name}, llm); err != nil {
+			return nil, fmt.Errorf("failed to get the llm of pii guard: %w", err)
+		}
+		var err error
+		if model, err = langchainwrap.GetLangchainLLM(ctx, llm, cs.systemCli, spec.Model); err != nil {
+			return nil, fmt.Errorf("failed to get the llm of pii guard: %w", err)
+		}
+	}
+	return pii.NewGuard(spec.Detectors, model)
+}
+
+// guardPII detects the pii in the text and returns the text to use, which is masked in mask mode,
+// and whether the text is blocked in block mode. Every detection is audited, without the detected values.
+// The guard fails closed, the error of detection is returned instead of letting the text go.
+func (cs *ChatServer) guardPII(ctx context.Context, guard *pii.Guard, app *v1alpha1.Application, audit storage.PIIAudit, text string) (string, bool, error) {
+	findings, err := guard.Detect(ctx, text)
+	if err != nil {
+		return "", false, err
+	}
+	if len(findings) == 0 {
+		return text, false, nil
+	}
+	mode := app.Spec.PIIGuard.Mode
+	if mode == "" {
+		mode = v1alpha1.PIIGuardModeMask
+	}
+	audit.AppName = app.Name
+	audit.AppNamespace = app.Namespace
+	audit.Mode = string(mode)
+	audit.Types = strings.Join(pii.Types(findings), ",")
+	audit.Count = len(findings)
+	if err := cs.Storage().AddPIIAudit(&audit); err != nil {
+		// the text is let go only if it is audited
+		if mode == v1alpha1.PIIGuardModeAudit {
+			return "", false, fmt.Errorf("failed to audit pii: %w", err)
+		}
+		klog.FromContext(ctx).Error(err, "failed to audit pii", "conversationID", audit.ConversationID, "messageID", audit.MessageID)
+	}
+	switch mode {
+	case v1alpha1.PIIGuardModeBlock:
+		return text, true, nil
+	case v1alpha1.PIIGuardModeAudit:
+		return text, false, nil
+	default:
+		return pii.Mask(text, findings), false, nil
+	}
+}
+
+// ListPIIAudits lists the pii detections in the chats of the app
+func (cs *ChatServer) ListPIIAudits(ctx context.Context, req PIIAuditReqBody) ([]storage.PIIAudit, error) {
+	return cs.Storage().ListPIIAudits(storage.WithAppName(req.APPName), storage.WithAppNamespace(req.AppNamespace), storage.WithTimeRange(req.Since, req.Until))
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+)
+
+func TestGuardPII(t *testing.T) {
+	ctx := context.Background()
+	cs := &ChatServer{storage: storage.NewMemoryStorage()}
+	app := &v1alpha1.Application{ObjectMeta: v1.ObjectMeta{Name: "app", Namespace: "default"}}
+
+	guard, err := cs.newPIIGuard(ctx, app)
+	require.NoError(t, err)
+	require.Nil(t, guard, "no guard without the config")
+
+	query := "我的手机号是13812345678，邮箱a@example.com"
+	for _, tt := range []struct {
+		mode    v1alpha1.PIIGuardMode
+		want    string
+		blocked bool
+	}{
+		{mode: "", want: "我的手机号是138****5678，邮箱a@example.com"},
+		{mode: v1alpha1.PIIGuardModeMask, want: "我的手机号是138****5678，邮箱a@example.com"},
+		{mode: v1alpha1.PIIGuardModeAudit, want: query},
+		{mode: v1alpha1.PIIGuardModeBlock, want: query, blocked: true},
+	} {
+		app.Spec.PIIGuard = &v1alpha1.PIIGuard{Mode: tt.mode, Detectors: []string{"phone"}}
+		guard, err := cs.newPIIGuard(ctx, app)
+		require.NoError(t, err)
+		got, blocked, err := cs.guardPII(ctx, guard, app, storage.PIIAudit{ConversationID: "c", MessageID: "m", User: "u", Stage: PIIStageInput}, query)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, got, tt.mode)
+		require.Equal(t, tt.blocked, blocked, tt.mode)
+
+		// nothing is audited without pii
+		got, blocked, err = cs.guardPII(ctx, guard, app, storage.PIIAudit{}, "差旅报销的标准是什么？")
+		require.NoError(t, err)
+		require.Equal(t, "差旅报销的标准是什么？", got)
+		require.False(t, blocked)
+	}
+
+	audits, err := cs.ListPIIAudits(ctx, PIIAuditReqBody{APPMetadata: APPMetadata{APPName: "app", AppNamespace: "default"}})
+	require.NoError(t, err)
+	require.Len(t, audits, 4)
+	require.Equal(t, "mask", audits[0].Mode)
+	require.Equal(t, "phone", audits[0].Types)
+	require.Equal(t, 1, audits[0].Count)
+	require.Equal(t, "block", audits[3].Mode)
+	require.Equal(t, PIIStageInput, audits[3].Stage)
+
+	app.Spec.PIIGuard = &v1alpha1.PIIGuard{Detectors: []string{"passport"}}
+	_, err = cs.newPIIGuard(ctx, app)
+	require.Error(t, err)
+}
@@ -19,6 +19,9 @@ package chat
 import (
 	"time"
 
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+	"github.com/kubeagi/arcadia/pkg/appruntime/agent"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
 	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
 )
 
@@ -62,8 +65,8 @@ type MessageReqBody struct {
 }
 
 type ChatReqBody struct {
-	// Query user query string
-	Query string `json:"query" form:"query" binding:"required" example:"旷工最小计算单位为多少天？"`
+	// Query user query string, it is required unless regenerating a message
+	Query string `json:"query" form:"query" example:"旷工最小计算单位为多少天？"`
 	// Files this conversation will use in the context
 	Files []string `json:"files" form:"files" example:"test.pdf,song.mp3"`
 	// ResponseMode:
@@ -71,14 +74,133 @@ type ChatReqBody struct {
 	// * Streaming - means the response will use Server-Sent Events
 	ResponseMode        ResponseMode `json:"response_mode" form:"response_mode" binding:"required" example:"blocking"`
 	ConversationReqBody `json:",inline"`
+	// ParentMessageID forks the conversation, the new message follows this message instead of the last message of the selected branch.
+	// To edit a question and send it again, use the parent_id of the edited message.
+	ParentMessageID string `json:"parent_message_id,omitempty" form:"parent_message_id" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	// RegenerateMessageID is the message to regenerate, the new answer is in a new branch with the same query and files
+	RegenerateMessageID string    `json:"regenerate_message_id,omitempty" form:"regenerate_message_id" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
 	Debug               bool      `json:"-"`
 	NewChat             bool      `json:"-"`
 	StartTime           time.Time `json:"-"`
+	// Events receives the tool actions and progress of agent separately from the answer, only set by the websocket chat
+	Events chan base.StreamEvent `json:"-"`
+}
+
+type ApprovalReqBody struct {
+	MessageReqBody `json:",inline"`
+	// Approved is whether the user approves the tool call waiting for approval
+	Approved bool `json:"approved" example:"true"`
+	// Reason is told to the agent when the call is rejected
+	Reason string `json:"reason,omitempty" example:"the ticket is duplicated"`
+	// ResponseMode of the resumed chat, same as the chat request
+	ResponseMode ResponseMode `json:"response_mode" binding:"required" example:"blocking"`
+	StartTime    time.Time    `json:"-"`
+	// Events is the same as the one of chat request
+	Events chan base.StreamEvent `json:"-"`
+}
+
+type FeedbackReqBody struct {
+	MessageReqBody `json:",inline"`
+	// Rating is 1 for like and -1 for dislike
+	Rating int `json:"rating" binding:"required,oneof=1 -1" example:"-1"`
+	// Comment is what the user thinks about the answer
+	Comment string `json:"comment,omitempty" example:"the answer is out of date"`
+	// CorrectedAnswer is the answer the user expects, it is exported as the ground truth of rag evaluation
+	CorrectedAnswer string `json:"corrected_answer,omitempty" example:"旷工最小计算单位为1天。"`
+}
+
+type FeedbackExportReqBody struct {
+	APPMetadata `json:",inline" form:",inline"`
+	// Since and Until limit the time the feedbacks are given, no limit by default
+	Since time.Time `json:"since" form:"since" example:"2023-12-21T00:00:00+08:00"`
+	Until time.Time `json:"until" form:"until" example:"2023-12-28T00:00:00+08:00"`
+}
+
+type PIIAuditReqBody struct {
+	APPMetadata `json:",inline" form:",inline"`
+	// Since and Until limit the time the pii is detected, no limit by default
+	Since time.Time `json:"since" form:"since" example:"2023-12-21T00:00:00+08:00"`
+	Until time.Time `json:"until" form:"until" example:"2023-12-28T00:00:00+08:00"`
+}
+
+type ConversationExportReqBody struct {
+	APPMetadata    `json:",inline" form:",inline"`
+	ConversationID string `json:"-" form:"-"`
+	// Format of the exported file, one of json, markdown and pdf, json by default
+	Format string `json:"format" form:"format" binding:"omitempty,oneof=json markdown pdf" example:"markdown"`
+}
+
+type ConversationImportReqBody struct {
+	// APPMetadata is the app the conversation is imported to
+	APPMetadata `json:",inline"`
+	// Conversation is the json exported before
+	Conversation ConversationExport `json:"conversation"`
+}
+
+// Requester is the client sending the request, which is recorded in the audits of shares
+type Requester struct {
+	ClientIP  string `json:"-" form:"-"`
+	UserAgent string `json:"-" form:"-"`
+}
+
+type ShareReqBody struct {
+	ConversationReqBody `json:",inline"`
+	// FromMessageID and ToMessageID are the first and the last shared messages in the branch of ToMessageID, the whole current branch is shared by default
+	FromMessageID string `json:"from_message_id,omitempty" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	ToMessageID   string `json:"to_message_id,omitempty" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	// ExpiresInHours is 168 (7 days) by default, at most 720 (30 days)
+	ExpiresInHours int `json:"expires_in_hours,omitempty" example:"168"`
+	Requester      `json:"-"`
+}
+
+type ShareRespBody struct {
+	storage.Share `json:",inline"`
+	// Token is the signed token of the share
+	Token string `json:"token" example:"0b7c3a4e-7a1c-4b8e-9a55-2a43e6e3f1d2.1703730066.c2lnbmF0dXJl"`
+	// Link is the path to read the share without login, the frontend should add the address of apiserver
+	Link string `json:"link" example:"/chat/shared/0b7c3a4e-7a1c-4b8e-9a55-2a43e6e3f1d2.1703730066.c2lnbmF0dXJl"`
+}
+
+type DeleteUserDataRespBody struct {
+	// Conversations is the number of deleted conversations
+	Conversations int `json:"conversations" example:"10"`
+}
+
+type SearchReqBody struct {
+	// Keyword to search in the queries and answers, the messages with all the words separated by spaces are returned
+	Keyword string `json:"keyword" binding:"required" example:"差旅报销"`
+	// AppName limits the search in the conversations of the app, all apps by default
+	APPName      string `json:"app_name,omitempty" example:"chat-with-llm"`
+	AppNamespace string `json:"-"`
+	// Since and Until limit the time the messages are sent, no limit by default
+	Since time.Time `json:"since,omitempty" example:"2023-12-21T00:00:00+08:00"`
+	Until time.Time `json:"until,omitempty" example:"2023-12-28T00:00:00+08:00"`
+	// Page starts from 1
+	Page int `json:"page,omitempty" example:"1"`
+	// PageSize is 10 by default, at most 100
+	PageSize int `json:"page_size,omitempty" example:"10"`
+}
+
+type SearchHit struct {
+	storage.MessageHit `json:",inline"`
+	// QueryHighlight is the escaped query with the keyword wrapped in <em> tags, long texts are cut around the keyword
+	QueryHighlight string `json:"query_highlight" example:"<em>差旅报销</em>的标准是什么？"`
+	// AnswerHighlight is the escaped answer with the keyword wrapped in <em> tags, long texts are cut around the keyword
+	AnswerHighlight string `json:"answer_highlight" example:"<em>差旅报销</em>的标准为..."`
+}
+
+type SearchRespBody struct {
+	// Total is the number of all matched messages
+	Total int64 `json:"total" example:"1"`
+	// Hits are the matched messages in the page, the latest first
+	Hits []SearchHit `json:"hits"`
 }
 
 type ChatRespBody struct {
 	ConversationID string `json:"conversation_id" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
 	MessageID      string `json:"message_id" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	// ParentID is the previous message of this message in the branch, or the conversation id for the first message
+	ParentID string `json:"parent_id,omitempty" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
 	// Action indicates what is this chat for
 	Action string `json:"action,omitempty" example:"CHAT"`
 	// Message is what AI say
@@ -87,6 +209,14 @@ type ChatRespBody struct {
 	CreatedAt time.Time `json:"created_at" example:"2023-12-21T10:21:06.389359092+08:00"`
 	// References is the list of references
 	References []retriever.Reference `json:"references,omitempty"`
+	// Plan is the plan made by agent before using tools, only returned by plan-and-execute agent
+	Plan []string `json:"plan,omitempty"`
+	// IntermediateSteps are the tool calls of agent to get the answer
+	IntermediateSteps []agent.Step `json:"intermediate_steps,omitempty"`
+	// FailureReason is why the agent failed to answer
+	FailureReason string `json:"failure_reason,omitempty"`
+	// PendingApproval is the tool call waiting for the approval of user, the chat is resumed by the approval request
+	PendingApproval *agent.ToolCall `json:"pending_approval,omitempty"`
 	// Latency(ms) is how much time the server cost to process a certain request.
 	Latency int64 `json:"latency,omitempty" example:"1000"`
 	// Documents in this chat
@@ -99,6 +229,77 @@ type DocumentRespBody struct {
 	Object string `json:"object,omitempty" example:"application/base-chat-document-assistant/conversation/f54f5122-28fb-474e-8593-39f5b3760eaa/90fe100fb9ee6e6cb9ccb091fd91b6264f5f5444dea6d93b3c8ed7418e20c37d.pdf"`
 }
 
+// ChatCommandType is the type of the commands sent by the client of websocket chat
+type ChatCommandType string
+
+const (
+	// ChatCommandStart starts to answer the chat request
+	ChatCommandStart ChatCommandType = "start"
+	// ChatCommandCancel stops generating the answer of the message
+	ChatCommandCancel ChatCommandType = "cancel"
+	// ChatCommandAttach follows the answer of the message after reconnecting, the saved answer is returned if it is finished
+	ChatCommandAttach ChatCommandType = "attach"
+	// ChatCommandApprove approves or rejects the tool call waiting for approval, and follows the resumed answer
+	ChatCommandApprove ChatCommandType = "approve"
+)
+
+// ChatCommand is sent by the client of websocket chat
+type ChatCommand struct {
+	Type ChatCommandType `json:"type" example:"start"`
+	// Chat is the request of start command, the response mode is always streaming
+	Chat *ChatReqBody `json:"chat,omitempty"`
+	// Approval is the decision of approve command
+	Approval *ApprovalReqBody `json:"approval,omitempty"`
+	// ConversationID and MessageID are the answer to cancel or attach
+	ConversationID string `json:"conversation_id,omitempty" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
+	MessageID      string `json:"message_id,omitempty" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	// AfterSeq is the seq of the last event received, attach replays the events after it
+	AfterSeq int `json:"after_seq,omitempty" example:"10"`
+}
+
+// ChatEventType is the type of the events sent by the server of websocket chat
+type ChatEventType string
+
+const (
+	// ChatEventStart is the first event of an answer, with the conversation id and the message id to cancel or attach
+	ChatEventStart ChatEventType = "start"
+	// ChatEventToken is a part of the answer
+	ChatEventToken ChatEventType = "token"
+	// ChatEventToolAction is a tool call of agent
+	ChatEventToolAction ChatEventType = "tool_action"
+	// ChatEventProgress is the progress of agent, like the plan or the output of a running tool
+	ChatEventProgress ChatEventType = "progress"
+	// ChatEventReference has the references of the answer
+	ChatEventReference ChatEventType = "reference"
+	// ChatEventApproval ends the answer paused by the tool call waiting for approval
+	ChatEventApproval ChatEventType = "approval"
+	// ChatEventError ends the answer failed or cancelled, it is also sent for the invalid commands
+	ChatEventError ChatEventType = "error"
+	// ChatEventDone ends the answer with the whole response
+	ChatEventDone ChatEventType = "done"
+)
+
+// ChatEvent is sent by the server of websocket chat
+type ChatEvent struct {
+	Type ChatEventType `json:"type" example:"token"`
+	// Seq is the order of the event in the answer starting from 1, 0 for the events not of an answer
+	Seq            int    `json:"seq" example:"2"`
+	ConversationID string `json:"conversation_id,omitempty" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
+	MessageID      string `json:"message_id,omitempty" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	// Message is the content of token, tool action, progress and error events
+	Message string `json:"message,omitempty" example:"旷工最小计算单位"`
+	// References are the references of reference event
+	References []retriever.Reference `json:"references,omitempty"`
+	// Response is the whole response of done and approval events
+	Response  *ChatRespBody `json:"response,omitempty"`
+	CreatedAt time.Time     `json:"created_at" example:"2023-12-21T10:21:06.389359092+08:00"`
+}
+
+// IsFinal returns whether the event ends the answer
+func (e ChatEvent) IsFinal() bool {
+	return e.Type == ChatEventDone || e.Type == ChatEventError || e.Type == ChatEventApproval
+}
+
 type ErrorResp struct {
 	Err string `json:"error" example:"conversation is not found"`
 }
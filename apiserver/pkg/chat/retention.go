@@ -0,0 +1,157 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+	pkgconfig "github.com/kubeagi/arcadia/pkg/config"
+)
+
+// RunPurger purges the expired conversations in every interval until the context is done
+func (cs *ChatServer) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		retention, err := pkgconfig.GetChatRetention(ctx)
+		if err != nil {
+			// only the retention of the apps applies, keeping more is safer than deleting by mistake
+			klog.Errorf("failed to get chat retention, only the retention of applications is used: %s", err)
+		}
+		if _, err := cs.PurgeExpiredConversations(ctx, retention, time.Now()); err != nil {
+			klog.Errorf("failed to purge expired conversations: %s", err)
+		}
+	}
+}
+
+// PurgeExpiredConversations deletes the conversations not updated in the retention days of their apps permanently,
+// with the uploaded files and the conversation knowledgebases. It returns the number of purged conversations.
+func (cs *ChatServer) PurgeExpiredConversations(ctx context.Context, retention *pkgconfig.ChatRetention, now time.Time) (int, error) {
+	apps, err := cs.Storage().ListConversationApps()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	var errs []error
+	for _, app := range apps {
+		days, err := cs.retentionDays(ctx, app, retention)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if days <= 0 {
+			continue
+		}
+		n, err := cs.Storage().PurgeConversations(func(conversations []storage.Conversation) error {
+			return cs.cleanupConversations(ctx, conversations)
+		}, storage.WithAppName(app.AppName), storage.WithAppNamespace(app.AppNamespace), storage.WithTimeRange(time.Time{}, now.AddDate(0, 0, -days)))
+		total += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to purge conversations of app %s/%s: %w", app.AppNamespace, app.AppName, err))
+			continue
+		}
+		if n > 0 {
+			klog.Infof("purged %d conversations of app %s/%s older than %d days", n, app.AppNamespace, app.AppName, days)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// DeleteUserData deletes all conversations of current user permanently, with the feedbacks, shares and uploaded files.
+// It returns the number of deleted conversations.
+func (cs *ChatServer) DeleteUserData(ctx context.Context) (int, error) {
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	// the conversations of all users are matched without the user
+	if currentUser == "" {
+		return 0, errors.New("user is unknown")
+	}
+	return cs.Storage().PurgeConversations(func(conversations []storage.Conversation) error {
+		return cs.cleanupConversations(ctx, conversations)
+	}, storage.WithUser(currentUser))
+}
+
+// retentionDays returns the retention of the app, which overrides the retention of the namespace.
+// The retention of the namespace is used if the app is deleted.
+func (cs *ChatServer) retentionDays(ctx context.Context, app storage.ConversationApp, retention *pkgconfig.ChatRetention) (int, error) {
+	application := &v1alpha1.Application{}
+	err := cs.systemCli.Get(ctx, types.NamespacedName{Namespace: app.AppNamespace, Name: app.AppName}, application)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return 0, fmt.Errorf("failed to get app %s/%s: %w", app.AppNamespace, app.AppName, err)
+	}
+	if err == nil && application.Spec.ChatRetentionDays > 0 {
+		return application.Spec.ChatRetentionDays, nil
+	}
+	return retention.DaysOf(app.AppNamespace), nil
+}
+
+// cleanupConversations deletes the uploaded files and the knowledgebases of the conversations
+func (cs *ChatServer) cleanupConversations(ctx context.Context, conversations []storage.Conversation) error {
+	for _, c := range conversations {
+		if !hasUploadedDocuments(c) {
+			continue
+		}
+		kb := &v1alpha1.KnowledgeBase{ObjectMeta: v1.ObjectMeta{Name: c.ID, Namespace: c.AppNamespace}}
+		if err := runtimeclient.IgnoreNotFound(cs.systemCli.Delete(ctx, kb)); err != nil {
+			return fmt.Errorf("failed to delete knowledgebase of conversation %s: %w", c.ID, err)
+		}
+		ds, err := pkgconfig.GetSystemDatasourceOSS(ctx)
+		if err != nil {
+			return fmt.Errorf("no storage service found with err %w", err)
+		}
+		// the files are stored in the directory of the conversation
+		prefix := v1alpha1.ConversationFilePath(c.AppName, c.ID, "")
+		for object := range ds.Client.ListObjects(ctx, c.AppNamespace, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+			if object.Err != nil {
+				if minio.ToErrorResponse(object.Err).Code == "NoSuchBucket" {
+					break
+				}
+				return fmt.Errorf("failed to list files of conversation %s: %w", c.ID, object.Err)
+			}
+			if err := ds.Client.RemoveObject(ctx, c.AppNamespace, object.Key, minio.RemoveObjectOptions{}); err != nil {
+				return fmt.Errorf("failed to delete file %s of conversation %s: %w", object.Key, c.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func hasUploadedDocuments(c storage.Conversation) bool {
+	for _, m := range c.Messages {
+		if m.Action == "UPLOAD" && len(m.Documents) > 0 {
+			return true
+		}
+	}
+	return false
+}
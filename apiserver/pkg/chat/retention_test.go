@@ -0,0 +1,102 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+	pkgclient "github.com/kubeagi/arcadia/apiserver/pkg/client"
+	pkgconfig "github.com/kubeagi/arcadia/pkg/config"
+)
+
+func TestPurgeExpiredConversations(t *testing.T) {
+	app := &v1alpha1.Application{
+		ObjectMeta: v1.ObjectMeta{Name: "short", Namespace: "default"},
+		Spec:       v1alpha1.ApplicationSpec{ChatRetentionDays: 10},
+	}
+	cs := &ChatServer{
+		storage:   storage.NewMemoryStorage(),
+		systemCli: fake.NewClientBuilder().WithScheme(pkgclient.Scheme).WithObjects(app).Build(),
+	}
+	now := time.Now()
+	for _, c := range []storage.Conversation{
+		{ID: "short-expired", AppName: "short", AppNamespace: "default", UpdatedAt: now.AddDate(0, 0, -20)},
+		{ID: "short-kept", AppName: "short", AppNamespace: "default", UpdatedAt: now.AddDate(0, 0, -5)},
+		// the app is deleted, the retention of the namespace is used
+		{ID: "deleted-expired", AppName: "deleted", AppNamespace: "default", UpdatedAt: now.AddDate(0, 0, -40)},
+		{ID: "deleted-kept", AppName: "deleted", AppNamespace: "default", UpdatedAt: now.AddDate(0, 0, -20)},
+		{ID: "forever", AppName: "app", AppNamespace: "keep", UpdatedAt: now.AddDate(-1, 0, 0)},
+	} {
+		c := c
+		require.NoError(t, cs.Storage().UpdateConversation(&c))
+	}
+	require.NoError(t, cs.Storage().CreateShare(&storage.Share{ID: "share", ConversationID: "short-expired"}))
+	require.NoError(t, cs.Storage().AddShareAudit(&storage.ShareAudit{ShareID: "share", Action: storage.ShareActionCreate}))
+
+	// only the retention of the app applies without the retention in system config
+	n, err := cs.PurgeExpiredConversations(context.Background(), nil, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	retention := &pkgconfig.ChatRetention{Days: 30, Namespaces: map[string]int{"keep": 0}}
+	n, err = cs.PurgeExpiredConversations(context.Background(), retention, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	conversations, err := cs.Storage().ListConversations()
+	require.NoError(t, err)
+	ids := make([]string, 0, len(conversations))
+	for _, c := range conversations {
+		ids = append(ids, c.ID)
+	}
+	require.ElementsMatch(t, []string{"short-kept", "deleted-kept", "forever"}, ids)
+	_, err = cs.Storage().FindShare("share")
+	require.ErrorIs(t, err, storage.ErrShareNotFound)
+	audits, err := cs.Storage().ListShareAudits("share")
+	require.NoError(t, err)
+	require.Empty(t, audits)
+}
+
+func TestDeleteUserData(t *testing.T) {
+	cs := &ChatServer{storage: storage.NewMemoryStorage()}
+	for _, c := range []storage.Conversation{
+		{ID: "c1", AppName: "app", AppNamespace: "default", User: "alice"},
+		{ID: "c2", AppName: "other", AppNamespace: "test", User: "alice"},
+		{ID: "c3", AppName: "app", AppNamespace: "default", User: "bob"},
+	} {
+		c := c
+		require.NoError(t, cs.Storage().UpdateConversation(&c))
+	}
+	_, err := cs.DeleteUserData(context.Background())
+	require.Error(t, err)
+
+	n, err := cs.DeleteUserData(context.WithValue(context.Background(), auth.UserNameContextKey, "alice"))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	conversations, err := cs.Storage().ListConversations()
+	require.NoError(t, err)
+	require.Len(t, conversations, 1)
+	require.Equal(t, "c3", conversations[0].ID)
+}
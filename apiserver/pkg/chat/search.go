@@ -0,0 +1,133 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+)
+
+const (
+	defaultSearchPageSize = 10
+	maxSearchPageSize     = 100
+	// maxHighlightLength is the max number of characters in the highlighted snippet
+	maxHighlightLength = 120
+
+	highlightStart = "<em>"
+	highlightEnd   = "</em>"
+)
+
+// SearchMessages searches the queries and answers in the conversations of current user
+func (cs *ChatServer) SearchMessages(ctx context.Context, req SearchReqBody) (*SearchRespBody, error) {
+	if strings.TrimSpace(req.Keyword) == "" {
+		return nil, errors.New("keyword is required")
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultSearchPageSize
+	}
+	if req.PageSize > maxSearchPageSize {
+		req.PageSize = maxSearchPageSize
+	}
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	hits, total, err := cs.Storage().SearchMessages(req.Keyword,
+		storage.WithAppName(req.APPName),
+		storage.WithAppNamespace(req.AppNamespace),
+		storage.WithUser(currentUser),
+		storage.WithTimeRange(req.Since, req.Until),
+		storage.WithPaging(req.Page, req.PageSize))
+	if err != nil {
+		return nil, err
+	}
+	terms := storage.KeywordTerms(req.Keyword)
+	resp := &SearchRespBody{Total: total, Hits: make([]SearchHit, 0, len(hits))}
+	for _, hit := range hits {
+		resp.Hits = append(resp.Hits, SearchHit{
+			MessageHit:      hit,
+			QueryHighlight:  Highlight(hit.Query, terms, maxHighlightLength),
+			AnswerHighlight: Highlight(hit.Answer, terms, maxHighlightLength),
+		})
+	}
+	return resp, nil
+}
+
+// Highlight escapes the text as html and wraps the terms in <em> tags, case insensitive.
+// If the text is longer than maxLength characters, only the part around the first term is kept.
+func Highlight(text string, terms []string, maxLength int) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	hit := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		t := []rune(strings.ToLower(term))
+		if len(t) == 0 {
+			continue
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) != string(t) {
+				continue
+			}
+			for j := i; j < i+len(t); j++ {
+				hit[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if maxLength > 0 && len(runes) > maxLength {
+		// keep some context before the first term
+		if first > maxLength/4 {
+			start = first - maxLength/4
+		}
+		end = start + maxLength
+		if end > len(runes) {
+			end = len(runes)
+			start = end - maxLength
+		}
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && hit[j] == hit[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if hit[i] {
+			segment = highlightStart + segment + highlightEnd
+		}
+		b.WriteString(segment)
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+)
+
+func TestSearchMessages(t *testing.T) {
+	cs := &ChatServer{storage: storage.NewMemoryStorage()}
+	now := time.Now()
+	conversation := func(id, app, user string, messages ...storage.Message) *storage.Conversation {
+		for i := range messages {
+			messages[i].ConversationID = id
+			messages[i].CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		}
+		return &storage.Conversation{ID: id, AppName: app, AppNamespace: "default", User: user, Messages: messages}
+	}
+	require.NoError(t, cs.Storage().UpdateConversation(conversation("c1", "app", "alice",
+		storage.Message{ID: "m1", Query: "What is the Travel policy?", Answer: "The travel budget is 500 per day."},
+		storage.Message{ID: "m2", Query: "who are you?", Answer: "a bot"},
+	)))
+	require.NoError(t, cs.Storage().UpdateConversation(conversation("c2", "other", "alice",
+		storage.Message{ID: "m3", Query: "travel <policy> of sales", Answer: "ask your manager"},
+	)))
+	require.NoError(t, cs.Storage().UpdateConversation(conversation("c3", "app", "bob",
+		storage.Message{ID: "m4", Query: "travel policy", Answer: "no idea"},
+	)))
+
+	ctx := context.WithValue(context.Background(), auth.UserNameContextKey, "alice")
+	resp, err := cs.SearchMessages(ctx, SearchReqBody{Keyword: "travel POLICY", AppNamespace: "default"})
+	require.NoError(t, err)
+	// the messages of other users are not returned
+	require.EqualValues(t, 2, resp.Total)
+	require.Len(t, resp.Hits, 2)
+	require.Equal(t, "m1", resp.Hits[0].MessageID)
+	require.Equal(t, "What is the <em>Travel</em> <em>policy</em>?", resp.Hits[0].QueryHighlight)
+	require.Equal(t, "The <em>travel</em> budget is 500 per day.", resp.Hits[0].AnswerHighlight)
+	require.Equal(t, "<em>travel</em> &lt;<em>policy</em>&gt; of sales", resp.Hits[1].QueryHighlight)
+
+	resp, err = cs.SearchMessages(ctx, SearchReqBody{Keyword: "travel", APPName: "other", AppNamespace: "default"})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, resp.Total)
+	require.Equal(t, "c2", resp.Hits[0].ConversationID)
+
+	resp, err = cs.SearchMessages(ctx, SearchReqBody{Keyword: "travel", AppNamespace: "default", Page: 2, PageSize: 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, resp.Total)
+	require.Len(t, resp.Hits, 1)
+
+	resp, err = cs.SearchMessages(ctx, SearchReqBody{Keyword: "travel", AppNamespace: "default", Since: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Zero(t, resp.Total)
+
+	_, err = cs.SearchMessages(ctx, SearchReqBody{Keyword: " "})
+	require.Error(t, err)
+}
+
+func TestHighlight(t *testing.T) {
+	require.Equal(t, "no match", Highlight("no match", []string{"foo"}, 0))
+	require.Equal(t, "差旅<em>报销</em>标准", Highlight("差旅报销标准", []string{"报销"}, 0))
+	long := strings.Repeat("a", 100) + "keyword" + strings.Repeat("b", 100)
+	got := Highlight(long, []string{"keyword"}, 40)
+	require.Equal(t, "..."+strings.Repeat("a", 10)+"<em>keyword</em>"+strings.Repeat("b", 23)+"...", got)
+	// the end of the text is kept when the keyword is near the end
+	got = Highlight(strings.Repeat("a", 100)+"keyword", []string{"keyword"}, 40)
+	require.Equal(t, "..."+strings.Repeat("a", 33)+"<em>keyword</em>", got)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeagi/arcadia/apiserver/config"
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+)
+
+const (
+	defaultShareExpiresHours = 7 * 24
+	maxShareExpiresHours     = 30 * 24
+
+	// SharedPathPrefix is the path the shares are read by, followed by the token
+	SharedPathPrefix = "/chat/shared/"
+)
+
+var (
+	ErrInvalidShareToken = errors.New("share link is invalid")
+	ErrShareExpired      = errors.New("share link is expired")
+	ErrShareRevoked      = errors.New("share link is revoked")
+)
+
+var (
+	shareSecretOnce sync.Once
+	shareSecret     []byte
+)
+
+func getShareSecret() []byte {
+	shareSecretOnce.Do(func() {
+		if secret := config.GetConfig().ShareSecret; secret != "" {
+			shareSecret = []byte(secret)
+			return
+		}
+		klog.Warning("share secret is not set, a random one is used, the share links are invalid after restart and among replicas")
+		shareSecret = make([]byte, 32)
+		if _, err := rand.Read(shareSecret); err != nil {
+			panic(err)
+		}
+	})
+	return shareSecret
+}
+
+// SignShareToken returns the token of the share, which is <share id>.<expires at in unix seconds>.<signature>
+func SignShareToken(secret []byte, shareID string, expiresAt time.Time) string {
+	payload := shareID + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + shareSignature(secret, payload)
+}
+
+// VerifyShareToken checks the signature and the expiry of the token, and returns the share id
+func VerifyShareToken(secret []byte, token string, now time.Time) (string, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return "", ErrInvalidShareToken
+	}
+	payload, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(shareSignature(secret, payload))) {
+		return "", ErrInvalidShareToken
+	}
+	shareID, expires, ok := strings.Cut(payload, ".")
+	if !ok {
+		return "", ErrInvalidShareToken
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", ErrInvalidShareToken
+	}
+	if !now.Before(time.Unix(expiresAt, 0)) {
+		return "", ErrShareExpired
+	}
+	return shareID, nil
+}
+
+func shareSignature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// CreateShare snapshots the messages of the conversation of current user and signs a link to read them
+func (cs *ChatServer) CreateShare(ctx context.Context, req ShareReqBody) (*ShareRespBody, error) {
+	if req.ExpiresInHours <= 0 {
+		req.ExpiresInHours = defaultShareExpiresHours
+	}
+	if req.ExpiresInHours > maxShareExpiresHours {
+		return nil, errors.New("expires_in_hours should be at most 720")
+	}
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	conversation, err := cs.Storage().FindExistingConversation(req.ConversationID, storage.WithAppName(req.APPName), storage.WithAppNamespace(req.AppNamespace), storage.WithUser(currentUser))
+	if err != nil {
+		return nil, err
+	}
+	to := req.ToMessageID
+	if to == "" {
+		to = conversation.LastMessageID()
+	}
+	messages, err := conversation.Branch(to)
+	if err != nil {
+		return nil, err
+	}
+	if req.FromMessageID != "" {
+		from := -1
+		for i := range messages {
+			if messages[i].ID == req.FromMessageID {
+				from = i
+				break
+			}
+		}
+		if from < 0 {
+			return nil, storage.ErrMessageNotFound
+		}
+		messages = messages[from:]
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("no message to share")
+	}
+
+	now := time.Now()
+	share := &storage.Share{
+		ID:             string(uuid.NewUUID()),
+		ConversationID: conversation.ID,
+		AppName:        conversation.AppName,
+		AppNamespace:   conversation.AppNamespace,
+		User:           currentUser,
+		Messages:       snapshotMessages(messages),
+		ExpiresAt:      now.Add(time.Duration(req.ExpiresInHours) * time.Hour),
+		CreatedAt:      now,
+	}
+	if err := cs.Storage().CreateShare(share); err != nil {
+		return nil, err
+	}
+	cs.auditShare(share.ID, storage.ShareActionCreate, currentUser, req.Requester)
+	token := SignShareToken(getShareSecret(), share.ID, share.ExpiresAt)
+	share.Messages = nil
+	return &ShareRespBody{Share: *share, Token: token, Link: SharedPathPrefix + token}, nil
+}
+
+// ListShares lists the shares of current user, without the messages
+func (cs *ChatServer) ListShares(ctx context.Context, req ConversationReqBody) ([]storage.Share, error) {
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	return cs.Storage().ListShares(storage.WithAppName(req.APPName), storage.WithAppNamespace(req.AppNamespace), storage.WithConversationID(req.ConversationID), storage.WithUser(currentUser))
+}
+
+// RevokeShare revokes a share of current user, the link can not be read since then
+func (cs *ChatServer) RevokeShare(ctx context.Context, shareID string, requester Requester) error {
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	if err := cs.Storage().RevokeShare(shareID, storage.WithUser(currentUser)); err != nil {
+		return err
+	}
+	cs.auditShare(shareID, storage.ShareActionRevoke, currentUser, requester)
+	return nil
+}
+
+// ListShareAudits lists who creates, views and revokes a share of current user
+func (cs *ChatServer) ListShareAudits(ctx context.Context, shareID string) ([]storage.ShareAudit, error) {
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	if _, err := cs.Storage().FindShare(shareID, storage.WithUser(currentUser)); err != nil {
+		return nil, err
+	}
+	return cs.Storage().ListShareAudits(shareID)
+}
+
+// GetSharedConversation returns the shared messages by the token, no permission on the app is needed
+func (cs *ChatServer) GetSharedConversation(ctx context.Context, token string, requester Requester) (*storage.Share, error) {
+	now := time.Now()
+	shareID, err := VerifyShareToken(getShareSecret(), token, now)
+	if err != nil {
+		return nil, err
+	}
+	share, err := cs.Storage().FindShare(shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share.RevokedAt != nil {
+		return nil, ErrShareRevoked
+	}
+	if !now.Before(share.ExpiresAt) {
+		return nil, ErrShareExpired
+	}
+	currentUser, _ := ctx.Value(auth.UserNameContextKey).(string)
+	cs.auditShare(share.ID, storage.ShareActionView, currentUser, requester)
+	return share, nil
+}
+
+// auditShare records the action, the failure is only logged so that it does not break the action
+func (cs *ChatServer) auditShare(shareID, action, user string, requester Requester) {
+	audit := &storage.ShareAudit{
+		ShareID:   shareID,
+		Action:    action,
+		User:      user,
+		ClientIP:  requester.ClientIP,
+		UserAgent: requester.UserAgent,
+	}
+	if err := cs.Storage().AddShareAudit(audit); err != nil {
+		klog.Errorf("failed to record %s of share %s: %s", action, shareID, err)
+	}
+}
+
+// snapshotMessages copies what readers of the share need, the feedbacks, agent states and object paths of files are not shared
+func snapshotMessages(messages []storage.Message) storage.SharedMessages {
+	res := make(storage.SharedMessages, len(messages))
+	for i, m := range messages {
+		res[i] = storage.Message{
+			ID:            m.ID,
+			Latency:       m.Latency,
+			Action:        m.Action,
+			Query:         m.Query,
+			Answer:        m.Answer,
+			References:    m.References,
+			FailureReason: m.FailureReason,
+			CreatedAt:     m.CreatedAt,
+		}
+		for _, d := range m.Documents {
+			res[i].Documents = append(res[i].Documents, storage.Document{ID: d.ID, Name: d.Name})
+		}
+	}
+	return res
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
+)
+
+func TestShareToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+	token := SignShareToken(secret, "share", now.Add(time.Hour))
+	id, err := VerifyShareToken(secret, token, now)
+	require.NoError(t, err)
+	require.Equal(t, "share", id)
+
+	_, err = VerifyShareToken(secret, token, now.Add(2*time.Hour))
+	require.ErrorIs(t, err, ErrShareExpired)
+	_, err = VerifyShareToken([]byte("other"), token, now)
+	require.ErrorIs(t, err, ErrInvalidShareToken)
+	// the expiry can not be changed without the secret
+	parts := strings.Split(token, ".")
+	_, err = VerifyShareToken(secret, parts[0]+".9999999999."+parts[2], now)
+	require.ErrorIs(t, err, ErrInvalidShareToken)
+	_, err = VerifyShareToken(secret, "invalid", now)
+	require.ErrorIs(t, err, ErrInvalidShareToken)
+}
+
+func TestShare(t *testing.T) {
+	cs := &ChatServer{storage: storage.NewMemoryStorage()}
+	require.NoError(t, cs.Storage().UpdateConversation(&storage.Conversation{
+		ID:           "conversation",
+		AppName:      "app",
+		AppNamespace: "default",
+		User:         "alice",
+		Messages: []storage.Message{
+			{ID: "m1", ConversationID: "conversation", Query: "q1", Answer: "a1", Feedback: &storage.Feedback{Rating: storage.RatingLike}},
+			{ID: "m2", ConversationID: "conversation", Query: "q2", Answer: "a2"},
+			{ID: "m3", ConversationID: "conversation", Query: "q3", Answer: "a3"},
+			// regenerated answer of q2
+			{ID: "m4", ConversationID: "conversation", ParentID: "m1", Query: "q2", Answer: "a2 again"},
+		},
+	}))
+	alice := context.WithValue(context.Background(), auth.UserNameContextKey, "alice")
+	req := ShareReqBody{FromMessageID: "m2", ToMessageID: "m3", Requester: Requester{ClientIP: "10.0.0.1"}}
+	req.APPName, req.AppNamespace, req.ConversationID = "app", "default", "conversation"
+	_, err := cs.CreateShare(context.WithValue(context.Background(), auth.UserNameContextKey, "bob"), req)
+	require.Error(t, err)
+	share, err := cs.CreateShare(alice, req)
+	require.NoError(t, err)
+	require.Equal(t, SharedPathPrefix+share.Token, share.Link)
+	require.WithinDuration(t, time.Now().Add(7*24*time.Hour), share.ExpiresAt, time.Minute)
+
+	// the link is read by anyone without login
+	shared, err := cs.GetSharedConversation(context.Background(), share.Token, Requester{ClientIP: "10.0.0.2"})
+	require.NoError(t, err)
+	require.Len(t, shared.Messages, 2)
+	require.Equal(t, "m2", shared.Messages[0].ID)
+	require.Equal(t, "a3", shared.Messages[1].Answer)
+
+	// the current branch is shared by default, and the feedbacks are not shared
+	req.FromMessageID, req.ToMessageID = "", ""
+	all, err := cs.CreateShare(alice, req)
+	require.NoError(t, err)
+	shared, err = cs.GetSharedConversation(context.Background(), all.Token, Requester{})
+	require.NoError(t, err)
+	require.Len(t, shared.Messages, 2)
+	require.Equal(t, "m4", shared.Messages[1].ID)
+	require.Nil(t, shared.Messages[0].Feedback)
+
+	req.FromMessageID = "m2"
+	_, err = cs.CreateShare(alice, req)
+	require.ErrorIs(t, err, storage.ErrMessageNotFound)
+
+	shares, err := cs.ListShares(alice, req.ConversationReqBody)
+	require.NoError(t, err)
+	require.Len(t, shares, 2)
+	require.Empty(t, shares[0].Messages)
+
+	require.ErrorIs(t, cs.RevokeShare(context.WithValue(context.Background(), auth.UserNameContextKey, "bob"), share.ID, Requester{}), storage.ErrShareNotFound)
+	require.NoError(t, cs.RevokeShare(alice, share.ID, Requester{}))
+	_, err = cs.GetSharedConversation(context.Background(), share.Token, Requester{})
+	require.ErrorIs(t, err, ErrShareRevoked)
+
+	audits, err := cs.ListShareAudits(alice, share.ID)
+	require.NoError(t, err)
+	require.Len(t, audits, 3)
+	require.Equal(t, storage.ShareActionCreate, audits[0].Action)
+	require.Equal(t, "alice", audits[0].User)
+	require.Equal(t, storage.ShareActionView, audits[1].Action)
+	require.Empty(t, audits[1].User)
+	require.Equal(t, "10.0.0.2", audits[1].ClientIP)
+	require.Equal(t, storage.ShareActionRevoke, audits[2].Action)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+// The messages of a conversation form a tree, each message follows its parent and
+// the first messages follow the conversation itself. A branch is the path from the
+// conversation to a message, which is the history of the chat when the message is sent.
+// Messages saved before branching was supported have no parent, they follow the previous
+// message in the conversation, so the old conversations are a single branch.
+
+// ParentIDOf returns the parent id of the message at index
+func (c *Conversation) ParentIDOf(index int) string {
+	if parentID := c.Messages[index].ParentID; parentID != "" {
+		return parentID
+	}
+	if index == 0 {
+		return c.ID
+	}
+	return c.Messages[index-1].ID
+}
+
+// IndexOf returns the index of the message, -1 if it is not in the conversation
+func (c *Conversation) IndexOf(messageID string) int {
+	for i := range c.Messages {
+		if c.Messages[i].ID == messageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// LastMessageID returns the last message of the selected branch, or the conversation id if there is no message yet
+func (c *Conversation) LastMessageID() string {
+	if c.CurrentMessageID != "" && c.IndexOf(c.CurrentMessageID) >= 0 {
+		return c.CurrentMessageID
+	}
+	if len(c.Messages) == 0 {
+		return c.ID
+	}
+	return c.Messages[len(c.Messages)-1].ID
+}
+
+// Branch returns the messages from the first one to the message with the id, it is empty if the id is the conversation id.
+func (c *Conversation) Branch(messageID string) ([]Message, error) {
+	branch := make([]Message, 0)
+	for id := messageID; id != c.ID; {
+		index := c.IndexOf(id)
+		// the length check stops loops in broken data
+		if index < 0 || len(branch) >= len(c.Messages) {
+			return nil, ErrMessageNotFound
+		}
+		branch = append(branch, c.Messages[index])
+		id = c.ParentIDOf(index)
+	}
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return branch, nil
+}
+
+// BuildTree sets the parent ids and the children of messages, so the tree can be shown
+func (c *Conversation) BuildTree() {
+	children := make(map[string][]string, len(c.Messages))
+	for i := range c.Messages {
+		c.Messages[i].ParentID = c.ParentIDOf(i)
+		children[c.Messages[i].ParentID] = append(children[c.Messages[i].ParentID], c.Messages[i].ID)
+	}
+	for i := range c.Messages {
+		c.Messages[i].Children = children[c.Messages[i].ID]
+	}
+	if len(c.Messages) > 0 {
+		c.CurrentMessageID = c.LastMessageID()
+	}
+}
+
+// SummaryOf returns the summary and the number of messages it covers if the summary covers the beginning of the branch,
+// otherwise the summary is of another branch and nothing is returned
+func (c *Conversation) SummaryOf(branch []Message) (summary string, count int) {
+	if c.Summary == "" {
+		return "", 0
+	}
+	for i := range branch {
+		if branch[i].ID == c.SummaryMessageID {
+			return c.Summary, i + 1
+		}
+	}
+	return "", 0
+}
+
+// SetSummary saves the summary of the first count messages of the branch
+func (c *Conversation) SetSummary(branch []Message, summary string, count int) {
+	if count <= 0 || count > len(branch) {
+		return
+	}
+	c.Summary = summary
+	c.SummaryMessageID = branch[count-1].ID
+}
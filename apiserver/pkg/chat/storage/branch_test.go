@@ -0,0 +1,98 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ids(messages []Message) []string {
+	res := make([]string, 0, len(messages))
+	for _, m := range messages {
+		res = append(res, m.ID)
+	}
+	return res
+}
+
+func TestBranch(t *testing.T) {
+	// m1 and m2 are saved before branching was supported, m3 edits the question of m2 and m4 follows m3
+	c := &Conversation{ID: "c", Messages: []Message{
+		{ID: "m1"},
+		{ID: "m2"},
+		{ID: "m3", ParentID: "m1"},
+		{ID: "m4", ParentID: "m3"},
+		{ID: "m5", ParentID: "c"},
+	}}
+	require.Equal(t, "m5", c.LastMessageID())
+	c.CurrentMessageID = "m4"
+	require.Equal(t, "m4", c.LastMessageID())
+
+	branch, err := c.Branch("m4")
+	require.NoError(t, err)
+	require.Equal(t, []string{"m1", "m3", "m4"}, ids(branch))
+	branch, err = c.Branch("m2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"m1", "m2"}, ids(branch))
+	branch, err = c.Branch("c")
+	require.NoError(t, err)
+	require.Empty(t, branch)
+	_, err = c.Branch("unknown")
+	require.ErrorIs(t, err, ErrMessageNotFound)
+
+	c.BuildTree()
+	require.Equal(t, "c", c.Messages[0].ParentID)
+	require.Equal(t, "m1", c.Messages[1].ParentID)
+	require.Equal(t, []string{"m2", "m3"}, c.Messages[0].Children)
+	require.Empty(t, c.Messages[1].Children)
+	require.Equal(t, "m4", c.CurrentMessageID)
+
+	// broken data with a loop doesn't hang
+	loop := &Conversation{ID: "c", Messages: []Message{{ID: "a", ParentID: "b"}, {ID: "b", ParentID: "a"}}}
+	_, err = loop.Branch("a")
+	require.ErrorIs(t, err, ErrMessageNotFound)
+}
+
+func TestSummaryOf(t *testing.T) {
+	c := &Conversation{ID: "c", Messages: []Message{
+		{ID: "m1"},
+		{ID: "m2"},
+		{ID: "m3", ParentID: "m1"},
+	}}
+	branch, err := c.Branch("m2")
+	require.NoError(t, err)
+	summary, count := c.SummaryOf(branch)
+	require.Empty(t, summary)
+	require.Zero(t, count)
+
+	c.SetSummary(branch, "summary of m1 and m2", 2)
+	require.Equal(t, "m2", c.SummaryMessageID)
+	summary, count = c.SummaryOf(branch)
+	require.Equal(t, "summary of m1 and m2", summary)
+	require.Equal(t, 2, count)
+
+	// m3 branches from m1, so the summary covering m2 is not of its branch
+	other, err := c.Branch("m3")
+	require.NoError(t, err)
+	summary, count = c.SummaryOf(other)
+	require.Empty(t, summary)
+	require.Zero(t, count)
+
+	c.SetSummary(other, "ignored", 3)
+	require.Equal(t, "m2", c.SummaryMessageID)
+}
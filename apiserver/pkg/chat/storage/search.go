@@ -16,6 +16,11 @@ limitations under the License.
 
 package storage
 
+import (
+	"strings"
+	"time"
+)
+
 type Search struct {
 	ConversationID *string
 	MessageID      *string
@@ -23,6 +28,12 @@ type Search struct {
 	AppNamespace   *string
 	User           *string
 	Debug          *bool
+	// Since and Until limit the creation time of feedbacks and messages, Until limits the last update time of purged conversations
+	Since *time.Time
+	Until *time.Time
+	// Page starts from 1, there is no paging if PageSize is 0
+	Page     int
+	PageSize int
 }
 
 type SearchOption func(options *Search)
@@ -95,3 +106,47 @@ func WithDebug(debug bool) SearchOption {
 		o.Debug = &debug
 	}
 }
+
+// WithTimeRange returns a Search for setting the time range, zero time means no limit.
+func WithTimeRange(since, until time.Time) SearchOption {
+	return func(o *Search) {
+		if !since.IsZero() {
+			o.Since = &since
+		}
+		if !until.IsZero() {
+			o.Until = &until
+		}
+	}
+}
+
+// WithPaging returns a Search for setting the page and page size.
+func WithPaging(page, pageSize int) SearchOption {
+	return func(o *Search) {
+		if page < 1 {
+			page = 1
+		}
+		o.Page = page
+		o.PageSize = pageSize
+	}
+}
+
+// paging returns the range of the page in n items
+func (s *Search) paging(n int) (start, end int) {
+	if s.PageSize <= 0 {
+		return 0, n
+	}
+	start = (s.Page - 1) * s.PageSize
+	end = start + s.PageSize
+	if start > n {
+		start = n
+	}
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+// KeywordTerms splits the keyword of search into lower case terms by spaces
+func KeywordTerms(keyword string) []string {
+	return strings.Fields(strings.ToLower(keyword))
+}
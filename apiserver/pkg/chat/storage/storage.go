@@ -23,11 +23,28 @@ import (
 
 	"gorm.io/gorm"
 
+	"github.com/kubeagi/arcadia/pkg/appruntime/agent"
 	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
 )
 
 var (
 	ErrConversationNotFound = errors.New("conversation is not found")
+	ErrMessageNotFound      = errors.New("message is not found")
+	ErrShareNotFound        = errors.New("share is not found")
+)
+
+const (
+	// RatingLike means the user is satisfied with the answer
+	RatingLike = 1
+	// RatingDislike means the user is not satisfied with the answer
+	RatingDislike = -1
+)
+
+const (
+	// ShareActionCreate, ShareActionView and ShareActionRevoke are the actions recorded in the audits of shares
+	ShareActionCreate = "create"
+	ShareActionView   = "view"
+	ShareActionRevoke = "revoke"
 )
 
 // Conversation represent a conversation in storage
@@ -43,6 +60,12 @@ type Conversation struct {
 	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at;type:time;comment:the time the conversation deleted at" json:"-"`
 	// icon only valid in conversation list api
 	Icon string `gorm:"-" json:"icon"`
+	// CurrentMessageID is the last message of the selected branch, the new message follows it by default
+	CurrentMessageID string `gorm:"column:current_message_id;type:string;comment:the last message of the selected branch" json:"current_message_id,omitempty" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	// Summary is the summary of the older messages kept by the summary buffer memory, it covers the branch up to SummaryMessageID
+	Summary string `gorm:"column:summary;type:string;comment:the summary of the older messages" json:"-"`
+	// SummaryMessageID is the last message covered by Summary
+	SummaryMessageID string `gorm:"column:summary_message_id;type:string;comment:the last message covered by the summary" json:"-"`
 }
 
 // Message represent a message in storage
@@ -50,6 +73,10 @@ type Message struct {
 	ID             string `gorm:"column:id;primaryKey;type:uuid;comment:message id" json:"id" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
 	ConversationID string `gorm:"column:conversation_id;type:uuid;comment:conversation id" json:"-"`
 	Latency        int64  `gorm:"column:latency;type:int;comment:request latency, in ms" json:"latency" example:"1000"`
+	// ParentID is the previous message in the branch, or the conversation id for the first messages
+	ParentID string `gorm:"column:parent_id;type:string;comment:the previous message in the branch" json:"parent_id,omitempty" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
+	// Children are the ids of the messages following this message, each of them starts a branch
+	Children []string `gorm:"-" json:"children,omitempty"`
 
 	// Action indicates what is this message for
 	// Chat(by default),UPLOAD,etc...
@@ -62,6 +89,14 @@ type Message struct {
 	RawFiles   string     `gorm:"column:files;type:string;comment:input files" json:"-"`
 	Answer     string     `gorm:"column:answer;type:string;comment:ai response" json:"answer" example:"旷工最小计算单位为0.5天。"`
 	References References `gorm:"column:references;type:json;comment:references" json:"references,omitempty"`
+	// FailureReason is why the agent failed to answer
+	FailureReason string `gorm:"column:failure_reason;type:string;comment:failure reason of agent" json:"failure_reason,omitempty"`
+	// Approval is set when the agent is paused by a tool call waiting for approval of user
+	Approval *Approval `gorm:"column:approval;type:json;comment:agent state waiting for approval" json:"approval,omitempty"`
+	// Feedback is the feedback of user to the answer
+	Feedback *Feedback `gorm:"foreignKey:MessageID" json:"feedback,omitempty"`
+	// CreatedAt is the time the message is sent, it is empty for the messages saved before it is recorded
+	CreatedAt time.Time `gorm:"column:created_at;type:time;autoCreateTime;comment:the time the message created at" json:"created_at,omitempty" example:"2023-12-21T10:21:06.389359092+08:00"`
 
 	// For Action Upload
 	Documents []Document `gorm:"foreignKey:MessageID" json:"documents"`
@@ -81,8 +116,93 @@ type Document struct {
 	Summary        string `gorm:"column:summary;type:string;comment:document summary" json:"summary" example:"kaoqin.pdf"`
 }
 
+// Feedback represent the feedback of user to an answer in storage.
+// The query and answer are kept as they are when the feedback is given.
+type Feedback struct {
+	MessageID      string `gorm:"column:message_id;primaryKey;type:uuid;comment:message id" json:"message_id" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	ConversationID string `gorm:"column:conversation_id;type:uuid;comment:conversation id" json:"conversation_id" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
+	AppName        string `gorm:"column:app_name;type:string;index:idx_feedback_app;comment:app name" json:"-"`
+	AppNamespace   string `gorm:"column:app_namespace;type:string;index:idx_feedback_app;comment:app namespace" json:"-"`
+	User           string `gorm:"column:user;type:string;comment:the user who gives the feedback" json:"-"`
+	// Rating is 1 for like and -1 for dislike
+	Rating  int    `gorm:"column:rating;type:int;comment:1 for like and -1 for dislike" json:"rating" example:"-1"`
+	Comment string `gorm:"column:comment;type:string;comment:comment of user" json:"comment,omitempty" example:"the answer is out of date"`
+	// CorrectedAnswer is the answer the user expects
+	CorrectedAnswer string    `gorm:"column:corrected_answer;type:string;comment:answer corrected by user" json:"corrected_answer,omitempty" example:"旷工最小计算单位为1天。"`
+	Query           string    `gorm:"column:query;type:string;comment:user input" json:"-"`
+	Answer          string    `gorm:"column:answer;type:string;comment:ai response" json:"-"`
+	CreatedAt       time.Time `gorm:"column:created_at;type:time;autoCreateTime;comment:the time the feedback created at" json:"created_at" example:"2023-12-21T10:21:06.389359092+08:00"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;type:time;autoUpdateTime;comment:the time the feedback updated at" json:"updated_at" example:"2023-12-22T10:21:06.389359092+08:00"`
+}
+
+// MessageHit is a message matching the keyword of search
+type MessageHit struct {
+	ConversationID string    `json:"conversation_id" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
+	MessageID      string    `json:"message_id" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	AppName        string    `json:"app_name" example:"chat-with-llm"`
+	AppNamespace   string    `json:"app_namespace" example:"arcadia"`
+	Query          string    `json:"query" example:"差旅报销的标准是什么？"`
+	Answer         string    `json:"answer" example:"差旅报销的标准为..."`
+	CreatedAt      time.Time `json:"created_at" example:"2023-12-21T10:21:06.389359092+08:00"`
+}
+
+// Share is a read-only snapshot of the messages of a conversation, which can be read by a signed link before it expires
+type Share struct {
+	ID             string `gorm:"column:id;primaryKey;type:uuid;comment:share id" json:"id" example:"0b7c3a4e-7a1c-4b8e-9a55-2a43e6e3f1d2"`
+	ConversationID string `gorm:"column:conversation_id;type:uuid;index;comment:conversation id" json:"conversation_id" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
+	AppName        string `gorm:"column:app_name;type:string;index:idx_share_app;comment:app name" json:"app_name" example:"chat-with-llm"`
+	AppNamespace   string `gorm:"column:app_namespace;type:string;index:idx_share_app;comment:app namespace" json:"app_namespace" example:"arcadia"`
+	User           string `gorm:"column:user;type:string;comment:the user who shares the conversation" json:"-"`
+	// Messages are the shared messages in order, they are not changed by the later chats
+	Messages  SharedMessages `gorm:"column:messages;type:json;comment:snapshot of the shared messages" json:"messages,omitempty"`
+	ExpiresAt time.Time      `gorm:"column:expires_at;type:time;comment:the time the share expires at" json:"expires_at" example:"2023-12-28T10:21:06.389359092+08:00"`
+	// RevokedAt is set when the user revokes the share, the link can not be read since then
+	RevokedAt *time.Time `gorm:"column:revoked_at;type:time;comment:the time the share revoked at" json:"revoked_at,omitempty" example:"2023-12-22T10:21:06.389359092+08:00"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:time;autoCreateTime;comment:the time the share created at" json:"created_at" example:"2023-12-21T10:21:06.389359092+08:00"`
+}
+
+// ShareAudit records who creates, views or revokes a share
+type ShareAudit struct {
+	ID      uint   `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	ShareID string `gorm:"column:share_id;type:uuid;index;comment:share id" json:"share_id" example:"0b7c3a4e-7a1c-4b8e-9a55-2a43e6e3f1d2"`
+	Action  string `gorm:"column:action;type:string;comment:create, view or revoke" json:"action" example:"view"`
+	// User is empty when the share is viewed by the link without login
+	User      string    `gorm:"column:user;type:string;comment:the user who takes the action" json:"user,omitempty" example:"admin"`
+	ClientIP  string    `gorm:"column:client_ip;type:string;comment:ip of the client" json:"client_ip" example:"10.0.0.1"`
+	UserAgent string    `gorm:"column:user_agent;type:string;comment:user agent of the client" json:"user_agent" example:"Mozilla/5.0"`
+	CreatedAt time.Time `gorm:"column:created_at;type:time;autoCreateTime;comment:the time of the action" json:"created_at" example:"2023-12-21T10:21:06.389359092+08:00"`
+}
+
+// PIIAudit records a detection of personally identifiable information in a chat,
+// only the types and the count are recorded, never the detected values
+type PIIAudit struct {
+	ID             uint   `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	ConversationID string `gorm:"column:conversation_id;type:uuid;index;comment:conversation id" json:"conversation_id" example:"5a41f3ca-763b-41ec-91c3-4bbbb00736d0"`
+	MessageID      string `gorm:"column:message_id;type:uuid;comment:message id" json:"message_id" example:"4f3546dd-5404-4bf8-a3bc-4fa3f9a7ba24"`
+	AppName        string `gorm:"column:app_name;type:string;index:idx_pii_audit_app;comment:app name" json:"app_name" example:"chat-with-llm"`
+	AppNamespace   string `gorm:"column:app_namespace;type:string;index:idx_pii_audit_app;comment:app namespace" json:"app_namespace" example:"arcadia"`
+	User           string `gorm:"column:user;type:string;comment:the user who chats" json:"user" example:"admin"`
+	// Stage is input for the questions and output for the answers
+	Stage string `gorm:"column:stage;type:string;comment:input or output" json:"stage" example:"input"`
+	// Mode is what is done with the detected information, mask, block or audit
+	Mode      string    `gorm:"column:mode;type:string;comment:mask, block or audit" json:"mode" example:"mask"`
+	Types     string    `gorm:"column:types;type:string;comment:the detected types separated by commas" json:"types" example:"idcard,phone"`
+	Count     int       `gorm:"column:count;comment:how many values are detected" json:"count" example:"2"`
+	CreatedAt time.Time `gorm:"column:created_at;type:time;autoCreateTime;comment:the time of the detection" json:"created_at" example:"2023-12-21T10:21:06.389359092+08:00"`
+}
+
+// ConversationApp is an application having conversations in storage, including the deleted conversations
+type ConversationApp struct {
+	AppName      string `gorm:"column:app_name" json:"app_name"`
+	AppNamespace string `gorm:"column:app_namespace" json:"app_namespace"`
+}
+
 type References []retriever.Reference
 
+type Approval agent.Approval
+
+type SharedMessages []Message
+
 func (Conversation) TableName() string {
 	return "app_chat_conversation"
 }
@@ -95,10 +215,29 @@ func (Document) TableName() string {
 	return "app_chat_document"
 }
 
+func (Feedback) TableName() string {
+	return "app_chat_feedback"
+}
+
+func (Share) TableName() string {
+	return "app_chat_share"
+}
+
+func (ShareAudit) TableName() string {
+	return "app_chat_share_audit"
+}
+
+func (PIIAudit) TableName() string {
+	return "app_chat_pii_audit"
+}
+
 type Storage interface {
 	ConversationStorage
 	MessageStorage
 	DocumentStorage
+	FeedbackStorage
+	ShareStorage
+	PIIAuditStorage
 }
 
 // ConversationStorage interface
@@ -121,6 +260,20 @@ type ConversationStorage interface {
 	//
 	// It accepts SearchOption(s) and returns a slice of Conversation and an error.
 	ListConversations(opts ...SearchOption) ([]Conversation, error)
+	// SearchMessages searches the queries and answers in the conversations for the keyword, the latest first.
+	//
+	// It accepts SearchOption(s) of app, user, time range and paging, and returns the hits in the page and the total count.
+	SearchMessages(keyword string, opts ...SearchOption) ([]MessageHit, int64, error)
+	// ListConversationApps returns the applications having conversations, including the deleted ones.
+	ListConversationApps() ([]ConversationApp, error)
+	// PurgeConversations deletes the conversations permanently, with their messages, documents, feedbacks, shares and pii audits.
+	// Unlike Delete, the deleted conversations are purged too.
+	//
+	// The cleanup is called with the conversations, messages and documents before they are deleted in batches,
+	// so the files and knowledgebases can be cleaned up, the batch is kept if it returns an error.
+	// It accepts SearchOption(s) of conversation, app, user and Until of the time range, which limits the last update time.
+	// It returns the number of purged conversations.
+	PurgeConversations(cleanup func([]Conversation) error, opts ...SearchOption) (int, error)
 }
 
 type MessageStorage interface {
@@ -132,6 +285,47 @@ type MessageStorage interface {
 	CountMessages(appName, appNamespace string) (int64, error)
 }
 
+type FeedbackStorage interface {
+	// UpdateFeedback creates or updates the feedback of a message.
+	//
+	// It returns ErrMessageNotFound if the message is not in the conversation.
+	UpdateFeedback(*Feedback) error
+	// ListFeedbacks returns the feedbacks based on the provided options, the earliest first.
+	//
+	// It accepts SearchOption(s) of app, conversation and time range.
+	ListFeedbacks(opts ...SearchOption) ([]Feedback, error)
+}
+
+type ShareStorage interface {
+	// CreateShare saves the share with the snapshot of messages.
+	CreateShare(*Share) error
+	// FindShare finds a share by id, it returns ErrShareNotFound if it does not exist.
+	//
+	// It accepts SearchOption(s) of app and user.
+	FindShare(id string, opts ...SearchOption) (*Share, error)
+	// ListShares returns the shares without messages based on the provided options, the latest first.
+	//
+	// It accepts SearchOption(s) of app, conversation and user.
+	ListShares(opts ...SearchOption) ([]Share, error)
+	// RevokeShare sets the revoked time of the share, the share is kept for audit.
+	//
+	// It returns ErrShareNotFound if the share does not exist, and does nothing if it is revoked already.
+	RevokeShare(id string, opts ...SearchOption) error
+	// AddShareAudit records an action on a share.
+	AddShareAudit(*ShareAudit) error
+	// ListShareAudits returns the audits of a share, the earliest first.
+	ListShareAudits(shareID string) ([]ShareAudit, error)
+}
+
+type PIIAuditStorage interface {
+	// AddPIIAudit records a detection of personally identifiable information.
+	AddPIIAudit(*PIIAudit) error
+	// ListPIIAudits returns the audits based on the provided options, the earliest first.
+	//
+	// It accepts SearchOption(s) of app, conversation, user and time range.
+	ListPIIAudits(opts ...SearchOption) ([]PIIAudit, error)
+}
+
 type DocumentStorage interface {
 	// TO BE DEFINED
 }
@@ -18,7 +18,9 @@ package storage
 
 import (
 	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 var _ Storage = (*MemoryStorage)(nil)
@@ -26,6 +28,9 @@ var _ Storage = (*MemoryStorage)(nil)
 type MemoryStorage struct {
 	mu            sync.Mutex
 	conversations map[string]Conversation
+	shares        map[string]Share
+	shareAudits   []ShareAudit
+	piiAudits     []PIIAudit
 }
 
 func (m *MemoryStorage) CountMessages(appName, appNamespace string) (res int64, err error) {
@@ -44,6 +49,7 @@ func (m *MemoryStorage) CountMessages(appName, appNamespace string) (res int64,
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
 		conversations: make(map[string]Conversation),
+		shares:        make(map[string]Share),
 	}
 }
 
@@ -180,3 +186,350 @@ func (m *MemoryStorage) FindExistingDocument(conversationID string, messageID st
 	}
 	return nil, nil
 }
+
+// UpdateFeedback sets the feedback of the message in MemoryStorage.
+func (m *MemoryStorage) UpdateFeedback(feedback *Feedback) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.conversations[feedback.ConversationID]
+	if !ok {
+		return ErrConversationNotFound
+	}
+	for i := range c.Messages {
+		if c.Messages[i].ID != feedback.MessageID {
+			continue
+		}
+		now := time.Now()
+		feedback.CreatedAt = now
+		if c.Messages[i].Feedback != nil {
+			feedback.CreatedAt = c.Messages[i].Feedback.CreatedAt
+		}
+		feedback.UpdatedAt = now
+		saved := *feedback
+		c.Messages[i].Feedback = &saved
+		m.conversations[c.ID] = c
+		return nil
+	}
+	return ErrMessageNotFound
+}
+
+// ListFeedbacks retrieves feedbacks from MemoryStorage based on the provided options.
+func (m *MemoryStorage) ListFeedbacks(opts ...SearchOption) (feedbacks []Feedback, err error) {
+	searchOpt := applyOptions(nil, opts...)
+	m.mu.Lock()
+	for _, c := range m.conversations {
+		for _, message := range c.Messages {
+			f := message.Feedback
+			if f == nil {
+				continue
+			}
+			if searchOpt.ConversationID != nil && f.ConversationID != *searchOpt.ConversationID {
+				continue
+			}
+			if searchOpt.AppName != nil && f.AppName != *searchOpt.AppName {
+				continue
+			}
+			if searchOpt.AppNamespace != nil && f.AppNamespace != *searchOpt.AppNamespace {
+				continue
+			}
+			if searchOpt.User != nil && f.User != *searchOpt.User {
+				continue
+			}
+			if searchOpt.Since != nil && f.CreatedAt.Before(*searchOpt.Since) {
+				continue
+			}
+			if searchOpt.Until != nil && !f.CreatedAt.Before(*searchOpt.Until) {
+				continue
+			}
+			feedbacks = append(feedbacks, *f)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(feedbacks, func(i, j int) bool {
+		return feedbacks[i].CreatedAt.Before(feedbacks[j].CreatedAt)
+	})
+	return feedbacks, nil
+}
+
+// SearchMessages searches the messages in MemoryStorage by substring matching, all terms of the keyword should be matched.
+func (m *MemoryStorage) SearchMessages(keyword string, opts ...SearchOption) ([]MessageHit, int64, error) {
+	searchOpt := applyOptions(nil, opts...)
+	terms := KeywordTerms(keyword)
+	hits := make([]MessageHit, 0)
+	m.mu.Lock()
+	for _, c := range m.conversations {
+		if c.Debug {
+			continue
+		}
+		if searchOpt.ConversationID != nil && c.ID != *searchOpt.ConversationID {
+			continue
+		}
+		if searchOpt.AppName != nil && c.AppName != *searchOpt.AppName {
+			continue
+		}
+		if searchOpt.AppNamespace != nil && c.AppNamespace != *searchOpt.AppNamespace {
+			continue
+		}
+		if searchOpt.User != nil && c.User != *searchOpt.User {
+			continue
+		}
+		for _, message := range c.Messages {
+			if message.Action == "UPLOAD" {
+				continue
+			}
+			if searchOpt.Since != nil && message.CreatedAt.Before(*searchOpt.Since) {
+				continue
+			}
+			if searchOpt.Until != nil && !message.CreatedAt.Before(*searchOpt.Until) {
+				continue
+			}
+			if !matchTerms(strings.ToLower(message.Query+"\n"+message.Answer), terms) {
+				continue
+			}
+			hits = append(hits, MessageHit{
+				ConversationID: c.ID,
+				MessageID:      message.ID,
+				AppName:        c.AppName,
+				AppNamespace:   c.AppNamespace,
+				Query:          message.Query,
+				Answer:         message.Answer,
+				CreatedAt:      message.CreatedAt,
+			})
+		}
+	}
+	m.mu.Unlock()
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].CreatedAt.After(hits[j].CreatedAt)
+	})
+	start, end := searchOpt.paging(len(hits))
+	return hits[start:end], int64(len(hits)), nil
+}
+
+func matchTerms(text string, terms []string) bool {
+	if len(terms) == 0 {
+		return false
+	}
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateShare saves the share in MemoryStorage.
+func (m *MemoryStorage) CreateShare(share *Share) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if share.CreatedAt.IsZero() {
+		share.CreatedAt = time.Now()
+	}
+	m.shares[share.ID] = *share
+	return nil
+}
+
+// FindShare finds the share in MemoryStorage.
+func (m *MemoryStorage) FindShare(id string, opts ...SearchOption) (*Share, error) {
+	searchOpt := applyOptions(nil, opts...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	share, ok := m.shares[id]
+	if !ok || !matchShare(share, searchOpt) {
+		return nil, ErrShareNotFound
+	}
+	return &share, nil
+}
+
+// ListShares retrieves shares from MemoryStorage based on the provided options.
+func (m *MemoryStorage) ListShares(opts ...SearchOption) ([]Share, error) {
+	searchOpt := applyOptions(nil, opts...)
+	shares := make([]Share, 0)
+	m.mu.Lock()
+	for _, share := range m.shares {
+		if !matchShare(share, searchOpt) {
+			continue
+		}
+		share.Messages = nil
+		shares = append(shares, share)
+	}
+	m.mu.Unlock()
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].CreatedAt.After(shares[j].CreatedAt)
+	})
+	return shares, nil
+}
+
+// RevokeShare sets the revoked time of the share in MemoryStorage.
+func (m *MemoryStorage) RevokeShare(id string, opts ...SearchOption) error {
+	searchOpt := applyOptions(nil, opts...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	share, ok := m.shares[id]
+	if !ok || !matchShare(share, searchOpt) {
+		return ErrShareNotFound
+	}
+	if share.RevokedAt == nil {
+		now := time.Now()
+		share.RevokedAt = &now
+		m.shares[id] = share
+	}
+	return nil
+}
+
+// AddShareAudit records the audit in MemoryStorage.
+func (m *MemoryStorage) AddShareAudit(audit *ShareAudit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	audit.ID = uint(len(m.shareAudits) + 1)
+	if audit.CreatedAt.IsZero() {
+		audit.CreatedAt = time.Now()
+	}
+	m.shareAudits = append(m.shareAudits, *audit)
+	return nil
+}
+
+// ListShareAudits retrieves the audits of the share from MemoryStorage.
+func (m *MemoryStorage) ListShareAudits(shareID string) ([]ShareAudit, error) {
+	audits := make([]ShareAudit, 0)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, audit := range m.shareAudits {
+		if audit.ShareID == shareID {
+			audits = append(audits, audit)
+		}
+	}
+	return audits, nil
+}
+
+// AddPIIAudit records the audit in MemoryStorage.
+func (m *MemoryStorage) AddPIIAudit(audit *PIIAudit) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	audit.ID = uint(len(m.piiAudits) + 1)
+	if audit.CreatedAt.IsZero() {
+		audit.CreatedAt = time.Now()
+	}
+	m.piiAudits = append(m.piiAudits, *audit)
+	return nil
+}
+
+// ListPIIAudits retrieves the audits from MemoryStorage based on the provided options.
+func (m *MemoryStorage) ListPIIAudits(opts ...SearchOption) ([]PIIAudit, error) {
+	searchOpt := applyOptions(nil, opts...)
+	audits := make([]PIIAudit, 0)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, audit := range m.piiAudits {
+		if searchOpt.ConversationID != nil && audit.ConversationID != *searchOpt.ConversationID {
+			continue
+		}
+		if searchOpt.AppName != nil && audit.AppName != *searchOpt.AppName {
+			continue
+		}
+		if searchOpt.AppNamespace != nil && audit.AppNamespace != *searchOpt.AppNamespace {
+			continue
+		}
+		if searchOpt.User != nil && audit.User != *searchOpt.User {
+			continue
+		}
+		if searchOpt.Since != nil && audit.CreatedAt.Before(*searchOpt.Since) {
+			continue
+		}
+		if searchOpt.Until != nil && !audit.CreatedAt.Before(*searchOpt.Until) {
+			continue
+		}
+		audits = append(audits, audit)
+	}
+	return audits, nil
+}
+
+func matchShare(share Share, searchOpt *Search) bool {
+	if searchOpt.ConversationID != nil && share.ConversationID != *searchOpt.ConversationID {
+		return false
+	}
+	if searchOpt.AppName != nil && share.AppName != *searchOpt.AppName {
+		return false
+	}
+	if searchOpt.AppNamespace != nil && share.AppNamespace != *searchOpt.AppNamespace {
+		return false
+	}
+	if searchOpt.User != nil && share.User != *searchOpt.User {
+		return false
+	}
+	return true
+}
+
+// ListConversationApps returns the apps of conversations in MemoryStorage.
+func (m *MemoryStorage) ListConversationApps() ([]ConversationApp, error) {
+	apps := make([]ConversationApp, 0)
+	seen := make(map[ConversationApp]bool)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.conversations {
+		app := ConversationApp{AppName: c.AppName, AppNamespace: c.AppNamespace}
+		if !seen[app] {
+			seen[app] = true
+			apps = append(apps, app)
+		}
+	}
+	return apps, nil
+}
+
+// PurgeConversations deletes the conversations and their shares from MemoryStorage.
+func (m *MemoryStorage) PurgeConversations(cleanup func([]Conversation) error, opts ...SearchOption) (int, error) {
+	searchOpt := applyOptions(nil, opts...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	batch := make([]Conversation, 0)
+	for _, c := range m.conversations {
+		if searchOpt.ConversationID != nil && c.ID != *searchOpt.ConversationID {
+			continue
+		}
+		if searchOpt.AppName != nil && c.AppName != *searchOpt.AppName {
+			continue
+		}
+		if searchOpt.AppNamespace != nil && c.AppNamespace != *searchOpt.AppNamespace {
+			continue
+		}
+		if searchOpt.User != nil && c.User != *searchOpt.User {
+			continue
+		}
+		if searchOpt.Until != nil && !c.UpdatedAt.Before(*searchOpt.Until) {
+			continue
+		}
+		batch = append(batch, c)
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := cleanup(batch); err != nil {
+		return 0, err
+	}
+	for _, c := range batch {
+		delete(m.conversations, c.ID)
+		for shareID, share := range m.shares {
+			if share.ConversationID == c.ID {
+				delete(m.shares, shareID)
+			}
+		}
+	}
+	audits := m.shareAudits[:0]
+	for _, audit := range m.shareAudits {
+		if _, ok := m.shares[audit.ShareID]; ok {
+			audits = append(audits, audit)
+		}
+	}
+	m.shareAudits = audits
+	purged := make(map[string]bool, len(batch))
+	for _, c := range batch {
+		purged[c.ID] = true
+	}
+	piiAudits := m.piiAudits[:0]
+	for _, audit := range m.piiAudits {
+		if !purged[audit.ConversationID] {
+			piiAudits = append(piiAudits, audit)
+		}
+	}
+	m.piiAudits = piiAudits
+	return len(batch), nil
+}
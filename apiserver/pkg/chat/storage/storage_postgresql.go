@@ -19,9 +19,11 @@ package storage
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -57,10 +59,43 @@ func (r References) Value() (driver.Value, error) {
 	return json.Marshal(r)
 }
 
+func (a *Approval) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal JSONB value:%#v", value)
+	}
+	return json.Unmarshal(bytes, a)
+}
+
+func (a Approval) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (m *SharedMessages) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal JSONB value:%#v", value)
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+func (m SharedMessages) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
 var _ Storage = (*PostgreSQLStorage)(nil)
 
+// purgeBatchSize is the number of conversations deleted in one transaction
+const purgeBatchSize = 500
+
+// textSearchConfigs are the text search configurations used in full-text search in order of preference,
+// "chinese" is the configuration usually created with the zhparser extension for chinese tokenization.
+var textSearchConfigs = []string{"chinese", "simple"}
+
 type PostgreSQLStorage struct {
 	db *gorm.DB
+	// textSearchConfig is the text search configuration used in full-text search
+	textSearchConfig string
 }
 
 func (p *PostgreSQLStorage) CountMessages(appName, appNamespace string) (int64, error) {
@@ -103,7 +138,7 @@ func (p *PostgreSQLStorage) ListConversations(opts ...SearchOption) ([]Conversat
 	conversationQuery.Debug = false
 	conversationQuery.DeletedAt.Valid = false
 	res := make([]Conversation, 0)
-	tx := p.db.Preload("Messages.Documents").Order("updated_at DESC").Find(&res, conversationQuery)
+	tx := p.db.Preload("Messages.Documents").Preload("Messages.Feedback").Order("updated_at DESC").Find(&res, conversationQuery)
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -115,6 +150,14 @@ func (p *PostgreSQLStorage) UpdateConversation(conversation *Conversation) error
 	if tx.Error != nil {
 		return tx.Error
 	}
+	// the existing messages are not updated when saving the associations of conversation,
+	// but their answers may change, like the message resumed after approval
+	if len(conversation.Messages) > 0 {
+		tx = p.db.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(&conversation.Messages)
+		if tx.Error != nil {
+			return tx.Error
+		}
+	}
 	return nil
 }
 
@@ -124,7 +167,7 @@ func NewPostgreSQLStorage(conn *pgx.Conn) (*PostgreSQLStorage, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := db.AutoMigrate(&Conversation{}, &Message{}, &Document{}); err != nil {
+	if err := db.AutoMigrate(&Conversation{}, &Message{}, &Document{}, &Feedback{}, &Share{}, &ShareAudit{}, &PIIAudit{}); err != nil {
 		return nil, err
 	}
 	customLogger := logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
@@ -134,11 +177,35 @@ func NewPostgreSQLStorage(conn *pgx.Conn) (*PostgreSQLStorage, error) {
 		Colorful:                  false,
 	})
 	db.Logger = customLogger
+	textSearchConfig := detectTextSearchConfig(db)
+	// the index is only used when the configuration in the query is the same
+	if err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_app_chat_message_fts_%s ON app_chat_message USING gin (%s)", textSearchConfig, messageTSVector(textSearchConfig, ""))).Error; err != nil {
+		log.Printf("failed to create full-text search index of messages: %s", err)
+	}
 	return &PostgreSQLStorage{
-		db: db,
+		db:               db,
+		textSearchConfig: textSearchConfig,
 	}, nil
 }
 
+func detectTextSearchConfig(db *gorm.DB) string {
+	for _, config := range textSearchConfigs[:len(textSearchConfigs)-1] {
+		var count int64
+		if err := db.Raw("SELECT count(*) FROM pg_ts_config WHERE cfgname = ?", config).Scan(&count).Error; err == nil && count > 0 {
+			return config
+		}
+	}
+	return textSearchConfigs[len(textSearchConfigs)-1]
+}
+
+// messageTSVector returns the tsvector expression of the query and answer of messages in the table alias
+func messageTSVector(config, table string) string {
+	if table != "" {
+		table += "."
+	}
+	return fmt.Sprintf("to_tsvector('%s', coalesce(%squery, '') || ' ' || coalesce(%sanswer, ''))", config, table, table)
+}
+
 func (p *PostgreSQLStorage) FindExistingConversation(conversationID string, opts ...SearchOption) (*Conversation, error) {
 	searchOpt := applyOptions(&conversationID, opts...)
 	conversationQuery := Conversation{ID: conversationID}
@@ -157,7 +224,7 @@ func (p *PostgreSQLStorage) FindExistingConversation(conversationID string, opts
 	conversationQuery.Debug = false
 	conversationQuery.DeletedAt.Valid = false
 	res := &Conversation{}
-	tx := p.db.Preload("Messages.Documents").First(res, conversationQuery)
+	tx := p.db.Preload("Messages.Documents").Preload("Messages.Feedback").First(res, conversationQuery)
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -229,7 +296,7 @@ func (p *PostgreSQLStorage) FindExistingMessage(conversationID string, messageID
 	conversationQuery.DeletedAt.Valid = false
 	conversation := &Conversation{}
 	message := &Message{}
-	tx := p.db.Preload("Documents").First(message, Message{ID: messageID})
+	tx := p.db.Preload("Documents").Preload("Feedback").First(message, Message{ID: messageID})
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -264,3 +331,260 @@ func (p *PostgreSQLStorage) FindExistingDocument(conversationID, messageID strin
 	}
 	return document, nil
 }
+
+func (p *PostgreSQLStorage) UpdateFeedback(feedback *Feedback) error {
+	var count int64
+	tx := p.db.Model(&Message{}).Where(Message{ID: feedback.MessageID, ConversationID: feedback.ConversationID}).Count(&count)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if count == 0 {
+		return ErrMessageNotFound
+	}
+	tx = p.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rating", "comment", "corrected_answer", "query", "answer", "updated_at"}),
+	}).Create(feedback)
+	return tx.Error
+}
+
+func (p *PostgreSQLStorage) ListFeedbacks(opts ...SearchOption) ([]Feedback, error) {
+	searchOpt := applyOptions(nil, opts...)
+	feedbackQuery := Feedback{}
+	if searchOpt.ConversationID != nil {
+		feedbackQuery.ConversationID = *searchOpt.ConversationID
+	}
+	if searchOpt.AppName != nil {
+		feedbackQuery.AppName = *searchOpt.AppName
+	}
+	if searchOpt.AppNamespace != nil {
+		feedbackQuery.AppNamespace = *searchOpt.AppNamespace
+	}
+	if searchOpt.User != nil {
+		feedbackQuery.User = *searchOpt.User
+	}
+	tx := p.db.Where(feedbackQuery)
+	if searchOpt.Since != nil {
+		tx = tx.Where("created_at >= ?", *searchOpt.Since)
+	}
+	if searchOpt.Until != nil {
+		tx = tx.Where("created_at < ?", *searchOpt.Until)
+	}
+	res := make([]Feedback, 0)
+	if err := tx.Order("created_at").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SearchMessages searches the messages by the full-text search of PostgreSQL,
+// the substring matching is also used as the fallback when the keyword is not tokenized as expected.
+func (p *PostgreSQLStorage) SearchMessages(keyword string, opts ...SearchOption) ([]MessageHit, int64, error) {
+	searchOpt := applyOptions(nil, opts...)
+	like := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.TrimSpace(keyword)) + "%"
+	tx := p.db.Table("app_chat_message AS m").
+		Joins("JOIN app_chat_conversation AS c ON c.id = m.conversation_id").
+		Where("c.deleted_at IS NULL AND c.debug = ? AND coalesce(m.action, '') <> ?", false, "UPLOAD").
+		Where(fmt.Sprintf("(%s @@ plainto_tsquery('%s', ?) OR m.query ILIKE ? OR m.answer ILIKE ?)", messageTSVector(p.textSearchConfig, "m"), p.textSearchConfig), keyword, like, like)
+	if searchOpt.ConversationID != nil {
+		tx = tx.Where("c.id = ?", *searchOpt.ConversationID)
+	}
+	if searchOpt.AppName != nil {
+		tx = tx.Where("c.app_name = ?", *searchOpt.AppName)
+	}
+	if searchOpt.AppNamespace != nil {
+		tx = tx.Where("c.app_namespace = ?", *searchOpt.AppNamespace)
+	}
+	if searchOpt.User != nil {
+		tx = tx.Where(`c."user" = ?`, *searchOpt.User)
+	}
+	if searchOpt.Since != nil {
+		tx = tx.Where("m.created_at >= ?", *searchOpt.Since)
+	}
+	if searchOpt.Until != nil {
+		tx = tx.Where("m.created_at < ?", *searchOpt.Until)
+	}
+	// the conditions are shared by the count and the paged query
+	tx = tx.Session(&gorm.Session{})
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	page := tx
+	if searchOpt.PageSize > 0 {
+		page = tx.Offset((searchOpt.Page - 1) * searchOpt.PageSize).Limit(searchOpt.PageSize)
+	}
+	hits := make([]MessageHit, 0)
+	err := page.Select("m.conversation_id, m.id AS message_id, c.app_name, c.app_namespace, m.query, m.answer, m.created_at").
+		Order("m.created_at DESC NULLS LAST").Scan(&hits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return hits, total, nil
+}
+
+func (p *PostgreSQLStorage) CreateShare(share *Share) error {
+	return p.db.Create(share).Error
+}
+
+func (p *PostgreSQLStorage) FindShare(id string, opts ...SearchOption) (*Share, error) {
+	res := &Share{}
+	tx := p.db.Where(shareQuery(id, applyOptions(nil, opts...))).First(res)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrShareNotFound
+		}
+		return nil, tx.Error
+	}
+	return res, nil
+}
+
+func (p *PostgreSQLStorage) ListShares(opts ...SearchOption) ([]Share, error) {
+	res := make([]Share, 0)
+	tx := p.db.Omit("messages").Where(shareQuery("", applyOptions(nil, opts...))).Order("created_at DESC").Find(&res)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return res, nil
+}
+
+func (p *PostgreSQLStorage) RevokeShare(id string, opts ...SearchOption) error {
+	query := shareQuery(id, applyOptions(nil, opts...))
+	var count int64
+	if err := p.db.Model(&Share{}).Where(query).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrShareNotFound
+	}
+	return p.db.Model(&Share{}).Where(query).Where("revoked_at IS NULL").Update("revoked_at", time.Now()).Error
+}
+
+func (p *PostgreSQLStorage) AddShareAudit(audit *ShareAudit) error {
+	return p.db.Create(audit).Error
+}
+
+func (p *PostgreSQLStorage) ListShareAudits(shareID string) ([]ShareAudit, error) {
+	res := make([]ShareAudit, 0)
+	tx := p.db.Where(ShareAudit{ShareID: shareID}).Order("created_at").Find(&res)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return res, nil
+}
+
+func (p *PostgreSQLStorage) AddPIIAudit(audit *PIIAudit) error {
+	return p.db.Create(audit).Error
+}
+
+func (p *PostgreSQLStorage) ListPIIAudits(opts ...SearchOption) ([]PIIAudit, error) {
+	searchOpt := applyOptions(nil, opts...)
+	auditQuery := PIIAudit{}
+	if searchOpt.ConversationID != nil {
+		auditQuery.ConversationID = *searchOpt.ConversationID
+	}
+	if searchOpt.AppName != nil {
+		auditQuery.AppName = *searchOpt.AppName
+	}
+	if searchOpt.AppNamespace != nil {
+		auditQuery.AppNamespace = *searchOpt.AppNamespace
+	}
+	if searchOpt.User != nil {
+		auditQuery.User = *searchOpt.User
+	}
+	tx := p.db.Where(auditQuery)
+	if searchOpt.Since != nil {
+		tx = tx.Where("created_at >= ?", *searchOpt.Since)
+	}
+	if searchOpt.Until != nil {
+		tx = tx.Where("created_at < ?", *searchOpt.Until)
+	}
+	res := make([]PIIAudit, 0)
+	if err := tx.Order("created_at").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func shareQuery(id string, searchOpt *Search) Share {
+	query := Share{ID: id}
+	if searchOpt.ConversationID != nil {
+		query.ConversationID = *searchOpt.ConversationID
+	}
+	if searchOpt.AppName != nil {
+		query.AppName = *searchOpt.AppName
+	}
+	if searchOpt.AppNamespace != nil {
+		query.AppNamespace = *searchOpt.AppNamespace
+	}
+	if searchOpt.User != nil {
+		query.User = *searchOpt.User
+	}
+	return query
+}
+
+func (p *PostgreSQLStorage) ListConversationApps() ([]ConversationApp, error) {
+	res := make([]ConversationApp, 0)
+	tx := p.db.Unscoped().Model(&Conversation{}).Distinct("app_name", "app_namespace").Find(&res)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return res, nil
+}
+
+func (p *PostgreSQLStorage) PurgeConversations(cleanup func([]Conversation) error, opts ...SearchOption) (int, error) {
+	searchOpt := applyOptions(nil, opts...)
+	conversationQuery := Conversation{}
+	if searchOpt.ConversationID != nil {
+		conversationQuery.ID = *searchOpt.ConversationID
+	}
+	if searchOpt.AppName != nil {
+		conversationQuery.AppName = *searchOpt.AppName
+	}
+	if searchOpt.AppNamespace != nil {
+		conversationQuery.AppNamespace = *searchOpt.AppNamespace
+	}
+	if searchOpt.User != nil {
+		conversationQuery.User = *searchOpt.User
+	}
+	purged := 0
+	for {
+		batch := make([]Conversation, 0)
+		query := p.db.Unscoped().Preload("Messages.Documents").Where(conversationQuery)
+		if searchOpt.Until != nil {
+			query = query.Where("updated_at < ?", *searchOpt.Until)
+		}
+		if err := query.Limit(purgeBatchSize).Find(&batch).Error; err != nil {
+			return purged, err
+		}
+		if len(batch) == 0 {
+			return purged, nil
+		}
+		if err := cleanup(batch); err != nil {
+			return purged, err
+		}
+		ids := make([]string, len(batch))
+		for i := range batch {
+			ids[i] = batch[i].ID
+		}
+		err := p.db.Transaction(func(tx *gorm.DB) error {
+			shares := tx.Model(&Share{}).Select("id").Where("conversation_id IN ?", ids)
+			if err := tx.Where("share_id IN (?)", shares).Delete(&ShareAudit{}).Error; err != nil {
+				return err
+			}
+			for _, model := range []any{&Share{}, &PIIAudit{}, &Feedback{}, &Document{}, &Message{}} {
+				if err := tx.Where("conversation_id IN ?", ids).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Unscoped().Where("id IN ?", ids).Delete(&Conversation{}).Error
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged += len(batch)
+		if len(batch) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
@@ -34,7 +34,10 @@ import (
 	"github.com/kubeagi/arcadia/apiserver/pkg/llm"
 	"github.com/kubeagi/arcadia/apiserver/pkg/worker"
 	llmspkg "github.com/kubeagi/arcadia/pkg/llms"
+	"github.com/kubeagi/arcadia/pkg/llms/anthropic"
+	"github.com/kubeagi/arcadia/pkg/llms/dashscope"
 	"github.com/kubeagi/arcadia/pkg/llms/openai"
+	"github.com/kubeagi/arcadia/pkg/llms/qianfan"
 	"github.com/kubeagi/arcadia/pkg/llms/zhipuai"
 )
 
@@ -318,6 +321,14 @@ func CheckModelService(ctx context.Context, c client.Client, input generated.Cre
 			info, err = checkOpenAI(ctx, input)
 		case "zhipuai":
 			info, err = checkZhipuAI(ctx, input)
+		case "anthropic":
+			info, err = checkAnthropic(ctx, input)
+		case "azureopenai", "moonshot":
+			info, err = checkOpenAICompatible(ctx, input)
+		case "qianfan":
+			info, err = checkQianfan(ctx, input)
+		case "dashscope":
+			info, err = checkDashScope(ctx, input)
 		default:
 			err = fmt.Errorf("not support api type %s", *input.APIType)
 		}
@@ -369,3 +380,78 @@ func checkZhipuAI(ctx context.Context, input generated.CreateModelServiceInput)
 	}
 	return res.String(), nil
 }
+
+func checkAnthropic(ctx context.Context, input generated.CreateModelServiceInput) (string, error) {
+	apiKey := input.Endpoint.Auth["apiKey"].(string)
+	client := anthropic.NewAnthropic(apiKey, input.Endpoint.URL)
+	var options []llms.CallOption
+	if len(input.LlmModels) > 0 {
+		options = append(options, llms.WithModel(input.LlmModels[0]))
+	}
+	res, err := client.Validate(ctx, options...)
+	if err != nil {
+		return "", err
+	}
+	return res.String(), nil
+}
+
+// checkOpenAICompatible checks azure openai and moonshot which provide openai compatible apis
+func checkOpenAICompatible(ctx context.Context, input generated.CreateModelServiceInput) (string, error) {
+	apiKey := input.Endpoint.Auth["apiKey"].(string)
+	var client *openai.OpenAI
+	var err error
+	var model string
+	if *input.APIType == "azureopenai" {
+		client, err = openai.NewAzureOpenAI(apiKey, input.Endpoint.URL)
+	} else {
+		client, err = openai.NewMoonshot(apiKey, input.Endpoint.URL)
+		model = "moonshot-v1-8k"
+	}
+	if err != nil {
+		return "", err
+	}
+	// use the first model(the deployment name for azure) if specified by user
+	if len(input.LlmModels) > 0 {
+		model = input.LlmModels[0]
+	}
+	if model == "" {
+		return "", errors.New("llm models are required")
+	}
+	res, err := client.Validate(ctx, llms.WithModel(model))
+	if err != nil {
+		return "", err
+	}
+	return res.String(), nil
+}
+
+func checkQianfan(ctx context.Context, input generated.CreateModelServiceInput) (string, error) {
+	apiKey := input.Endpoint.Auth["apiKey"].(string)
+	secretKey, _ := input.Endpoint.Auth["secretKey"].(string)
+	client, err := qianfan.NewQianfan(apiKey, secretKey, input.Endpoint.URL)
+	if err != nil {
+		return "", err
+	}
+	var options []llms.CallOption
+	if len(input.LlmModels) > 0 {
+		options = append(options, llms.WithModel(input.LlmModels[0]))
+	}
+	res, err := client.Validate(ctx, options...)
+	if err != nil {
+		return "", err
+	}
+	return res.String(), nil
+}
+
+func checkDashScope(ctx context.Context, input generated.CreateModelServiceInput) (string, error) {
+	apiKey := input.Endpoint.Auth["apiKey"].(string)
+	client := dashscope.NewDashScope(apiKey, false)
+	var options []llms.CallOption
+	if len(input.LlmModels) > 0 {
+		options = append(options, llms.WithModel(input.LlmModels[0]))
+	}
+	res, err := client.Validate(ctx, options...)
+	if err != nil {
+		return "", err
+	}
+	return res.String(), nil
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package service
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -35,6 +37,7 @@ import (
 	"github.com/kubeagi/arcadia/apiserver/config"
 	"github.com/kubeagi/arcadia/apiserver/pkg/auth"
 	"github.com/kubeagi/arcadia/apiserver/pkg/chat"
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat/storage"
 	"github.com/kubeagi/arcadia/apiserver/pkg/client"
 	"github.com/kubeagi/arcadia/apiserver/pkg/oidc"
 	"github.com/kubeagi/arcadia/apiserver/pkg/requestid"
@@ -57,7 +60,7 @@ func NewChatService(cli runtimeclient.Client, isGpts bool) (*ChatService, error)
 
 // @Summary	chat with application
 // @Schemes
-// @Description	chat with application
+// @Description	chat with application, set regenerate_message_id to regenerate an answer, or parent_message_id to continue from an earlier message in a new branch
 // @Tags			application
 // @Accept			json
 // @Produce		json
@@ -77,120 +80,188 @@ func (cs *ChatService) ChatHandler() gin.HandlerFunc {
 		}
 		req.AppNamespace = NamespaceInHeader(c)
 		req.Debug = c.Query("debug") == "true"
-		req.NewChat = len(req.ConversationID) == 0
-		if req.NewChat {
-			req.ConversationID = string(uuid.NewUUID())
+		if err := prepareChatReq(&req); err != nil {
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
 		}
 		messageID := string(uuid.NewUUID())
-		var response *chat.ChatRespBody
-		var err error
+		response := cs.respond(c, req.ResponseMode, req.ConversationID, messageID, req.StartTime, func(respStream chan string, timeout *float64) (*chat.ChatRespBody, error) {
+			return cs.server.AppRun(c.Request.Context(), req, respStream, messageID, timeout)
+		})
 		logger := klog.FromContext(c.Request.Context())
-		chatTimeoutSecond := pointer.Float64(WaitTimeoutForChatStreaming)
-
-		if req.ResponseMode.IsStreaming() {
-			buf := strings.Builder{}
-			// handle chat streaming mode
-			respStream := make(chan string, 1)
-			manualStop := make(chan bool)
-			go func() {
-				defer func() {
-					if e := recover(); e != nil {
-						err, ok := e.(error)
-						if ok {
-							logger.Error(err, "A panic occurred when run chat.AppRun")
-						} else {
-							logger.Error(fmt.Errorf("get err:%#v", e), "A panic occurred when run chat.AppRun")
-						}
-					}
-				}()
-				response, err = cs.server.AppRun(c.Request.Context(), req, respStream, messageID, chatTimeoutSecond)
-				if err != nil {
-					c.SSEvent("error", chat.ChatRespBody{
-						MessageID:      messageID,
-						ConversationID: req.ConversationID,
-						Message:        err.Error(),
-						CreatedAt:      time.Now(),
-						Latency:        time.Since(req.StartTime).Milliseconds(),
-					})
-					// c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
-					logger.Error(err, "error resp, stop the stream")
-					manualStop <- true
-					return
-				}
-				if response != nil {
-					if str := buf.String(); response.Message == str || strings.TrimSpace(str) == strings.TrimSpace(response.Message) {
-						logger.Info("blocking resp is same with streaming resp, no new message received, stop the stream")
-						manualStop <- true
+		switch {
+		case logger.V(5).Enabled():
+			logger.Info("chat done", "req", req, "resp", response)
+		case logger.V(3).Enabled():
+			logger.Info("chat done", "req", req)
+		default:
+			logger.Info("chat done")
+		}
+	}
+}
+
+// prepareChatReq validates the chat request and sets the conversation id of the new chat
+func prepareChatReq(req *chat.ChatReqBody) error {
+	req.NewChat = len(req.ConversationID) == 0
+	if req.Query == "" && req.RegenerateMessageID == "" {
+		return errors.New("query is required")
+	}
+	if req.NewChat && (req.RegenerateMessageID != "" || req.ParentMessageID != "") {
+		return errors.New("conversation_id is required to regenerate or fork messages")
+	}
+	if req.NewChat {
+		req.ConversationID = string(uuid.NewUUID())
+	}
+	return nil
+}
+
+// @Summary	approve or reject the tool call of agent
+// @Schemes
+// @Description	approve or reject the tool call waiting for approval, the paused chat is resumed with the decision
+// @Tags			application
+// @Accept			json
+// @Produce		json
+// @Param			namespace	header		string					true	"namespace this request is in"
+// @Param			messageID	path		string					true	"messageID"
+// @Param			request		body		chat.ApprovalReqBody	true	"query params"
+// @Success		200			{object}	chat.ChatRespBody		"blocking mode, will return all field; streaming mode, only conversation_id, message and created_at will be returned"
+// @Failure		400			{object}	chat.ErrorResp
+// @Failure		500			{object}	chat.ErrorResp
+// @Router			/chat/messages/{messageID}/approval [post]
+func (cs *ChatService) ApprovalHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.ApprovalReqBody{StartTime: time.Now()}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		req.MessageID = c.Param("messageID")
+		req.AppNamespace = NamespaceInHeader(c)
+		if req.ConversationID == "" {
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: "conversation_id is required"})
+			return
+		}
+		response := cs.respond(c, req.ResponseMode, req.ConversationID, req.MessageID, req.StartTime, func(respStream chan string, timeout *float64) (*chat.ChatRespBody, error) {
+			return cs.server.ResumeAppRun(c.Request.Context(), req, respStream, timeout)
+		})
+		klog.FromContext(c.Request.Context()).V(3).Info("chat resumed", "req", req, "resp", response)
+	}
+}
+
+// respond runs the chat and writes the response in the response mode, the response is nil if the chat fails.
+// In streaming mode, the tool call waiting for approval is sent in an "approval" event.
+func (cs *ChatService) respond(c *gin.Context, mode chat.ResponseMode, conversationID, messageID string, startTime time.Time, run func(respStream chan string, timeout *float64) (*chat.ChatRespBody, error)) *chat.ChatRespBody {
+	var response *chat.ChatRespBody
+	var err error
+	logger := klog.FromContext(c.Request.Context())
+	chatTimeoutSecond := pointer.Float64(WaitTimeoutForChatStreaming)
+
+	if mode.IsStreaming() {
+		buf := strings.Builder{}
+		// handle chat streaming mode
+		respStream := make(chan string, 1)
+		manualStop := make(chan bool)
+		go func() {
+			defer func() {
+				if e := recover(); e != nil {
+					err, ok := e.(error)
+					if ok {
+						logger.Error(err, "A panic occurred when run chat.AppRun")
+					} else {
+						logger.Error(fmt.Errorf("get err:%#v", e), "A panic occurred when run chat.AppRun")
 					}
 				}
 			}()
+			response, err = run(respStream, chatTimeoutSecond)
+			if err != nil {
+				c.SSEvent("error", chat.ChatRespBody{
+					MessageID:      messageID,
+					ConversationID: conversationID,
+					Message:        err.Error(),
+					CreatedAt:      time.Now(),
+					Latency:        time.Since(startTime).Milliseconds(),
+				})
+				// c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+				logger.Error(err, "error resp, stop the stream")
+				manualStop <- true
+				return
+			}
+			if response != nil && response.PendingApproval != nil {
+				c.SSEvent("approval", response)
+				logger.Info("agent is waiting for approval, stop the stream")
+				manualStop <- true
+				return
+			}
+			if response != nil {
+				if str := buf.String(); response.Message == str || strings.TrimSpace(str) == strings.TrimSpace(response.Message) {
+					logger.Info("blocking resp is same with streaming resp, no new message received, stop the stream")
+					manualStop <- true
+				}
+			}
+		}()
 
-			LatestTimestampGetDataFromLLM := time.Now()
-			// Use ticker to check if there is no data from llm for a long time and close the entire stream
-			ticker := time.NewTicker(time.Microsecond * 500)
-			defer ticker.Stop()
-			go func() {
-				for range ticker.C {
-					timeout := time.Second * time.Duration(*chatTimeoutSecond)
-					if time.Since(LatestTimestampGetDataFromLLM) > timeout {
-						logger.Info("no data from LLM for a long time, stop the stream", "timeout", timeout)
-						manualStop <- true
-						return
-					}
+		LatestTimestampGetDataFromLLM := time.Now()
+		// Use ticker to check if there is no data from llm for a long time and close the entire stream
+		ticker := time.NewTicker(time.Microsecond * 500)
+		defer ticker.Stop()
+		go func() {
+			for range ticker.C {
+				timeout := time.Second * time.Duration(*chatTimeoutSecond)
+				if time.Since(LatestTimestampGetDataFromLLM) > timeout {
+					logger.Info("no data from LLM for a long time, stop the stream", "timeout", timeout)
+					manualStop <- true
+					return
 				}
-			}()
-			c.Writer.Header().Set("Content-Type", "text/event-stream")
-			c.Writer.Header().Set("Cache-Control", "no-cache")
-			c.Writer.Header().Set("Connection", "keep-alive")
-			c.Writer.Header().Set("Transfer-Encoding", "chunked")
-			logger.Info("start to receive messages...")
-			clientDisconnected := c.Stream(func(w io.Writer) bool {
-				for {
-					select {
-					case <-manualStop:
+			}
+		}()
+		c.Writer.Header().Set("Content-Type", "text/event-stream")
+		c.Writer.Header().Set("Cache-Control", "no-cache")
+		c.Writer.Header().Set("Connection", "keep-alive")
+		c.Writer.Header().Set("Transfer-Encoding", "chunked")
+		logger.Info("start to receive messages...")
+		clientDisconnected := c.Stream(func(w io.Writer) bool {
+			for {
+				select {
+				case <-manualStop:
+					return false
+				case msg, ok := <-respStream:
+					if !ok {
 						return false
-					case msg, ok := <-respStream:
-						if !ok {
-							return false
-						}
-						t := time.Now()
-						c.SSEvent("", chat.ChatRespBody{
-							MessageID:      messageID,
-							ConversationID: req.ConversationID,
-							Message:        msg,
-							CreatedAt:      t,
-							Latency:        time.Since(req.StartTime).Milliseconds(),
-						})
-						LatestTimestampGetDataFromLLM = t
-						buf.WriteString(msg)
-						return true
 					}
+					t := time.Now()
+					c.SSEvent("", chat.ChatRespBody{
+						MessageID:      messageID,
+						ConversationID: conversationID,
+						Message:        msg,
+						CreatedAt:      t,
+						Latency:        time.Since(startTime).Milliseconds(),
+					})
+					LatestTimestampGetDataFromLLM = t
+					buf.WriteString(msg)
+					return true
 				}
-			})
-			if clientDisconnected {
-				manualStop <- true
-				logger.Info("chatHandler: the client is disconnected")
-			}
-			logger.Info("end to receive messages")
-		} else {
-			// handle chat blocking mode
-			response, err = cs.server.AppRun(c.Request.Context(), req, nil, messageID, chatTimeoutSecond)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
-				logger.Error(err, "error resp")
-				return
 			}
-			c.JSON(http.StatusOK, response)
+		})
+		if clientDisconnected {
+			manualStop <- true
+			logger.Info("chatHandler: the client is disconnected")
 		}
-		switch {
-		case logger.V(5).Enabled():
-			logger.Info("chat done", "req", req, "resp", response)
-		case logger.V(3).Enabled():
-			logger.Info("chat done", "req", req)
-		default:
-			logger.Info("chat done")
+		logger.Info("end to receive messages")
+	} else {
+		// handle chat blocking mode
+		response, err = run(nil, chatTimeoutSecond)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, chat.ErrPIIBlocked) {
+				status = http.StatusForbidden
+			}
+			c.JSON(status, chat.ErrorResp{Err: err.Error()})
+			logger.Error(err, "error resp")
+			return nil
 		}
+		c.JSON(http.StatusOK, response)
 	}
+	return response
 }
 
 // @Summary	receive conversational files for one conversation
@@ -320,9 +391,273 @@ func (cs *ChatService) DeleteConversationHandler() gin.HandlerFunc {
 	}
 }
 
+// @Summary	export one conversation
+// @Schemes
+// @Description	export the messages, references, uploaded document names and timestamps of one conversation as markdown or pdf for reading, or as json which can be imported again
+// @Tags			application
+// @Produce		json
+// @Produce		text/markdown
+// @Produce		application/pdf
+// @Param			namespace		header		string	true	"namespace this request is in"
+// @Param			conversationID	path		string	true	"conversationID"
+// @Param			app_name		query		string	true	"app name"
+// @Param			format			query		string	false	"json, markdown or pdf, json by default"
+// @Success		200				{object}	chat.ConversationExport
+// @Failure		400				{object}	chat.ErrorResp
+// @Failure		500				{object}	chat.ErrorResp
+// @Router			/chat/conversations/{conversationID}/export [get]
+func (cs *ChatService) ExportConversationHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.ConversationExportReqBody{}
+		if err := c.ShouldBindQuery(&req); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "exportConversationHandler: error binding query")
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		req.ConversationID = c.Param("conversationID")
+		req.AppNamespace = NamespaceInHeader(c)
+		buf := &bytes.Buffer{}
+		if err := cs.server.ExportConversation(c.Request.Context(), req, buf); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error export conversation")
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("export conversation done", "req", req)
+		contentType, ext := "application/json", "json"
+		switch req.Format {
+		case chat.ExportFormatMarkdown:
+			contentType, ext = "text/markdown; charset=utf-8", "md"
+		case chat.ExportFormatPDF:
+			contentType, ext = "application/pdf", "pdf"
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=conversation-%s.%s", req.ConversationID, ext))
+		c.Data(http.StatusOK, contentType, buf.Bytes())
+	}
+}
+
+// @Summary	import one conversation
+// @Schemes
+// @Description	recreate a conversation exported as json in the app for current user, new ids are generated for the conversation and messages
+// @Tags			application
+// @Accept			json
+// @Produce		json
+// @Param			namespace	header		string							true	"namespace this request is in"
+// @Param			request		body		chat.ConversationImportReqBody	true	"query params"
+// @Success		200			{object}	storage.Conversation
+// @Failure		400			{object}	chat.ErrorResp
+// @Failure		500			{object}	chat.ErrorResp
+// @Router			/chat/conversations/import [post]
+func (cs *ChatService) ImportConversationHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.ConversationImportReqBody{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "importConversationHandler: error binding json")
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		req.AppNamespace = NamespaceInHeader(c)
+		resp, err := cs.server.ImportConversation(c.Request.Context(), req)
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error import conversation")
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("import conversation done", "app", req.APPName, "conversationID", resp.ID)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Summary	share one conversation
+// @Schemes
+// @Description	snapshot the messages of one conversation, or a range of them, and sign a read-only link which expires later, the link can be read without permission on the app
+// @Tags			application
+// @Accept			json
+// @Produce		json
+// @Param			namespace	header		string				true	"namespace this request is in"
+// @Param			request		body		chat.ShareReqBody	true	"query params"
+// @Success		200			{object}	chat.ShareRespBody
+// @Failure		400			{object}	chat.ErrorResp
+// @Failure		404			{object}	chat.ErrorResp
+// @Failure		500			{object}	chat.ErrorResp
+// @Router			/chat/shares [post]
+func (cs *ChatService) CreateShareHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.ShareReqBody{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "createShareHandler: error binding json")
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		if req.ConversationID == "" {
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: "conversation_id is required"})
+			return
+		}
+		req.AppNamespace = NamespaceInHeader(c)
+		req.Requester = requester(c)
+		resp, err := cs.server.CreateShare(c.Request.Context(), req)
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error create share")
+			if errors.Is(err, storage.ErrMessageNotFound) {
+				c.JSON(http.StatusNotFound, chat.ErrorResp{Err: err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("create share done", "conversationID", req.ConversationID, "shareID", resp.ID)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Summary	list the shares
+// @Schemes
+// @Description	list the shares of current user in the app, without the messages
+// @Tags			application
+// @Produce		json
+// @Param			namespace		header		string	true	"namespace this request is in"
+// @Param			app_name		query		string	true	"app name"
+// @Param			conversation_id	query		string	false	"only list the shares of the conversation"
+// @Success		200				{object}	[]storage.Share
+// @Failure		400				{object}	chat.ErrorResp
+// @Failure		500				{object}	chat.ErrorResp
+// @Router			/chat/shares [get]
+func (cs *ChatService) ListSharesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.ConversationReqBody{}
+		if err := c.ShouldBindQuery(&req); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "listSharesHandler: error binding query")
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		req.AppNamespace = NamespaceInHeader(c)
+		resp, err := cs.server.ListShares(c.Request.Context(), req)
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error list shares")
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("list shares done", "req", req)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Summary	revoke one share
+// @Schemes
+// @Description	revoke one share of current user, the link can not be read since then
+// @Tags			application
+// @Produce		json
+// @Param			shareID	path		string	true	"shareID"
+// @Success		200		{object}	chat.SimpleResp
+// @Failure		404		{object}	chat.ErrorResp
+// @Failure		500		{object}	chat.ErrorResp
+// @Router			/chat/shares/{shareID} [delete]
+func (cs *ChatService) RevokeShareHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shareID := c.Param("shareID")
+		if err := cs.server.RevokeShare(c.Request.Context(), shareID, requester(c)); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error revoke share")
+			if errors.Is(err, storage.ErrShareNotFound) {
+				c.JSON(http.StatusNotFound, chat.ErrorResp{Err: err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("revoke share done", "shareID", shareID)
+		c.JSON(http.StatusOK, chat.SimpleResp{Message: "ok"})
+	}
+}
+
+// @Summary	list the audits of one share
+// @Schemes
+// @Description	list who creates, views and revokes one share of current user, the earliest first
+// @Tags			application
+// @Produce		json
+// @Param			shareID	path		string	true	"shareID"
+// @Success		200		{object}	[]storage.ShareAudit
+// @Failure		404		{object}	chat.ErrorResp
+// @Failure		500		{object}	chat.ErrorResp
+// @Router			/chat/shares/{shareID}/audits [get]
+func (cs *ChatService) ShareAuditsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shareID := c.Param("shareID")
+		resp, err := cs.server.ListShareAudits(c.Request.Context(), shareID)
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error list share audits")
+			if errors.Is(err, storage.ErrShareNotFound) {
+				c.JSON(http.StatusNotFound, chat.ErrorResp{Err: err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("list share audits done", "shareID", shareID)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Summary	read one shared conversation
+// @Schemes
+// @Description	read the shared messages by the signed link, no login or permission on the app is needed
+// @Tags			application
+// @Produce		json
+// @Param			token	path		string	true	"token of the share"
+// @Success		200		{object}	storage.Share
+// @Failure		403		{object}	chat.ErrorResp
+// @Failure		404		{object}	chat.ErrorResp
+// @Failure		410		{object}	chat.ErrorResp
+// @Failure		500		{object}	chat.ErrorResp
+// @Router			/chat/shared/{token} [get]
+func (cs *ChatService) SharedConversationHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := cs.server.GetSharedConversation(c.Request.Context(), c.Param("token"), requester(c))
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error get shared conversation")
+			switch {
+			case errors.Is(err, chat.ErrInvalidShareToken):
+				c.JSON(http.StatusForbidden, chat.ErrorResp{Err: err.Error()})
+			case errors.Is(err, storage.ErrShareNotFound):
+				c.JSON(http.StatusNotFound, chat.ErrorResp{Err: err.Error()})
+			case errors.Is(err, chat.ErrShareExpired), errors.Is(err, chat.ErrShareRevoked):
+				c.JSON(http.StatusGone, chat.ErrorResp{Err: err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			}
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("get shared conversation done", "shareID", resp.ID)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func requester(c *gin.Context) chat.Requester {
+	return chat.Requester{ClientIP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// @Summary	delete all data of current user
+// @Schemes
+// @Description	delete all conversations of current user permanently, with the messages, feedbacks, shares, uploaded files and conversation knowledgebases, it can not be undone
+// @Tags			application
+// @Produce		json
+// @Success		200	{object}	chat.DeleteUserDataRespBody
+// @Failure		500	{object}	chat.ErrorResp
+// @Router			/chat/user-data [delete]
+func (cs *ChatService) DeleteUserDataHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n, err := cs.server.DeleteUserData(c.Request.Context())
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error delete user data", "conversations", n)
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("delete user data done", "conversations", n)
+		c.JSON(http.StatusOK, chat.DeleteUserDataRespBody{Conversations: n})
+	}
+}
+
 // @Summary	get all messages history for one conversation
 // @Schemes
-// @Description	get all messages history for one conversation
+// @Description	get all messages history for one conversation, the messages form a tree by their parent_id and children, current_message_id is the last message of the selected branch
 // @Tags			application
 // @Accept			json
 // @Produce		json
@@ -394,6 +729,144 @@ func (cs *ChatService) ReferenceHandler() gin.HandlerFunc {
 	}
 }
 
+// @Summary	give feedback to one message
+// @Schemes
+// @Description	like or dislike the answer of one message, with comment and corrected answer, the feedback given before is replaced
+// @Tags			application
+// @Accept			json
+// @Produce		json
+// @Param			namespace	header		string					true	"namespace this request is in"
+// @Param			messageID	path		string					true	"messageID"
+// @Param			request		body		chat.FeedbackReqBody	true	"query params"
+// @Success		200			{object}	storage.Feedback
+// @Failure		400			{object}	chat.ErrorResp
+// @Failure		404			{object}	chat.ErrorResp
+// @Failure		500			{object}	chat.ErrorResp
+// @Router			/chat/messages/{messageID}/feedback [post]
+func (cs *ChatService) FeedbackHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.FeedbackReqBody{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "feedbackHandler: error binding json")
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		req.MessageID = c.Param("messageID")
+		req.AppNamespace = NamespaceInHeader(c)
+		resp, err := cs.server.SaveFeedback(c.Request.Context(), req)
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error save feedback")
+			if errors.Is(err, storage.ErrMessageNotFound) {
+				c.JSON(http.StatusNotFound, chat.ErrorResp{Err: err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("save feedback done", "req", req)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Summary	export the feedbacks of app as dataset
+// @Schemes
+// @Description	export the questions and the liked or corrected answers as a csv with columns q and a, which can be uploaded to a versioned dataset as the test data of rag evaluation
+// @Tags			application
+// @Produce		text/csv
+// @Param			namespace	header		string	true	"namespace this request is in"
+// @Param			app_name	query		string	true	"app name"
+// @Param			since		query		string	false	"the feedbacks given since the time, in RFC3339"
+// @Param			until		query		string	false	"the feedbacks given before the time, in RFC3339"
+// @Success		200			{string}	string	"csv file"
+// @Failure		400			{object}	chat.ErrorResp
+// @Failure		500			{object}	chat.ErrorResp
+// @Router			/chat/feedbacks/export [get]
+func (cs *ChatService) ExportFeedbackHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.FeedbackExportReqBody{}
+		if err := c.ShouldBindQuery(&req); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "exportFeedbackHandler: error binding query")
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		req.AppNamespace = NamespaceInHeader(c)
+		buf := &bytes.Buffer{}
+		if err := cs.server.ExportFeedbackDataset(c.Request.Context(), req, buf); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error export feedbacks")
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("export feedbacks done", "req", req)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-feedback.csv", req.APPName))
+		c.Data(http.StatusOK, "text/csv", buf.Bytes())
+	}
+}
+
+// @Summary	list the pii detections of app
+// @Schemes
+// @Description	list the personally identifiable information detected in the chats of app, only the types and the count are recorded
+// @Tags			application
+// @Produce		json
+// @Param			namespace	header		string	true	"namespace this request is in"
+// @Param			app_name	query		string	true	"app name"
+// @Param			since		query		string	false	"detected since the time, in RFC3339"
+// @Param			until		query		string	false	"detected before the time, in RFC3339"
+// @Success		200			{object}	[]storage.PIIAudit
+// @Failure		400			{object}	chat.ErrorResp
+// @Failure		500			{object}	chat.ErrorResp
+// @Router			/chat/pii-audits [get]
+func (cs *ChatService) PIIAuditsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.PIIAuditReqBody{}
+		if err := c.ShouldBindQuery(&req); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "piiAuditsHandler: error binding query")
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		req.AppNamespace = NamespaceInHeader(c)
+		resp, err := cs.server.ListPIIAudits(c.Request.Context(), req)
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error list pii audits")
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("list pii audits done", "req", req)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// @Summary	search the conversation history
+// @Schemes
+// @Description	search the queries and answers in the conversations of current user in the namespace, the keyword in the results is wrapped in <em> tags
+// @Tags			application
+// @Accept			json
+// @Produce		json
+// @Param			namespace	header		string				true	"namespace this request is in"
+// @Param			request		body		chat.SearchReqBody	true	"query params"
+// @Success		200			{object}	chat.SearchRespBody
+// @Failure		400			{object}	chat.ErrorResp
+// @Failure		500			{object}	chat.ErrorResp
+// @Router			/chat/messages/search [post]
+func (cs *ChatService) SearchHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := chat.SearchReqBody{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "searchHandler: error binding json")
+			c.JSON(http.StatusBadRequest, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		req.AppNamespace = NamespaceInHeader(c)
+		resp, err := cs.server.SearchMessages(c.Request.Context(), req)
+		if err != nil {
+			klog.FromContext(c.Request.Context()).Error(err, "error search messages")
+			c.JSON(http.StatusInternalServerError, chat.ErrorResp{Err: err.Error()})
+			return
+		}
+		klog.FromContext(c.Request.Context()).V(3).Info("search messages done", "req", req, "total", resp.Total)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
 // @Summary	get app's prompt starters
 // @Schemes
 // @Description	get app's prompt starters
@@ -454,15 +927,35 @@ func registerChat(g *gin.RouterGroup, conf config.ServerConfig) {
 	if err != nil {
 		panic(err)
 	}
+	// the gpts chat shares the same storage, so only the purger of admin chat is started
+	if conf.ChatPurgeInterval > 0 {
+		go chatService.server.RunPurger(context.Background(), conf.ChatPurgeInterval)
+	}
 
-	g.POST("", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ChatHandler()) // chat with bot
+	g.POST("", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ChatHandler())                                // chat with bot
+	g.GET("/ws", websocketHeaders(), auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ChatWebSocketHandler()) // chat with bot over websocket
 
-	g.POST("/conversations/file", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ChatFile())                               // upload fles for conversation
-	g.POST("/conversations", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ListConversationHandler())                     // list conversations
-	g.DELETE("/conversations/:conversationID", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.DeleteConversationHandler()) // delete conversation
+	g.POST("/conversations/file", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ChatFile())                                   // upload fles for conversation
+	g.POST("/conversations", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ListConversationHandler())                         // list conversations
+	g.DELETE("/conversations/:conversationID", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.DeleteConversationHandler())     // delete conversation
+	g.DELETE("/user-data", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.DeleteUserDataHandler())                             // delete all data of current user
+	g.GET("/conversations/:conversationID/export", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ExportConversationHandler()) // export conversation
+	g.POST("/conversations/import", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ImportConversationHandler())                // import conversation
 
 	g.POST("/messages", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.HistoryHandler())                         // messages history
+	g.POST("/messages/search", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.SearchHandler())                   // search conversation history
 	g.POST("/messages/:messageID/references", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ReferenceHandler()) // messages reference
+	g.POST("/messages/:messageID/approval", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ApprovalHandler())    // approve tool call of agent
+	g.POST("/messages/:messageID/feedback", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.FeedbackHandler())    // like or dislike the answer
+	g.GET("/feedbacks/export", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "update", "applications"), requestid.RequestIDInterceptor(), chatService.ExportFeedbackHandler())        // export feedbacks as dataset
+	g.GET("/pii-audits", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "update", "applications"), requestid.RequestIDInterceptor(), chatService.PIIAuditsHandler())                   // pii detected in chats
+
+	g.POST("/shares", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.CreateShareHandler())                // share conversation
+	g.GET("/shares", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ListSharesHandler())                  // list shares
+	g.DELETE("/shares/:shareID", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.RevokeShareHandler())     // revoke share
+	g.GET("/shares/:shareID/audits", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.ShareAuditsHandler()) // audits of share
+	// the shared conversations are read by the signed links without login
+	g.GET("/shared/:token", requestid.RequestIDInterceptor(), chatService.SharedConversationHandler())
 
 	g.POST("/prompt-starter", auth.AuthInterceptor(conf.EnableOIDC, oidc.Verifier, v1alpha1.GroupVersion, "get", "applications"), requestid.RequestIDInterceptor(), chatService.PromptStartersHandler())
 }
@@ -0,0 +1,242 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeagi/arcadia/apiserver/pkg/chat"
+)
+
+const (
+	// chatWebSocketPingInterval keeps the idle connection alive through the proxies
+	chatWebSocketPingInterval = 30 * time.Second
+	chatWebSocketWriteTimeout = 10 * time.Second
+	chatWebSocketReadLimit    = 1 << 20
+)
+
+var chatUpgrader = websocket.Upgrader{
+	// any origin is allowed like the cors of apiserver, the requests are authorized by token instead of cookie
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// websocketHeaders copies the token and the namespace in query to the headers, since browsers can't set the headers of websocket requests
+func websocketHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		if namespace := c.Query(namespaceHeader); namespace != "" && c.GetHeader(namespaceHeader) == "" {
+			c.Request.Header.Set(namespaceHeader, namespace)
+		}
+		c.Next()
+	}
+}
+
+// @Summary	chat with application over websocket
+// @Schemes
+// @Description	chat with application over websocket, the client sends chat.ChatCommand and the server sends chat.ChatEvent in json.
+// @Description	* start: start to answer the chat request, the start event has the message id of the answer
+// @Description	* cancel: stop generating the answer of message_id
+// @Description	* attach: follow the answer of message_id again after reconnecting, the events after after_seq are sent. The saved answer is sent if it is finished
+// @Description	* approve: approve or reject the tool call waiting for approval, and follow the resumed answer
+// @Description	The answer goes on after the connection is closed. Each answer ends with a done, approval or error event.
+// @Tags			application
+// @Param			namespace	header	string	false	"namespace this request is in, or use the namespace query"
+// @Param			namespace	query	string	false	"namespace this request is in, for the browsers which can't set headers"
+// @Param			token		query	string	false	"bearer token, for the browsers which can't set the Authorization header"
+// @Param			debug		query	bool	false	"Should the chat request be treated as debugging?"
+// @Success		101
+// @Failure		400			{object}	chat.ErrorResp
+// @Router			/chat/ws [get]
+func (cs *ChatService) ChatWebSocketHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := klog.FromContext(c.Request.Context())
+		conn, err := chatUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// the upgrader has replied the error
+			logger.Error(err, "failed to upgrade to websocket")
+			return
+		}
+		s := &chatSession{server: cs.server, conn: conn, namespace: NamespaceInHeader(c), debug: c.Query("debug") == "true"}
+		logger.Info("websocket chat connected")
+		s.serve(c.Request.Context())
+		logger.Info("websocket chat disconnected")
+	}
+}
+
+// chatSession handles the commands of a websocket connection, the events of the answers it follows are sent to the connection
+type chatSession struct {
+	server    *chat.ChatServer
+	conn      *websocket.Conn
+	namespace string
+	debug     bool
+	// writeMu serializes the writes, websocket connection supports only one concurrent writer
+	writeMu sync.Mutex
+}
+
+func (s *chatSession) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer s.conn.Close()
+	s.conn.SetReadLimit(chatWebSocketReadLimit)
+	go s.ping(ctx)
+	logger := klog.FromContext(ctx)
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.V(3).Info("failed to read websocket message", "err", err)
+			}
+			return
+		}
+		var cmd chat.ChatCommand
+		if err = json.Unmarshal(data, &cmd); err == nil {
+			err = s.handle(ctx, cmd)
+		}
+		if err != nil {
+			logger.V(3).Info("invalid websocket chat command", "type", cmd.Type, "err", err)
+			_ = s.send(chat.ChatEvent{Type: chat.ChatEventError, ConversationID: cmd.ConversationID, MessageID: cmd.MessageID, Message: err.Error(), CreatedAt: time.Now()})
+		}
+	}
+}
+
+func (s *chatSession) handle(ctx context.Context, cmd chat.ChatCommand) error {
+	switch cmd.Type {
+	case chat.ChatCommandStart:
+		if cmd.Chat == nil {
+			return errors.New("chat is required")
+		}
+		req := *cmd.Chat
+		req.StartTime = time.Now()
+		req.AppNamespace = s.namespace
+		req.Debug = s.debug
+		if err := prepareChatReq(&req); err != nil {
+			return err
+		}
+		r, err := s.server.StartAppRun(ctx, req, string(uuid.NewUUID()))
+		if err != nil {
+			return err
+		}
+		go s.follow(ctx, r, 0)
+	case chat.ChatCommandApprove:
+		if cmd.Approval == nil {
+			return errors.New("approval is required")
+		}
+		req := *cmd.Approval
+		req.StartTime = time.Now()
+		req.AppNamespace = s.namespace
+		if req.ConversationID == "" || req.MessageID == "" {
+			return errors.New("conversation_id and message_id are required")
+		}
+		r, err := s.server.StartResumeAppRun(ctx, req)
+		if err != nil {
+			return err
+		}
+		go s.follow(ctx, r, 0)
+	case chat.ChatCommandCancel:
+		r := s.server.GetLiveRun(ctx, s.namespace, cmd.MessageID)
+		if r == nil {
+			return errors.New("the answer is not being generated")
+		}
+		r.Cancel()
+	case chat.ChatCommandAttach:
+		if r := s.server.GetLiveRun(ctx, s.namespace, cmd.MessageID); r != nil {
+			go s.follow(ctx, r, cmd.AfterSeq)
+			return nil
+		}
+		if cmd.ConversationID == "" || cmd.MessageID == "" {
+			return errors.New("conversation_id and message_id are required")
+		}
+		event, err := s.server.SavedAnswer(ctx, s.namespace, cmd.ConversationID, cmd.MessageID)
+		if err != nil {
+			return err
+		}
+		return s.send(event)
+	default:
+		return fmt.Errorf("unknown command type %q", cmd.Type)
+	}
+	return nil
+}
+
+// follow sends the events of the answer after the seq, until the final event is sent or the connection is closed
+func (s *chatSession) follow(ctx context.Context, r *chat.LiveRun, afterSeq int) {
+	for {
+		past, ch := r.Subscribe(afterSeq)
+		stop := func() bool {
+			defer r.Unsubscribe(ch)
+			for _, e := range past {
+				if s.send(e) != nil || e.IsFinal() {
+					return true
+				}
+				afterSeq = e.Seq
+			}
+			for {
+				select {
+				case e, ok := <-ch:
+					if !ok {
+						// this subscriber is too slow, subscribe again for the rest events
+						return false
+					}
+					if s.send(e) != nil || e.IsFinal() {
+						return true
+					}
+					afterSeq = e.Seq
+				case <-ctx.Done():
+					return true
+				}
+			}
+		}()
+		if stop {
+			return
+		}
+	}
+}
+
+func (s *chatSession) send(event chat.ChatEvent) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(chatWebSocketWriteTimeout))
+	return s.conn.WriteJSON(event)
+}
+
+func (s *chatSession) ping(ctx context.Context) {
+	ticker := time.NewTicker(chatWebSocketPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// WriteControl can be called concurrently with the other writes
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(chatWebSocketWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -38,6 +39,7 @@ import (
 	agentv1alpha1 "github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
 	chainv1alpha1 "github.com/kubeagi/arcadia/api/app-node/chain/v1alpha1"
 	documentloaderv1alpha1 "github.com/kubeagi/arcadia/api/app-node/documentloader/v1alpha1"
+	guardrailv1alpha1 "github.com/kubeagi/arcadia/api/app-node/guardrail/v1alpha1"
 	promptv1alpha1 "github.com/kubeagi/arcadia/api/app-node/prompt/v1alpha1"
 	retrieveralpha1 "github.com/kubeagi/arcadia/api/app-node/retriever/v1alpha1"
 	arcadiav1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
@@ -57,6 +59,8 @@ const (
 	MultiQueryRetrieverIndexKey    = "metadata.multiqueryretriever"
 	AgentIndexKey                  = "metadata.agent"
 	DocumentLoaderIndexKey         = "metadata.documentloader"
+	InputGuardrailIndexKey         = "metadata.inputguardrail"
+	OutputGuardrailIndexKey        = "metadata.outputguardrail"
 )
 
 // ApplicationReconciler reconciles an Application object
@@ -86,6 +90,8 @@ type ApplicationReconciler struct {
 //+kubebuilder:rbac:groups=prompt.arcadia.kubeagi.k8s.com.cn,resources=prompts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=prompt.arcadia.kubeagi.k8s.com.cn,resources=prompts/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=prompt.arcadia.kubeagi.k8s.com.cn,resources=prompts/finalizers,verbs=update
+//+kubebuilder:rbac:groups=guardrail.arcadia.kubeagi.k8s.com.cn,resources=inputguardrails,verbs=get;list;watch
+//+kubebuilder:rbac:groups=guardrail.arcadia.kubeagi.k8s.com.cn,resources=outputguardrails,verbs=get;list;watch
 //+kubebuilder:rbac:groups=retriever.arcadia.kubeagi.k8s.com.cn,resources=knowledgebaseretrievers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=retriever.arcadia.kubeagi.k8s.com.cn,resources=knowledgebaseretrievers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=retriever.arcadia.kubeagi.k8s.com.cn,resources=knowledgebaseretrievers/finalizers,verbs=update
@@ -177,7 +183,7 @@ func MigrateAppCategory(app *arcadiav1alpha1.Application) bool {
 // 2. output node must not have next node
 // 3. input node must only have one
 // 4. input node must only have one
-// 5. only one node connected to output, and this node type should be chain or agent
+// 5. only one node connected to output, and this node type should be chain, agent or output guardrail
 // 6. when this node points to output, it can only point to output
 // 7. should not have cycle TODO
 // 8. nodeName should be unique
@@ -229,9 +235,10 @@ func (r *ApplicationReconciler) validateNodes(ctx context.Context, log logr.Logg
 					r.setCondition(app, app.Status.ErrorCondition("node should have ref.group setting")...)
 					return app, ctrl.Result{RequeueAfter: waitMedium}, nil
 				}
-				// Only allow chain group or agent node as the ending node
-				if *group != chainv1alpha1.Group && (*group != agentv1alpha1.Group && node.Ref.Kind != "agent") {
-					r.setCondition(app, app.Status.ErrorCondition("ending node should be a chain or agent")...)
+				// Only allow chain group, agent or output guardrail node as the ending node
+				isOutputGuardrail := *group == guardrailv1alpha1.Group && strings.EqualFold(node.Ref.Kind, "OutputGuardrail")
+				if *group != chainv1alpha1.Group && !isOutputGuardrail && (*group != agentv1alpha1.Group && node.Ref.Kind != "agent") {
+					r.setCondition(app, app.Status.ErrorCondition("ending node should be a chain, agent or output guardrail")...)
 					return app, ctrl.Result{RequeueAfter: waitMedium}, nil
 				}
 			}
@@ -370,6 +377,8 @@ func (r *ApplicationReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 		{MultiQueryRetrieverIndexKey, "retriever", "multiqueryretriever"},
 		{AgentIndexKey, "", "agent"},
 		{DocumentLoaderIndexKey, "", "documentloader"},
+		{InputGuardrailIndexKey, "guardrail", "inputguardrail"},
+		{OutputGuardrailIndexKey, "guardrail", "outputguardrail"},
 	}
 	for _, d := range dependencies {
 		d := d
@@ -426,6 +435,8 @@ func (r *ApplicationReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 		Watches(&source.Kind{Type: &retrieveralpha1.MultiQueryRetriever{}}, getEventHandler(MultiQueryRetrieverIndexKey)).
 		Watches(&source.Kind{Type: &agentv1alpha1.Agent{}}, getEventHandler(AgentIndexKey)).
 		Watches(&source.Kind{Type: &documentloaderv1alpha1.DocumentLoader{}}, getEventHandler(DocumentLoaderIndexKey)).
+		Watches(&source.Kind{Type: &guardrailv1alpha1.InputGuardrail{}}, getEventHandler(InputGuardrailIndexKey)).
+		Watches(&source.Kind{Type: &guardrailv1alpha1.OutputGuardrail{}}, getEventHandler(OutputGuardrailIndexKey)).
 		Complete(r)
 }
 
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/go-logr/logr"
 	langchainembeddings "github.com/tmc/langchaingo/embeddings"
@@ -41,12 +42,21 @@ import (
 
 	arcadiav1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
 	"github.com/kubeagi/arcadia/pkg/embeddings"
+	embeddingsdashscope "github.com/kubeagi/arcadia/pkg/embeddings/dashscope"
 	embeddingszhipuai "github.com/kubeagi/arcadia/pkg/embeddings/zhipuai"
+	"github.com/kubeagi/arcadia/pkg/langchainwrap"
+	llmsopenai "github.com/kubeagi/arcadia/pkg/llms/openai"
+	"github.com/kubeagi/arcadia/pkg/llms/qianfan"
 	"github.com/kubeagi/arcadia/pkg/llms/zhipuai"
+	"github.com/kubeagi/arcadia/pkg/probe"
 )
 
 const (
 	_StatusNilResponse = "No err replied but response is not string"
+
+	probeKindEmbedder     = "Embedder"
+	probeEmbeddingText    = "ping"
+	probeSucceededMessage = "Probe succeeded"
 )
 
 // EmbedderReconciler reconciles a Embedder object
@@ -107,6 +117,7 @@ func (r *EmbedderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			logger.Error(err, "Failed to remove finalizer for Embedder")
 			return ctrl.Result{}, err
 		}
+		probe.Forget(probeKindEmbedder, instance.Namespace, instance.Name)
 		logger.Info("Remove Embedder done")
 		return ctrl.Result{}, nil
 	}
@@ -124,9 +135,22 @@ func (r *EmbedderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{Requeue: true}, err
 	}
 
+	// 3rd party embedders are validated by calling models, so only probe them once the spec is validated
+	health := instance.Status.Health
+	if instance.Spec.Probe != nil && providerType == arcadiav1alpha1.ProviderType3rdParty &&
+		health != nil && health.ObservedGeneration == instance.Generation {
+		interval, err := r.probeEmbedder(ctx, logger, instance)
+		return ctrl.Result{RequeueAfter: interval}, err
+	}
+
 	if err := r.CheckEmbedder(ctx, logger, instance); err != nil {
 		return ctrl.Result{RequeueAfter: waitMedium}, err
 	}
+
+	if instance.Spec.Probe != nil {
+		interval, err := r.probeEmbedder(ctx, logger, instance)
+		return ctrl.Result{RequeueAfter: interval}, err
+	}
 	return ctrl.Result{RequeueAfter: waitLonger}, nil
 }
 
@@ -249,6 +273,46 @@ func (r *EmbedderReconciler) check3rdPartyEmbedder(ctx context.Context, logger l
 			}
 			msg = "Success"
 		}
+	case embeddings.AzureOpenAI:
+		// validate all embedding deployments
+		for _, model := range models {
+			llm, err := langchainopenai.New(llmsopenai.AzureOptions(apiKey, instance.Spec.Endpoint.URL, model)...)
+			if err != nil {
+				return r.UpdateStatus(ctx, instance, nil, err)
+			}
+			embedClient, err := langchainembeddings.NewEmbedder(llm)
+			if err != nil {
+				return r.UpdateStatus(ctx, instance, nil, err)
+			}
+			_, err = embedClient.EmbedQuery(ctx, embedingText)
+			if err != nil {
+				return r.UpdateStatus(ctx, instance, nil, err)
+			}
+			msg = "Success"
+		}
+	case embeddings.Qianfan:
+		secretKey, err := instance.AuthSecretKey(ctx, r.Client)
+		if err != nil {
+			return r.UpdateStatus(ctx, instance, nil, err)
+		}
+		client, err := qianfan.NewQianfan(apiKey, secretKey, instance.Spec.Endpoint.URL)
+		if err != nil {
+			return r.UpdateStatus(ctx, instance, nil, err)
+		}
+		// validate all embedding models
+		for _, model := range models {
+			_, err = client.Embedding(ctx, model, []string{embedingText})
+			if err != nil {
+				return r.UpdateStatus(ctx, instance, nil, err)
+			}
+			msg = "Success"
+		}
+	case embeddings.DashScope:
+		embedClient := embeddingsdashscope.NewDashScopeEmbedder(apiKey)
+		if _, err = embedClient.EmbedQuery(ctx, embedingText); err != nil {
+			return r.UpdateStatus(ctx, instance, nil, err)
+		}
+		msg = "Success"
 	default:
 		return r.UpdateStatus(ctx, instance, nil, fmt.Errorf("unsupported service type: %s", instance.Spec.Type))
 	}
@@ -277,8 +341,46 @@ func (r *EmbedderReconciler) checkWorkerEmbedder(ctx context.Context, logger log
 	return r.UpdateStatus(ctx, instance, msg, err)
 }
 
+// probeEmbedder embeds a short text, records the result into status.health and
+// marks the embedder as not ready once the consecutive failures reach the threshold.
+// It returns the wait time before the next probe.
+func (r *EmbedderReconciler) probeEmbedder(ctx context.Context, logger logr.Logger, instance *arcadiav1alpha1.Embedder) (time.Duration, error) {
+	cfg := probe.ConfigFromSpec(instance.Spec.Probe)
+	sample, err := probe.Run(ctx, cfg.Timeout, func(ctx context.Context) error {
+		embedder, err := langchainwrap.GetLangchainEmbedder(ctx, instance, r.Client, "")
+		if err != nil {
+			return err
+		}
+		_, err = embedder.EmbedQuery(ctx, probeEmbeddingText)
+		return err
+	})
+	if err != nil {
+		logger.Error(err, "Failed to probe Embedder")
+	}
+
+	// status may be updated by CheckEmbedder, get the latest one
+	latest := &arcadiav1alpha1.Embedder{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(instance), latest); err != nil {
+		return cfg.Interval, err
+	}
+	latestCopy := latest.DeepCopy()
+	health := probe.Update(latestCopy.Status.Health, latest.Generation, sample, err, cfg)
+	latestCopy.Status.Health = health
+	switch {
+	case probe.Unhealthy(health, cfg):
+		latestCopy.Status.SetConditions(latest.ErrorCondition("probe failed: " + health.LastFailureReason))
+	case sample.Success && !latest.Status.IsReady():
+		latestCopy.Status.SetConditions(latest.ReadyCondition(probeSucceededMessage))
+	}
+	probe.Observe(probeKindEmbedder, latest.Namespace, latest.Name, sample, health, latestCopy.Status.IsReady())
+	return cfg.Interval, r.Client.Status().Update(ctx, latestCopy)
+}
+
 func (r *EmbedderReconciler) UpdateStatus(ctx context.Context, instance *arcadiav1alpha1.Embedder, t interface{}, err error) error {
 	instanceCopy := instance.DeepCopy()
+	if instance.Spec.Probe == nil {
+		instanceCopy.Status.Health = nil
+	}
 	var newCondition arcadiav1alpha1.Condition
 	if err != nil {
 		// set condition to False
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	langchainllms "github.com/tmc/langchaingo/llms"
@@ -40,9 +41,20 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	arcadiav1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/langchainwrap"
 	"github.com/kubeagi/arcadia/pkg/llms"
+	"github.com/kubeagi/arcadia/pkg/llms/anthropic"
+	"github.com/kubeagi/arcadia/pkg/llms/dashscope"
 	"github.com/kubeagi/arcadia/pkg/llms/openai"
+	"github.com/kubeagi/arcadia/pkg/llms/qianfan"
 	"github.com/kubeagi/arcadia/pkg/llms/zhipuai"
+	"github.com/kubeagi/arcadia/pkg/probe"
+)
+
+const (
+	probeKindLLM   = "LLM"
+	probePrompt    = "Hi"
+	probeMaxTokens = 8
 )
 
 // LLMReconciler reconciles a LLM object
@@ -104,6 +116,7 @@ func (r *LLMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 			logger.Error(err, "Failed to remove finalizer for LLM")
 			return ctrl.Result{}, err
 		}
+		probe.Forget(probeKindLLM, instance.Namespace, instance.Name)
 		logger.Info("Remove LLM done")
 		return ctrl.Result{}, nil
 	}
@@ -120,6 +133,14 @@ func (r *LLMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{Requeue: true}, err
 	}
 
+	// 3rd party llms are validated by calling models, so only probe them once the spec is validated
+	health := instance.Status.Health
+	if instance.Spec.Probe != nil && providerType == arcadiav1alpha1.ProviderType3rdParty &&
+		health != nil && health.ObservedGeneration == instance.Generation {
+		interval, err := r.probeLLM(ctx, logger, instance)
+		return ctrl.Result{RequeueAfter: interval}, err
+	}
+
 	err := r.CheckLLM(ctx, logger, instance)
 	if err != nil {
 		logger.Error(err, "Failed to check LLM")
@@ -127,6 +148,10 @@ func (r *LLMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{RequeueAfter: waitMedium}, err
 	}
 
+	if instance.Spec.Probe != nil {
+		interval, err := r.probeLLM(ctx, logger, instance)
+		return ctrl.Result{RequeueAfter: interval}, err
+	}
 	return ctrl.Result{RequeueAfter: waitLonger}, nil
 }
 
@@ -168,6 +193,10 @@ func (r *LLMReconciler) SetupWithManager(mgr ctrl.Manager) error {
 func (r *LLMReconciler) CheckLLM(ctx context.Context, logger logr.Logger, instance *arcadiav1alpha1.LLM) error {
 	logger.Info("Checking LLM instance")
 
+	if err := r.checkFallbackLLMs(ctx, instance); err != nil {
+		return r.UpdateStatus(ctx, instance, nil, err)
+	}
+
 	switch instance.Spec.Provider.GetType() {
 	case arcadiav1alpha1.ProviderType3rdParty:
 		return r.check3rdPartyLLM(ctx, logger, instance)
@@ -178,6 +207,23 @@ func (r *LLMReconciler) CheckLLM(ctx context.Context, logger logr.Logger, instan
 	return nil
 }
 
+// checkFallbackLLMs makes sure all fallback llms exist and this llm is not one of them
+func (r *LLMReconciler) checkFallbackLLMs(ctx context.Context, instance *arcadiav1alpha1.LLM) error {
+	if instance.Spec.Fallback == nil {
+		return nil
+	}
+	for _, ref := range instance.Spec.Fallback.LLMs {
+		namespace := ref.GetNamespace(instance.GetNamespace())
+		if ref.Name == instance.GetName() && namespace == instance.GetNamespace() {
+			return errors.New("llm can't fall back to itself")
+		}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, &arcadiav1alpha1.LLM{}); err != nil {
+			return fmt.Errorf("failed to get fallback llm %s: %w", ref.Name, err)
+		}
+	}
+	return nil
+}
+
 func (r *LLMReconciler) check3rdPartyLLM(ctx context.Context, logger logr.Logger, instance *arcadiav1alpha1.LLM) error {
 	logger.Info("Checking 3rd party LLM resource")
 
@@ -229,6 +275,57 @@ func (r *LLMReconciler) check3rdPartyLLM(ctx context.Context, logger logr.Logger
 			}
 			msg = strings.Join([]string{msg, res}, "\n")
 		}
+	case llms.Anthropic:
+		llmClient := anthropic.NewAnthropic(apiKey, instance.Spec.Endpoint.URL)
+		for _, model := range models {
+			res, err := llmClient.Validate(ctx, langchainllms.WithModel(model))
+			if err != nil {
+				return r.UpdateStatus(ctx, instance, nil, err)
+			}
+			msg = strings.Join([]string{msg, res.String()}, "\n")
+		}
+	case llms.AzureOpenAI, llms.Moonshot:
+		var llmClient *openai.OpenAI
+		if instance.Spec.Type == llms.AzureOpenAI {
+			llmClient, err = openai.NewAzureOpenAI(apiKey, instance.Spec.Endpoint.URL)
+		} else {
+			llmClient, err = openai.NewMoonshot(apiKey, instance.Spec.Endpoint.URL)
+		}
+		if err != nil {
+			return r.UpdateStatus(ctx, instance, nil, err)
+		}
+		for _, model := range models {
+			res, err := llmClient.Validate(ctx, langchainllms.WithModel(model))
+			if err != nil {
+				return r.UpdateStatus(ctx, instance, nil, err)
+			}
+			msg = strings.Join([]string{msg, res.String()}, "\n")
+		}
+	case llms.Qianfan:
+		secretKey, err := instance.AuthSecretKey(ctx, r.Client)
+		if err != nil {
+			return r.UpdateStatus(ctx, instance, nil, err)
+		}
+		llmClient, err := qianfan.NewQianfan(apiKey, secretKey, instance.Spec.Endpoint.URL)
+		if err != nil {
+			return r.UpdateStatus(ctx, instance, nil, err)
+		}
+		for _, model := range models {
+			res, err := llmClient.Validate(ctx, langchainllms.WithModel(model))
+			if err != nil {
+				return r.UpdateStatus(ctx, instance, nil, err)
+			}
+			msg = strings.Join([]string{msg, res.String()}, "\n")
+		}
+	case llms.DashScope:
+		llmClient := dashscope.NewDashScope(apiKey, false)
+		for _, model := range models {
+			res, err := llmClient.Validate(ctx, langchainllms.WithModel(model))
+			if err != nil {
+				return r.UpdateStatus(ctx, instance, nil, err)
+			}
+			msg = strings.Join([]string{msg, res.String()}, "\n")
+		}
 	default:
 		return r.UpdateStatus(ctx, instance, nil, fmt.Errorf("unsupported service type: %s", instance.Spec.Type))
 	}
@@ -257,8 +354,49 @@ func (r *LLMReconciler) checkWorkerLLM(ctx context.Context, logger logr.Logger,
 	return r.UpdateStatus(ctx, instance, msg, err)
 }
 
+// probeLLM calls the llm with a cheap prompt, records the result into status.health and
+// marks the llm as not ready once the consecutive failures reach the threshold.
+// It returns the wait time before the next probe.
+func (r *LLMReconciler) probeLLM(ctx context.Context, logger logr.Logger, instance *arcadiav1alpha1.LLM) (time.Duration, error) {
+	cfg := probe.ConfigFromSpec(instance.Spec.Probe)
+	// probe this llm only, without its fallback llms
+	target := instance.DeepCopy()
+	target.Spec.Fallback = nil
+	sample, err := probe.Run(ctx, cfg.Timeout, func(ctx context.Context) error {
+		llm, err := langchainwrap.GetLangchainLLM(ctx, target, r.Client, "")
+		if err != nil {
+			return err
+		}
+		_, err = langchainllms.GenerateFromSinglePrompt(ctx, llm, probePrompt, langchainllms.WithMaxTokens(probeMaxTokens))
+		return err
+	})
+	if err != nil {
+		logger.Error(err, "Failed to probe LLM")
+	}
+
+	// status may be updated by CheckLLM, get the latest one
+	latest := &arcadiav1alpha1.LLM{}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(instance), latest); err != nil {
+		return cfg.Interval, err
+	}
+	latestCopy := latest.DeepCopy()
+	health := probe.Update(latestCopy.Status.Health, latest.Generation, sample, err, cfg)
+	latestCopy.Status.Health = health
+	switch {
+	case probe.Unhealthy(health, cfg):
+		latestCopy.Status.SetConditions(latest.ErrorCondition("probe failed: " + health.LastFailureReason))
+	case sample.Success && !latest.Status.IsReady():
+		latestCopy.Status.SetConditions(latest.ReadyCondition(probeSucceededMessage))
+	}
+	probe.Observe(probeKindLLM, latest.Namespace, latest.Name, sample, health, latestCopy.Status.IsReady())
+	return cfg.Interval, r.Client.Status().Update(ctx, latestCopy)
+}
+
 func (r *LLMReconciler) UpdateStatus(ctx context.Context, instance *arcadiav1alpha1.LLM, t interface{}, err error) error {
 	instanceCopy := instance.DeepCopy()
+	if instance.Spec.Probe == nil {
+		instanceCopy.Status.Health = nil
+	}
 	var newCondition arcadiav1alpha1.Condition
 	if err != nil {
 		// set condition to False
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	arcadiav1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/tools"
+)
+
+// ToolReconciler reconciles a Tool object
+type ToolReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=arcadia.kubeagi.k8s.com.cn,resources=tools,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=arcadia.kubeagi.k8s.com.cn,resources=tools/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=arcadia.kubeagi.k8s.com.cn,resources=tools/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile validates the type and parameters of the Tool, the Tool is ready if they are valid.
+func (r *ToolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(5).Info("Reconciling tool resource")
+
+	instance := &arcadiav1alpha1.Tool{}
+	if err := r.Get(ctx, req.NamespacedName, instance); err != nil {
+		// There's no need to requeue if the resource no longer exists.
+		// Otherwise, we'll be requeued implicitly because we return an error.
+		logger.V(1).Info("Failed to get Tool")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if instance.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	status := instance.Status.DeepCopy()
+	status.ObservedGeneration = instance.Generation
+	err := r.validate(ctx, instance)
+	if err != nil {
+		logger.Info("Tool is invalid", "reason", err.Error())
+		status.SetConditions(status.ErrorCondition(err.Error())...)
+	} else {
+		status.SetConditions(status.ReadyCondition()...)
+	}
+	if err := r.patchStatus(ctx, instance, status); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err != nil {
+		// the secrets of parameters may be created later
+		return ctrl.Result{RequeueAfter: waitMedium}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *ToolReconciler) validate(ctx context.Context, instance *arcadiav1alpha1.Tool) error {
+	impl, err := tools.Lookup(instance.Spec.Type)
+	if err != nil {
+		return fmt.Errorf("%w, available types: %s", err, strings.Join(tools.Types(), ", "))
+	}
+	params, err := instance.ResolveParameters(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+	return tools.ValidateParameters(impl, params)
+}
+
+func (r *ToolReconciler) patchStatus(ctx context.Context, instance *arcadiav1alpha1.Tool, status *arcadiav1alpha1.ToolStatus) error {
+	if reflect.DeepEqual(instance.Status, *status) {
+		return nil
+	}
+	patch := client.MergeFrom(instance.DeepCopy())
+	instance.Status = *status
+	return r.Client.Status().Patch(ctx, instance, patch, client.FieldOwner("Tool-controller"))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ToolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&arcadiav1alpha1.Tool{}).
+		Complete(r)
+}
@@ -40,11 +40,13 @@ import (
 	agentv1alpha1 "github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
 	apichain "github.com/kubeagi/arcadia/api/app-node/chain/v1alpha1"
 	documentloaderv1alpha1 "github.com/kubeagi/arcadia/api/app-node/documentloader/v1alpha1"
+	apiguardrail "github.com/kubeagi/arcadia/api/app-node/guardrail/v1alpha1"
 	apiprompt "github.com/kubeagi/arcadia/api/app-node/prompt/v1alpha1"
 	apiretriever "github.com/kubeagi/arcadia/api/app-node/retriever/v1alpha1"
 	arcadiav1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
 	evaluationarcadiav1alpha1 "github.com/kubeagi/arcadia/api/evaluation/v1alpha1"
 	chaincontrollers "github.com/kubeagi/arcadia/controllers/app-node/chain"
+	guardrailcontrollers "github.com/kubeagi/arcadia/controllers/app-node/guardrail"
 	promptcontrollers "github.com/kubeagi/arcadia/controllers/app-node/prompt"
 	retrievertrollers "github.com/kubeagi/arcadia/controllers/app-node/retriever"
 	basecontrollers "github.com/kubeagi/arcadia/controllers/base"
@@ -75,6 +77,7 @@ func init() {
 	utilruntime.Must(agentv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(rbacv1.AddToScheme(scheme))
 	utilruntime.Must(documentloaderv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(apiguardrail.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -178,6 +181,13 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Datasource")
 		os.Exit(1)
 	}
+	if err = (&basecontrollers.ToolReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Tool")
+		os.Exit(1)
+	}
 	if err = (&basecontrollers.EmbedderReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -278,6 +288,20 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "MultiQueryRetriever")
 		os.Exit(1)
 	}
+	if err = (&guardrailcontrollers.InputGuardrailReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "InputGuardrail")
+		os.Exit(1)
+	}
+	if err = (&guardrailcontrollers.OutputGuardrailReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OutputGuardrail")
+		os.Exit(1)
+	}
 	if err = (&promptcontrollers.PromptReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	langchaintools "github.com/tmc/langchaingo/tools"
+
+	"github.com/kubeagi/arcadia/pkg/appruntime/tools"
+)
+
+var ErrNoPendingApproval = errors.New("no tool call is waiting for approval")
+
+// ToolCall is a call of tool by agent
+type ToolCall struct {
+	Tool  string `json:"tool"`
+	Input string `json:"input"`
+}
+
+// Decision is the decision of user on a tool call
+type Decision struct {
+	ToolCall `json:",inline"`
+	Approved bool `json:"approved"`
+	// Reason is told to llm when the call is rejected
+	Reason string `json:"reason,omitempty"`
+}
+
+// Approval is the state of agent paused by a tool call which requires approval.
+// It is stored with the chat message, the agent runs again with it after the user makes the decision,
+// the finished tool calls are not called again and the decided calls are not asked again.
+type Approval struct {
+	// Pending is the tool call waiting for approval
+	Pending *ToolCall `json:"pending,omitempty"`
+	// Decisions are the decisions made in this run of agent
+	Decisions []Decision `json:"decisions,omitempty"`
+	// Steps are the finished tool calls in this run of agent
+	Steps []Step `json:"steps,omitempty"`
+}
+
+// Decide records the decision on the pending tool call
+func (a *Approval) Decide(approved bool, reason string) error {
+	if a == nil || a.Pending == nil {
+		return ErrNoPendingApproval
+	}
+	a.Decisions = append(a.Decisions, Decision{ToolCall: *a.Pending, Approved: approved, Reason: reason})
+	a.Pending = nil
+	return nil
+}
+
+// ApprovalRequiredError is returned by agent when it is paused
+type ApprovalRequiredError struct {
+	Approval *Approval
+}
+
+func (e *ApprovalRequiredError) Error() string {
+	return "waiting for approval to call tool " + e.Approval.Pending.Tool
+}
+
+// approvalState is shared by the tools of an agent run
+type approvalState struct {
+	mu sync.Mutex
+	// previous is the approval state of last run, nil for a new run
+	previous *Approval
+	// steps are the tool calls finished in this run
+	steps []Step
+}
+
+func (s *approvalState) decision(call ToolCall) (Decision, bool) {
+	if s.previous == nil {
+		return Decision{}, false
+	}
+	for _, d := range s.previous.Decisions {
+		if d.ToolCall == call {
+			return d, true
+		}
+	}
+	return Decision{}, false
+}
+
+func (s *approvalState) finished(call ToolCall) (string, bool) {
+	if s.previous == nil {
+		return "", false
+	}
+	for _, step := range s.previous.Steps {
+		if step.Tool == call.Tool && step.Input == call.Input {
+			return step.Observation, true
+		}
+	}
+	return "", false
+}
+
+func (s *approvalState) record(call ToolCall, observation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, Step{Tool: call.Tool, Input: call.Input, Observation: observation})
+}
+
+// pause returns the error to pause the agent at the call
+func (s *approvalState) pause(call ToolCall) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	approval := &Approval{Pending: &call, Steps: append([]Step{}, s.steps...)}
+	if s.previous != nil {
+		approval.Decisions = s.previous.Decisions
+	}
+	return &ApprovalRequiredError{Approval: approval}
+}
+
+// approvalTool asks for approval before calling the tool if it is required,
+// and returns the result of last run for the finished calls.
+type approvalTool struct {
+	langchaintools.Tool
+	requiresApproval bool
+	state            *approvalState
+}
+
+func (t approvalTool) Call(ctx context.Context, input string) (string, error) {
+	call := ToolCall{Tool: t.Name(), Input: input}
+	if observation, ok := t.state.finished(call); ok {
+		t.state.record(call, observation)
+		return observation, nil
+	}
+	if t.requiresApproval {
+		decision, ok := t.state.decision(call)
+		if !ok {
+			return "", t.state.pause(call)
+		}
+		if !decision.Approved {
+			observation := "The user rejected this call of the tool."
+			if decision.Reason != "" {
+				observation += " The reason is: " + decision.Reason
+			}
+			t.state.record(call, observation)
+			return observation, nil
+		}
+	}
+	observation, err := t.Tool.Call(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	t.state.record(call, observation)
+	return observation, nil
+}
+
+// withApproval wraps the tools with the approval state of last run
+func withApproval(allowedTools []langchaintools.Tool, previous *Approval) []langchaintools.Tool {
+	state := &approvalState{previous: previous}
+	result := make([]langchaintools.Tool, 0, len(allowedTools))
+	for _, tool := range allowedTools {
+		t, ok := tool.(tools.ToolWithApproval)
+		result = append(result, approvalTool{Tool: tool, requiresApproval: ok && t.RequiresApproval(), state: state})
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ticketTool creates tickets, it requires approval
+type ticketTool struct {
+	created *[]string
+}
+
+func (ticketTool) Name() string           { return "ticket" }
+func (ticketTool) Description() string    { return "create a ticket" }
+func (ticketTool) RequiresApproval() bool { return true }
+func (t ticketTool) Call(_ context.Context, input string) (string, error) {
+	*t.created = append(*t.created, input)
+	return "ticket created", nil
+}
+
+func runApprovalAgent(t *testing.T, answers []string, allowedTools []tools.Tool, previous *Approval) (map[string]any, error) {
+	llm := &scriptedLLM{answers: answers}
+	executor, err := agents.Initialize(llm, observeToolErrors(withApproval(allowedTools, previous)), agents.ZeroShotReactDescription,
+		agents.WithMaxIterations(5), agents.WithReturnIntermediateSteps())
+	require.NoError(t, err)
+	return executor.Call(context.Background(), map[string]any{"input": "Beijing is too hot, report it"})
+}
+
+func TestApproval(t *testing.T) {
+	created := make([]string, 0)
+	allowedTools := []tools.Tool{fakeTool{}, ticketTool{created: &created}}
+	weather := "Thought: get the weather\nAction: weather\nAction Input: Beijing"
+	ticket := "Thought: create a ticket\nAction: ticket\nAction Input: Beijing is hot"
+	finish := "Thought: I now know the final answer\nFinal Answer: done"
+
+	_, err := runApprovalAgent(t, []string{weather, ticket}, allowedTools, nil)
+	var approvalErr *ApprovalRequiredError
+	require.True(t, errors.As(err, &approvalErr))
+	approval := approvalErr.Approval
+	require.Equal(t, &ToolCall{Tool: "ticket", Input: "Beijing is hot"}, approval.Pending)
+	require.Equal(t, []Step{{Tool: "weather", Input: "Beijing", Observation: "sunny in Beijing"}}, approval.Steps)
+	require.Empty(t, created)
+
+	// the user rejects the call
+	rejected := *approval
+	require.NoError(t, rejected.Decide(false, "not now"))
+	require.ErrorIs(t, rejected.Decide(true, ""), ErrNoPendingApproval)
+	response, err := runApprovalAgent(t, []string{weather, ticket, finish}, allowedTools, &rejected)
+	require.NoError(t, err)
+	require.Equal(t, "The user rejected this call of the tool. The reason is: not now", StepsOf(response)[1].Observation)
+	require.Empty(t, created)
+
+	// the user approves the call
+	require.NoError(t, approval.Decide(true, ""))
+	response, err = runApprovalAgent(t, []string{weather, ticket, finish}, allowedTools, approval)
+	require.NoError(t, err)
+	require.Contains(t, response["output"], "done")
+	require.Equal(t, []string{"Beijing is hot"}, created)
+
+	// the tool call with other input needs approval again
+	_, err = runApprovalAgent(t, []string{weather, "Action: ticket\nAction Input: Shanghai is hot"}, allowedTools, approval)
+	require.True(t, errors.As(err, &approvalErr))
+	require.Equal(t, "Shanghai is hot", approvalErr.Approval.Pending.Input)
+	require.Len(t, approvalErr.Approval.Decisions, 1)
+}
@@ -20,22 +20,46 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/tmc/langchaingo/agents"
 	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/chains"
 	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
 	langchaingoschema "github.com/tmc/langchaingo/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	chainv1alpha1 "github.com/kubeagi/arcadia/api/app-node/chain/v1alpha1"
 	"github.com/kubeagi/arcadia/pkg/appruntime/base"
 	"github.com/kubeagi/arcadia/pkg/appruntime/chain"
 	"github.com/kubeagi/arcadia/pkg/appruntime/log"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
 	"github.com/kubeagi/arcadia/pkg/appruntime/tools"
 )
 
+const (
+	// intermediateStepsOutputKey is the key of intermediate steps in the output of agents.Executor
+	intermediateStepsOutputKey = "intermediateSteps"
+
+	// zeroShotToolsPrompt follows the prompt of agent, it is the default prefix of zero-shot agent in langchaingo
+	zeroShotToolsPrompt = `Today is {{.today}} and you can use tools to get new information.
+Answer the following questions as best you can using the following tools:
+
+{{.tool_descriptions}}`
+
+	// conversationalToolsPrompt follows the prompt of agent, it is the tools part of default prefix of conversational agent in langchaingo
+	conversationalToolsPrompt = `TOOLS:
+------
+
+Assistant has access to the following tools:
+
+{{.tool_descriptions}}`
+)
+
 type Executor struct {
 	base.BaseNode
 }
@@ -59,7 +83,18 @@ func (p *Executor) Run(ctx context.Context, cli client.Client, args map[string]a
 	if err := cli.Get(ctx, types.NamespacedName{Namespace: p.RefNamespace(), Name: p.Ref.Name}, instance); err != nil {
 		return args, fmt.Errorf("can't find the agent in cluster: %w", err)
 	}
-	allowedTools := tools.InitTools(ctx, instance.Spec.AllowedTools)
+	allowedTools, err := tools.InitTools(ctx, cli, llm, p.RefNamespace(), instance.Spec.AllowedTools)
+	if err != nil {
+		return args, fmt.Errorf("failed to init tools of agent: %w", err)
+	}
+	if len(instance.Spec.MCPServers) > 0 {
+		mcpTools, closeMCP, err := tools.InitMCPTools(ctx, cli, p.RefNamespace(), instance.Spec.MCPServers)
+		if err != nil {
+			return args, fmt.Errorf("failed to init mcp tools of agent: %w", err)
+		}
+		defer closeMCP()
+		allowedTools = append(allowedTools, mcpTools...)
+	}
 
 	var history langchaingoschema.ChatMessageHistory
 	if v3, ok := args[base.LangchaingoChatMessageHistoryKeyInArg]; ok && v3 != nil {
@@ -68,31 +103,158 @@ func (p *Executor) Run(ctx context.Context, cli client.Client, args map[string]a
 			return args, errors.New("history not memory.ChatMessageHistory")
 		}
 	}
-	// Initialize executor using langchaingo
-	executorOptions := func(o *agents.CreationOptions) {
-		agents.WithCallbacksHandler(log.KLogHandler{LogLevel: 3})(o)
-		agents.WithMaxIterations(instance.Spec.Options.MaxIterations)(o)
-		// Only show tool action in the streaming output if configured
-		if instance.Spec.Options.ShowToolAction {
-			if needStream, ok := args[base.InputIsNeedStreamKeyInArg].(bool); ok && needStream {
-				streamHandler := StreamHandler{callbacks.SimpleHandler{}, args}
-				agents.WithCallbacksHandler(streamHandler)(o)
-			}
+	if history, err = chain.SummarizeHistory(ctx, llm, instance.Spec.Options.Memory, history, args); err != nil {
+		return args, err
+	}
+	// Only show tool action in the streaming output if configured
+	var streamHandler callbacks.Handler
+	if instance.Spec.Options.ShowToolAction {
+		if needStream, ok := args[base.InputIsNeedStreamKeyInArg].(bool); ok && needStream {
+			streamHandler = StreamHandler{callbacks.SimpleHandler{}, args}
 		}
-		agents.WithMemory(chain.GetMemory(llm, instance.Spec.AgentConfig.Options.Memory, history, "", ""))(o)
 	}
-	executor, err := agents.Initialize(llm, allowedTools, agents.ZeroShotReactDescription, executorOptions)
-	if err != nil {
-		return args, fmt.Errorf("failed to initialize executor: %w", err)
+	for _, tool := range allowedTools {
+		if t, ok := tool.(tools.ToolWithArgs); ok {
+			t.SetArgs(args)
+		}
+		if t, ok := tool.(tools.ToolWithProgress); ok && streamHandler != nil {
+			t.SetProgress(func(ctx context.Context, message string) {
+				handleEvent(ctx, streamHandler, base.StreamEventProgress, message)
+			})
+		}
+	}
+	question, _ := args[base.InputQuestionKeyInArg].(string)
+	// the tools called by executor return errors to llm as observations, and wait for approval if required
+	previous, _ := args[base.AgentApprovalInArg].(*Approval)
+	executorTools := observeToolErrors(withApproval(allowedTools, previous))
+	var response map[string]any
+	switch instance.Spec.Type {
+	case v1alpha1.AgentTypeToolCalling:
+		// the agent needs the original tools for their parameters
+		agent := NewToolCallingAgent(llm, allowedTools, instance.Spec.Prompt, history, streamHandler)
+		executor := agents.NewExecutor(agent, executorTools,
+			agents.WithCallbacksHandler(log.KLogHandler{LogLevel: 3}),
+			agents.WithMaxIterations(instance.Spec.Options.MaxIterations),
+			agents.WithReturnIntermediateSteps())
+		response, err = executor.Call(ctx, map[string]any{"input": question})
+	case v1alpha1.AgentTypePlanAndExecute:
+		agent := NewPlanAndExecuteAgent(llm, executorTools, instance.Spec.Prompt, history, instance.Spec.Options.MaxIterations, streamHandler)
+		var result *PlanAndExecuteResult
+		result, err = agent.Run(ctx, question)
+		response = map[string]any{"output": result.Output, intermediateStepsOutputKey: result.Steps}
+		args[base.AgentPlanInArg] = result.Plan
+	case v1alpha1.AgentTypeConversational:
+		options := p.executorOptions(instance, streamHandler)
+		if instance.Spec.Prompt != "" {
+			options = append(options, agents.WithPromptPrefix(instance.Spec.Prompt+"\n\n"+conversationalToolsPrompt))
+		}
+		options = append(options, agents.WithMemory(conversationalMemory(llm, instance.Spec.Options.Memory, history)))
+		var executor agents.Executor
+		if executor, err = agents.Initialize(llm, executorTools, agents.ConversationalReactDescription, options...); err != nil {
+			return args, fmt.Errorf("failed to initialize executor: %w", err)
+		}
+		// chains.Call loads the history into the prompt
+		response, err = chains.Call(ctx, executor, map[string]any{"input": question})
+	default:
+		options := p.executorOptions(instance, streamHandler)
+		if instance.Spec.Prompt != "" {
+			options = append(options, agents.WithPromptPrefix(instance.Spec.Prompt+"\n\n"+zeroShotToolsPrompt))
+		}
+		var executor agents.Executor
+		if executor, err = agents.Initialize(llm, executorTools, agents.ZeroShotReactDescription, options...); err != nil {
+			return args, fmt.Errorf("failed to initialize executor: %w", err)
+		}
+		response, err = executor.Call(ctx, map[string]any{"input": question})
+	}
+	steps := StepsOf(response)
+	if len(steps) > 0 {
+		args[base.AgentIntermediateStepsInArg] = steps
 	}
-	input := make(map[string]any)
-	input["input"] = fmt.Sprintf("%s, %s", instance.Spec.Prompt, args["question"])
-	response, err := executor.Call(ctx, input)
 	if err != nil {
+		var approvalErr *ApprovalRequiredError
+		if errors.As(err, &approvalErr) {
+			klog.FromContext(ctx).V(3).Info("agent is waiting for approval", "tool", approvalErr.Approval.Pending.Tool)
+			return args, approvalErr
+		}
+		if ctx.Err() != nil {
+			return args, fmt.Errorf("error when call agent: %w", err)
+		}
 		klog.FromContext(ctx).Error(err, "error when call agent")
-		// return args, fmt.Errorf("error when call agent: %w", err)
+		outcome := NewOutcome(err, steps, instance.Spec.Options.GiveUpMessage, instance.Spec.Options.MaxIterations)
+		args[base.AgentOutputInArg] = outcome.Answer
+		args[base.AgentFailureReasonInArg] = outcome.FailureReason
+	} else {
+		klog.FromContext(ctx).V(5).Info("use agent, blocking out:", response["output"])
+		args[base.AgentOutputInArg] = response["output"]
+	}
+	// add the references of tools, like the hits of knowledgebases, to the chat references
+	for _, tool := range allowedTools {
+		if t, ok := tool.(tools.ToolWithReferences); ok {
+			if refs := t.References(); len(refs) > 0 {
+				args = retriever.AddReferencesToArgs(args, refs)
+			}
+		}
 	}
-	klog.FromContext(ctx).V(5).Info("use agent, blocking out:", response["output"])
-	args[base.AgentOutputInArg] = response["output"]
 	return args, nil
 }
+
+func (p *Executor) executorOptions(instance *v1alpha1.Agent, streamHandler callbacks.Handler) []agents.CreationOption {
+	options := []agents.CreationOption{
+		agents.WithCallbacksHandler(log.KLogHandler{LogLevel: 3}),
+		agents.WithMaxIterations(instance.Spec.Options.MaxIterations),
+		agents.WithReturnIntermediateSteps(),
+	}
+	if streamHandler != nil {
+		options = append(options, agents.WithCallbacksHandler(streamHandler))
+	}
+	return options
+}
+
+// conversationalMemory returns the memory with the keys used by conversational agent,
+// all the history is used if no limit is configured.
+func conversationalMemory(llm llms.Model, config chainv1alpha1.Memory, history langchaingoschema.ChatMessageHistory) langchaingoschema.Memory {
+	if history == nil {
+		history = memory.NewChatMessageHistory()
+	}
+	if config.MaxTokenLimit > 0 || config.ConversionWindowSize != nil {
+		return chain.GetMemory(llm, config, history, "input", "output")
+	}
+	return memory.NewConversationBuffer(memory.WithInputKey("input"), memory.WithOutputKey("output"), memory.WithChatHistory(history))
+}
+
+// Step is an intermediate step of agent
+type Step struct {
+	// Plan is the step of plan this action belongs to, only set by plan-and-execute agent
+	Plan string `json:"plan,omitempty"`
+	// Tool is the name of tool called
+	Tool string `json:"tool,omitempty"`
+	// Input is the input of tool
+	Input string `json:"input,omitempty"`
+	// Observation is the output of tool
+	Observation string `json:"observation,omitempty"`
+	// Error is the error message if the tool call failed
+	Error string `json:"error,omitempty"`
+}
+
+// StepsOf returns the intermediate steps in the output of agent executor
+func StepsOf(response map[string]any) []Step {
+	switch v := response[intermediateStepsOutputKey].(type) {
+	case []Step:
+		return v
+	case []langchaingoschema.AgentStep:
+		steps := make([]Step, 0, len(v))
+		for _, s := range v {
+			// a step without tool is the parse error of llm output
+			if s.Action.Tool == "" {
+				continue
+			}
+			step := Step{Tool: s.Action.Tool, Input: s.Action.ToolInput, Observation: s.Observation}
+			if strings.HasPrefix(s.Observation, toolErrorPrefix) {
+				step.Error = strings.TrimPrefix(s.Observation, toolErrorPrefix)
+			}
+			steps = append(steps, step)
+		}
+		return steps
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	// DefaultGiveUpMessage is the answer when agent fails and no give up message is configured
+	DefaultGiveUpMessage = "Sorry, I couldn't finish this task."
+
+	// toolErrorPrefix is the prefix of observation when the tool call fails
+	toolErrorPrefix = "Error: the tool call failed: "
+)
+
+// errorObservingTool returns the error of tool call to llm as the observation instead of stopping the agent,
+// so llm can retry with other input or tools, and the error is shown in the intermediate steps.
+type errorObservingTool struct {
+	tools.Tool
+}
+
+func (t errorObservingTool) Call(ctx context.Context, input string) (string, error) {
+	output, err := t.Tool.Call(ctx, input)
+	if err != nil {
+		// the agent can't go on if the request is canceled or it is waiting for approval
+		var approvalErr *ApprovalRequiredError
+		if ctx.Err() != nil || errors.As(err, &approvalErr) {
+			return "", err
+		}
+		return toolErrorPrefix + err.Error(), nil
+	}
+	return output, nil
+}
+
+func observeToolErrors(allowedTools []tools.Tool) []tools.Tool {
+	result := make([]tools.Tool, 0, len(allowedTools))
+	for _, tool := range allowedTools {
+		result = append(result, errorObservingTool{Tool: tool})
+	}
+	return result
+}
+
+// Outcome is the result of agent when it fails
+type Outcome struct {
+	// Answer is the give up message with the partial answer
+	Answer string
+	// FailureReason is why the agent fails
+	FailureReason string
+}
+
+// NewOutcome returns the outcome of the agent error, the last observation of steps is used as partial answer.
+func NewOutcome(err error, steps []Step, giveUpMessage string, maxIterations int) Outcome {
+	if giveUpMessage == "" {
+		giveUpMessage = DefaultGiveUpMessage
+	}
+	outcome := Outcome{Answer: giveUpMessage}
+	switch {
+	case errors.Is(err, agents.ErrNotFinished):
+		outcome.FailureReason = fmt.Sprintf("the agent didn't get the answer in %d iterations", maxIterations)
+	case errors.Is(err, agents.ErrUnableToParseOutput):
+		outcome.FailureReason = "the output of llm is not in the expected format: " + err.Error()
+	case errors.Is(err, agents.ErrAgentNoReturn):
+		outcome.FailureReason = "llm neither used a tool nor gave the answer"
+	default:
+		outcome.FailureReason = err.Error()
+	}
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].Error == "" && strings.TrimSpace(steps[i].Observation) != "" {
+			outcome.Answer = fmt.Sprintf("%s\nThe last result of tool %s is:\n%s", giveUpMessage, steps[i].Tool, steps[i].Observation)
+			break
+		}
+	}
+	return outcome
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/tools"
+)
+
+type failingTool struct{}
+
+var _ tools.Tool = failingTool{}
+
+func (failingTool) Name() string        { return "search" }
+func (failingTool) Description() string { return "search the web" }
+func (failingTool) Call(context.Context, string) (string, error) {
+	return "", errors.New("rate limited")
+}
+
+func TestAgentFailure(t *testing.T) {
+	// the agent keeps calling tools until max iterations are reached
+	llm := &scriptedLLM{answers: []string{
+		"Thought: search it\nAction: search\nAction Input: weather",
+		"Thought: try another tool\nAction: weather\nAction Input: Beijing",
+	}}
+	executor, err := agents.Initialize(llm, observeToolErrors([]tools.Tool{failingTool{}, fakeTool{}}), agents.ZeroShotReactDescription,
+		agents.WithMaxIterations(2), agents.WithReturnIntermediateSteps())
+	require.NoError(t, err)
+	response, err := executor.Call(context.Background(), map[string]any{"input": "How is the weather in Beijing?"})
+	require.ErrorIs(t, err, agents.ErrNotFinished)
+
+	steps := StepsOf(response)
+	require.Equal(t, []Step{
+		{Tool: "search", Input: "weather", Observation: toolErrorPrefix + "rate limited", Error: "rate limited"},
+		{Tool: "weather", Input: "Beijing", Observation: "sunny in Beijing"},
+	}, steps)
+
+	outcome := NewOutcome(err, steps, "", 2)
+	require.Equal(t, DefaultGiveUpMessage+"\nThe last result of tool weather is:\nsunny in Beijing", outcome.Answer)
+	require.Equal(t, "the agent didn't get the answer in 2 iterations", outcome.FailureReason)
+
+	outcome = NewOutcome(errors.New("llm is down"), steps[:1], "Please try again later.", 2)
+	require.Equal(t, "Please try again later.", outcome.Answer)
+	require.Equal(t, "llm is down", outcome.FailureReason)
+}
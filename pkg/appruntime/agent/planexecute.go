@@ -0,0 +1,230 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/prompts"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+	"github.com/kubeagi/arcadia/pkg/appruntime/log"
+)
+
+const (
+	planPromptTemplate = `{{.prompt}}
+
+Let's first understand the problem and devise a plan to solve the problem.
+Please output the plan starting with the header "Plan:" and then followed by a numbered list of steps.
+Please make the plan the minimum number of steps required to accurately complete the task.
+If the task is a question, the final step should almost always be "Given the above steps taken, please respond to the user's original question".
+At the end of your plan, say "<END_OF_PLAN>".
+
+The steps can use the following tools:
+
+{{.tool_descriptions}}
+{{.history}}
+Question: {{.input}}`
+
+	stepInputTemplate = `Objective: {{.objective}}
+
+Previous steps and their results:
+{{.previous}}
+
+Current step: {{.step}}`
+
+	planEndMarker = "<END_OF_PLAN>"
+)
+
+// planStepRegexp matches the numbered steps like "1. xxx", "2) xxx" or "3、xxx"
+var planStepRegexp = regexp.MustCompile(`^\s*\d+\s*[.)、:]\s*(.+)$`)
+
+// PlanAndExecuteAgent asks llm to make a plan of steps for the question first,
+// then runs each step with a zero-shot ReAct agent, the result of the last step is the answer.
+type PlanAndExecuteAgent struct {
+	LLM   llms.Model
+	Tools []tools.Tool
+	// Prompt is added to the beginning of planning prompt
+	Prompt string
+	// History is the chat history before this question
+	History schema.ChatMessageHistory
+	// MaxIterations limits the iterations of each step
+	MaxIterations int
+	// CallbacksHandler receives the plan and the streaming content of steps if set
+	CallbacksHandler callbacks.Handler
+}
+
+// PlanAndExecuteResult is the result of PlanAndExecuteAgent
+type PlanAndExecuteResult struct {
+	Plan   []string
+	Steps  []Step
+	Output string
+}
+
+func NewPlanAndExecuteAgent(llm llms.Model, tools []tools.Tool, prompt string, history schema.ChatMessageHistory, maxIterations int, handler callbacks.Handler) *PlanAndExecuteAgent {
+	return &PlanAndExecuteAgent{
+		LLM:              llm,
+		Tools:            tools,
+		Prompt:           prompt,
+		History:          history,
+		MaxIterations:    maxIterations,
+		CallbacksHandler: handler,
+	}
+}
+
+// Run makes the plan and executes it, the result contains the finished steps even if an error is returned.
+func (a *PlanAndExecuteAgent) Run(ctx context.Context, question string) (*PlanAndExecuteResult, error) {
+	result := &PlanAndExecuteResult{}
+	plan, err := a.plan(ctx, question)
+	if err != nil {
+		return result, fmt.Errorf("failed to make a plan: %w", err)
+	}
+	result.Plan = plan
+	if a.CallbacksHandler != nil {
+		handleEvent(ctx, a.CallbacksHandler, base.StreamEventProgress, FormatPlan(plan)+"\n\n")
+	}
+
+	previous := make([]string, 0, len(plan))
+	for i, step := range plan {
+		output, steps, err := a.execute(ctx, question, previous, step)
+		for _, s := range steps {
+			s.Plan = step
+			result.Steps = append(result.Steps, s)
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to execute step %d of plan: %w", i+1, err)
+		}
+		previous = append(previous, fmt.Sprintf("Step: %s\nResult: %s", step, output))
+		result.Output = output
+	}
+	return result, nil
+}
+
+func (a *PlanAndExecuteAgent) plan(ctx context.Context, question string) ([]string, error) {
+	history := ""
+	if a.History != nil {
+		messages, err := a.History.Messages(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) > 0 {
+			buffer, err := schema.GetBufferString(messages, "Human", "AI")
+			if err != nil {
+				return nil, err
+			}
+			history = "\nPrevious conversation:\n" + buffer + "\n"
+		}
+	}
+	prompt, err := prompts.NewPromptTemplate(planPromptTemplate, []string{"prompt", "tool_descriptions", "history", "input"}).Format(map[string]any{
+		"prompt":            a.Prompt,
+		"tool_descriptions": toolDescriptions(a.Tools),
+		"history":           history,
+		"input":             question,
+	})
+	if err != nil {
+		return nil, err
+	}
+	output, err := llms.GenerateFromSinglePrompt(ctx, a.LLM, prompt)
+	if err != nil {
+		return nil, err
+	}
+	plan := ParsePlan(output)
+	if len(plan) == 0 {
+		// llm doesn't give a valid plan, just answer the question in one step
+		plan = []string{question}
+	}
+	return plan, nil
+}
+
+func (a *PlanAndExecuteAgent) execute(ctx context.Context, question string, previous []string, step string) (string, []Step, error) {
+	options := []agents.CreationOption{
+		agents.WithCallbacksHandler(log.KLogHandler{LogLevel: 3}),
+		agents.WithMaxIterations(a.MaxIterations),
+		agents.WithReturnIntermediateSteps(),
+	}
+	if a.CallbacksHandler != nil {
+		options = append(options, agents.WithCallbacksHandler(a.CallbacksHandler))
+	}
+	executor, err := agents.Initialize(a.LLM, a.Tools, agents.ZeroShotReactDescription, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	done := "None"
+	if len(previous) > 0 {
+		done = strings.Join(previous, "\n\n")
+	}
+	input, err := prompts.NewPromptTemplate(stepInputTemplate, []string{"objective", "previous", "step"}).Format(map[string]any{
+		"objective": question,
+		"previous":  done,
+		"step":      step,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	response, err := executor.Call(ctx, map[string]any{"input": input})
+	steps := StepsOf(response)
+	if err != nil {
+		return "", steps, err
+	}
+	output, _ := response["output"].(string)
+	return strings.TrimSpace(output), steps, nil
+}
+
+// ParsePlan parses the numbered steps in the output of llm
+func ParsePlan(text string) []string {
+	if i := strings.Index(text, planEndMarker); i >= 0 {
+		text = text[:i]
+	}
+	if i := strings.Index(text, "Plan:"); i >= 0 {
+		text = text[i+len("Plan:"):]
+	}
+	plan := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if matches := planStepRegexp.FindStringSubmatch(line); len(matches) == 2 {
+			if step := strings.TrimSpace(matches[1]); step != "" {
+				plan = append(plan, step)
+			}
+		}
+	}
+	return plan
+}
+
+// FormatPlan formats the plan as a numbered list
+func FormatPlan(plan []string) string {
+	var b strings.Builder
+	b.WriteString("Plan:")
+	for i, step := range plan {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, step)
+	}
+	return b.String()
+}
+
+func toolDescriptions(tools []tools.Tool) string {
+	var b strings.Builder
+	for _, tool := range tools {
+		fmt.Fprintf(&b, "- %s: %s\n", tool.Name(), tool.Description())
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// scriptedLLM returns the answers in order and records the prompts
+type scriptedLLM struct {
+	answers []string
+	prompts []string
+}
+
+func (f *scriptedLLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, f, prompt, options...)
+}
+
+func (f *scriptedLLM) GenerateContent(_ context.Context, messages []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	var prompt strings.Builder
+	for _, m := range messages {
+		for _, p := range m.Parts {
+			if text, ok := p.(llms.TextContent); ok {
+				prompt.WriteString(text.Text)
+			}
+		}
+	}
+	f.prompts = append(f.prompts, prompt.String())
+	answer := f.answers[0]
+	f.answers = f.answers[1:]
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: answer}}}, nil
+}
+
+type fakeTool struct{}
+
+var _ tools.Tool = fakeTool{}
+
+func (fakeTool) Name() string        { return "weather" }
+func (fakeTool) Description() string { return "get the weather of a city" }
+func (fakeTool) Call(_ context.Context, input string) (string, error) {
+	return "sunny in " + input, nil
+}
+
+type recordHandler struct {
+	callbacks.SimpleHandler
+	chunks *[]string
+}
+
+func (h recordHandler) HandleStreamingFunc(_ context.Context, chunk []byte) {
+	*h.chunks = append(*h.chunks, string(chunk))
+}
+
+func TestParsePlan(t *testing.T) {
+	plan := ParsePlan("Sure.\nPlan:\n1. Get the weather of Beijing\n2) Compare it\n\n3、Given the above steps taken, respond\n<END_OF_PLAN>\n4. ignored")
+	require.Equal(t, []string{"Get the weather of Beijing", "Compare it", "Given the above steps taken, respond"}, plan)
+	require.Empty(t, ParsePlan("I don't know"))
+	require.Equal(t, "Plan:\n1. a\n2. b", FormatPlan([]string{"a", "b"}))
+}
+
+func TestPlanAndExecuteAgent(t *testing.T) {
+	llm := &scriptedLLM{answers: []string{
+		"Plan:\n1. Get the weather of Beijing\n2. Respond to the user\n<END_OF_PLAN>",
+		"Thought: I need the weather\nAction: weather\nAction Input: Beijing",
+		"Thought: I now know the final answer\nFinal Answer: it is sunny",
+		"Thought: I now know the final answer\nFinal Answer: Beijing is sunny today",
+	}}
+	chunks := make([]string, 0)
+	agent := NewPlanAndExecuteAgent(llm, []tools.Tool{fakeTool{}}, "You are a weather assistant.", nil, 5, recordHandler{chunks: &chunks})
+	result, err := agent.Run(context.Background(), "How is the weather in Beijing?")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Get the weather of Beijing", "Respond to the user"}, result.Plan)
+	require.Equal(t, "Beijing is sunny today", result.Output)
+	require.Equal(t, []Step{{Plan: "Get the weather of Beijing", Tool: "weather", Input: "Beijing", Observation: "sunny in Beijing"}}, result.Steps)
+
+	require.Contains(t, llm.prompts[0], "You are a weather assistant.")
+	require.Contains(t, llm.prompts[0], "- weather: get the weather of a city")
+	// the result of first step is passed to the second step
+	require.Contains(t, llm.prompts[3], "Result: it is sunny")
+	require.Contains(t, llm.prompts[3], "Current step: Respond to the user")
+	require.Equal(t, "Plan:\n1. Get the weather of Beijing\n2. Respond to the user\n\n", chunks[0])
+}
@@ -44,6 +44,28 @@ func (handler StreamHandler) HandleStreamingFunc(ctx context.Context, chunk []by
 			return
 		}
 		logger.V(5).Info("stream out:" + string(chunk))
-		streamChan <- string(chunk)
+		select {
+		case streamChan <- string(chunk):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// HandleEvent sends the event to the event stream, or streams its content as a part of the answer if there is no event stream
+func (handler StreamHandler) HandleEvent(ctx context.Context, eventType base.StreamEventType, content string) {
+	if base.SendEvent(ctx, handler.args, base.StreamEvent{Type: eventType, Content: content}) {
+		return
+	}
+	handler.HandleStreamingFunc(ctx, []byte(content))
+}
+
+// handleEvent sends the event by the handler if it supports events, otherwise the content is streamed
+func handleEvent(ctx context.Context, handler callbacks.Handler, eventType base.StreamEventType, content string) {
+	if h, ok := handler.(interface {
+		HandleEvent(ctx context.Context, eventType base.StreamEventType, content string)
+	}); ok {
+		h.HandleEvent(ctx, eventType, content)
+		return
 	}
+	handler.HandleStreamingFunc(ctx, []byte(content))
 }
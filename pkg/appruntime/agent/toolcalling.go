@@ -0,0 +1,236 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/callbacks"
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+	langchaintools "github.com/tmc/langchaingo/tools"
+
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+	"github.com/kubeagi/arcadia/pkg/appruntime/tools"
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+const (
+	toolCallingInputKey  = "input"
+	toolCallingOutputKey = "output"
+)
+
+var ErrEmptyLLMResponse = errors.New("llm returns no choice")
+
+// defaultToolParameters is the schema of tools which only accept a string input
+var defaultToolParameters = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		toolCallingInputKey: map[string]any{
+			"type":        "string",
+			"description": "the input of the tool",
+		},
+	},
+	"required": []string{toolCallingInputKey},
+}
+
+// ToolCallingAgent is an agent which uses the native tool calling of llm
+// instead of parsing the ReAct style text output.
+type ToolCallingAgent struct {
+	LLM   langchainllms.Model
+	Tools []langchaintools.Tool
+	// Prompt is sent to llm as the system message
+	Prompt string
+	// History is the chat history before this question
+	History schema.ChatMessageHistory
+	// CallbacksHandler receives the streaming content and tool calls if set
+	CallbacksHandler callbacks.Handler
+
+	// rounds records the tool calls requested by llm, the steps of executor are the results of them in order
+	rounds []toolCallRound
+}
+
+type toolCallRound struct {
+	content string
+	calls   []llms.ToolCall
+}
+
+var _ agents.Agent = (*ToolCallingAgent)(nil)
+
+func NewToolCallingAgent(llm langchainllms.Model, tools []langchaintools.Tool, prompt string, history schema.ChatMessageHistory, handler callbacks.Handler) *ToolCallingAgent {
+	return &ToolCallingAgent{
+		LLM:              llm,
+		Tools:            tools,
+		Prompt:           prompt,
+		History:          history,
+		CallbacksHandler: handler,
+	}
+}
+
+// Plan asks llm which tools to call with the steps done, returns a finish if no tool is needed any more.
+func (a *ToolCallingAgent) Plan(ctx context.Context, steps []schema.AgentStep, inputs map[string]string) ([]schema.AgentAction, *schema.AgentFinish, error) {
+	messages, err := a.messages(ctx, steps, inputs[toolCallingInputKey])
+	if err != nil {
+		return nil, nil, err
+	}
+	options := []langchainllms.CallOption{langchainllms.WithFunctions(a.functions(steps))}
+	if a.CallbacksHandler != nil {
+		options = append(options, langchainllms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			a.CallbacksHandler.HandleStreamingFunc(ctx, chunk)
+			return nil
+		}))
+	}
+	resp, err := a.LLM.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, nil, ErrEmptyLLMResponse
+	}
+	choice := resp.Choices[0]
+	calls := llms.ToolCallsOf(choice)
+	if len(calls) == 0 {
+		return nil, &schema.AgentFinish{
+			ReturnValues: map[string]any{toolCallingOutputKey: choice.Content},
+			Log:          choice.Content,
+		}, nil
+	}
+	for i := range calls {
+		// some llms don't return the id of tool calls
+		if calls[i].ID == "" {
+			calls[i].ID = fmt.Sprintf("call_%d_%d", len(a.rounds), i)
+		}
+	}
+	a.rounds = append(a.rounds, toolCallRound{content: choice.Content, calls: calls})
+
+	actions := make([]schema.AgentAction, 0, len(calls))
+	for _, call := range calls {
+		log := fmt.Sprintf("Action: %s\nAction Input: %s\n", call.Name, call.Arguments)
+		if a.CallbacksHandler != nil {
+			handleEvent(ctx, a.CallbacksHandler, base.StreamEventToolAction, log)
+		}
+		actions = append(actions, schema.AgentAction{
+			Tool:      call.Name,
+			ToolInput: a.toolInput(call),
+			Log:       log,
+		})
+	}
+	return actions, nil, nil
+}
+
+func (a *ToolCallingAgent) GetInputKeys() []string {
+	return []string{toolCallingInputKey}
+}
+
+func (a *ToolCallingAgent) GetOutputKeys() []string {
+	return []string{toolCallingOutputKey}
+}
+
+// messages builds the messages sent to llm: system prompt, history, question and then the tool calls with their results
+func (a *ToolCallingAgent) messages(ctx context.Context, steps []schema.AgentStep, input string) ([]langchainllms.MessageContent, error) {
+	messages := make([]langchainllms.MessageContent, 0)
+	if a.Prompt != "" {
+		messages = append(messages, langchainllms.TextParts(schema.ChatMessageTypeSystem, a.Prompt))
+	}
+	if a.History != nil {
+		history, err := a.History.Messages(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chat history: %w", err)
+		}
+		for _, msg := range history {
+			messages = append(messages, langchainllms.TextParts(msg.GetType(), msg.GetContent()))
+		}
+	}
+	messages = append(messages, langchainllms.TextParts(schema.ChatMessageTypeHuman, input))
+
+	step := 0
+	for _, round := range a.rounds {
+		messages = append(messages, langchainllms.MessageContent{
+			Role: schema.ChatMessageTypeAI,
+			Parts: []langchainllms.ContentPart{llms.ToolCallPart{
+				TextContent: langchainllms.TextContent{Text: round.content},
+				ToolCalls:   round.calls,
+			}},
+		})
+		for _, call := range round.calls {
+			observation := ""
+			if step < len(steps) {
+				observation = steps[step].Observation
+			}
+			step++
+			messages = append(messages, langchainllms.MessageContent{
+				Role: schema.ChatMessageTypeFunction,
+				Parts: []langchainllms.ContentPart{llms.ToolResultPart{
+					TextContent: langchainllms.TextContent{Text: observation},
+					ToolCallID:  call.ID,
+					Name:        call.Name,
+				}},
+			})
+		}
+	}
+	return messages, nil
+}
+
+// functions returns the definitions of tools which are not used yet, as the executor won't call a tool twice
+func (a *ToolCallingAgent) functions(steps []schema.AgentStep) []langchainllms.FunctionDefinition {
+	used := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		used[step.Action.Tool] = true
+	}
+	functions := make([]langchainllms.FunctionDefinition, 0, len(a.Tools))
+	for _, tool := range a.Tools {
+		if used[tool.Name()] {
+			continue
+		}
+		parameters := defaultToolParameters
+		if t, ok := tool.(tools.ToolWithParameters); ok {
+			parameters = t.Parameters()
+		}
+		functions = append(functions, langchainllms.FunctionDefinition{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  parameters,
+		})
+	}
+	return functions
+}
+
+// toolInput returns the input passed to the tool.
+// Tools with parameters get the raw arguments, others get the value of the input argument.
+func (a *ToolCallingAgent) toolInput(call llms.ToolCall) string {
+	for _, tool := range a.Tools {
+		if tool.Name() != call.Name {
+			continue
+		}
+		if _, ok := tool.(tools.ToolWithParameters); ok {
+			return call.Arguments
+		}
+		break
+	}
+	args := make(map[string]any)
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return call.Arguments
+	}
+	if input, ok := args[toolCallingInputKey].(string); ok {
+		return input
+	}
+	return call.Arguments
+}
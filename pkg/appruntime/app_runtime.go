@@ -36,6 +36,7 @@ import (
 	"github.com/kubeagi/arcadia/pkg/appruntime/base"
 	"github.com/kubeagi/arcadia/pkg/appruntime/chain"
 	"github.com/kubeagi/arcadia/pkg/appruntime/documentloader"
+	"github.com/kubeagi/arcadia/pkg/appruntime/guardrail"
 	"github.com/kubeagi/arcadia/pkg/appruntime/knowledgebase"
 	"github.com/kubeagi/arcadia/pkg/appruntime/llm"
 	"github.com/kubeagi/arcadia/pkg/appruntime/prompt"
@@ -52,10 +53,27 @@ type Input struct {
 	NeedStream     bool
 	History        langchaingoschema.ChatMessageHistory
 	ConversationID string
+	// Approval is the state of paused agent with the decision of user, the agent resumes with it
+	Approval *agent.Approval
+	// Summary is the saved summary of the older rounds of History, used by the summary buffer memory
+	Summary chain.Summary
+	// Events receives the events other than the tokens of answer, like the tool actions of agent.
+	// If it is nil, the events are streamed as a part of the answer.
+	Events chan base.StreamEvent
 }
 type Output struct {
 	Answer     string
 	References []retriever.Reference
+	// Plan is the plan made by plan-and-execute agent
+	Plan []string
+	// IntermediateSteps are the tool calls of agent
+	IntermediateSteps []agent.Step
+	// FailureReason is why the agent fails, the give up message of agent is passed to next nodes instead of its answer
+	FailureReason string
+	// PendingApproval is set when the agent is paused by a tool call which requires approval, the answer is empty
+	PendingApproval *agent.Approval
+	// Summary is the updated summary of the history, it is empty if the summary is not updated
+	Summary chain.Summary
 }
 
 type Application struct {
@@ -148,12 +166,25 @@ func (a *Application) Run(ctx context.Context, cli client.Client, respStream cha
 		base.OutputAnswerStreamChanKeyInArg:        respStream,
 		base.InputIsNeedStreamKeyInArg:             input.NeedStream,
 		base.LangchaingoChatMessageHistoryKeyInArg: input.History,
+		base.ConversationIDInArg:                   input.ConversationID,
+		base.AgentApprovalInArg:                    input.Approval,
+		base.ConversationSummaryInArg:              input.Summary,
+		base.APPNameInArg:                          a.Name,
+		base.APPNamespaceInArg:                     a.Namespace,
 		// Use an empty context before run
 		"context": "",
 	}
 	if a.Spec.DocNullReturn != "" {
 		out[base.APPDocNullReturn] = a.Spec.DocNullReturn
 	}
+	if input.Events != nil {
+		out[base.OutputEventStreamChanKeyInArg] = input.Events
+	}
+	if input.NeedStream && a.hasOutputGuardrail() {
+		// the answer is streamed by the output guardrail after it is checked
+		out[base.InputIsNeedStreamKeyInArg] = false
+		out[base.GuardrailHoldStreamInArg] = true
+	}
 	if input.ConversationID != "" { // means this is not a new conversation
 		conversationKnowledgebaseExist := true
 		kb := &arcadiav1alpha1.KnowledgeBase{}
@@ -194,6 +225,10 @@ func (a *Application) Run(ctx context.Context, cli client.Client, respStream cha
 				waitRunningNodes.PushBack(e)
 				continue
 			}
+			// stop before the next node if the answer is cancelled
+			if err := ctx.Err(); err != nil {
+				return Output{}, err
+			}
 			klog.FromContext(ctx).V(3).Info(fmt.Sprintf("try to run node:%s", e.Name()))
 			defer func() {
 				if r := recover(); r != nil {
@@ -203,6 +238,20 @@ func (a *Application) Run(ctx context.Context, cli client.Client, respStream cha
 			defer e.Cleanup()
 			if out, err = e.Run(ctx, cli, out); err != nil {
 				var er *base.RetrieverGetNullDocError
+				var approvalErr *agent.ApprovalRequiredError
+				if errors.As(err, &approvalErr) {
+					// the rest nodes run after the agent is resumed
+					return Output{PendingApproval: approvalErr.Approval}, nil
+				}
+				var refusedErr *base.GuardrailRefusedError
+				if errors.As(err, &refusedErr) {
+					if input.NeedStream && respStream != nil {
+						go func() {
+							respStream <- refusedErr.Msg
+						}()
+					}
+					return Output{Answer: refusedErr.Msg}, nil
+				}
 				if errors.As(err, &er) {
 					agentReturnNothing := true
 					v, ok := out[base.OutputAnswerKeyInArg]
@@ -239,12 +288,41 @@ func (a *Application) Run(ctx context.Context, cli client.Client, respStream cha
 			output.References = references
 		}
 	}
+	if a, ok := out[base.AgentPlanInArg]; ok {
+		if plan, ok := a.([]string); ok && len(plan) > 0 {
+			output.Plan = plan
+		}
+	}
+	if a, ok := out[base.AgentIntermediateStepsInArg]; ok {
+		if steps, ok := a.([]agent.Step); ok && len(steps) > 0 {
+			output.IntermediateSteps = steps
+		}
+	}
+	if a, ok := out[base.AgentFailureReasonInArg]; ok {
+		if reason, ok := a.(string); ok {
+			output.FailureReason = reason
+		}
+	}
+	if a, ok := out[base.ConversationSummaryInArg]; ok {
+		if summary, ok := a.(chain.Summary); ok && summary != input.Summary {
+			output.Summary = summary
+		}
+	}
 	if output.Answer == "" && respStream == nil {
 		return Output{}, errors.New("no answer")
 	}
 	return output, nil
 }
 
+func (a *Application) hasOutputGuardrail() bool {
+	for _, n := range a.Nodes {
+		if _, ok := n.(*guardrail.OutputGuardrail); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func InitNode(ctx context.Context, appNamespace, name string, ref arcadiav1alpha1.TypedObjectReference) (n base.Node, err error) {
 	logger := klog.FromContext(ctx)
 	defer func() {
@@ -304,6 +382,17 @@ func InitNode(ctx context.Context, appNamespace, name string, ref arcadiav1alpha
 		default:
 			return nil, err
 		}
+	case "guardrail":
+		switch baseNode.Kind() {
+		case "inputguardrail":
+			logger.V(3).Info("initnode inputguardrail")
+			return guardrail.NewInputGuardrail(baseNode), nil
+		case "outputguardrail":
+			logger.V(3).Info("initnode outputguardrail")
+			return guardrail.NewOutputGuardrail(baseNode), nil
+		default:
+			return nil, err
+		}
 	case "prompt":
 		switch baseNode.Kind() {
 		case "prompt":
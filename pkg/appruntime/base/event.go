@@ -0,0 +1,48 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package base
+
+import "context"
+
+// StreamEventType is the type of the events pushed while the answer is generated, besides the tokens of the answer
+type StreamEventType string
+
+const (
+	// StreamEventToolAction is a tool call of agent
+	StreamEventToolAction StreamEventType = "tool_action"
+	// StreamEventProgress is the progress of agent, like the plan or the output of a running tool
+	StreamEventProgress StreamEventType = "progress"
+)
+
+type StreamEvent struct {
+	Type    StreamEventType
+	Content string
+}
+
+// SendEvent sends the event to the event stream in args, it returns false if there is no event stream,
+// then the caller streams the content as a part of the answer instead.
+func SendEvent(ctx context.Context, args map[string]any, event StreamEvent) bool {
+	events, ok := args[OutputEventStreamChanKeyInArg].(chan StreamEvent)
+	if !ok || events == nil {
+		return false
+	}
+	select {
+	case events <- event:
+	case <-ctx.Done():
+	}
+	return true
+}
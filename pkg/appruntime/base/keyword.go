@@ -22,12 +22,22 @@ const (
 	LangchaingoChatMessageHistoryKeyInArg = "_history"
 	OutputAnswerKeyInArg                  = "_answer"
 	AgentOutputInArg                      = "_agent_answer"
+	AgentIntermediateStepsInArg           = "_agent_intermediate_steps"
+	AgentPlanInArg                        = "_agent_plan"
+	AgentFailureReasonInArg               = "_agent_failure_reason"
+	AgentApprovalInArg                    = "_agent_approval"
 	MapReduceDocumentOutputInArg          = "_mapreduce_document_answer"
 	OutputAnswerStreamChanKeyInArg        = "_answer_stream"
+	OutputEventStreamChanKeyInArg         = "_event_stream" // the events other than the tokens of answer, only set by the clients showing them separately
 	RuntimeRetrieverReferencesKeyInArg    = "_references"
 	LangchaingoRetrieverKeyInArg          = "retriever"
 	LangchaingoLLMKeyInArg                = "llm"
 	LangchaingoPromptKeyInArg             = "prompt"
 	APPDocNullReturn                      = "_app_doc_null_return"
 	ConversationKnowledgeBaseInArg        = "_conversation_knowledgebase" // the conversation Knowledgebase cr in args, status has ready
+	ConversationIDInArg                   = "_conversation_id"
+	APPNameInArg                          = "_app_name"
+	APPNamespaceInArg                     = "_app_namespace"
+	GuardrailHoldStreamInArg              = "_guardrail_hold_stream" // the answer is streamed by the output guardrail after it is checked
+	ConversationSummaryInArg              = "_conversation_summary"  // the summary of the older rounds of history, updated by the chains with summary buffer memory
 )
@@ -127,3 +127,11 @@ type RetrieverGetNullDocError struct {
 }
 
 func (e *RetrieverGetNullDocError) Error() string { return e.Msg }
+
+// GuardrailRefusedError is returned by the guardrails, the refusal message is the answer of the app
+type GuardrailRefusedError struct {
+	Msg    string
+	Reason string
+}
+
+func (e *GuardrailRefusedError) Error() string { return "refused by guardrail: " + e.Reason }
@@ -87,6 +87,9 @@ func (l *APIChain) Run(ctx context.Context, _ client.Client, args map[string]any
 	instance := l.Instance
 	options := GetChainOptions(instance.Spec.CommonChainConfig)
 
+	if history, err = SummarizeHistory(ctx, llm, instance.Spec.Memory, history, args); err != nil {
+		return args, err
+	}
 	chain := chains.NewAPIChain(llm, http.DefaultClient)
 	chain.RequestChain.Memory = GetMemory(llm, instance.Spec.Memory, history, "", "")
 	chain.AnswerChain.Memory = GetMemory(llm, instance.Spec.Memory, history, "input", "")
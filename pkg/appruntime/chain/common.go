@@ -42,7 +42,12 @@ func stream(res map[string]any) func(ctx context.Context, chunk []byte) error {
 				return err
 			}
 			logger.V(5).Info("stream out:" + string(chunk))
-			streamChan <- string(chunk)
+			select {
+			case streamChan <- string(chunk):
+			case <-ctx.Done():
+				// stop the streaming of llm when the answer is cancelled
+				return ctx.Err()
+			}
 		}
 		return nil
 	}
@@ -90,6 +95,10 @@ func GetMemory(llm llms.Model, config v1alpha1.Memory, history langchaingoschema
 	if outputKey == "" {
 		outputKey = "text"
 	}
+	if config.SummaryBuffer != nil {
+		// the older rounds are already replaced by the summary, see SummarizeHistory
+		return memory.NewConversationBuffer(memory.WithInputKey(inputKey), memory.WithOutputKey(outputKey), memory.WithChatHistory(history))
+	}
 	if config.MaxTokenLimit > 0 {
 		return memory.NewConversationTokenBuffer(llm, config.MaxTokenLimit, memory.WithInputKey(inputKey), memory.WithOutputKey(outputKey), memory.WithChatHistory(history))
 	}
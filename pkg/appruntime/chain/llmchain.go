@@ -64,6 +64,7 @@ func (l *LLMChain) Run(ctx context.Context, cli client.Client, args map[string]a
 				CommonChainConfig: v1alpha1.CommonChainConfig{
 					Memory: v1alpha1.Memory{
 						ConversionWindowSize: pointer.Int(v1alpha1.DefaultConversionWindowSize),
+						SummaryBuffer:        l.Instance.Spec.Memory.SummaryBuffer,
 					},
 				},
 			},
@@ -130,6 +131,9 @@ func (l *LLMChain) Run(ctx context.Context, cli client.Client, args map[string]a
 		args["context"] = fmt.Sprintf("%s\n%s", args["context"], args[base.MapReduceDocumentOutputInArg])
 	}
 
+	if history, err = SummarizeHistory(ctx, llm, instance.Spec.Memory, history, args); err != nil {
+		return args, err
+	}
 	chain := chains.NewLLMChain(llm, prompt)
 	if history != nil {
 		chain.Memory = GetMemory(llm, instance.Spec.Memory, history, "", "")
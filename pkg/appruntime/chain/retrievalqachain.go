@@ -158,6 +158,9 @@ func (l *RetrievalQAChain) Run(ctx context.Context, cli client.Client, args map[
 		retriever = &appruntimeretriever.Fakeretriever{Docs: []langchainschema.Document{doc}, Name: "AddMapReduceOutputRetriever"}
 	}
 
+	if history, err = SummarizeHistory(ctx, llm, instance.Spec.Memory, history, args); err != nil {
+		return args, err
+	}
 	llmChain := chains.NewLLMChain(llm, prompt)
 	if history != nil {
 		llmChain.Memory = GetMemory(llm, instance.Spec.Memory, history, "", "")
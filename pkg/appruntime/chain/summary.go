@@ -0,0 +1,110 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	langchaingoschema "github.com/tmc/langchaingo/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeagi/arcadia/api/app-node/chain/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+)
+
+const summaryPrompt = `Progressively summarize the lines of conversation provided, adding onto the previous summary and returning a new summary.
+Keep the names, numbers and facts the later questions may refer to, and write the summary in the language of the conversation.
+
+Current summary:
+%s
+
+New lines of conversation:
+%s
+
+New summary:`
+
+// Summary is the summary of the first Rounds rounds of the conversation history, a round is a question with its answer
+type Summary struct {
+	Text   string
+	Rounds int
+}
+
+// SummarizeHistory returns the history with the summary in place of the older rounds if the summary buffer is configured,
+// otherwise the history is returned as it is.
+// The rounds beyond the recent ones are added to the summary in args[base.ConversationSummaryInArg] by the llm,
+// if it fails, the rounds are kept and summarized next time.
+func SummarizeHistory(ctx context.Context, llm llms.Model, config v1alpha1.Memory, history langchaingoschema.ChatMessageHistory, args map[string]any) (langchaingoschema.ChatMessageHistory, error) {
+	if config.SummaryBuffer == nil || history == nil {
+		return history, nil
+	}
+	messages, err := history.Messages(ctx)
+	if err != nil {
+		return history, err
+	}
+	summary, _ := args[base.ConversationSummaryInArg].(Summary)
+	rounds := len(messages) / 2
+	if summary.Rounds > rounds {
+		// the summary is not of this history
+		summary = Summary{}
+	}
+	recent := config.SummaryBuffer.RecentRounds
+	if recent <= 0 {
+		recent = v1alpha1.DefaultConversionWindowSize
+	}
+	if rounds-summary.Rounds > recent {
+		text, err := summarize(ctx, llm, summary.Text, messages[summary.Rounds*2:(rounds-recent)*2])
+		if err != nil {
+			klog.FromContext(ctx).Error(err, "failed to summarize the conversation history, keep the rounds as they are")
+		} else {
+			summary = Summary{Text: text, Rounds: rounds - recent}
+			args[base.ConversationSummaryInArg] = summary
+		}
+	}
+	res := memory.NewChatMessageHistory()
+	if summary.Text != "" {
+		if err := res.AddMessage(ctx, langchaingoschema.SystemChatMessage{Content: "Summary of the earlier conversation: " + summary.Text}); err != nil {
+			return history, err
+		}
+	}
+	for _, m := range messages[summary.Rounds*2:] {
+		if err := res.AddMessage(ctx, m); err != nil {
+			return history, err
+		}
+	}
+	return res, nil
+}
+
+func summarize(ctx context.Context, llm llms.Model, summary string, messages []langchaingoschema.ChatMessage) (string, error) {
+	lines, err := langchaingoschema.GetBufferString(messages, "Human", "AI")
+	if err != nil {
+		return "", err
+	}
+	res, err := llms.GenerateFromSinglePrompt(ctx, llm, fmt.Sprintf(summaryPrompt, summary, lines), llms.WithTemperature(0))
+	if err != nil {
+		return "", err
+	}
+	res = strings.TrimSpace(res)
+	if res == "" {
+		return "", errors.New("empty summary")
+	}
+	return res, nil
+}
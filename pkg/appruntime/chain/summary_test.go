@@ -0,0 +1,168 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/memory"
+	langchaingoschema "github.com/tmc/langchaingo/schema"
+
+	"github.com/kubeagi/arcadia/api/app-node/chain/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+)
+
+// fakeLLM returns the answer and records the prompts
+type fakeLLM struct {
+	answer  string
+	err     error
+	prompts []string
+}
+
+func (f *fakeLLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, f, prompt, options...)
+}
+
+func (f *fakeLLM) GenerateContent(_ context.Context, messages []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	for _, m := range messages {
+		for _, p := range m.Parts {
+			if text, ok := p.(llms.TextContent); ok {
+				f.prompts = append(f.prompts, text.Text)
+			}
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: f.answer}}}, nil
+}
+
+func newHistory(t *testing.T, rounds int) langchaingoschema.ChatMessageHistory {
+	history := memory.NewChatMessageHistory()
+	for i := 1; i <= rounds; i++ {
+		if err := history.AddUserMessage(context.Background(), fmt.Sprintf("q%d", i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := history.AddAIMessage(context.Background(), fmt.Sprintf("a%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return history
+}
+
+func contents(t *testing.T, history langchaingoschema.ChatMessageHistory) []string {
+	messages, err := history.Messages(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := make([]string, 0, len(messages))
+	for _, m := range messages {
+		res = append(res, m.GetContent())
+	}
+	return res
+}
+
+func TestSummarizeHistory(t *testing.T) {
+	ctx := context.Background()
+	config := v1alpha1.Memory{SummaryBuffer: &v1alpha1.SummaryBuffer{RecentRounds: 2}}
+
+	t.Run("not configured", func(t *testing.T) {
+		history := newHistory(t, 5)
+		res, err := SummarizeHistory(ctx, &fakeLLM{}, v1alpha1.Memory{}, history, map[string]any{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res != history {
+			t.Error("the history should be returned as it is")
+		}
+	})
+
+	t.Run("recent rounds only", func(t *testing.T) {
+		llm := &fakeLLM{answer: "unused"}
+		args := map[string]any{}
+		res, err := SummarizeHistory(ctx, llm, config, newHistory(t, 2), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(llm.prompts) != 0 {
+			t.Errorf("llm should not be called, got %d prompts", len(llm.prompts))
+		}
+		if got := strings.Join(contents(t, res), ","); got != "q1,a1,q2,a2" {
+			t.Errorf("history = %s", got)
+		}
+		if _, ok := args[base.ConversationSummaryInArg]; ok {
+			t.Error("summary should not be set")
+		}
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		llm := &fakeLLM{answer: " new summary "}
+		args := map[string]any{base.ConversationSummaryInArg: Summary{Text: "old summary", Rounds: 1}}
+		res, err := SummarizeHistory(ctx, llm, config, newHistory(t, 4), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(llm.prompts) != 1 {
+			t.Fatalf("llm should be called once, got %d prompts", len(llm.prompts))
+		}
+		prompt := llm.prompts[0]
+		if !strings.Contains(prompt, "old summary") || !strings.Contains(prompt, "Human: q2\nAI: a2") || strings.Contains(prompt, "q1") || strings.Contains(prompt, "q3") {
+			t.Errorf("only the second round should be summarized onto the old summary, prompt: %s", prompt)
+		}
+		if got := args[base.ConversationSummaryInArg]; got != (Summary{Text: "new summary", Rounds: 2}) {
+			t.Errorf("summary = %#v", got)
+		}
+		got := contents(t, res)
+		if len(got) != 5 || !strings.HasSuffix(got[0], "new summary") || strings.Join(got[1:], ",") != "q3,a3,q4,a4" {
+			t.Errorf("history = %v", got)
+		}
+	})
+
+	t.Run("summary of another branch", func(t *testing.T) {
+		llm := &fakeLLM{answer: "branch summary"}
+		args := map[string]any{base.ConversationSummaryInArg: Summary{Text: "old summary", Rounds: 5}}
+		if _, err := SummarizeHistory(ctx, llm, config, newHistory(t, 3), args); err != nil {
+			t.Fatal(err)
+		}
+		if len(llm.prompts) != 1 || strings.Contains(llm.prompts[0], "old summary") {
+			t.Errorf("the summary should be started over, prompts: %v", llm.prompts)
+		}
+		if got := args[base.ConversationSummaryInArg]; got != (Summary{Text: "branch summary", Rounds: 1}) {
+			t.Errorf("summary = %#v", got)
+		}
+	})
+
+	t.Run("llm fails", func(t *testing.T) {
+		summary := Summary{Text: "old summary", Rounds: 1}
+		args := map[string]any{base.ConversationSummaryInArg: summary}
+		res, err := SummarizeHistory(ctx, &fakeLLM{err: errors.New("unavailable")}, config, newHistory(t, 4), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if args[base.ConversationSummaryInArg] != summary {
+			t.Errorf("summary should not change, got %#v", args[base.ConversationSummaryInArg])
+		}
+		if got := contents(t, res); len(got) != 7 || strings.Join(got[1:], ",") != "q2,a2,q3,a3,q4,a4" {
+			t.Errorf("the rounds not summarized should be kept, history = %v", got)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiguardrail "github.com/kubeagi/arcadia/api/app-node/guardrail/v1alpha1"
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/langchainwrap"
+)
+
+type blocklist struct {
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+func newBlocklist(b *apiguardrail.Blocklist) (*blocklist, error) {
+	patterns, err := b.Compile()
+	if err != nil {
+		return nil, err
+	}
+	res := &blocklist{patterns: patterns}
+	if b != nil {
+		for _, k := range b.Keywords {
+			if k = strings.TrimSpace(k); k != "" {
+				res.keywords = append(res.keywords, strings.ToLower(k))
+			}
+		}
+	}
+	return res, nil
+}
+
+// match returns the reason if the text is blocked, or an empty string
+func (b *blocklist) match(text string) string {
+	lower := strings.ToLower(text)
+	for _, k := range b.keywords {
+		if strings.Contains(lower, k) {
+			return fmt.Sprintf("keyword %q is blocked", k)
+		}
+	}
+	for _, p := range b.patterns {
+		if p.MatchString(text) {
+			return fmt.Sprintf("pattern %q is blocked", p.String())
+		}
+	}
+	return ""
+}
+
+func getLLM(ctx context.Context, cli client.Client, ref *v1alpha1.TypedObjectReference, namespace, model string) (langchainllms.Model, error) {
+	llm := &v1alpha1.LLM{}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: ref.GetNamespace(namespace), Name: ref.Name}, llm); err != nil {
+		return nil, fmt.Errorf("can't find the llm in cluster: %w", err)
+	}
+	res, err := langchainwrap.GetLangchainLLM(ctx, llm, cli, model)
+	if err != nil {
+		return nil, fmt.Errorf("can't convert to langchain llm: %w", err)
+	}
+	return res, nil
+}
+
+// askYesNo asks the llm a yes or no question, anything other than yes is no so that the guardrail fails closed
+func askYesNo(ctx context.Context, llm langchainllms.Model, prompt string) (bool, error) {
+	answer, err := langchainllms.GenerateFromSinglePrompt(ctx, llm, prompt, langchainllms.WithTemperature(0))
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.Trim(strings.TrimSpace(answer), "\"'`.。*"))
+	return strings.HasPrefix(answer, "yes") || strings.HasPrefix(answer, "是"), nil
+}
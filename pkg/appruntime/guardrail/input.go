@@ -0,0 +1,122 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiguardrail "github.com/kubeagi/arcadia/api/app-node/guardrail/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+)
+
+const topicPrompt = `Decide whether the question below is about any of the allowed topics.
+
+Allowed topics:
+%s
+
+Question:
+"""
+%s
+"""
+
+Reply only yes or no.`
+
+// InputGuardrail refuses the questions by the blocklist and the topic allowlist before they reach the chain
+type InputGuardrail struct {
+	base.BaseNode
+	Instance  *apiguardrail.InputGuardrail
+	blocklist *blocklist
+	// classifier checks the topics, nil if there is no topic allowlist
+	classifier langchainllms.Model
+}
+
+func NewInputGuardrail(baseNode base.BaseNode) *InputGuardrail {
+	return &InputGuardrail{
+		BaseNode: baseNode,
+	}
+}
+
+func (g *InputGuardrail) Init(ctx context.Context, cli client.Client, _ map[string]any) (err error) {
+	instance := &apiguardrail.InputGuardrail{}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: g.RefNamespace(), Name: g.BaseNode.Ref.Name}, instance); err != nil {
+		return fmt.Errorf("can't find the input guardrail in cluster: %w", err)
+	}
+	g.Instance = instance
+	if g.blocklist, err = newBlocklist(instance.Spec.Blocklist); err != nil {
+		return err
+	}
+	if topics := instance.Spec.TopicAllowlist; topics != nil && len(topics.Topics) > 0 {
+		if topics.LLM == nil {
+			return errors.New("no llm to classify the topics")
+		}
+		if g.classifier, err = getLLM(ctx, cli, topics.LLM, g.RefNamespace(), topics.Model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *InputGuardrail) Run(ctx context.Context, _ client.Client, args map[string]any) (map[string]any, error) {
+	question, ok := args[base.InputQuestionKeyInArg].(string)
+	if !ok {
+		return args, errors.New("no question in args")
+	}
+	reason, err := g.check(ctx, question)
+	if err != nil {
+		return args, err
+	}
+	if reason != "" {
+		klog.FromContext(ctx).Info("question is refused by input guardrail", "guardrail", g.Ref.Name, "reason", reason)
+		return args, &base.GuardrailRefusedError{Msg: g.Instance.Spec.GetRefusalMessage(), Reason: reason}
+	}
+	return args, nil
+}
+
+// check returns why the question is refused, or an empty string if it passes
+func (g *InputGuardrail) check(ctx context.Context, question string) (string, error) {
+	if reason := g.blocklist.match(question); reason != "" {
+		return reason, nil
+	}
+	if g.classifier == nil {
+		return "", nil
+	}
+	topics := g.Instance.Spec.TopicAllowlist.Topics
+	list := make([]string, len(topics))
+	for i, t := range topics {
+		list[i] = "- " + t
+	}
+	allowed, err := askYesNo(ctx, g.classifier, fmt.Sprintf(topicPrompt, strings.Join(list, "\n"), question))
+	if err != nil {
+		return "", fmt.Errorf("failed to classify the topic of question: %w", err)
+	}
+	if !allowed {
+		return "question is not about the allowed topics", nil
+	}
+	return "", nil
+}
+
+func (g *InputGuardrail) Ready() (isReady bool, msg string) {
+	return g.Instance.Status.IsReadyOrGetReadyMessage()
+}
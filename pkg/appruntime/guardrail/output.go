@@ -0,0 +1,206 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiguardrail "github.com/kubeagi/arcadia/api/app-node/guardrail/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+)
+
+const groundingPrompt = `Decide whether every statement of the answer below is supported by the references.
+
+References:
+"""
+%s
+"""
+
+Answer:
+"""
+%s
+"""
+
+Reply only yes or no.`
+
+// OutputGuardrail refuses the answers of the chain by the blocklist and the grounding check.
+// The chains before it do not stream, the answer is streamed by it after the check.
+type OutputGuardrail struct {
+	base.BaseNode
+	Instance  *apiguardrail.OutputGuardrail
+	blocklist *blocklist
+	// judge checks the grounding, nil if the words are looked up instead
+	judge langchainllms.Model
+}
+
+func NewOutputGuardrail(baseNode base.BaseNode) *OutputGuardrail {
+	return &OutputGuardrail{
+		BaseNode: baseNode,
+	}
+}
+
+func (g *OutputGuardrail) Init(ctx context.Context, cli client.Client, _ map[string]any) (err error) {
+	instance := &apiguardrail.OutputGuardrail{}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: g.RefNamespace(), Name: g.BaseNode.Ref.Name}, instance); err != nil {
+		return fmt.Errorf("can't find the output guardrail in cluster: %w", err)
+	}
+	g.Instance = instance
+	if g.blocklist, err = newBlocklist(instance.Spec.Blocklist); err != nil {
+		return err
+	}
+	if grounding := instance.Spec.Grounding; grounding != nil && grounding.LLM != nil {
+		if g.judge, err = getLLM(ctx, cli, grounding.LLM, g.RefNamespace(), grounding.Model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *OutputGuardrail) Run(ctx context.Context, _ client.Client, args map[string]any) (map[string]any, error) {
+	answer, ok := args[base.OutputAnswerKeyInArg].(string)
+	if !ok {
+		return args, errors.New("no answer in args")
+	}
+	references, _ := args[base.RuntimeRetrieverReferencesKeyInArg].([]retriever.Reference)
+	reason, err := g.check(ctx, answer, references)
+	if err != nil {
+		return args, err
+	}
+	if reason != "" {
+		klog.FromContext(ctx).Info("answer is refused by output guardrail", "guardrail", g.Ref.Name, "reason", reason)
+		return args, &base.GuardrailRefusedError{Msg: g.Instance.Spec.GetRefusalMessage(), Reason: reason}
+	}
+	if hold, _ := args[base.GuardrailHoldStreamInArg].(bool); hold {
+		if stream, ok := args[base.OutputAnswerStreamChanKeyInArg].(chan string); ok && stream != nil {
+			select {
+			case stream <- answer:
+			case <-ctx.Done():
+				return args, ctx.Err()
+			}
+		}
+	}
+	return args, nil
+}
+
+// check returns why the answer is refused, or an empty string if it passes
+func (g *OutputGuardrail) check(ctx context.Context, answer string, references []retriever.Reference) (string, error) {
+	if reason := g.blocklist.match(answer); reason != "" {
+		return reason, nil
+	}
+	grounding := g.Instance.Spec.Grounding
+	if grounding == nil {
+		return "", nil
+	}
+	if len(references) == 0 {
+		return "no reference to ground the answer", nil
+	}
+	texts := make([]string, 0, len(references))
+	for _, r := range references {
+		texts = append(texts, referenceText(r))
+	}
+	if g.judge != nil {
+		grounded, err := askYesNo(ctx, g.judge, fmt.Sprintf(groundingPrompt, strings.Join(texts, "\n\n"), answer))
+		if err != nil {
+			return "", fmt.Errorf("failed to check the grounding of answer: %w", err)
+		}
+		if !grounded {
+			return "answer is not supported by the references", nil
+		}
+		return "", nil
+	}
+	if score := GroundingScore(answer, texts); score < grounding.MinScore {
+		return fmt.Sprintf("grounding score %.2f is less than %.2f", score, grounding.MinScore), nil
+	}
+	return "", nil
+}
+
+func (g *OutputGuardrail) Ready() (isReady bool, msg string) {
+	return g.Instance.Status.IsReadyOrGetReadyMessage()
+}
+
+func referenceText(r retriever.Reference) string {
+	return strings.TrimSpace(strings.Join([]string{r.Question, r.Answer, r.Content}, "\n"))
+}
+
+// GroundingScore returns the ratio of the distinct words of the answer found in the references.
+// The words are the runs of letters and digits, and the characters of CJK text are paired as bigrams since they are not separated by spaces.
+func GroundingScore(answer string, references []string) float64 {
+	words := terms(answer)
+	if len(words) == 0 {
+		return 1
+	}
+	known := make(map[string]bool)
+	for _, r := range references {
+		for w := range terms(r) {
+			known[w] = true
+		}
+	}
+	found := 0
+	for w := range words {
+		if known[w] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(words))
+}
+
+func terms(text string) map[string]bool {
+	res := make(map[string]bool)
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			res[string(word)] = true
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			res[string(cjk)] = true
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			res[string(cjk[i:i+2])] = true
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return res
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tmc/langchaingo/tools"
+	"github.com/tmc/langchaingo/tools/scraper"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/log"
+	"github.com/kubeagi/arcadia/pkg/tools/openapi"
+	"github.com/kubeagi/arcadia/pkg/tools/weather"
+)
+
+// the tools which don't depend on the app runtime, other tools register themselves in their own files
+func init() {
+	Register(weather.ToolName, NewImplementation([]Parameter{
+		{Name: "apiKey", Type: ParameterString, Required: true},
+	}, func(ctx context.Context, options Options) ([]tools.Tool, error) {
+		tool, err := weather.New(specOf(weather.ToolName, options))
+		if err != nil {
+			return nil, err
+		}
+		tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+		return []tools.Tool{tool}, nil
+	}))
+	Register(openapi.ToolName, NewImplementation([]Parameter{
+		{Name: openapi.ParamConfigMap, Type: ParameterString},
+		{Name: openapi.ParamConfigMapKey, Type: ParameterString},
+		{Name: openapi.ParamBucket, Type: ParameterString},
+		{Name: openapi.ParamObject, Type: ParameterString},
+		{Name: openapi.ParamServer, Type: ParameterString},
+		{Name: openapi.ParamAllowedHosts, Type: ParameterString},
+		{Name: openapi.ParamAuthSecret, Type: ParameterString},
+		{Name: openapi.ParamOperations, Type: ParameterString},
+		{Name: openapi.ParamTimeout, Type: ParameterInteger},
+		{Name: openapi.ParamMaxResponseLength, Type: ParameterInteger},
+	}, func(ctx context.Context, options Options) ([]tools.Tool, error) {
+		operations, err := openapi.New(ctx, options.Client, options.Namespace, specOf(openapi.ToolName, options))
+		if err != nil {
+			return nil, err
+		}
+		result := make([]tools.Tool, 0, len(operations))
+		for _, tool := range operations {
+			tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+			result = append(result, tool)
+		}
+		return result, nil
+	}))
+	Register(tools.Calculator{}.Name(), NewImplementation(nil, func(ctx context.Context, options Options) ([]tools.Tool, error) {
+		tool := tools.Calculator{}
+		tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+		return []tools.Tool{tool}, nil
+	}))
+	Register(scraper.Scraper{}.Name(), NewImplementation([]Parameter{
+		{Name: "delay", Type: ParameterInteger},
+		{Name: "async", Type: ParameterBoolean},
+		{Name: "handleLinks", Type: ParameterBoolean},
+		{Name: "blacklist", Type: ParameterString},
+		{Name: "maxScrapedDataLength", Type: ParameterInteger},
+	}, newScraper))
+}
+
+// specOf returns the tool spec which is accepted by the constructors of tools
+func specOf(name string, options Options) *v1alpha1.AllowedTool {
+	return &v1alpha1.AllowedTool{Name: name, Params: options.Params}
+}
+
+func newScraper(ctx context.Context, options Options) ([]tools.Tool, error) {
+	params := options.Params
+	scraperOptions := make([]scraper.Options, 0)
+	if params["delay"] != "" {
+		delay, err := strconv.ParseInt(params["delay"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse delay %s: %w", params["delay"], err)
+		}
+		scraperOptions = append(scraperOptions, scraper.WithDelay(delay))
+	}
+	if params["async"] != "" {
+		async, err := strconv.ParseBool(params["async"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse async %s: %w", params["async"], err)
+		}
+		scraperOptions = append(scraperOptions, scraper.WithAsync(async))
+	}
+	if params["handleLinks"] != "" {
+		handleLinks, err := strconv.ParseBool(params["handleLinks"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse handleLinks %s: %w", params["handleLinks"], err)
+		}
+		scraperOptions = append(scraperOptions, scraper.WithHandleLinks(handleLinks))
+	}
+	if params["blacklist"] != "" {
+		blacklistArray := strings.Split(params["blacklist"], ",")
+		scraperOptions = append(scraperOptions, scraper.WithBlacklist(blacklistArray))
+	}
+	if params["maxScrapedDataLength"] != "" {
+		maxScrapedDataLength, err := strconv.Atoi(params["maxScrapedDataLength"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse maxScrapedDataLength %s: %w", params["maxScrapedDataLength"], err)
+		}
+		scraperOptions = append(scraperOptions, scraper.WithMaxScrapedDataLength(maxScrapedDataLength))
+	}
+	tool, err := scraper.New(scraperOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return []tools.Tool{tool}, nil
+}
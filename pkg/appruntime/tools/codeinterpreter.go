@@ -0,0 +1,104 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/tools"
+
+	agentv1alpha1 "github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+	"github.com/kubeagi/arcadia/pkg/appruntime/log"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+	"github.com/kubeagi/arcadia/pkg/tools/codeinterpreter"
+)
+
+// ToolWithArgs is a tool which needs the args of the app runtime, like the conversation id
+type ToolWithArgs interface {
+	tools.Tool
+	SetArgs(args map[string]any)
+}
+
+// ToolWithProgress is a tool which reports its progress, it is streamed to the chat if ShowToolAction is enabled
+type ToolWithProgress interface {
+	tools.Tool
+	SetProgress(progress func(ctx context.Context, message string))
+}
+
+// codeInterpreterTool mounts the uploaded files of the conversation into the sandbox,
+// and adds the generated files as references of the chat
+type codeInterpreterTool struct {
+	*codeinterpreter.Tool
+}
+
+var (
+	_ ToolWithReferences = &codeInterpreterTool{}
+	_ ToolWithArgs       = &codeInterpreterTool{}
+	_ ToolWithProgress   = &codeInterpreterTool{}
+)
+
+func init() {
+	Register(codeinterpreter.ToolName, NewImplementation([]Parameter{
+		{Name: codeinterpreter.ParamLanguage, Type: ParameterString},
+		{Name: codeinterpreter.ParamPythonImage, Type: ParameterString},
+		{Name: codeinterpreter.ParamNodeImage, Type: ParameterString},
+		{Name: codeinterpreter.ParamCPU, Type: ParameterString},
+		{Name: codeinterpreter.ParamMemory, Type: ParameterString},
+		{Name: codeinterpreter.ParamTimeout, Type: ParameterInteger},
+	}, func(ctx context.Context, options Options) ([]tools.Tool, error) {
+		tool, err := codeinterpreter.New(options.Client, options.Namespace, &agentv1alpha1.AllowedTool{Name: codeinterpreter.ToolName, Params: options.Params})
+		if err != nil {
+			return nil, err
+		}
+		tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+		return []tools.Tool{&codeInterpreterTool{tool}}, nil
+	}))
+}
+
+func (t *codeInterpreterTool) SetArgs(args map[string]any) {
+	appName, _ := args[base.APPNameInArg].(string)
+	appNamespace, _ := args[base.APPNamespaceInArg].(string)
+	conversationID, _ := args[base.ConversationIDInArg].(string)
+	if appName == "" || appNamespace == "" || conversationID == "" {
+		return
+	}
+	// uploaded files are stored in the bucket of app namespace, see chat_docs.go in apiserver
+	t.InputBucket = appNamespace
+	t.InputPrefix = v1alpha1.ConversationFilePath(appName, conversationID, "")
+	t.OutputBucket = appNamespace
+	t.OutputPrefix = v1alpha1.ConversationFilePath(appName, conversationID, "code-interpreter/")
+}
+
+func (t *codeInterpreterTool) SetProgress(progress func(ctx context.Context, message string)) {
+	t.Progress = progress
+}
+
+func (t *codeInterpreterTool) References() []retriever.Reference {
+	files := t.GeneratedFiles()
+	refs := make([]retriever.Reference, 0, len(files))
+	for _, f := range files {
+		refs = append(refs, retriever.Reference{
+			Title:    fmt.Sprintf("File generated by %s", codeinterpreter.ToolName),
+			FileName: f.Name,
+			Content:  fmt.Sprintf("%s/%s", f.Bucket, f.Object),
+		})
+	}
+	return refs
+}
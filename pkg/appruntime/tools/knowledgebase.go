@@ -0,0 +1,211 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/tools"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentv1alpha1 "github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	apiretriever "github.com/kubeagi/arcadia/api/app-node/retriever/v1alpha1"
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+	"github.com/kubeagi/arcadia/pkg/appruntime/log"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+)
+
+const (
+	KnowledgeBaseToolName = "KnowledgeBase"
+
+	ParamKnowledgeBaseName      = "name"
+	ParamKnowledgeBaseNamespace = "namespace"
+	ParamNumDocuments           = "numDocuments"
+	ParamScoreThreshold         = "scoreThreshold"
+
+	defaultNumDocuments = 5
+)
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// ToolWithReferences is a tool which records the references of its results,
+// they are added to the references of the chat after the agent finishes.
+type ToolWithReferences interface {
+	tools.Tool
+	References() []retriever.Reference
+}
+
+// KnowledgeBaseTool searches a knowledgebase for the input
+type KnowledgeBaseTool struct {
+	cli             client.Client
+	name            string
+	namespace       string
+	description     string
+	retrieverConfig apiretriever.CommonRetrieverConfig
+	// retrieve is retriever.GenerateKnowledgebaseRetriever, it can be replaced in tests
+	retrieve func(ctx context.Context, cli client.Client, knowledgebaseName, knowledgebaseNamespace string, retrieverConfig apiretriever.CommonRetrieverConfig, args map[string]any) (map[string]any, func(), error)
+
+	mu         sync.Mutex
+	references []retriever.Reference
+
+	CallbacksHandler callbacks.Handler
+}
+
+var _ ToolWithReferences = &KnowledgeBaseTool{}
+
+func init() {
+	Register(KnowledgeBaseToolName, NewImplementation([]Parameter{
+		{Name: ParamKnowledgeBaseName, Type: ParameterString, Required: true},
+		{Name: ParamKnowledgeBaseNamespace, Type: ParameterString},
+		{Name: ParamNumDocuments, Type: ParameterInteger},
+		{Name: ParamScoreThreshold, Type: ParameterNumber},
+	}, func(ctx context.Context, options Options) ([]tools.Tool, error) {
+		tool, err := NewKnowledgeBaseTool(ctx, options.Client, options.Namespace, &agentv1alpha1.AllowedTool{Name: KnowledgeBaseToolName, Params: options.Params})
+		if err != nil {
+			return nil, err
+		}
+		tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+		return []tools.Tool{tool}, nil
+	}))
+}
+
+// NewKnowledgeBaseTool creates a tool for the knowledgebase in the params.
+// The knowledgebase must be in the namespace of the agent, the knowledgebase namespace can only be the same one if it is set.
+func NewKnowledgeBaseTool(ctx context.Context, cli client.Client, namespace string, toolSpec *agentv1alpha1.AllowedTool) (*KnowledgeBaseTool, error) {
+	name := toolSpec.Params[ParamKnowledgeBaseName]
+	if name == "" {
+		return nil, errors.New("knowledgebase name is required")
+	}
+	if ns := toolSpec.Params[ParamKnowledgeBaseNamespace]; ns != "" && ns != namespace {
+		return nil, fmt.Errorf("knowledgebase %s/%s is not in the namespace %s of the agent", ns, name, namespace)
+	}
+	kb := &v1alpha1.KnowledgeBase{}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, kb); err != nil {
+		return nil, fmt.Errorf("can't find the knowledgebase in cluster: %w", err)
+	}
+	t := &KnowledgeBaseTool{
+		cli:       cli,
+		name:      name,
+		namespace: namespace,
+		retrieve:  retriever.GenerateKnowledgebaseRetriever,
+		retrieverConfig: apiretriever.CommonRetrieverConfig{
+			NumDocuments: defaultNumDocuments,
+		},
+	}
+	if v := toolSpec.Params[ParamNumDocuments]; v != "" {
+		num, err := strconv.Atoi(v)
+		if err != nil || num < 1 {
+			return nil, fmt.Errorf("invalid numDocuments %s", v)
+		}
+		t.retrieverConfig.NumDocuments = num
+	}
+	if v := toolSpec.Params[ParamScoreThreshold]; v != "" {
+		threshold, err := strconv.ParseFloat(v, 32)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return nil, fmt.Errorf("invalid scoreThreshold %s", v)
+		}
+		score := float32(threshold)
+		t.retrieverConfig.ScoreThreshold = &score
+	}
+	displayName := kb.Spec.DisplayName
+	if displayName == "" {
+		displayName = name
+	}
+	t.description = fmt.Sprintf("Search the knowledgebase %q for relevant content. %s Input should be a search query.", displayName, kb.Spec.Description)
+	return t, nil
+}
+
+func (t *KnowledgeBaseTool) Name() string {
+	// function names of llms only allow letters, digits, underscores and dashes
+	return "knowledgebase_" + invalidToolNameChars.ReplaceAllString(t.name, "_")
+}
+
+func (t *KnowledgeBaseTool) Description() string {
+	return t.description
+}
+
+func (t *KnowledgeBaseTool) Call(ctx context.Context, input string) (string, error) {
+	klog.FromContext(ctx).V(3).Info(fmt.Sprintf("running tool %s", t.Name()))
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolStart(ctx, input)
+	}
+	result, err := t.search(ctx, input)
+	if err != nil {
+		if t.CallbacksHandler != nil {
+			t.CallbacksHandler.HandleToolError(ctx, err)
+		}
+		return "", err
+	}
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolEnd(ctx, result)
+	}
+	return result, nil
+}
+
+func (t *KnowledgeBaseTool) search(ctx context.Context, input string) (string, error) {
+	// use a separate args, the retriever of the app should not be affected by the tool
+	args := map[string]any{"question": input}
+	out, finish, err := t.retrieve(ctx, t.cli, t.name, t.namespace, t.retrieverConfig, args)
+	if finish != nil {
+		defer finish()
+	}
+	if err != nil {
+		return "", err
+	}
+	refs, _ := out[base.RuntimeRetrieverReferencesKeyInArg].([]retriever.Reference)
+	if len(refs) == 0 {
+		return fmt.Sprintf("No relevant content found in knowledgebase %s.", t.name), nil
+	}
+	t.mu.Lock()
+	t.references = append(t.references, refs...)
+	t.mu.Unlock()
+
+	var sb strings.Builder
+	for i, ref := range refs {
+		sb.WriteString(fmt.Sprintf("[%d]", i+1))
+		if ref.FileName != "" {
+			sb.WriteString(" " + ref.FileName)
+			if ref.PageNumber > 0 {
+				sb.WriteString(fmt.Sprintf(" page %d", ref.PageNumber))
+			}
+		}
+		sb.WriteString(":\n")
+		sb.WriteString(ref.Question)
+		if ref.Answer != "" {
+			sb.WriteString("\n" + ref.Answer)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// References returns the references of all searches
+func (t *KnowledgeBaseTool) References() []retriever.Reference {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]retriever.Reference{}, t.references...)
+}
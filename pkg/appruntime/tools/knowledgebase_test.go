@@ -0,0 +1,89 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	apiretriever "github.com/kubeagi/arcadia/api/app-node/retriever/v1alpha1"
+	basev1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/base"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+)
+
+func TestKnowledgeBaseTool(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, basev1alpha1.AddToScheme(scheme))
+	kb := &basev1alpha1.KnowledgeBase{ObjectMeta: metav1.ObjectMeta{Name: "hr.policy", Namespace: "default"}}
+	kb.Spec.DisplayName = "HR policy"
+	kb.Spec.Description = "Policies of the company."
+	other := &basev1alpha1.KnowledgeBase{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "other"}}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(kb, other).Build()
+	ctx := context.Background()
+
+	newTool := func(params map[string]string) (*KnowledgeBaseTool, error) {
+		return NewKnowledgeBaseTool(ctx, cli, "default", &v1alpha1.AllowedTool{Name: KnowledgeBaseToolName, Params: params})
+	}
+	_, err := newTool(map[string]string{})
+	require.ErrorContains(t, err, "name is required")
+	_, err = newTool(map[string]string{ParamKnowledgeBaseName: "secret", ParamKnowledgeBaseNamespace: "other"})
+	require.ErrorContains(t, err, "not in the namespace default")
+	_, err = newTool(map[string]string{ParamKnowledgeBaseName: "secret"})
+	require.ErrorContains(t, err, "can't find the knowledgebase")
+	_, err = newTool(map[string]string{ParamKnowledgeBaseName: "hr.policy", ParamNumDocuments: "0"})
+	require.ErrorContains(t, err, "invalid numDocuments")
+	_, err = newTool(map[string]string{ParamKnowledgeBaseName: "hr.policy", ParamScoreThreshold: "1.5"})
+	require.ErrorContains(t, err, "invalid scoreThreshold")
+
+	tool, err := newTool(map[string]string{ParamKnowledgeBaseName: "hr.policy", ParamKnowledgeBaseNamespace: "default", ParamNumDocuments: "2", ParamScoreThreshold: "0.5"})
+	require.NoError(t, err)
+	require.Equal(t, "knowledgebase_hr_policy", tool.Name())
+	require.Contains(t, tool.Description(), `"HR policy"`)
+	require.Contains(t, tool.Description(), "Policies of the company.")
+
+	var refs []retriever.Reference
+	tool.retrieve = func(_ context.Context, _ client.Client, name, namespace string, config apiretriever.CommonRetrieverConfig, args map[string]any) (map[string]any, func(), error) {
+		require.Equal(t, "hr.policy", name)
+		require.Equal(t, "default", namespace)
+		require.Equal(t, 2, config.NumDocuments)
+		require.InDelta(t, 0.5, *config.ScoreThreshold, 1e-6)
+		require.Equal(t, "annual leave", args["question"])
+		return map[string]any{base.RuntimeRetrieverReferencesKeyInArg: refs}, nil, nil
+	}
+
+	out, err := tool.Call(ctx, "annual leave")
+	require.NoError(t, err)
+	require.Equal(t, "No relevant content found in knowledgebase hr.policy.", out)
+	require.Empty(t, tool.References())
+
+	refs = []retriever.Reference{
+		{Question: "How many days of annual leave?", Answer: "5 days.", FileName: "leave.pdf", PageNumber: 3},
+		{Question: "Annual leave can be carried over to the next year."},
+	}
+	out, err = tool.Call(ctx, "annual leave")
+	require.NoError(t, err)
+	require.Equal(t, "[1] leave.pdf page 3:\nHow many days of annual leave?\n5 days.\n[2]:\nAnnual leave can be carried over to the next year.\n", out)
+	require.Equal(t, refs, tool.References())
+}
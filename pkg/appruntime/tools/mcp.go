@@ -0,0 +1,119 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tmc/langchaingo/tools"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/log"
+	"github.com/kubeagi/arcadia/pkg/tools/mcp"
+)
+
+const defaultMCPTimeout = 30 * time.Second
+
+// InitMCPTools connects to the MCP servers and returns their tools,
+// the returned function closes the connections and must be called after the agent finishes.
+func InitMCPTools(ctx context.Context, cli client.Client, namespace string, servers []v1alpha1.MCPServer) ([]tools.Tool, func(), error) {
+	clients := make([]*mcp.Client, 0, len(servers))
+	closeAll := func() {
+		for _, c := range clients {
+			if err := c.Close(); err != nil {
+				klog.FromContext(ctx).Error(err, "failed to close mcp client")
+			}
+		}
+	}
+	result := make([]tools.Tool, 0)
+	for _, server := range servers {
+		c, serverTools, err := initMCPServer(ctx, cli, namespace, server)
+		if c != nil {
+			clients = append(clients, c)
+		}
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to init mcp server %s: %w", server.Name, err)
+		}
+		result = append(result, serverTools...)
+	}
+	return result, closeAll, nil
+}
+
+func initMCPServer(ctx context.Context, cli client.Client, namespace string, server v1alpha1.MCPServer) (*mcp.Client, []tools.Tool, error) {
+	timeout := defaultMCPTimeout
+	if server.TimeoutSeconds > 0 {
+		timeout = time.Duration(server.TimeoutSeconds) * time.Second
+	}
+	auth := make(map[string]string)
+	if server.AuthSecret != nil {
+		secret := &corev1.Secret{}
+		if err := cli.Get(ctx, types.NamespacedName{Namespace: server.AuthSecret.GetNamespace(namespace), Name: server.AuthSecret.Name}, secret); err != nil {
+			return nil, nil, err
+		}
+		for k, v := range secret.Data {
+			auth[k] = string(v)
+		}
+	}
+
+	var transport mcp.Transport
+	switch {
+	case server.URL != "":
+		transport = mcp.NewHTTPTransport(server.URL, auth, &http.Client{})
+	case len(server.Command) > 0:
+		env := make(map[string]string, len(server.Env)+len(auth))
+		for k, v := range server.Env {
+			env[k] = v
+		}
+		for k, v := range auth {
+			env[k] = v
+		}
+		var err error
+		if transport, err = mcp.NewStdioTransport(server.Command, env); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, errors.New("one of url and command is required")
+	}
+	c := mcp.NewClient(transport)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	info, err := c.Initialize(ctx)
+	if err != nil {
+		return c, nil, err
+	}
+	klog.FromContext(ctx).V(5).Info("connected to mcp server", "server", server.Name, "serverInfo", info.ServerInfo.Name, "protocolVersion", info.ProtocolVersion)
+	serverTools, err := mcp.NewTools(ctx, c, server.Name, server.Tools, timeout)
+	if err != nil {
+		return c, nil, err
+	}
+	result := make([]tools.Tool, 0, len(serverTools))
+	for _, tool := range serverTools {
+		tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+		result = append(result, tool)
+	}
+	return c, requireApproval(result, server.RequiresApproval), nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+)
+
+// namedTool overrides the name and description of a tool, and marks whether the tool requires approval,
+// the optional interfaces of the wrapped tool are kept by delegation.
+type namedTool struct {
+	tools.Tool
+	name             string
+	description      string
+	requiresApproval bool
+}
+
+var (
+	_ ToolWithReferences = &namedTool{}
+	_ ToolWithArgs       = &namedTool{}
+	_ ToolWithProgress   = &namedTool{}
+	_ ToolWithApproval   = &namedTool{}
+)
+
+// namedToolWithParameters is a namedTool of a ToolWithParameters
+type namedToolWithParameters struct {
+	*namedTool
+}
+
+var _ ToolWithParameters = &namedToolWithParameters{}
+
+// withNameAndDescription returns the tool with the name and description, empty values are not overridden
+func withNameAndDescription(tool tools.Tool, name, description string) tools.Tool {
+	t := &namedTool{Tool: tool, name: invalidToolNameChars.ReplaceAllString(name, "_"), description: description}
+	if _, ok := tool.(ToolWithParameters); ok {
+		return &namedToolWithParameters{t}
+	}
+	return t
+}
+
+// withApproval marks the tool requires approval before it is called
+func withApproval(tool tools.Tool) tools.Tool {
+	switch t := tool.(type) {
+	case *namedTool:
+		t.requiresApproval = true
+	case *namedToolWithParameters:
+		t.requiresApproval = true
+	default:
+		tool = withApproval(withNameAndDescription(tool, "", ""))
+	}
+	return tool
+}
+
+func (t *namedTool) Name() string {
+	if t.name == "" {
+		return t.Tool.Name()
+	}
+	return t.name
+}
+
+func (t *namedTool) Description() string {
+	if t.description == "" {
+		return t.Tool.Description()
+	}
+	return t.description
+}
+
+func (t *namedTool) References() []retriever.Reference {
+	if inner, ok := t.Tool.(ToolWithReferences); ok {
+		return inner.References()
+	}
+	return nil
+}
+
+func (t *namedTool) SetArgs(args map[string]any) {
+	if inner, ok := t.Tool.(ToolWithArgs); ok {
+		inner.SetArgs(args)
+	}
+}
+
+func (t *namedTool) SetProgress(progress func(ctx context.Context, message string)) {
+	if inner, ok := t.Tool.(ToolWithProgress); ok {
+		inner.SetProgress(progress)
+	}
+}
+
+func (t *namedTool) RequiresApproval() bool {
+	if t.requiresApproval {
+		return true
+	}
+	if inner, ok := t.Tool.(ToolWithApproval); ok {
+		return inner.RequiresApproval()
+	}
+	return false
+}
+
+func (t *namedToolWithParameters) Parameters() map[string]any {
+	return t.Tool.(ToolWithParameters).Parameters()
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	ErrUnknownTool      = errors.New("unknown tool type")
+	ErrInvalidParameter = errors.New("invalid tool parameter")
+)
+
+// ParameterType is the type of the value of a tool parameter
+type ParameterType string
+
+const (
+	ParameterString  ParameterType = "string"
+	ParameterInteger ParameterType = "integer"
+	ParameterNumber  ParameterType = "number"
+	ParameterBoolean ParameterType = "boolean"
+)
+
+// Parameter declares a parameter accepted by a tool implementation
+type Parameter struct {
+	Name     string
+	Type     ParameterType
+	Required bool
+}
+
+// Options are passed to the implementation to create tools
+type Options struct {
+	Client client.Client
+	// LLM of the agent, used by tools which need llm, like the sql tool
+	LLM llms.Model
+	// Namespace of the agent, or the namespace of the Tool CR if the tool is referenced by CR
+	Namespace string
+	// Params are the values of parameters, values from secrets are resolved already
+	Params map[string]string
+}
+
+// Implementation creates the tools of a type.
+// New implementations are added by Register, the agent and the Tool controller find them by the type.
+type Implementation interface {
+	// Parameters declares the parameters accepted by the implementation, they are validated by the Tool controller
+	Parameters() []Parameter
+	// New creates the tools, an implementation may create several tools, like one for each operation of an OpenAPI document
+	New(ctx context.Context, options Options) ([]tools.Tool, error)
+}
+
+type implementation struct {
+	parameters []Parameter
+	newFunc    func(ctx context.Context, options Options) ([]tools.Tool, error)
+}
+
+func (i implementation) Parameters() []Parameter {
+	return i.parameters
+}
+
+func (i implementation) New(ctx context.Context, options Options) ([]tools.Tool, error) {
+	return i.newFunc(ctx, options)
+}
+
+// NewImplementation returns an Implementation which creates tools by the function
+func NewImplementation(parameters []Parameter, newFunc func(ctx context.Context, options Options) ([]tools.Tool, error)) Implementation {
+	return implementation{parameters: parameters, newFunc: newFunc}
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Implementation)
+)
+
+// Register makes a tool implementation available by the type, it panics if the type is registered twice
+func Register(toolType string, impl Implementation) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if impl == nil {
+		panic("tools: Register implementation is nil")
+	}
+	if _, ok := registry[toolType]; ok {
+		panic("tools: Register called twice for type " + toolType)
+	}
+	registry[toolType] = impl
+}
+
+// Lookup returns the implementation of the type
+func Lookup(toolType string) (Implementation, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	impl, ok := registry[toolType]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTool, toolType)
+	}
+	return impl, nil
+}
+
+// Types returns the sorted types of registered implementations
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ValidateParameters checks the params against the parameters declared by the implementation
+func ValidateParameters(impl Implementation, params map[string]string) error {
+	declared := make(map[string]Parameter)
+	for _, p := range impl.Parameters() {
+		declared[p.Name] = p
+		if p.Required && params[p.Name] == "" {
+			return fmt.Errorf("%w: %s is required", ErrInvalidParameter, p.Name)
+		}
+	}
+	for name, value := range params {
+		p, ok := declared[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown parameter %s", ErrInvalidParameter, name)
+		}
+		if value == "" {
+			continue
+		}
+		var err error
+		switch p.Type {
+		case ParameterInteger:
+			_, err = strconv.Atoi(value)
+		case ParameterNumber:
+			_, err = strconv.ParseFloat(value, 64)
+		case ParameterBoolean:
+			_, err = strconv.ParseBool(value)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s should be %s, but got %q", ErrInvalidParameter, name, p.Type, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/tools"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	basev1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+	"github.com/kubeagi/arcadia/pkg/tools/bingsearch"
+	"github.com/kubeagi/arcadia/pkg/tools/websearch"
+)
+
+func TestValidateParameters(t *testing.T) {
+	impl, err := Lookup(bingsearch.ToolName)
+	require.NoError(t, err)
+	require.NoError(t, ValidateParameters(impl, map[string]string{bingsearch.ParamAPIKey: "key", bingsearch.ParamCount: "5"}))
+	require.ErrorIs(t, ValidateParameters(impl, map[string]string{bingsearch.ParamCount: "5"}), ErrInvalidParameter)
+	require.ErrorIs(t, ValidateParameters(impl, map[string]string{bingsearch.ParamAPIKey: "key", bingsearch.ParamCount: "five"}), ErrInvalidParameter)
+	require.ErrorIs(t, ValidateParameters(impl, map[string]string{bingsearch.ParamAPIKey: "key", "unknown": "x"}), ErrInvalidParameter)
+
+	_, err = Lookup("not exist")
+	require.ErrorIs(t, err, ErrUnknownTool)
+	require.Contains(t, Types(), bingsearch.ToolName)
+}
+
+func TestInitTools(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, basev1alpha1.AddToScheme(scheme))
+
+	ready := basev1alpha1.ToolStatus{}
+	ready.SetConditions(ready.ReadyCondition()...)
+	search := &basev1alpha1.Tool{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-search", Namespace: "default"},
+		Spec: basev1alpha1.ToolSpec{
+			CommonSpec: basev1alpha1.CommonSpec{Description: "Search the internet for news."},
+			Type:       bingsearch.ToolName,
+			Parameters: []basev1alpha1.ToolParameter{
+				{Name: bingsearch.ParamAPIKey, ValueFrom: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "bing"}, Key: "apiKey"}},
+				{Name: bingsearch.ParamCount, Value: "3"},
+			},
+		},
+		Status: ready,
+	}
+	notReady := &basev1alpha1.Tool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"},
+		Spec:       basev1alpha1.ToolSpec{Type: tools.Calculator{}.Name()},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bing", Namespace: "default"},
+		Data:       map[string][]byte{"apiKey": []byte("secret-key")},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(search, notReady, secret).Build()
+
+	params, err := search.ResolveParameters(context.Background(), cli)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{bingsearch.ParamAPIKey: "secret-key", bingsearch.ParamCount: "3"}, params)
+
+	created, err := InitTools(context.Background(), cli, nil, "default", []v1alpha1.AllowedTool{
+		{Ref: &basev1alpha1.TypedObjectReference{Kind: basev1alpha1.ToolKind, Name: "web-search"}},
+		{Name: tools.Calculator{}.Name(), RequiresApproval: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	require.Equal(t, "web-search", created[0].Name())
+	require.Equal(t, "Search the internet for news.", created[0].Description())
+	require.False(t, created[0].(ToolWithApproval).RequiresApproval())
+	require.Equal(t, tools.Calculator{}.Name(), created[1].Name())
+	require.True(t, created[1].(ToolWithApproval).RequiresApproval())
+
+	_, err = InitTools(context.Background(), cli, nil, "default", []v1alpha1.AllowedTool{{Ref: &basev1alpha1.TypedObjectReference{Name: "pending"}}})
+	require.Error(t, err)
+	_, err = InitTools(context.Background(), cli, nil, "default", []v1alpha1.AllowedTool{{Name: "not exist"}})
+	require.ErrorIs(t, err, ErrUnknownTool)
+}
+
+func TestWebSearchReferences(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"title":"KubeAGI","url":"https://kubeagi.github.io","content":"a platform"}]}`))
+	}))
+	defer server.Close()
+
+	for _, name := range []string{websearch.SearxNGToolName, websearch.GoogleToolName, websearch.BaiduToolName, websearch.TavilyToolName} {
+		require.Contains(t, Types(), name)
+	}
+	impl, err := Lookup(websearch.SearxNGToolName)
+	require.NoError(t, err)
+	created, err := impl.New(context.Background(), Options{Params: map[string]string{websearch.ParamEndpoint: server.URL}})
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	_, err = created[0].Call(context.Background(), "kubeagi")
+	require.NoError(t, err)
+	require.Equal(t, []retriever.Reference{{Title: "KubeAGI", Content: "a platform", URL: "https://kubeagi.github.io"}}, created[0].(ToolWithReferences).References())
+}
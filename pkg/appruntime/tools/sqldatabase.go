@@ -0,0 +1,65 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/log"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+	"github.com/kubeagi/arcadia/pkg/tools/sqldatabase"
+)
+
+// sqlTool adds the sql executed as references of the chat
+type sqlTool struct {
+	*sqldatabase.Tool
+}
+
+var _ ToolWithReferences = &sqlTool{}
+
+func init() {
+	Register(sqldatabase.ToolName, NewImplementation([]Parameter{
+		{Name: sqldatabase.ParamDatasource, Type: ParameterString, Required: true},
+		{Name: sqldatabase.ParamNamespace, Type: ParameterString},
+		{Name: sqldatabase.ParamTables, Type: ParameterString},
+		{Name: sqldatabase.ParamMaxRows, Type: ParameterInteger},
+		{Name: sqldatabase.ParamTimeout, Type: ParameterInteger},
+	}, func(ctx context.Context, options Options) ([]tools.Tool, error) {
+		tool, err := sqldatabase.New(ctx, options.Client, options.LLM, options.Namespace, &v1alpha1.AllowedTool{Name: sqldatabase.ToolName, Params: options.Params})
+		if err != nil {
+			return nil, err
+		}
+		tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+		return []tools.Tool{&sqlTool{tool}}, nil
+	}))
+}
+
+func (t *sqlTool) References() []retriever.Reference {
+	queries := t.Queries()
+	refs := make([]retriever.Reference, 0, len(queries))
+	for _, query := range queries {
+		refs = append(refs, retriever.Reference{
+			Title:   fmt.Sprintf("SQL query on datasource %s", t.Datasource()),
+			Content: query,
+		})
+	}
+	return refs
+}
@@ -18,169 +18,96 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 
+	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/tools"
-	"github.com/tmc/langchaingo/tools/scraper"
-	"k8s.io/klog/v2"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
-	"github.com/kubeagi/arcadia/pkg/appruntime/log"
-	"github.com/kubeagi/arcadia/pkg/tools/bingsearch"
-	"github.com/kubeagi/arcadia/pkg/tools/weather"
+	basev1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
 )
 
-func InitTools(ctx context.Context, specTools []v1alpha1.Tool) []tools.Tool {
-	logger := klog.FromContext(ctx)
+// ToolWithParameters is a tool which accepts structured arguments.
+// When it is called by the tool calling agent, the arguments in JSON are passed to Call as is,
+// other tools get the value of the "input" argument.
+type ToolWithParameters interface {
+	tools.Tool
+	// Parameters returns the JSON schema of the arguments
+	Parameters() map[string]any
+}
+
+// ToolWithApproval is a tool which may need the approval of user before it is called
+type ToolWithApproval interface {
+	tools.Tool
+	RequiresApproval() bool
+}
+
+// InitTools creates the tools in the spec, cli and namespace are used to get the resources referenced by tools,
+// llm is used by tools which need llm, like the sql tool.
+// Tools are created by the implementation registered with the type, which is the name of tool or the type of the referenced Tool CR.
+func InitTools(ctx context.Context, cli client.Client, llm llms.Model, namespace string, specTools []v1alpha1.AllowedTool) ([]tools.Tool, error) {
 	allowedTools := make([]tools.Tool, 0, len(specTools))
 	for _, toolSpec := range specTools {
-		switch toolSpec.Name {
-		case bingsearch.ToolName:
-			client, err := bingsearch.New(&toolSpec)
-			if err != nil {
-				logger.Error(err, "failed to create a new bingsearch tool")
-				continue
-			}
-			client.CallbacksHandler = log.KLogHandler{LogLevel: 3}
-			allowedTools = append(allowedTools, client)
-		case weather.ToolName:
-			tool, err := weather.New(&toolSpec)
-			if err != nil {
-				logger.Error(err, "failed to create a new weather tool")
-				continue
-			}
-			tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
-			allowedTools = append(allowedTools, tool)
-		case tools.Calculator{}.Name():
-			tool := tools.Calculator{}
-			tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
-			allowedTools = append(allowedTools, tool)
-		case scraper.Scraper{}.Name():
-			// prepare options from toolSpec
-			options := make([]scraper.Options, 0)
-			if toolSpec.Params["delay"] != "" {
-				delay, err := strconv.ParseInt(toolSpec.Params["delay"], 10, 64)
-				if err != nil {
-					logger.Error(err, fmt.Sprintf("failed to parse delay %s", toolSpec.Params["delay"]))
-				} else {
-					options = append(options, scraper.WithDelay(delay))
-				}
-			}
-			if toolSpec.Params["async"] != "" {
-				async, err := strconv.ParseBool(toolSpec.Params["async"])
-				if err != nil {
-					logger.Error(err, fmt.Sprintf("failed to parse async %s", toolSpec.Params["async"]))
-				} else {
-					options = append(options, scraper.WithAsync(async))
-				}
-			}
-			if toolSpec.Params["handleLinks"] != "" {
-				handleLinks, err := strconv.ParseBool(toolSpec.Params["handleLinks"])
-				if err != nil {
-					logger.Error(err, fmt.Sprintf("failed to parse handleLinks %s", toolSpec.Params["handleLinks"]))
-				} else {
-					options = append(options, scraper.WithHandleLinks(handleLinks))
-				}
-			}
-			if toolSpec.Params["blacklist"] != "" {
-				blacklistArray := strings.Split(toolSpec.Params["blacklist"], ",")
-				options = append(options, scraper.WithBlacklist(blacklistArray))
-			}
-			if toolSpec.Params["maxScrapedDataLength"] != "" {
-				maxScrapedDataLength, err := strconv.Atoi(toolSpec.Params["maxScrapedDataLength"])
-				if err != nil {
-					klog.Errorln("failed to parse maxScrapedDataLength %s", toolSpec.Params["maxScrapedDataLength"])
-				} else {
-					options = append(options, scraper.WithMaxScrapedDataLength(maxScrapedDataLength))
-				}
-			}
-			tool, err := scraper.New(options...)
+		if toolSpec.Ref != nil {
+			created, err := initToolFromCR(ctx, cli, llm, namespace, toolSpec.Ref)
 			if err != nil {
-				logger.Error(err, "failed to create a new scraper tool")
-				continue
+				return nil, fmt.Errorf("failed to create tool %s: %w", toolSpec.Ref.Name, err)
 			}
-			allowedTools = append(allowedTools, tool)
-		default:
-			// Just continue if the tool does not exist
-			klog.Errorf("no tool found with name: %s", toolSpec.Name)
+			allowedTools = append(allowedTools, requireApproval(created, toolSpec.RequiresApproval)...)
+			continue
+		}
+		impl, err := Lookup(toolSpec.Name)
+		if err != nil {
+			return nil, err
 		}
+		created, err := impl.New(ctx, Options{Client: cli, LLM: llm, Namespace: namespace, Params: toolSpec.Params})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tool %s: %w", toolSpec.Name, err)
+		}
+		allowedTools = append(allowedTools, requireApproval(created, toolSpec.RequiresApproval)...)
 	}
-	return allowedTools
+	return allowedTools, nil
 }
 
-// FIXME: should add web reference into chat result
-// func RunTools(ctx context.Context, args map[string]any, ts []tools.Tool) map[string]any {
-//	if len(ts) == 0 {
-//		return args
-//	}
-//	input, ok := args["question"].(string)
-//	if !ok {
-//		return args
-//	}
-//	logger := klog.FromContext(ctx)
-//	logger.V(3).Info(fmt.Sprintf("tools call input: %s", input))
-//	result := make([]string, len(ts))
-//	resultRef := make([][]retriever.Reference, len(ts))
-//	for i := range resultRef {
-//		resultRef[i] = make([]retriever.Reference, 0)
-//	}
-//	var wg sync.WaitGroup
-//	wg.Add(len(ts))
-//	for i, tool := range ts {
-//		i, tool := i, tool
-//		go func(i int, tool tools.Tool) {
-//			defer wg.Done()
-//
-//			switch tool.Name() { // nolint:gocritic
-//			case bingsearch.ToolName:
-//				bingtool, ok := tool.(*bingsearch.Tool)
-//				if !ok {
-//					logger.Error(errors.New("failed to convert tool to bingsearch tool"), "")
-//					return
-//				}
-//				data, _, err := bingtool.Client.SearchGetDetailData(ctx, input)
-//				if err != nil {
-//					logger.Error(err, "failed to call bing search tool")
-//					return
-//				}
-//				ref := make([]retriever.Reference, len(data))
-//				for j := range data {
-//					ref[j] = retriever.Reference{
-//						Title:   data[j].Title,
-//						Content: data[j].Description,
-//						URL:     data[j].URL,
-//					}
-//				}
-//				resultRef[i] = ref
-//				result[i] = bingsearch.FormatResults(data)
-//			default:
-//				data, err := tool.Call(ctx, input)
-//				if err != nil {
-//					logger.Error(err, "failed to call tool")
-//					return
-//				}
-//				result[i] = data
-//			}
-//			klog.V(3).Info("tools call done")
-//		}(i, tool)
-//	}
-//	wg.Wait()
-//	res := make([]string, 0, len(result))
-//	for i := range result {
-//		if s := strings.TrimSpace(result[i]); s != "" {
-//			res = append(res, s)
-//		}
-//	}
-//	toolOut := strings.Join(res, "\n")
-//	old, exist := args["context"]
-//	if exist {
-//		toolOut = old.(string) + "\n" + toolOut
-//	}
-//	args["context"] = toolOut
-//	for i := range resultRef {
-//		args = retriever.AddReferencesToArgs(args, resultRef[i])
-//	}
-//	return args
-//}
+func requireApproval(created []tools.Tool, required bool) []tools.Tool {
+	if !required {
+		return created
+	}
+	for i := range created {
+		created[i] = withApproval(created[i])
+	}
+	return created
+}
+
+func initToolFromCR(ctx context.Context, cli client.Client, llm llms.Model, namespace string, ref *basev1alpha1.TypedObjectReference) ([]tools.Tool, error) {
+	if ref.Kind != "" && ref.Kind != basev1alpha1.ToolKind {
+		return nil, fmt.Errorf("unsupported kind %s", ref.Kind)
+	}
+	instance := &basev1alpha1.Tool{}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: ref.GetNamespace(namespace), Name: ref.Name}, instance); err != nil {
+		return nil, err
+	}
+	if ready, msg := instance.Status.IsReadyOrGetReadyMessage(); !ready {
+		return nil, errors.New("tool is not ready:" + msg)
+	}
+	impl, err := Lookup(instance.Spec.Type)
+	if err != nil {
+		return nil, err
+	}
+	params, err := instance.ResolveParameters(ctx, cli)
+	if err != nil {
+		return nil, err
+	}
+	created, err := impl.New(ctx, Options{Client: cli, LLM: llm, Namespace: instance.Namespace, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	// the name and description of CR are shown to llm, unless the implementation creates several tools
+	if len(created) == 1 {
+		created[0] = withNameAndDescription(created[0], instance.Name, instance.Spec.Description)
+	}
+	return created, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/tools"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/appruntime/log"
+	"github.com/kubeagi/arcadia/pkg/appruntime/retriever"
+	"github.com/kubeagi/arcadia/pkg/tools/bingsearch"
+	"github.com/kubeagi/arcadia/pkg/tools/websearch"
+)
+
+// webSearchTool adds the results of a web search tool to the references of the chat
+type webSearchTool struct {
+	*websearch.Tool
+}
+
+var _ ToolWithReferences = webSearchTool{}
+
+func (t webSearchTool) References() []retriever.Reference {
+	results := t.Results()
+	refs := make([]retriever.Reference, 0, len(results))
+	for _, r := range results {
+		refs = append(refs, retriever.Reference{Title: r.Title, Content: r.Snippet, URL: r.URL})
+	}
+	return refs
+}
+
+// bingSearchTool adds the web pages found by bing to the references of the chat
+type bingSearchTool struct {
+	*bingsearch.Tool
+}
+
+var _ ToolWithReferences = bingSearchTool{}
+
+func (t bingSearchTool) References() []retriever.Reference {
+	pages := t.Pages()
+	refs := make([]retriever.Reference, 0, len(pages))
+	for _, p := range pages {
+		refs = append(refs, retriever.Reference{Title: p.Title, Content: p.Description, URL: p.URL})
+	}
+	return refs
+}
+
+func init() {
+	Register(bingsearch.ToolName, NewImplementation([]Parameter{
+		{Name: bingsearch.ParamAPIKey, Type: ParameterString, Required: true},
+		{Name: bingsearch.ParamCount, Type: ParameterInteger},
+		{Name: bingsearch.ParamScraperPage, Type: ParameterBoolean},
+	}, func(ctx context.Context, options Options) ([]tools.Tool, error) {
+		tool, err := bingsearch.New(specOf(bingsearch.ToolName, options))
+		if err != nil {
+			return nil, err
+		}
+		tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+		return []tools.Tool{bingSearchTool{tool}}, nil
+	}))
+
+	common := []Parameter{
+		{Name: websearch.ParamEndpoint, Type: ParameterString},
+		{Name: websearch.ParamCount, Type: ParameterInteger},
+		{Name: websearch.ParamTimeout, Type: ParameterInteger},
+	}
+	registerWebSearch(websearch.SearxNGToolName, websearch.NewSearxNG, append([]Parameter{
+		{Name: websearch.ParamLanguage, Type: ParameterString},
+	}, common...))
+	registerWebSearch(websearch.GoogleToolName, websearch.NewGoogle, append([]Parameter{
+		{Name: websearch.ParamAPIKey, Type: ParameterString, Required: true},
+		{Name: websearch.ParamSearchEngineID, Type: ParameterString, Required: true},
+	}, common...))
+	registerWebSearch(websearch.BaiduToolName, websearch.NewBaidu, append([]Parameter{
+		{Name: websearch.ParamAPIKey, Type: ParameterString, Required: true},
+	}, common...))
+	registerWebSearch(websearch.TavilyToolName, websearch.NewTavily, append([]Parameter{
+		{Name: websearch.ParamAPIKey, Type: ParameterString, Required: true},
+	}, common...))
+}
+
+func registerWebSearch(name string, newTool func(*v1alpha1.AllowedTool) (*websearch.Tool, error), parameters []Parameter) {
+	Register(name, NewImplementation(parameters, func(ctx context.Context, options Options) ([]tools.Tool, error) {
+		tool, err := newTool(specOf(name, options))
+		if err != nil {
+			return nil, err
+		}
+		tool.CallbacksHandler = log.KLogHandler{LogLevel: 3}
+		return []tools.Tool{webSearchTool{tool}}, nil
+	}))
+}
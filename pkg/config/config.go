@@ -115,6 +115,15 @@ func getConfig(ctx context.Context) (config *Config, err error) {
 	return config, nil
 }
 
+// GetChatRetention returns the retention of conversations, nil means keeping forever
+func GetChatRetention(ctx context.Context) (*ChatRetention, error) {
+	config, err := getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return config.ChatRetention, nil
+}
+
 // GetSystemEmbeddingSuite returns the embedder and vectorstore which are built-in in system config
 // Embedder and vectorstore are both required when generating a new embedding.That's why we call it a `EmbeddingSuit`
 func GetSystemEmbeddingSuite(ctx context.Context) (*arcadiav1alpha1.Embedder, *arcadiav1alpha1.VectorStore, error) {
@@ -42,6 +42,9 @@ type Config struct {
 	// the default rerank model
 	Rerank *arcadiav1alpha1.TypedObjectReference `json:"rerank,omitempty"`
 
+	// ChatRetention is how long the conversations are kept in chat storage, they are kept forever if it is empty
+	ChatRetention *ChatRetention `json:"chatRetention,omitempty"`
+
 	// Streamlit to get the Streamlit configuration
 	// Deprecated: this field no longer maintained
 	Streamlit *Streamlit `json:"streamlit,omitempty"`
@@ -56,6 +59,26 @@ type EmbeddingSuite struct {
 	VectorStore *arcadiav1alpha1.TypedObjectReference `json:"vectorStore,omitempty"`
 }
 
+// ChatRetention defines how many days the conversations are kept after their last update,
+// the applications can override it by spec.chatRetentionDays
+type ChatRetention struct {
+	// Days is the default retention, 0 means keeping forever
+	Days int `json:"days,omitempty"`
+	// Namespaces overrides the default retention in the namespaces
+	Namespaces map[string]int `json:"namespaces,omitempty"`
+}
+
+// DaysOf returns the retention days of the conversations in the namespace, 0 means keeping forever
+func (retention *ChatRetention) DaysOf(namespace string) int {
+	if retention == nil {
+		return 0
+	}
+	if days, ok := retention.Namespaces[namespace]; ok {
+		return days
+	}
+	return retention.Days
+}
+
 // Gateway defines the way to access llm apis host by Arcadia
 type Gateway struct {
 	// ExternalAPIServer is the api(LLM/Embedding) server address that can be accessed from internet
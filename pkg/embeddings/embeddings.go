@@ -19,14 +19,20 @@ package embeddings
 type EmbeddingType string
 
 const (
-	OpenAI  EmbeddingType = "openai"
-	ZhiPuAI EmbeddingType = "zhipuai"
-	Gemini  EmbeddingType = "gemini"
-	Unknown EmbeddingType = "unknown"
+	OpenAI      EmbeddingType = "openai"
+	ZhiPuAI     EmbeddingType = "zhipuai"
+	Gemini      EmbeddingType = "gemini"
+	AzureOpenAI EmbeddingType = "azureopenai"
+	Qianfan     EmbeddingType = "qianfan"
+	DashScope   EmbeddingType = "dashscope"
+	Unknown     EmbeddingType = "unknown"
 )
 
 var (
-	ZhiPuAIModels = []string{"text_embedding"}
-	OpenAIModels  = []string{"text-embedding-ada-002"}
-	GeminiModels  = []string{"embedding-001"}
+	ZhiPuAIModels   = []string{"text_embedding"}
+	OpenAIModels    = []string{"text-embedding-ada-002"}
+	GeminiModels    = []string{"embedding-001"}
+	QianfanModels   = []string{"embedding-v1", "bge-large-zh", "bge-large-en", "tao-8k"}
+	DashScopeModels = []string{"text-embedding-v1"}
+	// AzureOpenAI has no default models, the models must be the deployment names in spec.models
 )
@@ -30,7 +30,10 @@ import (
 	"github.com/kubeagi/arcadia/api/base/v1alpha1"
 	"github.com/kubeagi/arcadia/pkg/config"
 	"github.com/kubeagi/arcadia/pkg/embeddings"
+	dashscopeembeddings "github.com/kubeagi/arcadia/pkg/embeddings/dashscope"
 	zhipuaiembeddings "github.com/kubeagi/arcadia/pkg/embeddings/zhipuai"
+	llmsopenai "github.com/kubeagi/arcadia/pkg/llms/openai"
+	"github.com/kubeagi/arcadia/pkg/llms/qianfan"
 	"github.com/kubeagi/arcadia/pkg/llms/zhipuai"
 )
 
@@ -88,6 +91,45 @@ func GetLangchainEmbedder(ctx context.Context, e *v1alpha1.Embedder, c client.Cl
 				return nil, err
 			}
 			return langchaingoembeddings.NewEmbedder(llm, opts...)
+		case embeddings.AzureOpenAI:
+			apiKey, err := e.AuthAPIKey(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			// Azure uses the deployment name as model
+			model, err = defaultModel(model, e.GetModelList())
+			if err != nil {
+				return nil, err
+			}
+			llm, err := openai.New(llmsopenai.AzureOptions(apiKey, e.Get3rdPartyEmbedderBaseURL(), model)...)
+			if err != nil {
+				return nil, err
+			}
+			return langchaingoembeddings.NewEmbedder(llm, opts...)
+		case embeddings.Qianfan:
+			apiKey, err := e.AuthAPIKey(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			secretKey, err := e.AuthSecretKey(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			model, err = defaultModel(model, e.GetModelList())
+			if err != nil {
+				return nil, err
+			}
+			client, err := qianfan.NewQianfan(apiKey, secretKey, e.Get3rdPartyEmbedderBaseURL())
+			if err != nil {
+				return nil, err
+			}
+			return langchaingoembeddings.NewEmbedder(client.EmbedderClient(model), opts...)
+		case embeddings.DashScope:
+			apiKey, err := e.AuthAPIKey(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			return dashscopeembeddings.NewDashScopeEmbedder(apiKey), nil
 		}
 	case v1alpha1.ProviderTypeWorker:
 		gateway, err := config.GetGateway(ctx)
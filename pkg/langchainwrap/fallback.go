@@ -0,0 +1,227 @@
+/*
+Copyright 2024 The KubeAGI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package langchainwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+var (
+	_ langchainllms.Model = (*FallbackLLM)(nil)
+)
+
+// RetryPolicy defines how a single llm in the fallback chain is retried
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	RetryOn        []llms.ErrorClass
+}
+
+// DefaultRetryPolicy retries 2 times on retryable errors with 0.5s, 1s backoff
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     2,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	RetryOn:        llms.DefaultRetryableErrorClasses,
+}
+
+// RetryPolicyFromSpec converts the retry policy in LLM spec to RetryPolicy, unset fields use the default value
+func RetryPolicyFromSpec(spec *v1alpha1.RetryPolicy) RetryPolicy {
+	policy := DefaultRetryPolicy
+	if spec == nil {
+		return policy
+	}
+	if spec.MaxRetries != nil {
+		policy.MaxRetries = max(0, *spec.MaxRetries)
+	}
+	if spec.InitialBackoffMilliseconds > 0 {
+		policy.InitialBackoff = time.Duration(spec.InitialBackoffMilliseconds) * time.Millisecond
+	}
+	if spec.MaxBackoffMilliseconds > 0 {
+		policy.MaxBackoff = time.Duration(spec.MaxBackoffMilliseconds) * time.Millisecond
+	}
+	if spec.Multiplier >= 1 {
+		policy.Multiplier = spec.Multiplier
+	}
+	if len(spec.RetryOn) != 0 {
+		policy.RetryOn = spec.RetryOn
+	}
+	return policy
+}
+
+// Backoff returns the wait time before the given retry, retry starts from 1
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		backoff *= p.Multiplier
+		if p.MaxBackoff > 0 && time.Duration(backoff) >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return time.Duration(backoff)
+}
+
+// ShouldRetry returns whether the error class is in RetryOn
+func (p RetryPolicy) ShouldRetry(class llms.ErrorClass) bool {
+	for _, c := range p.RetryOn {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+type fallbackModel struct {
+	name  string
+	model langchainllms.Model
+}
+
+type FallbackOption func(*FallbackLLM)
+
+// WithFallback appends a llm to the fallback chain
+func WithFallback(name string, model langchainllms.Model) FallbackOption {
+	return func(f *FallbackLLM) {
+		f.models = append(f.models, fallbackModel{name: name, model: model})
+	}
+}
+
+// WithRetryPolicy sets the retry policy of each llm in the chain
+func WithRetryPolicy(policy RetryPolicy) FallbackOption {
+	return func(f *FallbackLLM) {
+		f.policy = policy
+	}
+}
+
+// WithTimeout sets the timeout of a single call, 0 means no timeout
+func WithTimeout(timeout time.Duration) FallbackOption {
+	return func(f *FallbackLLM) {
+		f.timeout = timeout
+	}
+}
+
+// FallbackLLM wraps a llm with retry and fallback llms.
+// Each llm is retried with exponential backoff on retryable errors, and the next llm is tried
+// once it still fails. Once any content has been streamed to the caller, errors are returned directly
+// to avoid sending duplicated content.
+type FallbackLLM struct {
+	models  []fallbackModel
+	policy  RetryPolicy
+	timeout time.Duration
+}
+
+func NewFallbackLLM(name string, model langchainllms.Model, opts ...FallbackOption) *FallbackLLM {
+	f := &FallbackLLM{
+		models: []fallbackModel{{name: name, model: model}},
+		policy: DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func (f *FallbackLLM) Call(ctx context.Context, prompt string, options ...langchainllms.CallOption) (string, error) {
+	return langchainllms.GenerateFromSinglePrompt(ctx, f, prompt, options...)
+}
+
+func (f *FallbackLLM) GenerateContent(ctx context.Context, messages []langchainllms.MessageContent, options ...langchainllms.CallOption) (*langchainllms.ContentResponse, error) {
+	logger := klog.FromContext(ctx)
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	// record whether we have sent anything to the caller
+	var streamed atomic.Bool
+	if opts.StreamingFunc != nil {
+		streamingFunc := opts.StreamingFunc
+		options = append(options, langchainllms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			// drop chunks from a call which is already timed out
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			streamed.Store(true)
+			return streamingFunc(ctx, chunk)
+		}))
+	}
+
+	var errs []error
+	for _, m := range f.models {
+		for retry := 0; ; retry++ {
+			if retry > 0 {
+				backoff := f.policy.Backoff(retry)
+				logger.Info("retry llm call", "llm", m.name, "retry", retry, "backoff", backoff)
+				select {
+				case <-ctx.Done():
+					return nil, errors.Join(append(errs, ctx.Err())...)
+				case <-time.After(backoff):
+				}
+			}
+			resp, err := f.generateContent(ctx, m.model, messages, options...)
+			if err == nil {
+				return resp, nil
+			}
+			class := llms.ClassifyError(err)
+			logger.Error(err, "llm call failed", "llm", m.name, "errorClass", class, "retry", retry)
+			errs = append(errs, fmt.Errorf("llm %s: %w", m.name, err))
+			if streamed.Load() || ctx.Err() != nil {
+				return nil, errors.Join(errs...)
+			}
+			if retry >= f.policy.MaxRetries || !f.policy.ShouldRetry(class) {
+				break
+			}
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// generateContent calls the llm with timeout.
+// Some llm clients don't respect the context, so the call runs in a goroutine and is abandoned after timeout.
+func (f *FallbackLLM) generateContent(ctx context.Context, model langchainllms.Model, messages []langchainllms.MessageContent, options ...langchainllms.CallOption) (*langchainllms.ContentResponse, error) {
+	if f.timeout <= 0 {
+		return model.GenerateContent(ctx, messages, options...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	type result struct {
+		resp *langchainllms.ContentResponse
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		resp, err := model.GenerateContent(ctx, messages, options...)
+		ch <- result{resp: resp, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.resp, r.err
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The KubeAGI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package langchainwrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+type fakeLLM struct {
+	errs   []error
+	calls  int
+	answer string
+	chunk  string
+	sleep  time.Duration
+}
+
+func (f *fakeLLM) Call(ctx context.Context, prompt string, options ...langchainllms.CallOption) (string, error) {
+	return langchainllms.GenerateFromSinglePrompt(ctx, f, prompt, options...)
+}
+
+func (f *fakeLLM) GenerateContent(ctx context.Context, _ []langchainllms.MessageContent, options ...langchainllms.CallOption) (*langchainllms.ContentResponse, error) {
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	f.calls++
+	time.Sleep(f.sleep)
+	if f.chunk != "" && opts.StreamingFunc != nil {
+		_ = opts.StreamingFunc(ctx, []byte(f.chunk))
+	}
+	if f.calls <= len(f.errs) {
+		return nil, f.errs[f.calls-1]
+	}
+	return &langchainllms.ContentResponse{Choices: []*langchainllms.ContentChoice{{Content: f.answer}}}, nil
+}
+
+var testPolicy = RetryPolicy{
+	MaxRetries:     2,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     time.Millisecond,
+	Multiplier:     2,
+	RetryOn:        llms.DefaultRetryableErrorClasses,
+}
+
+func TestFallbackLLM(t *testing.T) {
+	ctx := context.Background()
+	rateLimited := errors.New("API returned unexpected status code: 429: too many requests")
+	unauthorized := errors.New("API returned unexpected status code: 401: invalid api key")
+
+	primary := &fakeLLM{errs: []error{rateLimited}, answer: "primary"}
+	res, err := NewFallbackLLM("primary", primary, WithRetryPolicy(testPolicy)).Call(ctx, "hi")
+	if err != nil || res != "primary" || primary.calls != 2 {
+		t.Fatalf("expect primary to succeed on retry, got %q %v after %d calls", res, err, primary.calls)
+	}
+
+	primary = &fakeLLM{errs: []error{unauthorized}}
+	secondary := &fakeLLM{answer: "secondary"}
+	res, err = NewFallbackLLM("primary", primary, WithRetryPolicy(testPolicy), WithFallback("secondary", secondary)).Call(ctx, "hi")
+	if err != nil || res != "secondary" || primary.calls != 1 {
+		t.Fatalf("expect client error to fall back without retry, got %q %v after %d calls", res, err, primary.calls)
+	}
+
+	primary = &fakeLLM{errs: []error{rateLimited, rateLimited, rateLimited}}
+	secondary = &fakeLLM{errs: []error{unauthorized}}
+	_, err = NewFallbackLLM("primary", primary, WithRetryPolicy(testPolicy), WithFallback("secondary", secondary)).Call(ctx, "hi")
+	if err == nil || primary.calls != 3 || secondary.calls != 1 {
+		t.Fatalf("expect all llms to fail, got %v after %d/%d calls", err, primary.calls, secondary.calls)
+	}
+
+	primary = &fakeLLM{sleep: 50 * time.Millisecond, answer: "slow"}
+	secondary = &fakeLLM{answer: "fast"}
+	res, err = NewFallbackLLM("primary", primary, WithRetryPolicy(RetryPolicy{}), WithTimeout(10*time.Millisecond), WithFallback("secondary", secondary)).Call(ctx, "hi")
+	if err != nil || res != "fast" {
+		t.Fatalf("expect timeout to fall back, got %q %v", res, err)
+	}
+
+	primary = &fakeLLM{errs: []error{rateLimited}, chunk: "partial"}
+	secondary = &fakeLLM{answer: "secondary"}
+	_, err = NewFallbackLLM("primary", primary, WithRetryPolicy(testPolicy), WithFallback("secondary", secondary)).
+		Call(ctx, "hi", langchainllms.WithStreamingFunc(func(context.Context, []byte) error { return nil }))
+	if err == nil || primary.calls != 1 || secondary.calls != 0 {
+		t.Fatalf("expect no retry after streaming, got %v after %d/%d calls", err, primary.calls, secondary.calls)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+	for retry, expect := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 4: 300 * time.Millisecond} {
+		if got := p.Backoff(retry); got != expect {
+			t.Fatalf("retry %d: expect backoff %s, got %s", retry, expect, got)
+		}
+	}
+}
+
+func TestRetryPolicyFromSpec(t *testing.T) {
+	zero, three := 0, 3
+	tests := []struct {
+		name   string
+		spec   *v1alpha1.RetryPolicy
+		expect int
+	}{
+		{name: "not set", spec: nil, expect: DefaultRetryPolicy.MaxRetries},
+		{name: "max retries not set", spec: &v1alpha1.RetryPolicy{}, expect: DefaultRetryPolicy.MaxRetries},
+		{name: "no retry", spec: &v1alpha1.RetryPolicy{MaxRetries: &zero}, expect: 0},
+		{name: "retry 3 times", spec: &v1alpha1.RetryPolicy{MaxRetries: &three}, expect: 3},
+	}
+	for _, tt := range tests {
+		if got := RetryPolicyFromSpec(tt.spec).MaxRetries; got != tt.expect {
+			t.Errorf("%s: expect max retries %d, got %d", tt.name, tt.expect, got)
+		}
+	}
+}
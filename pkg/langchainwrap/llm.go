@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	langchainllms "github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/googleai"
@@ -32,6 +33,10 @@ import (
 	"github.com/kubeagi/arcadia/pkg/appruntime/log"
 	"github.com/kubeagi/arcadia/pkg/config"
 	"github.com/kubeagi/arcadia/pkg/llms"
+	"github.com/kubeagi/arcadia/pkg/llms/anthropic"
+	"github.com/kubeagi/arcadia/pkg/llms/dashscope"
+	llmsopenai "github.com/kubeagi/arcadia/pkg/llms/openai"
+	"github.com/kubeagi/arcadia/pkg/llms/qianfan"
 	"github.com/kubeagi/arcadia/pkg/llms/zhipuai"
 )
 
@@ -39,7 +44,37 @@ const (
 	GatewayUseExternalURLEnv = "GATEWAY_USE_EXTERNAL_URL"
 )
 
+// GetLangchainLLM returns the langchain llm of this LLM.
+// If llm.spec.fallback is set, the llm is wrapped with retry and the fallback llms.
 func GetLangchainLLM(ctx context.Context, llm *v1alpha1.LLM, c client.Client, model string) (langchainllms.Model, error) {
+	l, err := getLangchainLLM(ctx, llm, c, model)
+	if err != nil {
+		return nil, err
+	}
+	fallback := llm.Spec.Fallback
+	if fallback == nil {
+		return l, nil
+	}
+	opts := []FallbackOption{
+		WithRetryPolicy(RetryPolicyFromSpec(fallback.Retry)),
+		WithTimeout(time.Duration(fallback.TimeoutSeconds) * time.Second),
+	}
+	for _, ref := range fallback.LLMs {
+		fallbackLLM := &v1alpha1.LLM{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: ref.GetNamespace(llm.GetNamespace()), Name: ref.Name}, fallbackLLM); err != nil {
+			return nil, fmt.Errorf("failed to get fallback llm %s: %w", ref.Name, err)
+		}
+		// fallback llm's own fallback config is ignored to avoid loops
+		fallbackModel, err := getLangchainLLM(ctx, fallbackLLM, c, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to init fallback llm %s: %w", ref.Name, err)
+		}
+		opts = append(opts, WithFallback(fallbackLLM.GetName(), fallbackModel))
+	}
+	return NewFallbackLLM(llm.GetName(), l, opts...), nil
+}
+
+func getLangchainLLM(ctx context.Context, llm *v1alpha1.LLM, c client.Client, model string) (langchainllms.Model, error) {
 	switch llm.Spec.Provider.GetType() {
 	case v1alpha1.ProviderType3rdParty:
 		apiKey, err := llm.AuthAPIKey(ctx, c)
@@ -61,7 +96,11 @@ func GetLangchainLLM(ctx context.Context, llm *v1alpha1.LLM, c client.Client, mo
 				}
 				model = models[0]
 			}
-			return openai.New(openai.WithToken(apiKey), openai.WithBaseURL(llm.Get3rdPartyLLMBaseURL()), openai.WithModel(model), openai.WithCallback(log.KLogHandler{LogLevel: 3}), openai.WithHTTPClient(DebugHTTPClient))
+			openaiLLM, err := openai.New(openai.WithToken(apiKey), openai.WithBaseURL(llm.Get3rdPartyLLMBaseURL()), openai.WithModel(model), openai.WithCallback(log.KLogHandler{LogLevel: 3}), openai.WithHTTPClient(DebugHTTPClient))
+			if err != nil {
+				return nil, err
+			}
+			return withToolCalling(openaiLLM, llm.Get3rdPartyLLMBaseURL(), apiKey, model), nil
 		case llms.Gemini:
 			if model == "" {
 				models := llm.GetModelList()
@@ -76,6 +115,54 @@ func GetLangchainLLM(ctx context.Context, llm *v1alpha1.LLM, c client.Client, mo
 			}
 			googleLLM.CallbacksHandler = log.GeminiKLogHandler{KLogHandler: &log.KLogHandler{LogLevel: 3}}
 			return googleLLM, nil
+		case llms.Anthropic:
+			model, err = defaultModel(model, llm.GetModelList())
+			if err != nil {
+				return nil, err
+			}
+			return anthropic.NewAnthropicLLM(apiKey, llm.Get3rdPartyLLMBaseURL(), anthropic.WithModel(model), anthropic.WithCallback(log.KLogHandler{LogLevel: 3})), nil
+		case llms.AzureOpenAI:
+			// Azure uses the deployment name as model
+			model, err = defaultModel(model, llm.GetModelList())
+			if err != nil {
+				return nil, err
+			}
+			opts := append(llmsopenai.AzureOptions(apiKey, llm.Get3rdPartyLLMBaseURL(), model), openai.WithCallback(log.KLogHandler{LogLevel: 3}), openai.WithHTTPClient(DebugHTTPClient))
+			return openai.New(opts...)
+		case llms.Moonshot:
+			model, err = defaultModel(model, llm.GetModelList())
+			if err != nil {
+				return nil, err
+			}
+			baseURL := llm.Get3rdPartyLLMBaseURL()
+			if baseURL == "" {
+				baseURL = llmsopenai.MoonshotModelAPIURL
+			}
+			moonshotLLM, err := openai.New(openai.WithToken(apiKey), openai.WithBaseURL(baseURL), openai.WithModel(model), openai.WithCallback(log.KLogHandler{LogLevel: 3}), openai.WithHTTPClient(DebugHTTPClient))
+			if err != nil {
+				return nil, err
+			}
+			return withToolCalling(moonshotLLM, baseURL, apiKey, model), nil
+		case llms.Qianfan:
+			model, err = defaultModel(model, llm.GetModelList())
+			if err != nil {
+				return nil, err
+			}
+			secretKey, err := llm.AuthSecretKey(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			client, err := qianfan.NewQianfan(apiKey, secretKey, llm.Get3rdPartyLLMBaseURL())
+			if err != nil {
+				return nil, err
+			}
+			return qianfan.NewQianfanLLM(client, qianfan.WithModel(model), qianfan.WithCallback(log.KLogHandler{LogLevel: 3})), nil
+		case llms.DashScope:
+			model, err = defaultModel(model, llm.GetModelList())
+			if err != nil {
+				return nil, err
+			}
+			return dashscope.NewDashScopeLLM(apiKey, dashscope.WithModel(model), dashscope.WithCallback(log.KLogHandler{LogLevel: 3})), nil
 		}
 	case v1alpha1.ProviderTypeWorker:
 		gateway, err := config.GetGateway(ctx)
@@ -104,7 +191,32 @@ func GetLangchainLLM(ctx context.Context, llm *v1alpha1.LLM, c client.Client, mo
 		if os.Getenv(GatewayUseExternalURLEnv) == "true" {
 			gatewayURL = gateway.ExternalAPIServer
 		}
-		return openai.New(openai.WithModel(modelName), openai.WithBaseURL(gatewayURL), openai.WithToken("fake"), openai.WithCallback(log.KLogHandler{LogLevel: 3}), openai.WithHTTPClient(DebugHTTPClient))
+		workerLLM, err := openai.New(openai.WithModel(modelName), openai.WithBaseURL(gatewayURL), openai.WithToken("fake"), openai.WithCallback(log.KLogHandler{LogLevel: 3}), openai.WithHTTPClient(DebugHTTPClient))
+		if err != nil {
+			return nil, err
+		}
+		return withToolCalling(workerLLM, gatewayURL, "fake", modelName), nil
 	}
 	return nil, fmt.Errorf("unknown provider type")
 }
+
+// withToolCalling wraps the llm which provides OpenAI compatible apis to support tool calling
+func withToolCalling(llm langchainllms.Model, baseURL, token, model string) langchainllms.Model {
+	client := llmsopenai.NewToolCallingClient(baseURL, token,
+		llmsopenai.WithToolCallingModel(model),
+		llmsopenai.WithToolCallingHTTPClient(DebugHTTPClient),
+		llmsopenai.WithToolCallingCallback(log.KLogHandler{LogLevel: 3}),
+	)
+	return llmsopenai.NewToolCallingLLM(llm, client)
+}
+
+// defaultModel returns the model if specified, otherwise the first one in models
+func defaultModel(model string, models []string) (string, error) {
+	if model != "" {
+		return model, nil
+	}
+	if len(models) == 0 {
+		return "", errors.New("no valid models provided")
+	}
+	return models[0], nil
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NOTE: Reference https://docs.anthropic.com/claude/reference/messages_post
+
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+const (
+	AnthropicAPIURL         = "https://api.anthropic.com/v1"
+	AnthropicAPIVersion     = "2023-06-01"
+	AnthropicDefaultTimeout = 300 * time.Second
+	// DefaultMaxTokens is required by messages api
+	DefaultMaxTokens = 1024
+)
+
+type Role string
+
+const (
+	User      Role = "user"
+	Assistant Role = "assistant"
+)
+
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+type MessagesRequest struct {
+	Model         string    `json:"model"`
+	System        string    `json:"system,omitempty"`
+	Messages      []Message `json:"messages"`
+	MaxTokens     int       `json:"max_tokens"`
+	Temperature   float64   `json:"temperature,omitempty"`
+	TopP          float64   `json:"top_p,omitempty"`
+	StopSequences []string  `json:"stop_sequences,omitempty"`
+	Stream        bool      `json:"stream,omitempty"`
+}
+
+var _ llms.LLM = (*Anthropic)(nil)
+
+type Anthropic struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+func NewAnthropic(apiKey string, baseURL string) *Anthropic {
+	if baseURL == "" {
+		baseURL = AnthropicAPIURL
+	}
+	return &Anthropic{
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: AnthropicDefaultTimeout},
+	}
+}
+
+func (a Anthropic) Type() llms.LLMType {
+	return llms.Anthropic
+}
+
+// Call wraps a common AI api call
+func (a *Anthropic) Call(data []byte) (llms.Response, error) {
+	req := MessagesRequest{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+	return a.CreateMessage(context.Background(), req)
+}
+
+// Validate anthropic service against CallOption
+func (a *Anthropic) Validate(ctx context.Context, options ...langchainllms.CallOption) (llms.Response, error) {
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	model := opts.Model
+	if model == "" {
+		model = llms.AnthropicModels[len(llms.AnthropicModels)-1]
+	}
+	return a.CreateMessage(ctx, MessagesRequest{
+		Model:     model,
+		Messages:  []Message{{Role: User, Content: "Hello"}},
+		MaxTokens: 16,
+	})
+}
+
+// CreateMessage calls messages api and returns the result immediately
+func (a *Anthropic) CreateMessage(ctx context.Context, req MessagesRequest) (*Response, error) {
+	req.Stream = false
+	resp, err := a.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// StreamMessage calls messages api with stream and calls handler with each text delta
+func (a *Anthropic) StreamMessage(ctx context.Context, req MessagesRequest, handler func(text string) error) (*Response, error) {
+	req.Stream = true
+	resp, err := a.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &Response{Kind: "message", Role: Assistant}
+	text := strings.Builder{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		event := &StreamEvent{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), event); err != nil {
+			return nil, err
+		}
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				result.ID = event.Message.ID
+				result.Model = event.Message.Model
+				result.Usage.InputTokens = event.Message.Usage.InputTokens
+			}
+		case "content_block_delta":
+			if event.Delta == nil || event.Delta.Text == "" {
+				continue
+			}
+			text.WriteString(event.Delta.Text)
+			if handler != nil {
+				if err := handler(event.Delta.Text); err != nil {
+					return nil, err
+				}
+			}
+		case "message_delta":
+			if event.Delta != nil {
+				result.StopReason = event.Delta.StopReason
+			}
+			if event.Usage != nil {
+				result.Usage.OutputTokens = event.Usage.OutputTokens
+			}
+		case "error":
+			if event.Error != nil {
+				return nil, fmt.Errorf("anthropic stream error: %s: %s", event.Error.Type, event.Error.Message)
+			}
+			return nil, errors.New("anthropic stream error")
+		case "message_stop":
+			result.Content = []ContentBlock{{Type: "text", Text: text.String()}}
+			return result, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	result.Content = []ContentBlock{{Type: "text", Text: text.String()}}
+	return result, nil
+}
+
+func (a *Anthropic) do(ctx context.Context, req MessagesRequest) (*http.Response, error) {
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = DefaultMaxTokens
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", a.apiKey)
+	httpReq.Header.Set("anthropic-version", AnthropicAPIVersion)
+	if req.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		errResp := &ErrorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(errResp); err != nil || errResp.Error == nil {
+			return nil, fmt.Errorf("exception: %s", resp.Status)
+		}
+		return nil, fmt.Errorf("exception: %s: %s", resp.Status, errResp.Error.Message)
+	}
+	return resp, nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tmc/langchaingo/callbacks"
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+var (
+	ErrEmptyResponse = errors.New("no response")
+	ErrEmptyPrompt   = errors.New("empty prompt")
+)
+
+var (
+	_ langchainllms.Model = (*AnthropicLLM)(nil)
+)
+
+type options struct {
+	model            string
+	callbacksHandler callbacks.Handler
+}
+
+type Option func(*options)
+
+// WithModel sets the default model if the model is not set in call options
+func WithModel(model string) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+func WithCallback(callbacksHandler callbacks.Handler) Option {
+	return func(o *options) {
+		o.callbacksHandler = callbacksHandler
+	}
+}
+
+type AnthropicLLM struct {
+	c       *Anthropic
+	options *options
+}
+
+func NewAnthropicLLM(apiKey, baseURL string, opts ...Option) *AnthropicLLM {
+	a := &AnthropicLLM{
+		c:       NewAnthropic(apiKey, baseURL),
+		options: &options{},
+	}
+	for _, opt := range opts {
+		opt(a.options)
+	}
+	return a
+}
+
+func (a *AnthropicLLM) Call(ctx context.Context, prompt string, options ...langchainllms.CallOption) (string, error) {
+	return langchainllms.GenerateFromSinglePrompt(ctx, a, prompt, options...)
+}
+
+func (a *AnthropicLLM) GenerateContent(ctx context.Context, messages []langchainllms.MessageContent, options ...langchainllms.CallOption) (*langchainllms.ContentResponse, error) {
+	if a.options.callbacksHandler != nil {
+		a.options.callbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+	if len(messages) == 0 {
+		return nil, ErrEmptyPrompt
+	}
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	req := MessagesRequest{
+		Model:         a.options.model,
+		MaxTokens:     opts.MaxTokens,
+		Temperature:   opts.Temperature,
+		TopP:          opts.TopP,
+		StopSequences: opts.StopWords,
+	}
+	if opts.Model != "" {
+		req.Model = opts.Model
+	}
+	req.System, req.Messages = toMessages(messages)
+	if len(req.Messages) == 0 {
+		return nil, ErrEmptyPrompt
+	}
+
+	var resp *Response
+	var err error
+	if opts.StreamingFunc != nil {
+		resp, err = a.c.StreamMessage(ctx, req, func(text string) error {
+			return opts.StreamingFunc(ctx, []byte(text))
+		})
+	} else {
+		resp, err = a.c.CreateMessage(ctx, req)
+	}
+	if err != nil {
+		if a.options.callbacksHandler != nil {
+			a.options.callbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+	if resp == nil || len(resp.Content) == 0 {
+		return nil, ErrEmptyResponse
+	}
+	response := &langchainllms.ContentResponse{Choices: []*langchainllms.ContentChoice{{
+		Content:    resp.String(),
+		StopReason: resp.StopReason,
+		GenerationInfo: map[string]any{
+			"InputTokens":  resp.Usage.InputTokens,
+			"OutputTokens": resp.Usage.OutputTokens,
+			"TotalTokens":  resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}}}
+	if a.options.callbacksHandler != nil {
+		a.options.callbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+	}
+	return response, nil
+}
+
+// toMessages converts langchain messages to the system prompt and messages of anthropic.
+// Anthropic requires messages alternate between user and assistant and start with user,
+// so successive messages of the same role are merged.
+func toMessages(messages []langchainllms.MessageContent) (string, []Message) {
+	var system []string
+	result := make([]Message, 0, len(messages))
+	for _, mc := range messages {
+		content := textOf(mc)
+		var role Role
+		switch mc.Role {
+		case schema.ChatMessageTypeSystem:
+			system = append(system, content)
+			continue
+		case schema.ChatMessageTypeAI:
+			role = Assistant
+		default:
+			role = User
+		}
+		if len(result) == 0 && role == Assistant {
+			result = append(result, Message{Role: User, Content: "Hello"})
+		}
+		if len(result) > 0 && result[len(result)-1].Role == role {
+			result[len(result)-1].Content += "\n" + content
+			continue
+		}
+		result = append(result, Message{Role: role, Content: content})
+	}
+	return strings.Join(system, "\n"), result
+}
+
+func textOf(mc langchainllms.MessageContent) string {
+	texts := make([]string, 0, len(mc.Parts))
+	for _, part := range mc.Parts {
+		if c, ok := part.(langchainllms.TextContent); ok {
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
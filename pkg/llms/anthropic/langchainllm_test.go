@@ -0,0 +1,66 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestGenerateContentStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := MessagesRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.System != "be brief" || len(req.Messages) != 3 || req.Messages[0].Role != User || req.Messages[1].Role != Assistant {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, text := range []string{"Hello", " world"} {
+			fmt.Fprintf(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":%q}}\n\n", text)
+		}
+		fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":2}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	llm := NewAnthropicLLM("fake", server.URL, WithModel("claude-3-haiku-20240307"))
+	var streamed string
+	resp, err := llm.GenerateContent(context.Background(), []langchainllms.MessageContent{
+		{Role: schema.ChatMessageTypeSystem, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "be brief"}}},
+		{Role: schema.ChatMessageTypeHuman, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "hi"}}},
+		{Role: schema.ChatMessageTypeAI, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "hi"}}},
+		{Role: schema.ChatMessageTypeHuman, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "say hello"}}},
+	}, langchainllms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		streamed += string(chunk)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed != "Hello world" || resp.Choices[0].Content != "Hello world" || resp.Choices[0].StopReason != "end_turn" {
+		t.Fatalf("unexpected response: streamed %q, got %+v", streamed, resp.Choices[0])
+	}
+}
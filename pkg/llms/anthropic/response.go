@@ -0,0 +1,95 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+var _ llms.Response = (*Response)(nil)
+
+type ContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type Response struct {
+	ID         string         `json:"id"`
+	Kind       string         `json:"type"`
+	Role       Role           `json:"role"`
+	Model      string         `json:"model"`
+	Content    []ContentBlock `json:"content"`
+	StopReason string         `json:"stop_reason,omitempty"`
+	Usage      Usage          `json:"usage"`
+}
+
+type Error struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type ErrorResponse struct {
+	Type  string `json:"type"`
+	Error *Error `json:"error,omitempty"`
+}
+
+type StreamDelta struct {
+	Type       string `json:"type"`
+	Text       string `json:"text,omitempty"`
+	StopReason string `json:"stop_reason,omitempty"`
+}
+
+// StreamEvent is the data of a server-sent event in streaming mode
+type StreamEvent struct {
+	Type    string       `json:"type"`
+	Message *Response    `json:"message,omitempty"`
+	Delta   *StreamDelta `json:"delta,omitempty"`
+	Usage   *Usage       `json:"usage,omitempty"`
+	Error   *Error       `json:"error,omitempty"`
+}
+
+func (response *Response) Type() llms.LLMType {
+	return llms.Anthropic
+}
+
+func (response *Response) Bytes() []byte {
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		return []byte{}
+	}
+	return bytes
+}
+
+func (response *Response) String() string {
+	texts := make([]string, 0, len(response.Content))
+	for _, c := range response.Content {
+		texts = append(texts, c.Text)
+	}
+	return strings.Join(texts, "")
+}
+
+func (response *Response) Unmarshal(bytes []byte) error {
+	return json.Unmarshal(bytes, response)
+}
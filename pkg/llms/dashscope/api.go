@@ -36,6 +36,10 @@ const (
 type Model string
 
 const (
+	// 通义千问商业版模型，按效果和速度分为 turbo / plus / max
+	QWENTurbo Model = "qwen-turbo"
+	QWENPlus  Model = "qwen-plus"
+	QWENMax   Model = "qwen-max"
 	// 通义千问对外开源的 14B / 7B 规模参数量的经过人类指令对齐的 chat 模型
 	QWEN14BChat Model = "qwen-14b-chat"
 	QWEN7BChat  Model = "qwen-7b-chat"
@@ -75,8 +79,27 @@ func (z *DashScope) Call(data []byte) (llms.Response, error) {
 	return do(context.TODO(), DashScopeChatURL, z.apiKey, data, z.sse, false, params.Model)
 }
 
+// Validate sends a simple message to check whether the api key and the model are available.
+// qwen-turbo is used if the model is not set in call options.
 func (z *DashScope) Validate(ctx context.Context, options ...langchainllms.CallOption) (llms.Response, error) {
-	return nil, errors.New("not implemented")
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	params := DefaultModelParams()
+	params.Model = QWENTurbo
+	if opts.Model != "" {
+		params.Model = Model(opts.Model)
+	}
+	params.Input.Messages = []Message{{Role: User, Content: "Hello"}}
+	resp, err := do(ctx, DashScopeChatURL, z.apiKey, params.Marshal(), false, false, params.Model)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 func (z *DashScope) CreateEmbedding(ctx context.Context, inputTexts []string, query bool) ([]Embeddings, error) {
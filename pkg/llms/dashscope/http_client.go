@@ -70,10 +70,20 @@ func do(ctx context.Context, apiURL, token string, data []byte, sse, async bool,
 	}
 	defer resp.Body.Close()
 	var respData llms.Response
+	var common *CommonResponse
 	if model == CHATGLM6BV2 {
-		respData = &ResponseChatGLB6B{}
+		r := &ResponseChatGLB6B{}
+		respData, common = r, &r.CommonResponse
 	} else {
-		respData = &Response{}
+		r := &Response{}
+		respData, common = r, &r.CommonResponse
 	}
-	return parseHTTPResponse(resp, respData)
+	if _, err := parseHTTPResponse(resp, respData); err != nil {
+		return nil, err
+	}
+	// the http api doesn't return status_code in body
+	if common.StatusCode == 0 {
+		common.StatusCode = resp.StatusCode
+	}
+	return respData, nil
 }
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashscope
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tmc/langchaingo/callbacks"
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+var (
+	ErrEmptyResponse = errors.New("no response")
+	ErrEmptyPrompt   = errors.New("empty prompt")
+)
+
+var (
+	_ langchainllms.Model = (*DashScopeLLM)(nil)
+)
+
+type options struct {
+	model            string
+	callbacksHandler callbacks.Handler
+}
+
+type Option func(*options)
+
+// WithModel sets the default model if the model is not set in call options
+func WithModel(model string) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+func WithCallback(callbacksHandler callbacks.Handler) Option {
+	return func(o *options) {
+		o.callbacksHandler = callbacksHandler
+	}
+}
+
+type DashScopeLLM struct {
+	c       *DashScope
+	options *options
+}
+
+func NewDashScopeLLM(apiKey string, opts ...Option) *DashScopeLLM {
+	d := &DashScopeLLM{
+		c:       NewDashScope(apiKey, false),
+		options: &options{model: string(QWENTurbo)},
+	}
+	for _, opt := range opts {
+		opt(d.options)
+	}
+	return d
+}
+
+func (d *DashScopeLLM) Call(ctx context.Context, prompt string, options ...langchainllms.CallOption) (string, error) {
+	return langchainllms.GenerateFromSinglePrompt(ctx, d, prompt, options...)
+}
+
+func (d *DashScopeLLM) GenerateContent(ctx context.Context, messages []langchainllms.MessageContent, options ...langchainllms.CallOption) (*langchainllms.ContentResponse, error) {
+	if d.options.callbacksHandler != nil {
+		d.options.callbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+	if len(messages) == 0 {
+		return nil, ErrEmptyPrompt
+	}
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	params := DefaultModelParams()
+	params.Model = Model(d.options.model)
+	if opts.Model != "" {
+		params.Model = Model(opts.Model)
+	}
+	params.Parameters.Stop = opts.StopWords
+	if opts.TopP > 0 && opts.TopP < 1 {
+		params.Parameters.TopP = float32(opts.TopP)
+	}
+	if opts.Temperature > 0 && opts.Temperature < 2 {
+		params.Parameters.Temperature = float32(opts.Temperature)
+	}
+	if opts.MaxTokens > 0 {
+		params.Parameters.MaxTokens = opts.MaxTokens
+	}
+	if opts.RepetitionPenalty > 0 {
+		params.Parameters.RepetitionPenalty = float32(opts.RepetitionPenalty)
+	}
+	params.Input.Messages = toMessages(messages)
+	if err := ValidateModelParams(params); err != nil {
+		return nil, err
+	}
+
+	var resp llms.Response
+	var err error
+	if opts.StreamingFunc != nil {
+		resp, err = d.c.StreamChat(ctx, params.Marshal(), func(delta string) error {
+			return opts.StreamingFunc(ctx, []byte(delta))
+		})
+	} else {
+		resp, err = do(ctx, DashScopeChatURL, d.c.apiKey, params.Marshal(), false, false, params.Model)
+		if err == nil {
+			err = responseError(resp)
+		}
+	}
+	if err != nil {
+		if d.options.callbacksHandler != nil {
+			d.options.callbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+	if resp == nil {
+		return nil, ErrEmptyResponse
+	}
+	choice := &langchainllms.ContentChoice{Content: resp.String()}
+	if r, ok := resp.(*Response); ok {
+		if len(r.Output.Choices) > 0 {
+			choice.StopReason = string(r.Output.Choices[len(r.Output.Choices)-1].FinishReason)
+		}
+		choice.GenerationInfo = map[string]any{
+			"PromptTokens":     r.Usage.InputTokens,
+			"CompletionTokens": r.Usage.OutputTokens,
+			"TotalTokens":      r.Usage.InputTokens + r.Usage.OutputTokens,
+		}
+	}
+	response := &langchainllms.ContentResponse{Choices: []*langchainllms.ContentChoice{choice}}
+	if d.options.callbacksHandler != nil {
+		d.options.callbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+	}
+	return response, nil
+}
+
+// toMessages converts langchain messages to dashscope messages.
+// Only one system message is allowed and it must be the first one, so all system messages are merged.
+func toMessages(messages []langchainllms.MessageContent) []Message {
+	var system []string
+	result := make([]Message, 0, len(messages))
+	for _, mc := range messages {
+		content := textOf(mc)
+		switch mc.Role {
+		case schema.ChatMessageTypeSystem:
+			system = append(system, content)
+		case schema.ChatMessageTypeAI:
+			result = append(result, Message{Role: Assistant, Content: content})
+		default:
+			result = append(result, Message{Role: User, Content: content})
+		}
+	}
+	if len(system) > 0 {
+		result = append([]Message{{Role: System, Content: strings.Join(system, "\n")}}, result...)
+	}
+	return result
+}
+
+func textOf(mc langchainllms.MessageContent) string {
+	texts := make([]string, 0, len(mc.Parts))
+	for _, part := range mc.Parts {
+		if c, ok := part.(langchainllms.TextContent); ok {
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashscope
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// redirectTransport sends all requests to the stub server instead of dashscope
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (r *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGenerateContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fake" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":"InvalidApiKey","message":"Invalid API-key provided.","request_id":"1"}`)
+			return
+		}
+		params := ModelParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		messages := params.Input.Messages
+		if params.Model != QWENPlus || len(messages) != 4 || messages[0].Role != System || messages[0].Content != "be brief" || messages[2].Role != Assistant {
+			t.Errorf("unexpected request: %+v", params)
+		}
+		if r.Header.Get("X-DashScope-SSE") != "enable" {
+			fmt.Fprint(w, `{"output":{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"Hello world"}}]},"usage":{"input_tokens":5,"output_tokens":2},"request_id":"1"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		// each result event has the full content generated so far
+		for i, content := range []string{"Hello", "Hello world"} {
+			finish := "null"
+			if i == 1 {
+				finish = "stop"
+			}
+			fmt.Fprintf(w, "id:%d\nevent:result\n:HTTP_STATUS/200\ndata:{\"output\":{\"choices\":[{\"finish_reason\":%q,\"message\":{\"role\":\"assistant\",\"content\":%q}}]},\"usage\":{\"input_tokens\":5,\"output_tokens\":%d},\"request_id\":\"1\"}\n\n", i+1, finish, content, i+1)
+		}
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	defaultTransport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = &redirectTransport{target: target}
+	defer func() { http.DefaultClient.Transport = defaultTransport }()
+
+	llm := NewDashScopeLLM("fake", WithModel(string(QWENPlus)))
+	messages := []langchainllms.MessageContent{
+		{Role: schema.ChatMessageTypeSystem, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "be brief"}}},
+		{Role: schema.ChatMessageTypeHuman, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "hi"}}},
+		{Role: schema.ChatMessageTypeAI, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "hi"}}},
+		{Role: schema.ChatMessageTypeHuman, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "say hello"}}},
+	}
+
+	resp, err := llm.GenerateContent(context.Background(), messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Content != "Hello world" || resp.Choices[0].StopReason != "stop" || resp.Choices[0].GenerationInfo["TotalTokens"] != 7 {
+		t.Fatalf("unexpected response: %+v", resp.Choices[0])
+	}
+
+	var chunks []string
+	resp, err = llm.GenerateContent(context.Background(), messages, langchainllms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		chunks = append(chunks, string(chunk))
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(chunks, "|") != "Hello| world" || resp.Choices[0].Content != "Hello world" || resp.Choices[0].StopReason != "stop" {
+		t.Fatalf("unexpected response: streamed %q, got %+v", chunks, resp.Choices[0])
+	}
+
+	if _, err = NewDashScopeLLM("wrong").Call(context.Background(), "hi"); err == nil || !strings.Contains(err.Error(), "InvalidApiKey") {
+		t.Fatalf("expect the error of api key, got %v", err)
+	}
+}
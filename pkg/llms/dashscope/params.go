@@ -53,11 +53,17 @@ type Input struct {
 	History  *[]string `json:"history,omitempty"`
 }
 
+// +kubebuilder:object:generate=true
+
 type Parameters struct {
-	TopP         float32 `json:"top_p,omitempty"`
-	TopK         int     `json:"top_k,omitempty"`
-	Seed         int     `json:"seed,omitempty"`
-	ResultFormat string  `json:"result_format,omitempty"`
+	TopP              float32  `json:"top_p,omitempty"`
+	TopK              int      `json:"top_k,omitempty"`
+	Seed              int      `json:"seed,omitempty"`
+	ResultFormat      string   `json:"result_format,omitempty"`
+	Temperature       float32  `json:"temperature,omitempty"`
+	MaxTokens         int      `json:"max_tokens,omitempty"`
+	RepetitionPenalty float32  `json:"repetition_penalty,omitempty"`
+	Stop              []string `json:"stop,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -19,6 +19,7 @@ package dashscope
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 
 	"github.com/kubeagi/arcadia/pkg/llms"
 )
@@ -33,6 +34,22 @@ type CommonResponse struct {
 	Message    string `json:"message,omitempty"`
 	RequestID  string `json:"request_id"`
 }
+
+// Err returns the error in response if the status code is not 200 or the error code is set
+func (r *CommonResponse) Err() error {
+	if (r.StatusCode == 0 || r.StatusCode == 200) && r.Code == "" {
+		return nil
+	}
+	return fmt.Errorf("exception: %d %s: %s", r.StatusCode, r.Code, r.Message)
+}
+
+func responseError(resp llms.Response) error {
+	if r, ok := resp.(interface{ Err() error }); ok {
+		return r.Err()
+	}
+	return nil
+}
+
 type Response struct {
 	CommonResponse
 	Output Output `json:"output"`
@@ -73,6 +73,80 @@ func (z *DashScope) StreamCall(ctx context.Context, data []byte, handler func(ev
 	}
 }
 
+// StreamChat calls the chat api in sse mode and returns the last result.
+// DashScope returns the full content generated so far in each result event, so handler is called with the delta content.
+func (z *DashScope) StreamChat(ctx context.Context, data []byte, handler func(delta string) error) (*Response, error) {
+	resp, err := req(ctx, DashScopeChatURL, z.apiKey, data, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	eventChan, errorChan := NewSSEClient().Events(resp)
+
+	var result *Response
+	var last string
+	var callErr error
+	// stop reading the stream once an error occurs, the read loop exits after the body is closed
+	fail := func(err error) {
+		if callErr == nil {
+			callErr = err
+			resp.Body.Close()
+		}
+	}
+	for {
+		select {
+		case err := <-errorChan:
+			if callErr != nil {
+				return nil, callErr
+			}
+			if err != nil {
+				return nil, err
+			}
+			if result == nil {
+				return nil, fmt.Errorf("exception: %d: no result in response", resp.StatusCode)
+			}
+			return result, nil
+		case event := <-eventChan:
+			if callErr != nil {
+				continue
+			}
+			data := new(Response)
+			if err := json.Unmarshal(event.Data, data); err != nil {
+				fail(fmt.Errorf("failed to parse event %s: %w", event.Data, err))
+				continue
+			}
+			if data.StatusCode == 0 {
+				data.StatusCode = resp.StatusCode
+			}
+			if string(event.Event) == "error" {
+				if err := data.Err(); err != nil {
+					fail(err)
+				} else {
+					fail(fmt.Errorf("exception: %s", event.Data))
+				}
+				continue
+			}
+			if string(event.Event) != "result" {
+				continue
+			}
+			if err := data.Err(); err != nil {
+				fail(err)
+				continue
+			}
+			result = data
+			content := data.String()
+			delta := strings.TrimPrefix(content, last)
+			last = content
+			if delta == "" || handler == nil {
+				continue
+			}
+			if err := handler(delta); err != nil {
+				fail(err)
+			}
+		}
+	}
+}
+
 var (
 	headerID    = []byte("id:")
 	headerData  = []byte("data:")
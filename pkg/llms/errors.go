@@ -0,0 +1,94 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package llms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrorClass is the class of an error returned by a llm call
+// +kubebuilder:validation:Enum=timeout;rateLimit;server;network;client;unknown
+type ErrorClass string
+
+const (
+	// ErrorClassTimeout means the call exceeded its deadline
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassRateLimit means the provider rejected the call with 429 or a quota error
+	ErrorClassRateLimit ErrorClass = "rateLimit"
+	// ErrorClassServer means the provider returned a 5xx
+	ErrorClassServer ErrorClass = "server"
+	// ErrorClassNetwork means the provider can't be reached
+	ErrorClassNetwork ErrorClass = "network"
+	// ErrorClassClient means the request itself is bad, like 400/401/403/404
+	ErrorClassClient ErrorClass = "client"
+	// ErrorClassUnknown means the error can't be classified
+	ErrorClassUnknown ErrorClass = "unknown"
+)
+
+// DefaultRetryableErrorClasses are the error classes which are worth retrying by default
+var DefaultRetryableErrorClasses = []ErrorClass{ErrorClassTimeout, ErrorClassRateLimit, ErrorClassServer, ErrorClassNetwork}
+
+// statusCodeRegexp matches the status code in error messages like:
+// - openai: API returned unexpected status code: 429: ...
+// - zhipuai/dashscope: exception: 503 Service Unavailable
+var statusCodeRegexp = regexp.MustCompile(`(?:status code:?|exception:)\s*(\d{3})`)
+
+// ClassifyError returns the class of an error returned by a llm call
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+	if matches := statusCodeRegexp.FindStringSubmatch(err.Error()); len(matches) == 2 {
+		code, _ := strconv.Atoi(matches[1])
+		switch {
+		case code == 408:
+			return ErrorClassTimeout
+		case code == 429:
+			return ErrorClassRateLimit
+		case code >= 500:
+			return ErrorClassServer
+		case code >= 400:
+			return ErrorClassClient
+		}
+	}
+	if netErr != nil || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorClassNetwork
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "rate limit") || strings.Contains(msg, "too many requests"):
+		return ErrorClassRateLimit
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded"):
+		return ErrorClassTimeout
+	}
+	return ErrorClassUnknown
+}
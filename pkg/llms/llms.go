@@ -26,16 +26,25 @@ import (
 type LLMType string
 
 const (
-	OpenAI    LLMType = "openai"
-	ZhiPuAI   LLMType = "zhipuai"
-	DashScope LLMType = "dashscope"
-	Gemini    LLMType = "gemini"
-	Unknown   LLMType = "unknown"
+	OpenAI      LLMType = "openai"
+	ZhiPuAI     LLMType = "zhipuai"
+	DashScope   LLMType = "dashscope"
+	Gemini      LLMType = "gemini"
+	Anthropic   LLMType = "anthropic"
+	AzureOpenAI LLMType = "azureopenai"
+	Qianfan     LLMType = "qianfan"
+	Moonshot    LLMType = "moonshot"
+	Unknown     LLMType = "unknown"
 )
 
 var (
-	OpenAIModels = []string{"gpt-3.5", "gpt-3.5-turbo"}
-	GeminiModels = []string{"gemini-pro"}
+	OpenAIModels    = []string{"gpt-3.5", "gpt-3.5-turbo"}
+	GeminiModels    = []string{"gemini-pro"}
+	AnthropicModels = []string{"claude-3-opus-20240229", "claude-3-sonnet-20240229", "claude-3-haiku-20240307"}
+	QianfanModels   = []string{"ERNIE-4.0-8K", "ERNIE-3.5-8K", "ERNIE-Speed-8K", "ERNIE-Lite-8K"}
+	MoonshotModels  = []string{"moonshot-v1-8k", "moonshot-v1-32k", "moonshot-v1-128k"}
+	DashScopeModels = []string{"qwen-turbo", "qwen-plus", "qwen-max"}
+	// AzureOpenAI has no default models, the models must be the deployment names in spec.models
 )
 
 var (
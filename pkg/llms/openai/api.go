@@ -20,6 +20,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	langchainllms "github.com/tmc/langchaingo/llms"
@@ -31,11 +33,17 @@ import (
 const (
 	OpenaiModelAPIURL    = "https://api.openai.com/v1"
 	OpenaiDefaultTimeout = 300 * time.Second
+	MoonshotModelAPIURL  = "https://api.moonshot.cn/v1"
+	// AzureAPIVersionQuery is the query in azure endpoint url to set the api version,
+	// like https://{resource}.openai.azure.com?api-version=2024-02-01
+	AzureAPIVersionQuery = "api-version"
 )
 
 var _ llms.LLM = (*OpenAI)(nil)
 
+// OpenAI is the client of OpenAI and the services which provide OpenAI compatible apis, like Azure OpenAI and Moonshot
 type OpenAI struct {
+	llmType llms.LLMType
 	apiKey  string
 	baseURL string
 }
@@ -51,13 +59,62 @@ func NewOpenAI(apiKey string, baseURL string) (*OpenAI, error) {
 	}
 
 	return &OpenAI{
+		llmType: llms.OpenAI,
 		apiKey:  apiKey,
 		baseURL: baseURL,
 	}, nil
 }
 
+// NewMoonshot returns a client of Moonshot which provides OpenAI compatible apis
+func NewMoonshot(apiKey string, baseURL string) (*OpenAI, error) {
+	if baseURL == "" {
+		baseURL = MoonshotModelAPIURL
+	}
+	o, err := NewOpenAI(apiKey, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	o.llmType = llms.Moonshot
+	return o, nil
+}
+
+// NewAzureOpenAI returns a client of Azure OpenAI
+func NewAzureOpenAI(apiKey string, baseURL string) (*OpenAI, error) {
+	if baseURL == "" {
+		return nil, errors.New("endpoint url of azure openai is required")
+	}
+	o, err := NewOpenAI(apiKey, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	o.llmType = llms.AzureOpenAI
+	return o, nil
+}
+
+// AzureOptions returns the options to access Azure OpenAI service.
+// The api version can be set by the api-version query in baseURL, DefaultAPIVersion by default.
+// Azure uses deployment name as the model, so model is required.
+func AzureOptions(apiKey, baseURL, model string) []langchainopenai.Option {
+	apiVersion := langchainopenai.DefaultAPIVersion
+	if u, err := url.Parse(baseURL); err == nil {
+		if v := u.Query().Get(AzureAPIVersionQuery); v != "" {
+			apiVersion = v
+		}
+		u.RawQuery = ""
+		baseURL = u.String()
+	}
+	return []langchainopenai.Option{
+		langchainopenai.WithAPIType(langchainopenai.APITypeAzure),
+		langchainopenai.WithAPIVersion(apiVersion),
+		langchainopenai.WithBaseURL(strings.TrimSuffix(baseURL, "/")),
+		langchainopenai.WithToken(apiKey),
+		langchainopenai.WithModel(model),
+		langchainopenai.WithEmbeddingModel(model),
+	}
+}
+
 func (o OpenAI) Type() llms.LLMType {
-	return llms.OpenAI
+	return o.llmType
 }
 
 func (o *OpenAI) Call(data []byte) (llms.Response, error) {
@@ -67,10 +124,18 @@ func (o *OpenAI) Call(data []byte) (llms.Response, error) {
 // Validate OpenAI service
 func (o *OpenAI) Validate(ctx context.Context, options ...langchainllms.CallOption) (llms.Response, error) {
 	// validate against models
-	llm, err := langchainopenai.New(
+	llmOptions := []langchainopenai.Option{
 		langchainopenai.WithBaseURL(o.baseURL),
 		langchainopenai.WithToken(o.apiKey),
-	)
+	}
+	if o.llmType == llms.AzureOpenAI {
+		callOptions := langchainllms.CallOptions{}
+		for _, opt := range options {
+			opt(&callOptions)
+		}
+		llmOptions = AzureOptions(o.apiKey, o.baseURL, callOptions.Model)
+	}
+	llm, err := langchainopenai.New(llmOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("init openai client: %w", err)
 	}
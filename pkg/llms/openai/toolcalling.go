@@ -0,0 +1,392 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tmc/langchaingo/callbacks"
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+var (
+	_ langchainllms.Model = (*ToolCallingLLM)(nil)
+
+	ErrEmptyResponse = errors.New("no response")
+)
+
+// ToolCallingClient calls the chat completions api with tools.
+// It works with OpenAI and the services which provide OpenAI compatible apis,
+// like the workers behind arcadia gateway and ZhiPuAI v4 api.
+type ToolCallingClient struct {
+	baseURL          string
+	model            string
+	token            func() (string, error)
+	httpClient       *http.Client
+	callbacksHandler callbacks.Handler
+}
+
+type ToolCallingOption func(*ToolCallingClient)
+
+// WithToolCallingModel sets the default model if the model is not set in call options
+func WithToolCallingModel(model string) ToolCallingOption {
+	return func(c *ToolCallingClient) {
+		c.model = model
+	}
+}
+
+// WithToolCallingHTTPClient sets the http client
+func WithToolCallingHTTPClient(client *http.Client) ToolCallingOption {
+	return func(c *ToolCallingClient) {
+		c.httpClient = client
+	}
+}
+
+// WithTokenFunc sets the function to get the bearer token of each request, for services which use short-lived tokens
+func WithTokenFunc(token func() (string, error)) ToolCallingOption {
+	return func(c *ToolCallingClient) {
+		c.token = token
+	}
+}
+
+func WithToolCallingCallback(callbacksHandler callbacks.Handler) ToolCallingOption {
+	return func(c *ToolCallingClient) {
+		c.callbacksHandler = callbacksHandler
+	}
+}
+
+func NewToolCallingClient(baseURL, token string, opts ...ToolCallingOption) *ToolCallingClient {
+	if baseURL == "" {
+		baseURL = OpenaiModelAPIURL
+	}
+	c := &ToolCallingClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      func() (string, error) { return token, nil },
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Tools       []chatTool    `json:"tools,omitempty"`
+	ToolChoice  string        `json:"tool_choice,omitempty"`
+	Temperature float64       `json:"temperature,omitempty"`
+	TopP        float64       `json:"top_p,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+}
+
+type chatMessage struct {
+	Role       string         `json:"role,omitempty"`
+	Content    string         `json:"content"`
+	Name       string         `json:"name,omitempty"`
+	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+type chatTool struct {
+	Type     string                           `json:"type"`
+	Function langchainllms.FunctionDefinition `json:"function"`
+}
+
+type chatToolCall struct {
+	// Index is only set in stream deltas
+	Index    *int             `json:"index,omitempty"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function chatFunctionCall `json:"function"`
+}
+
+type chatFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		Delta        chatMessage `json:"delta"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+	// fastchat returns errors in stream like {"text": "...", "error_code": 50001}
+	Text      string `json:"text,omitempty"`
+	ErrorCode int    `json:"error_code,omitempty"`
+}
+
+func (r *chatResponse) err() error {
+	if r.Error != nil {
+		return fmt.Errorf("API returned error: %s", r.Error.Message)
+	}
+	if r.ErrorCode != 0 {
+		return fmt.Errorf("API returned error code %d: %s", r.ErrorCode, r.Text)
+	}
+	return nil
+}
+
+// GenerateContent calls the chat completions api with the functions in opts as tools
+func (c *ToolCallingClient) GenerateContent(ctx context.Context, messages []langchainllms.MessageContent, opts langchainllms.CallOptions) (*langchainllms.ContentResponse, error) {
+	if c.callbacksHandler != nil {
+		c.callbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+	req := chatRequest{
+		Model:       c.model,
+		Messages:    toChatMessages(messages),
+		ToolChoice:  string(opts.FunctionCallBehavior),
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		MaxTokens:   opts.MaxTokens,
+		Stop:        opts.StopWords,
+		Stream:      opts.StreamingFunc != nil,
+	}
+	if opts.Model != "" {
+		req.Model = opts.Model
+	}
+	for _, fn := range opts.Functions {
+		req.Tools = append(req.Tools, chatTool{Type: "function", Function: fn})
+	}
+	if len(req.Tools) == 0 {
+		req.ToolChoice = ""
+	}
+	resp, err := c.createChat(ctx, req, opts.StreamingFunc)
+	if err != nil {
+		if c.callbacksHandler != nil {
+			c.callbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+	if c.callbacksHandler != nil {
+		c.callbacksHandler.HandleLLMGenerateContentEnd(ctx, resp)
+	}
+	return resp, nil
+}
+
+func (c *ToolCallingClient) createChat(ctx context.Context, req chatRequest, streamingFunc func(ctx context.Context, chunk []byte) error) (*langchainllms.ContentResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned unexpected status code: %d: %s", resp.StatusCode, msg)
+	}
+
+	if !req.Stream {
+		result := &chatResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return nil, err
+		}
+		if err := result.err(); err != nil {
+			return nil, err
+		}
+		if len(result.Choices) == 0 {
+			return nil, ErrEmptyResponse
+		}
+		choice := result.Choices[0]
+		return toContentResponse(choice.Message.Content, choice.Message.ToolCalls, choice.FinishReason, result), nil
+	}
+
+	var content strings.Builder
+	var toolCalls []chatToolCall
+	var finishReason string
+	last := &chatResponse{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+		chunk := &chatResponse{}
+		if err := json.Unmarshal([]byte(data), chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse stream chunk %s: %w", data, err)
+		}
+		if err := chunk.err(); err != nil {
+			return nil, err
+		}
+		if chunk.Usage.TotalTokens > 0 {
+			last = chunk
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+		for i, call := range delta.ToolCalls {
+			index := i
+			if call.Index != nil {
+				index = *call.Index
+			}
+			for len(toolCalls) <= index {
+				toolCalls = append(toolCalls, chatToolCall{})
+			}
+			if call.ID != "" {
+				toolCalls[index].ID = call.ID
+			}
+			toolCalls[index].Function.Name += call.Function.Name
+			toolCalls[index].Function.Arguments += call.Function.Arguments
+		}
+		// tool calls are not streamed, agents decide how to show them
+		if delta.Content == "" {
+			continue
+		}
+		content.WriteString(delta.Content)
+		if err := streamingFunc(ctx, []byte(delta.Content)); err != nil {
+			return nil, fmt.Errorf("streaming func returned an error: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return toContentResponse(content.String(), toolCalls, finishReason, last), nil
+}
+
+func toContentResponse(content string, toolCalls []chatToolCall, finishReason string, resp *chatResponse) *langchainllms.ContentResponse {
+	choice := &langchainllms.ContentChoice{
+		Content:    content,
+		StopReason: finishReason,
+		GenerationInfo: map[string]any{
+			"CompletionTokens": resp.Usage.CompletionTokens,
+			"PromptTokens":     resp.Usage.PromptTokens,
+			"TotalTokens":      resp.Usage.TotalTokens,
+		},
+	}
+	if len(toolCalls) > 0 {
+		calls := make([]llms.ToolCall, 0, len(toolCalls))
+		for _, call := range toolCalls {
+			calls = append(calls, llms.ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+		}
+		choice.GenerationInfo[llms.GenerationInfoToolCalls] = calls
+		choice.FuncCall = &schema.FunctionCall{Name: calls[0].Name, Arguments: calls[0].Arguments}
+	}
+	return &langchainllms.ContentResponse{Choices: []*langchainllms.ContentChoice{choice}}
+}
+
+func toChatMessages(messages []langchainllms.MessageContent) []chatMessage {
+	result := make([]chatMessage, 0, len(messages))
+	for _, mc := range messages {
+		msg := chatMessage{}
+		switch mc.Role {
+		case schema.ChatMessageTypeSystem:
+			msg.Role = "system"
+		case schema.ChatMessageTypeAI:
+			msg.Role = "assistant"
+		case schema.ChatMessageTypeFunction:
+			msg.Role = "tool"
+		default:
+			msg.Role = "user"
+		}
+		texts := make([]string, 0, len(mc.Parts))
+		for _, part := range mc.Parts {
+			switch p := part.(type) {
+			case langchainllms.TextContent:
+				texts = append(texts, p.Text)
+			case llms.ToolCallPart:
+				if p.Text != "" {
+					texts = append(texts, p.Text)
+				}
+				for _, call := range p.ToolCalls {
+					msg.ToolCalls = append(msg.ToolCalls, chatToolCall{
+						ID:       call.ID,
+						Type:     "function",
+						Function: chatFunctionCall{Name: call.Name, Arguments: call.Arguments},
+					})
+				}
+			case llms.ToolResultPart:
+				texts = append(texts, p.Text)
+				msg.ToolCallID = p.ToolCallID
+				msg.Name = p.Name
+			}
+		}
+		msg.Content = strings.Join(texts, "\n")
+		result = append(result, msg)
+	}
+	return result
+}
+
+// ToolCallingLLM uses ToolCallingClient when tools are used, otherwise the wrapped llm
+type ToolCallingLLM struct {
+	langchainllms.Model
+	client *ToolCallingClient
+}
+
+func NewToolCallingLLM(llm langchainllms.Model, client *ToolCallingClient) *ToolCallingLLM {
+	return &ToolCallingLLM{
+		Model:  llm,
+		client: client,
+	}
+}
+
+func (t *ToolCallingLLM) Call(ctx context.Context, prompt string, options ...langchainllms.CallOption) (string, error) {
+	return langchainllms.GenerateFromSinglePrompt(ctx, t, prompt, options...)
+}
+
+func (t *ToolCallingLLM) GenerateContent(ctx context.Context, messages []langchainllms.MessageContent, options ...langchainllms.CallOption) (*langchainllms.ContentResponse, error) {
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if !llms.NeedToolCalling(messages, opts) {
+		return t.Model.GenerateContent(ctx, messages, options...)
+	}
+	return t.client.GenerateContent(ctx, messages, opts)
+}
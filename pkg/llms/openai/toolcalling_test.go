@@ -0,0 +1,126 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+var weatherTool = langchainllms.FunctionDefinition{
+	Name:        "weather",
+	Description: "get the weather of a city",
+	Parameters: map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"city": map[string]any{"type": "string"}},
+	},
+}
+
+func TestToolCallingGenerateContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := chatRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if r.Header.Get("Authorization") != "Bearer fake" || r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected request %s with authorization %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "weather" {
+			t.Errorf("unexpected tools: %+v", req.Tools)
+		}
+		if len(req.Messages) != 3 || req.Messages[1].Role != "assistant" || len(req.Messages[1].ToolCalls) != 1 ||
+			req.Messages[2].Role != "tool" || req.Messages[2].ToolCallID != "call_0" {
+			t.Errorf("unexpected messages: %+v", req.Messages)
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[`+
+			`{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Beijing\"}"}},`+
+			`{"id":"call_2","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Shanghai\"}"}}]},`+
+			`"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	}))
+	defer server.Close()
+
+	client := NewToolCallingClient(server.URL, "fake", WithToolCallingModel("gpt-3.5-turbo"))
+	resp, err := client.GenerateContent(context.Background(), []langchainllms.MessageContent{
+		langchainllms.TextParts(schema.ChatMessageTypeHuman, "weather of Hangzhou"),
+		{Role: schema.ChatMessageTypeAI, Parts: []langchainllms.ContentPart{llms.ToolCallPart{
+			ToolCalls: []llms.ToolCall{{ID: "call_0", Name: "weather", Arguments: `{"city":"Hangzhou"}`}},
+		}}},
+		{Role: schema.ChatMessageTypeFunction, Parts: []langchainllms.ContentPart{llms.ToolResultPart{
+			TextContent: langchainllms.TextContent{Text: "sunny"},
+			ToolCallID:  "call_0",
+			Name:        "weather",
+		}}},
+	}, langchainllms.CallOptions{Functions: []langchainllms.FunctionDefinition{weatherTool}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	calls := llms.ToolCallsOf(resp.Choices[0])
+	if len(calls) != 2 || calls[0].ID != "call_1" || calls[1].Arguments != `{"city":"Shanghai"}` {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+	if resp.Choices[0].FuncCall == nil || resp.Choices[0].FuncCall.Name != "weather" || resp.Choices[0].StopReason != "tool_calls" {
+		t.Errorf("unexpected choice: %+v", resp.Choices[0])
+	}
+}
+
+func TestToolCallingGenerateContentStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"delta":{"content":"Let me check."}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"weather","arguments":""}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Beijing\"}"}}]}}]}`,
+			`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := NewToolCallingClient(server.URL, "fake")
+	var streamed string
+	resp, err := client.GenerateContent(context.Background(), []langchainllms.MessageContent{
+		langchainllms.TextParts(schema.ChatMessageTypeHuman, "weather of Beijing"),
+	}, langchainllms.CallOptions{
+		Functions: []langchainllms.FunctionDefinition{weatherTool},
+		StreamingFunc: func(_ context.Context, chunk []byte) error {
+			streamed += string(chunk)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if streamed != "Let me check." || resp.Choices[0].Content != streamed {
+		t.Errorf("unexpected streamed content %q, content %q", streamed, resp.Choices[0].Content)
+	}
+	calls := llms.ToolCallsOf(resp.Choices[0])
+	if len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Name != "weather" || calls[0].Arguments != `{"city":"Beijing"}` {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+}
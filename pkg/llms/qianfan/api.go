@@ -0,0 +1,327 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NOTE: Reference https://cloud.baidu.com/doc/WENXINWORKSHOP/s/clntwmv7t
+
+package qianfan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+const (
+	QianfanAPIURL         = "https://aip.baidubce.com"
+	QianfanDefaultTimeout = 300 * time.Second
+	// EmbeddingBatchSize is the max number of texts in one embedding request
+	EmbeddingBatchSize = 16
+)
+
+// chatEndpoints maps the model name to its chat api endpoint,
+// models not in this map are treated as the endpoint of a custom deployment.
+var chatEndpoints = map[string]string{
+	"ERNIE-4.0-8K":   "completions_pro",
+	"ERNIE-3.5-8K":   "completions",
+	"ERNIE-Speed-8K": "ernie_speed",
+	"ERNIE-Lite-8K":  "ernie-lite-8k",
+}
+
+// embeddingEndpoints maps the embedding model name to its api endpoint
+var embeddingEndpoints = map[string]string{
+	"embedding-v1": "embedding-v1",
+	"bge-large-zh": "bge_large_zh",
+	"bge-large-en": "bge_large_en",
+	"tao-8k":       "tao_8k",
+}
+
+type Role string
+
+const (
+	User      Role = "user"
+	Assistant Role = "assistant"
+)
+
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+type ChatRequest struct {
+	Messages     []Message `json:"messages"`
+	System       string    `json:"system,omitempty"`
+	Temperature  float64   `json:"temperature,omitempty"`
+	TopP         float64   `json:"top_p,omitempty"`
+	PenaltyScore float64   `json:"penalty_score,omitempty"`
+	Stop         []string  `json:"stop,omitempty"`
+	Stream       bool      `json:"stream,omitempty"`
+}
+
+type EmbeddingRequest struct {
+	Input []string `json:"input"`
+}
+
+var _ llms.LLM = (*Qianfan)(nil)
+
+type Qianfan struct {
+	apiKey    string
+	secretKey string
+	baseURL   string
+	client    *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewQianfan(apiKey, secretKey, baseURL string) (*Qianfan, error) {
+	if apiKey == "" || secretKey == "" {
+		return nil, errors.New("both apiKey and secretKey are required")
+	}
+	if baseURL == "" {
+		baseURL = QianfanAPIURL
+	}
+	return &Qianfan{
+		apiKey:    apiKey,
+		secretKey: secretKey,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		client:    &http.Client{Timeout: QianfanDefaultTimeout},
+	}, nil
+}
+
+func (q *Qianfan) Type() llms.LLMType {
+	return llms.Qianfan
+}
+
+// Call wraps a common AI api call
+func (q *Qianfan) Call(data []byte) (llms.Response, error) {
+	req := ChatRequest{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+	return q.Chat(context.Background(), llms.QianfanModels[0], req)
+}
+
+// Validate qianfan service against CallOption
+func (q *Qianfan) Validate(ctx context.Context, options ...langchainllms.CallOption) (llms.Response, error) {
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	model := opts.Model
+	if model == "" {
+		model = llms.QianfanModels[len(llms.QianfanModels)-1]
+	}
+	return q.Chat(ctx, model, ChatRequest{Messages: []Message{{Role: User, Content: "Hello"}}})
+}
+
+// Chat calls chat api and returns the result immediately
+func (q *Qianfan) Chat(ctx context.Context, model string, req ChatRequest) (*Response, error) {
+	req.Stream = false
+	resp, err := q.post(ctx, "chat/"+ChatEndpoint(model), req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return nil, err
+	}
+	if err := data.Err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ChatStream calls chat api with stream and calls handler with each result chunk
+func (q *Qianfan) ChatStream(ctx context.Context, model string, req ChatRequest, handler func(text string) error) (*Response, error) {
+	req.Stream = true
+	resp, err := q.post(ctx, "chat/"+ChatEndpoint(model), req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// qianfan returns a normal json response instead of events when error occurs
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		data := &Response{}
+		if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+			return nil, err
+		}
+		return nil, data.Err()
+	}
+
+	result := &Response{}
+	text := strings.Builder{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		chunk := &Response{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), chunk); err != nil {
+			return nil, err
+		}
+		if err := chunk.Err(); err != nil {
+			return nil, err
+		}
+		text.WriteString(chunk.Result)
+		if handler != nil && chunk.Result != "" {
+			if err := handler(chunk.Result); err != nil {
+				return nil, err
+			}
+		}
+		result.ID = chunk.ID
+		result.Usage = chunk.Usage
+		if chunk.IsEnd {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	result.Result = text.String()
+	result.IsEnd = true
+	return result, nil
+}
+
+// EmbedderClient embeds texts with a fixed model
+// To compatible with langchaingo/embeddings.EmbedderClient
+type EmbedderClient struct {
+	q     *Qianfan
+	model string
+}
+
+// EmbedderClient returns an EmbedderClient with the given embedding model
+func (q *Qianfan) EmbedderClient(model string) *EmbedderClient {
+	return &EmbedderClient{q: q, model: model}
+}
+
+func (e *EmbedderClient) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.q.Embedding(ctx, e.model, texts)
+}
+
+// Embedding embeds texts with the given model in batches
+func (q *Qianfan) Embedding(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	endpoint, ok := embeddingEndpoints[model]
+	if !ok {
+		endpoint = model
+	}
+	result := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += EmbeddingBatchSize {
+		end := start + EmbeddingBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		resp, err := q.post(ctx, "embeddings/"+endpoint, EmbeddingRequest{Input: texts[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		data := &EmbeddingResponse{}
+		err = json.NewDecoder(resp.Body).Decode(data)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if data.ErrorCode != 0 {
+			return nil, fmt.Errorf("qianfan embedding error %d: %s", data.ErrorCode, data.ErrorMsg)
+		}
+		for _, d := range data.Data {
+			result = append(result, d.Embedding)
+		}
+	}
+	return result, nil
+}
+
+// ChatEndpoint returns the chat api endpoint of the model
+func ChatEndpoint(model string) string {
+	if endpoint, ok := chatEndpoints[model]; ok {
+		return endpoint
+	}
+	return model
+}
+
+func (q *Qianfan) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	token, err := q.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	apiURL := fmt.Sprintf("%s/rpc/2.0/ai_custom/v1/wenxinworkshop/%s?access_token=%s", q.baseURL, path, url.QueryEscape(token))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("exception: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// token returns the cached access token, and refreshes it when it's about to expire
+func (q *Qianfan) token(ctx context.Context) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.accessToken != "" && time.Now().Before(q.expiresAt) {
+		return q.accessToken, nil
+	}
+	tokenURL := fmt.Sprintf("%s/oauth/2.0/token?grant_type=client_credentials&client_id=%s&client_secret=%s",
+		q.baseURL, url.QueryEscape(q.apiKey), url.QueryEscape(q.secretKey))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data := &TokenResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return "", fmt.Errorf("exception: %s", resp.Status)
+	}
+	if data.AccessToken == "" {
+		return "", fmt.Errorf("failed to get access token: %s %s", data.Err, data.ErrorDescription)
+	}
+	q.accessToken = data.AccessToken
+	// refresh one hour ahead
+	q.expiresAt = time.Now().Add(time.Duration(data.ExpiresIn)*time.Second - time.Hour)
+	return q.accessToken, nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qianfan
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tmc/langchaingo/callbacks"
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+var (
+	ErrEmptyResponse = errors.New("no response")
+	ErrEmptyPrompt   = errors.New("empty prompt")
+)
+
+var (
+	_ langchainllms.Model = (*QianfanLLM)(nil)
+)
+
+type options struct {
+	model            string
+	callbacksHandler callbacks.Handler
+}
+
+type Option func(*options)
+
+// WithModel sets the default model if the model is not set in call options
+func WithModel(model string) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+func WithCallback(callbacksHandler callbacks.Handler) Option {
+	return func(o *options) {
+		o.callbacksHandler = callbacksHandler
+	}
+}
+
+type QianfanLLM struct {
+	c       *Qianfan
+	options *options
+}
+
+func NewQianfanLLM(c *Qianfan, opts ...Option) *QianfanLLM {
+	q := &QianfanLLM{
+		c:       c,
+		options: &options{},
+	}
+	for _, opt := range opts {
+		opt(q.options)
+	}
+	return q
+}
+
+func (q *QianfanLLM) Call(ctx context.Context, prompt string, options ...langchainllms.CallOption) (string, error) {
+	return langchainllms.GenerateFromSinglePrompt(ctx, q, prompt, options...)
+}
+
+func (q *QianfanLLM) GenerateContent(ctx context.Context, messages []langchainllms.MessageContent, options ...langchainllms.CallOption) (*langchainllms.ContentResponse, error) {
+	if q.options.callbacksHandler != nil {
+		q.options.callbacksHandler.HandleLLMGenerateContentStart(ctx, messages)
+	}
+	if len(messages) == 0 {
+		return nil, ErrEmptyPrompt
+	}
+	opts := langchainllms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	model := q.options.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	req := ChatRequest{
+		Stop: opts.StopWords,
+	}
+	// qianfan requires temperature and top_p in (0, 1]
+	if opts.Temperature > 0 && opts.Temperature <= 1 {
+		req.Temperature = opts.Temperature
+	}
+	if opts.TopP > 0 && opts.TopP <= 1 {
+		req.TopP = opts.TopP
+	}
+	if opts.RepetitionPenalty >= 1 && opts.RepetitionPenalty <= 2 {
+		req.PenaltyScore = opts.RepetitionPenalty
+	}
+	req.System, req.Messages = toMessages(messages)
+	if len(req.Messages) == 0 {
+		return nil, ErrEmptyPrompt
+	}
+
+	var resp *Response
+	var err error
+	if opts.StreamingFunc != nil {
+		resp, err = q.c.ChatStream(ctx, model, req, func(text string) error {
+			return opts.StreamingFunc(ctx, []byte(text))
+		})
+	} else {
+		resp, err = q.c.Chat(ctx, model, req)
+	}
+	if err != nil {
+		if q.options.callbacksHandler != nil {
+			q.options.callbacksHandler.HandleLLMError(ctx, err)
+		}
+		return nil, err
+	}
+	if resp == nil {
+		return nil, ErrEmptyResponse
+	}
+	response := &langchainllms.ContentResponse{Choices: []*langchainllms.ContentChoice{{
+		Content: resp.Result,
+		GenerationInfo: map[string]any{
+			"PromptTokens":     resp.Usage.PromptTokens,
+			"CompletionTokens": resp.Usage.CompletionTokens,
+			"TotalTokens":      resp.Usage.TotalTokens,
+		},
+	}}}
+	if q.options.callbacksHandler != nil {
+		q.options.callbacksHandler.HandleLLMGenerateContentEnd(ctx, response)
+	}
+	return response, nil
+}
+
+// toMessages converts langchain messages to the system prompt and messages of qianfan.
+// Qianfan requires messages alternate between user and assistant, start and end with user,
+// so successive messages of the same role are merged.
+func toMessages(messages []langchainllms.MessageContent) (string, []Message) {
+	var system []string
+	result := make([]Message, 0, len(messages))
+	for _, mc := range messages {
+		content := textOf(mc)
+		var role Role
+		switch mc.Role {
+		case schema.ChatMessageTypeSystem:
+			system = append(system, content)
+			continue
+		case schema.ChatMessageTypeAI:
+			role = Assistant
+		default:
+			role = User
+		}
+		if len(result) == 0 && role == Assistant {
+			continue
+		}
+		if len(result) > 0 && result[len(result)-1].Role == role {
+			result[len(result)-1].Content += "\n" + content
+			continue
+		}
+		result = append(result, Message{Role: role, Content: content})
+	}
+	if len(result) > 0 && result[len(result)-1].Role == Assistant {
+		result = result[:len(result)-1]
+	}
+	return strings.Join(system, "\n"), result
+}
+
+func textOf(mc langchainllms.MessageContent) string {
+	texts := make([]string, 0, len(mc.Parts))
+	for _, part := range mc.Parts {
+		if c, ok := part.(langchainllms.TextContent); ok {
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
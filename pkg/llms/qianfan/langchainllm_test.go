@@ -0,0 +1,126 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qianfan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+func newStubServer(t *testing.T, tokenRequests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/2.0/token" {
+			*tokenRequests++
+			if r.URL.Query().Get("client_id") != "ak" || r.URL.Query().Get("client_secret") != "sk" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"invalid_client","error_description":"unknown client id"}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"token","expires_in":2592000}`)
+			return
+		}
+		if r.URL.Query().Get("access_token") != "token" {
+			t.Errorf("unexpected access token: %s", r.URL.Query().Get("access_token"))
+		}
+		if r.URL.Path != "/rpc/2.0/ai_custom/v1/wenxinworkshop/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		req := ChatRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.System != "be brief" || len(req.Messages) != 3 || req.Messages[0].Role != User || req.Messages[1].Role != Assistant {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		if !req.Stream {
+			fmt.Fprint(w, `{"id":"as-1","result":"Hello world","is_end":true,"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"as-1\",\"result\":\"Hello\",\"is_end\":false}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"as-1\",\"result\":\" world\",\"is_end\":true,\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":2,\"total_tokens\":7}}\n\n")
+	}))
+}
+
+func TestGenerateContent(t *testing.T) {
+	var tokenRequests int
+	server := newStubServer(t, &tokenRequests)
+	defer server.Close()
+
+	c, err := NewQianfan("ak", "sk", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := NewQianfanLLM(c, WithModel("ERNIE-3.5-8K"))
+	messages := []langchainllms.MessageContent{
+		{Role: schema.ChatMessageTypeSystem, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "be brief"}}},
+		{Role: schema.ChatMessageTypeHuman, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "hi"}}},
+		{Role: schema.ChatMessageTypeAI, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "hi"}}},
+		{Role: schema.ChatMessageTypeHuman, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "say hello"}}},
+	}
+
+	resp, err := llm.GenerateContent(context.Background(), messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Content != "Hello world" || resp.Choices[0].GenerationInfo["TotalTokens"] != 7 {
+		t.Fatalf("unexpected response: %+v", resp.Choices[0])
+	}
+
+	var streamed string
+	resp, err = llm.GenerateContent(context.Background(), messages, langchainllms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		streamed += string(chunk)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed != "Hello world" || resp.Choices[0].Content != "Hello world" {
+		t.Fatalf("unexpected response: streamed %q, got %+v", streamed, resp.Choices[0])
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("access token should be cached, got %d token requests", tokenRequests)
+	}
+
+	c, err = NewQianfan("ak", "wrong", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewQianfanLLM(c).Call(context.Background(), "hi"); err == nil || !strings.Contains(err.Error(), "unknown client id") {
+		t.Fatalf("expect the error of access token, got %v", err)
+	}
+}
+
+func TestToMessages(t *testing.T) {
+	system, messages := toMessages([]langchainllms.MessageContent{
+		{Role: schema.ChatMessageTypeAI, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "greeting"}}},
+		{Role: schema.ChatMessageTypeHuman, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "a"}}},
+		{Role: schema.ChatMessageTypeHuman, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "b"}}},
+		{Role: schema.ChatMessageTypeAI, Parts: []langchainllms.ContentPart{langchainllms.TextContent{Text: "trailing"}}},
+	})
+	if system != "" || len(messages) != 1 || messages[0].Role != User || messages[0].Content != "a\nb" {
+		t.Fatalf("unexpected messages: %q %+v", system, messages)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qianfan
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubeagi/arcadia/pkg/llms"
+)
+
+var _ llms.Response = (*Response)(nil)
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type Response struct {
+	ID        string `json:"id"`
+	Result    string `json:"result"`
+	IsEnd     bool   `json:"is_end"`
+	Usage     Usage  `json:"usage"`
+	ErrorCode int    `json:"error_code,omitempty"`
+	ErrorMsg  string `json:"error_msg,omitempty"`
+}
+
+type EmbeddingData struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}
+
+type EmbeddingResponse struct {
+	ID        string          `json:"id"`
+	Data      []EmbeddingData `json:"data"`
+	Usage     Usage           `json:"usage"`
+	ErrorCode int             `json:"error_code,omitempty"`
+	ErrorMsg  string          `json:"error_msg,omitempty"`
+}
+
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Err              string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Err returns the error in response body if have
+func (response *Response) Err() error {
+	if response.ErrorCode == 0 {
+		return nil
+	}
+	// 18: QPS limit, 336501/336502: rpm/tpm limit
+	if response.ErrorCode == 18 || response.ErrorCode == 336501 || response.ErrorCode == 336502 {
+		return fmt.Errorf("qianfan rate limit error %d: %s", response.ErrorCode, response.ErrorMsg)
+	}
+	return fmt.Errorf("qianfan error %d: %s", response.ErrorCode, response.ErrorMsg)
+}
+
+func (response *Response) Type() llms.LLMType {
+	return llms.Qianfan
+}
+
+func (response *Response) Bytes() []byte {
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		return []byte{}
+	}
+	return bytes
+}
+
+func (response *Response) String() string {
+	return response.Result
+}
+
+func (response *Response) Unmarshal(bytes []byte) error {
+	return json.Unmarshal(bytes, response)
+}
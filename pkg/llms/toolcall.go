@@ -0,0 +1,81 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package llms
+
+import (
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// GenerationInfoToolCalls is the key of all tool calls in ContentChoice.GenerationInfo,
+// ContentChoice.FuncCall only holds the first one.
+const GenerationInfoToolCalls = "ToolCalls"
+
+// ToolCall is a tool call requested by llm
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallPart is a content part of an assistant message which records the tool calls requested by llm.
+// It embeds TextContent as the text replied along with the tool calls, so it can be used as a langchain content part.
+type ToolCallPart struct {
+	langchainllms.TextContent
+	ToolCalls []ToolCall
+}
+
+// ToolResultPart is the content part of a message with role schema.ChatMessageTypeFunction,
+// which holds the result of a tool call in TextContent.
+type ToolResultPart struct {
+	langchainllms.TextContent
+	ToolCallID string
+	Name       string
+}
+
+// NeedToolCalling returns whether the call uses tools, which means tools are provided
+// or there are tool calls in the messages.
+func NeedToolCalling(messages []langchainllms.MessageContent, opts langchainllms.CallOptions) bool {
+	if len(opts.Functions) > 0 {
+		return true
+	}
+	for _, mc := range messages {
+		if mc.Role == schema.ChatMessageTypeFunction {
+			return true
+		}
+		for _, part := range mc.Parts {
+			if _, ok := part.(ToolCallPart); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ToolCallsOf returns the tool calls in the choice
+func ToolCallsOf(choice *langchainllms.ContentChoice) []ToolCall {
+	if choice == nil {
+		return nil
+	}
+	if calls, ok := choice.GenerationInfo[GenerationInfoToolCalls].([]ToolCall); ok {
+		return calls
+	}
+	if choice.FuncCall != nil {
+		return []ToolCall{{Name: choice.FuncCall.Name, Arguments: choice.FuncCall.Arguments}}
+	}
+	return nil
+}
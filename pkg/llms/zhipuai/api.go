@@ -32,7 +32,9 @@ import (
 )
 
 const (
-	ZhipuaiModelAPIURL         = "https://open.bigmodel.cn/api/paas/v3/model-api"
+	ZhipuaiModelAPIURL = "https://open.bigmodel.cn/api/paas/v3/model-api"
+	// ZhipuaiV4APIURL is the OpenAI compatible api which supports tool calling
+	ZhipuaiV4APIURL            = "https://open.bigmodel.cn/api/paas/v4"
 	ZhipuaiModelDefaultTimeout = 300 * time.Second
 	RetryLimit                 = 3
 )
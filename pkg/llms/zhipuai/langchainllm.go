@@ -31,6 +31,9 @@ import (
 	"github.com/tmc/langchaingo/llms/openai"
 	"github.com/tmc/langchaingo/schema"
 	"k8s.io/klog/v2"
+
+	"github.com/kubeagi/arcadia/pkg/llms"
+	llmsopenai "github.com/kubeagi/arcadia/pkg/llms/openai"
 )
 
 var (
@@ -64,6 +67,8 @@ func WithCallback(callbacksHandler callbacks.Handler) Option {
 type ZhiPuAILLM struct {
 	c       *ZhiPuAI
 	options *options
+	// tools calls the v4 api which supports tool calling
+	tools *llmsopenai.ToolCallingClient
 }
 
 func NewZhiPuAILLM(apiKey string, opts ...Option) *ZhiPuAILLM {
@@ -77,6 +82,16 @@ func NewZhiPuAILLM(apiKey string, opts ...Option) *ZhiPuAILLM {
 	for _, opt := range opts {
 		opt(z.options)
 	}
+	toolOptions := []llmsopenai.ToolCallingOption{
+		llmsopenai.WithToolCallingModel(llms.ZhiPuAIGLM4),
+		llmsopenai.WithTokenFunc(func() (string, error) {
+			return GenerateToken(apiKey, APITokenTTLSeconds)
+		}),
+	}
+	if z.options.callbacksHandler != nil {
+		toolOptions = append(toolOptions, llmsopenai.WithToolCallingCallback(z.options.callbacksHandler))
+	}
+	z.tools = llmsopenai.NewToolCallingClient(ZhipuaiV4APIURL, "", toolOptions...)
 	return z
 }
 
@@ -96,6 +111,17 @@ func (z *ZhiPuAILLM) GenerateContent(ctx context.Context, messages []langchainll
 	for _, opt := range options {
 		opt(&opts)
 	}
+	if llms.NeedToolCalling(messages, opts) {
+		// only glm-3-turbo and glm-4 of the v4 api support tool calling
+		if opts.Model != llms.ZhiPuAIGLM3Turbo {
+			opts.Model = llms.ZhiPuAIGLM4
+		}
+		// zhipuai requires temperature in (0, 1)
+		if opts.Temperature <= 0 || opts.Temperature >= 1 {
+			opts.Temperature = 0
+		}
+		return z.tools.GenerateContent(ctx, messages, opts)
+	}
 	chatMsgs := make([]*openai.ChatMessage, 0, len(messages))
 	for _, mc := range messages {
 		msg := &openai.ChatMessage{MultiContent: mc.Parts}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pii
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+)
+
+const (
+	// TypeOther is the type of the values found by llm without a type
+	TypeOther = "other"
+
+	llmPrompt = `Find the personally identifiable information in the text below, such as names of persons, addresses, identity numbers, phone numbers, emails, bank accounts and license plates.
+Reply only a JSON array of objects with "type" and "text", the "text" must be copied from the text exactly. Reply [] if there is none.
+
+Text:
+"""
+%s
+"""`
+)
+
+// LLMDetector asks the llm to find the personally identifiable information which can not be matched by regex, such as names and addresses
+type LLMDetector struct {
+	LLM langchainllms.Model
+}
+
+type llmFinding struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (d *LLMDetector) Detect(ctx context.Context, text string) ([]Finding, error) {
+	answer, err := langchainllms.GenerateFromSinglePrompt(ctx, d.LLM, fmt.Sprintf(llmPrompt, text), langchainllms.WithTemperature(0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect pii by llm: %w", err)
+	}
+	found, err := parseLLMFindings(answer)
+	if err != nil {
+		return nil, err
+	}
+	var res []Finding
+	for _, v := range found {
+		// a single character is more likely a misjudgment, and masking all its occurrences breaks the text
+		if len([]rune(v.Text)) < 2 {
+			continue
+		}
+		typ := strings.ToLower(strings.TrimSpace(v.Type))
+		if typ == "" {
+			typ = TypeOther
+		}
+		// the llm does not tell the positions, all occurrences are found
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], v.Text)
+			if i < 0 {
+				break
+			}
+			start := offset + i
+			res = append(res, Finding{Type: typ, Start: start, End: start + len(v.Text), Value: v.Text})
+			offset = start + len(v.Text)
+		}
+	}
+	return res, nil
+}
+
+// parseLLMFindings parses the json array in the answer, which may be wrapped in a markdown code block or explanations
+func parseLLMFindings(answer string) ([]llmFinding, error) {
+	start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to detect pii by llm: no json array in the answer %q", answer)
+	}
+	var res []llmFinding
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &res); err != nil {
+		return nil, fmt.Errorf("failed to detect pii by llm: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pii detects and masks the personally identifiable information in texts
+package pii
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+)
+
+const (
+	TypeIDCard   = "idcard"
+	TypePhone    = "phone"
+	TypeEmail    = "email"
+	TypeBankCard = "bankcard"
+)
+
+// Finding is a piece of personally identifiable information found in a text
+type Finding struct {
+	Type string
+	// Start and End are the byte offsets of the value in the text
+	Start int
+	End   int
+	Value string
+}
+
+// Detector finds the personally identifiable information in a text
+type Detector interface {
+	Detect(ctx context.Context, text string) ([]Finding, error)
+}
+
+// Guard detects with the regex detectors and an optional llm detector
+type Guard struct {
+	detectors []Detector
+}
+
+// NewGuard creates a guard with the regex detectors of the types, all of them are used if types is empty.
+// The llm detector is added if llm is not nil.
+func NewGuard(types []string, llm langchainllms.Model) (*Guard, error) {
+	if len(types) == 0 {
+		types = RegexTypes()
+	}
+	g := &Guard{}
+	for _, t := range types {
+		d, ok := regexDetectors[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown pii detector %s, should be one of %s", t, strings.Join(RegexTypes(), ", "))
+		}
+		g.detectors = append(g.detectors, d)
+	}
+	if llm != nil {
+		g.detectors = append(g.detectors, &LLMDetector{LLM: llm})
+	}
+	return g, nil
+}
+
+// Detect returns the findings of all detectors in the order of their positions.
+// When findings overlap, the one of the former detector is kept.
+func (g *Guard) Detect(ctx context.Context, text string) ([]Finding, error) {
+	if text == "" {
+		return nil, nil
+	}
+	var res []Finding
+	for _, d := range g.detectors {
+		findings, err := d.Detect(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range findings {
+			if !overlaps(res, f) {
+				res = append(res, f)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Start < res[j].Start
+	})
+	return res, nil
+}
+
+func overlaps(findings []Finding, f Finding) bool {
+	for _, v := range findings {
+		if f.Start < v.End && v.Start < f.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask replaces the findings in the text with asterisks.
+// The findings should not overlap and be in the order of their positions, like what Guard.Detect returns.
+func Mask(text string, findings []Finding) string {
+	if len(findings) == 0 {
+		return text
+	}
+	b := &strings.Builder{}
+	last := 0
+	for _, f := range findings {
+		b.WriteString(text[last:f.Start])
+		b.WriteString(MaskValue(f.Type, f.Value))
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// MaskValue masks a value of the type. The first 3 and the last 4 characters of numbers,
+// and the first character and the domain of emails are kept so the value is still recognizable by its owner.
+func MaskValue(typ, value string) string {
+	r := []rune(value)
+	head, tail := 0, 0
+	switch typ {
+	case TypeIDCard, TypePhone, TypeBankCard:
+		head, tail = 3, 4
+	case TypeEmail:
+		if at := strings.LastIndex(value, "@"); at > 0 {
+			head, tail = 1, len([]rune(value[at:]))
+		}
+	}
+	if head+tail >= len(r) {
+		head, tail = 0, 0
+	}
+	return string(r[:head]) + strings.Repeat("*", len(r)-head-tail) + string(r[len(r)-tail:])
+}
+
+// Types returns the distinct types of the findings in order
+func Types(findings []Finding) []string {
+	seen := make(map[string]bool)
+	res := make([]string, 0)
+	for _, f := range findings {
+		if !seen[f.Type] {
+			seen[f.Type] = true
+			res = append(res, f.Type)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pii
+
+import (
+	"context"
+	"testing"
+
+	langchainllms "github.com/tmc/langchaingo/llms"
+)
+
+type fakeLLM struct {
+	answer string
+}
+
+func (f *fakeLLM) Call(ctx context.Context, prompt string, options ...langchainllms.CallOption) (string, error) {
+	return langchainllms.GenerateFromSinglePrompt(ctx, f, prompt, options...)
+}
+
+func (f *fakeLLM) GenerateContent(_ context.Context, _ []langchainllms.MessageContent, _ ...langchainllms.CallOption) (*langchainllms.ContentResponse, error) {
+	return &langchainllms.ContentResponse{Choices: []*langchainllms.ContentChoice{{Content: f.answer}}}, nil
+}
+
+func TestGuardDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		types []string
+	}{
+		{name: "id card", text: "我的身份证号是11010519491231002X，请帮我查询", types: []string{TypeIDCard}},
+		{name: "id card with wrong checksum", text: "身份证号110105194912310021", types: []string{}},
+		{name: "phone", text: "联系电话：138-1234-5678。", types: []string{TypePhone}},
+		{name: "phone with country code", text: "call +86 13812345678 please", types: []string{TypePhone}},
+		{name: "part of a longer number", text: "订单号 2013812345678123", types: []string{}},
+		{name: "email", text: "发邮件到zhang.san@example.com.cn", types: []string{TypeEmail}},
+		{name: "bank card", text: "卡号6222 0212 3456 7894，开户行", types: []string{TypeBankCard}},
+		{name: "bank card of 19 digits", text: "card 6228480123456789015", types: []string{TypeBankCard}},
+		{name: "bank card with wrong checksum", text: "card 6222021234567890", types: []string{}},
+		{name: "multiple", text: "13812345678 a@b.io 11010519491231002X", types: []string{TypeEmail, TypeIDCard, TypePhone}},
+		{name: "none", text: "差旅报销的标准是什么？", types: []string{}},
+	}
+	g, err := NewGuard(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := g.Detect(context.Background(), tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Types(findings); !equal(got, tt.types) {
+				t.Errorf("Detect() types = %v, want %v", got, tt.types)
+			}
+		})
+	}
+}
+
+func TestNewGuard(t *testing.T) {
+	if _, err := NewGuard([]string{"passport"}, nil); err == nil {
+		t.Error("NewGuard() with unknown detector should fail")
+	}
+	g, err := NewGuard([]string{TypeEmail}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, _ := g.Detect(context.Background(), "13812345678 a@b.io")
+	if got := Types(findings); !equal(got, []string{TypeEmail}) {
+		t.Errorf("Detect() types = %v, want only email", got)
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "身份证11010519491231002X", want: "身份证110***********002X"},
+		{text: "电话 138-1234-5678", want: "电话 138******5678"},
+		{text: "mail zhang.san@example.com", want: "mail z********@example.com"},
+		{text: "卡号6222021234567894和13812345678", want: "卡号622*********7894和138****5678"},
+	}
+	g, _ := NewGuard(nil, nil)
+	for _, tt := range tests {
+		findings, _ := g.Detect(context.Background(), tt.text)
+		if got := Mask(tt.text, findings); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLLMDetector(t *testing.T) {
+	llm := &fakeLLM{answer: "```json\n[{\"type\": \"Name\", \"text\": \"张三\"}, {\"type\": \"\", \"text\": \"北京市朝阳区\"}, {\"type\": \"name\", \"text\": \"李\"}]\n```"}
+	g, _ := NewGuard([]string{TypePhone}, llm)
+	text := "张三住在北京市朝阳区，张三的电话是13812345678，李"
+	findings, err := g.Detect(context.Background(), text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Types(findings); !equal(got, []string{"name", TypeOther, TypePhone}) {
+		t.Errorf("Detect() types = %v", got)
+	}
+	if got, want := Mask(text, findings), "**住在******，**的电话是138****5678，李"; got != want {
+		t.Errorf("Mask() = %q, want %q", got, want)
+	}
+
+	llm.answer = "there is no pii"
+	if _, err := g.Detect(context.Background(), text); err == nil {
+		t.Error("Detect() with an invalid answer should fail")
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pii
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+var regexDetectors = map[string]*RegexDetector{
+	TypeIDCard: {
+		Type:    TypeIDCard,
+		Pattern: regexp.MustCompile(`[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]`),
+		Valid:   validIDCard,
+	},
+	TypePhone: {
+		Type: TypePhone,
+		// mainland mobile numbers, with the optional country code and separators such as 138-1234-5678
+		Pattern: regexp.MustCompile(`(?:\+?86[ -]?)?1[3-9]\d(?:[ -]?\d{4}){2}`),
+	},
+	TypeEmail: {
+		Type:    TypeEmail,
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	},
+	TypeBankCard: {
+		Type: TypeBankCard,
+		// 16 to 19 digits, which may be grouped by 4 with separators
+		Pattern: regexp.MustCompile(`[1-9]\d{3}(?:[ -]?\d{4}){2,3}(?:[ -]?\d{1,3})?`),
+		Valid:   validBankCard,
+	},
+}
+
+// RegexTypes returns the types of the regex detectors
+func RegexTypes() []string {
+	return []string{TypeIDCard, TypePhone, TypeEmail, TypeBankCard}
+}
+
+// RegexDetector finds the values matching the pattern and passing the validation
+type RegexDetector struct {
+	Type    string
+	Pattern *regexp.Regexp
+	// Valid checks the matched value, such as the checksum, all matches are valid if it is nil
+	Valid func(value string) bool
+}
+
+func (d *RegexDetector) Detect(_ context.Context, text string) ([]Finding, error) {
+	var res []Finding
+	for _, loc := range d.Pattern.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		// a part of a longer number is not a match, such as an order number
+		if isDigitAt(text, start-1) || isDigitAt(text, end) {
+			continue
+		}
+		value := text[start:end]
+		if d.Valid != nil && !d.Valid(value) {
+			continue
+		}
+		res = append(res, Finding{Type: d.Type, Start: start, End: end, Value: value})
+	}
+	return res, nil
+}
+
+func isDigitAt(text string, i int) bool {
+	return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9'
+}
+
+// validIDCard checks the last character of the 18-digit resident identity card number by ISO 7064 MOD 11-2
+func validIDCard(value string) bool {
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(value[i]-'0') * w
+	}
+	return strings.ToUpper(value[17:]) == string("10X98765432"[sum%11])
+}
+
+// validBankCard checks the length and the Luhn checksum of the card number
+func validBankCard(value string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(value)
+	if len(digits) < 16 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
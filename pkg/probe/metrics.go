@@ -0,0 +1,98 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package probe
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+)
+
+const (
+	metricsNamespace = "arcadia"
+	metricsSubsystem = "model_probe"
+)
+
+var (
+	probeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "duration_seconds",
+		Help:      "Duration of model service probes",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"kind", "namespace", "name"})
+	probeTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "total",
+		Help:      "Total number of model service probes by result",
+	}, []string{"kind", "namespace", "name", "result"})
+	probeLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "latency_milliseconds",
+		Help:      "Latency percentiles of the successful probes in window",
+	}, []string{"kind", "namespace", "name", "quantile"})
+	probeErrorRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "error_rate",
+		Help:      "Error rate of the probes in window",
+	}, []string{"kind", "namespace", "name"})
+	modelReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "model_ready",
+		Help:      "Whether the model service is ready, 1 for ready and 0 for not",
+	}, []string{"kind", "namespace", "name"})
+)
+
+func init() {
+	// registered to the controller-runtime registry which is served by the manager's metrics endpoint
+	metrics.Registry.MustRegister(probeDuration, probeTotal, probeLatency, probeErrorRate, modelReady)
+}
+
+// Observe exports the probe sample and the health statistics as metrics
+func Observe(kind, namespace, name string, sample v1alpha1.ProbeSample, health *v1alpha1.HealthStatus, ready bool) {
+	result := "success"
+	if !sample.Success {
+		result = "failure"
+	}
+	probeDuration.WithLabelValues(kind, namespace, name).Observe(float64(sample.LatencyMilliseconds) / 1000)
+	probeTotal.WithLabelValues(kind, namespace, name, result).Inc()
+	if health != nil {
+		probeLatency.WithLabelValues(kind, namespace, name, "0.5").Set(float64(health.P50LatencyMilliseconds))
+		probeLatency.WithLabelValues(kind, namespace, name, "0.95").Set(float64(health.P95LatencyMilliseconds))
+		probeErrorRate.WithLabelValues(kind, namespace, name).Set(health.ErrorRate)
+	}
+	readyValue := 0.0
+	if ready {
+		readyValue = 1
+	}
+	modelReady.WithLabelValues(kind, namespace, name).Set(readyValue)
+}
+
+// Forget removes the metrics of a deleted model service
+func Forget(kind, namespace, name string) {
+	probeDuration.DeleteLabelValues(kind, namespace, name)
+	probeTotal.DeleteLabelValues(kind, namespace, name, "success")
+	probeTotal.DeleteLabelValues(kind, namespace, name, "failure")
+	probeLatency.DeleteLabelValues(kind, namespace, name, "0.5")
+	probeLatency.DeleteLabelValues(kind, namespace, name, "0.95")
+	probeErrorRate.DeleteLabelValues(kind, namespace, name)
+	modelReady.DeleteLabelValues(kind, namespace, name)
+}
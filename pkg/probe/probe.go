@@ -0,0 +1,173 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package probe runs synthetic probes against model services(llms and embedders)
+// and keeps the latency and error statistics in their status.
+package probe
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+)
+
+const (
+	DefaultInterval         = 5 * time.Minute
+	DefaultTimeout          = 30 * time.Second
+	DefaultFailureThreshold = 3
+	DefaultWindow           = 20
+
+	// maxFailureReasonLength limits the failure reason stored in status
+	maxFailureReasonLength = 512
+)
+
+// Config is the probe spec with defaults applied
+type Config struct {
+	Interval         time.Duration
+	Timeout          time.Duration
+	FailureThreshold int
+	Window           int
+	LatencySLO       time.Duration
+}
+
+// ConfigFromSpec converts the probe spec to Config, unset fields use the default value
+func ConfigFromSpec(spec *v1alpha1.Probe) Config {
+	cfg := Config{
+		Interval:         DefaultInterval,
+		Timeout:          DefaultTimeout,
+		FailureThreshold: DefaultFailureThreshold,
+		Window:           DefaultWindow,
+	}
+	if spec == nil {
+		return cfg
+	}
+	if spec.IntervalSeconds > 0 {
+		cfg.Interval = time.Duration(spec.IntervalSeconds) * time.Second
+	}
+	if spec.TimeoutSeconds > 0 {
+		cfg.Timeout = time.Duration(spec.TimeoutSeconds) * time.Second
+	}
+	if spec.FailureThreshold > 0 {
+		cfg.FailureThreshold = spec.FailureThreshold
+	}
+	if spec.Window > 0 {
+		cfg.Window = spec.Window
+	}
+	cfg.LatencySLO = time.Duration(spec.LatencySLOMilliseconds) * time.Millisecond
+	return cfg
+}
+
+// Run calls fn with timeout and returns the probe sample.
+// Some clients don't respect the context, so fn runs in a goroutine and is abandoned after timeout.
+func Run(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) (v1alpha1.ProbeSample, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	ch := make(chan error, 1)
+	go func() {
+		ch <- fn(ctx)
+	}()
+	var err error
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case err = <-ch:
+	}
+	return v1alpha1.ProbeSample{
+		Time:                metav1.NewTime(start),
+		LatencyMilliseconds: time.Since(start).Milliseconds(),
+		Success:             err == nil,
+	}, err
+}
+
+// Update records the sample into health, the statistics are reset if the spec generation changes.
+// It returns the updated health status.
+func Update(health *v1alpha1.HealthStatus, generation int64, sample v1alpha1.ProbeSample, err error, cfg Config) *v1alpha1.HealthStatus {
+	if health == nil || health.ObservedGeneration != generation {
+		health = &v1alpha1.HealthStatus{ObservedGeneration: generation}
+	}
+	Record(health, sample, err, cfg)
+	return health
+}
+
+// Record adds the sample to health, keeps the latest cfg.Window samples and recalculates the statistics.
+// err is the error of a failed sample.
+func Record(health *v1alpha1.HealthStatus, sample v1alpha1.ProbeSample, err error, cfg Config) {
+	health.LastProbeTime = sample.Time
+	health.Samples = append(health.Samples, sample)
+	if len(health.Samples) > cfg.Window {
+		health.Samples = health.Samples[len(health.Samples)-cfg.Window:]
+	}
+	if sample.Success {
+		health.ConsecutiveFailures = 0
+	} else {
+		health.ConsecutiveFailures++
+		health.LastFailureTime = sample.Time.DeepCopy()
+		if err != nil {
+			health.LastFailureReason = truncate(err.Error(), maxFailureReasonLength)
+		}
+	}
+
+	latencies := make([]int64, 0, len(health.Samples))
+	failures := 0
+	for _, s := range health.Samples {
+		if s.Success {
+			latencies = append(latencies, s.LatencyMilliseconds)
+		} else {
+			failures++
+		}
+	}
+	health.ErrorRate = float64(failures) / float64(len(health.Samples))
+	health.P50LatencyMilliseconds = Percentile(latencies, 50)
+	health.P95LatencyMilliseconds = Percentile(latencies, 95)
+	health.SLOViolated = cfg.LatencySLO > 0 && health.P95LatencyMilliseconds > cfg.LatencySLO.Milliseconds()
+}
+
+// Unhealthy returns whether the consecutive failures reach the failure threshold
+func Unhealthy(health *v1alpha1.HealthStatus, cfg Config) bool {
+	return health != nil && health.ConsecutiveFailures >= cfg.FailureThreshold
+}
+
+// Percentile returns the p-th percentile of values with the nearest-rank method, 0 if values is empty
+func Percentile(values []int64, p float64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
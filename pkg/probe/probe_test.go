@@ -0,0 +1,107 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package probe
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubeagi/arcadia/api/base/v1alpha1"
+)
+
+func TestPercentile(t *testing.T) {
+	cases := []struct {
+		values []int64
+		p      float64
+		want   int64
+	}{
+		{values: nil, p: 50, want: 0},
+		{values: []int64{7}, p: 95, want: 7},
+		{values: []int64{5, 1, 4, 2, 3}, p: 50, want: 3},
+		{values: []int64{5, 1, 4, 2, 3}, p: 95, want: 5},
+		{values: []int64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}, p: 95, want: 100},
+		{values: []int64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}, p: 50, want: 50},
+	}
+	for _, c := range cases {
+		if got := Percentile(c.values, c.p); got != c.want {
+			t.Errorf("Percentile(%v, %v) = %d, want %d", c.values, c.p, got, c.want)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	cfg := Config{Window: 4, FailureThreshold: 2, LatencySLO: 150 * time.Millisecond}
+	health := &v1alpha1.HealthStatus{}
+	for _, latency := range []int64{100, 200, 300} {
+		Record(health, v1alpha1.ProbeSample{LatencyMilliseconds: latency, Success: true}, nil, cfg)
+	}
+	if health.P50LatencyMilliseconds != 200 || health.P95LatencyMilliseconds != 300 {
+		t.Errorf("unexpected latency p50 %d p95 %d", health.P50LatencyMilliseconds, health.P95LatencyMilliseconds)
+	}
+	if !health.SLOViolated {
+		t.Error("expected latency slo violated")
+	}
+
+	Record(health, v1alpha1.ProbeSample{Success: false}, errors.New("401 unauthorized"), cfg)
+	Record(health, v1alpha1.ProbeSample{Success: false}, errors.New("503 unavailable"), cfg)
+	if len(health.Samples) != 4 {
+		t.Fatalf("expected 4 samples in window, got %d", len(health.Samples))
+	}
+	if health.ErrorRate != 0.5 {
+		t.Errorf("expected error rate 0.5, got %v", health.ErrorRate)
+	}
+	if health.LastFailureReason != "503 unavailable" || health.LastFailureTime == nil {
+		t.Errorf("unexpected last failure %q", health.LastFailureReason)
+	}
+	if !Unhealthy(health, cfg) {
+		t.Error("expected unhealthy after 2 consecutive failures")
+	}
+
+	Record(health, v1alpha1.ProbeSample{LatencyMilliseconds: 100, Success: true}, nil, cfg)
+	if Unhealthy(health, cfg) || health.ConsecutiveFailures != 0 {
+		t.Error("expected healthy after a successful probe")
+	}
+}
+
+func TestUpdateResetOnGenerationChange(t *testing.T) {
+	cfg := ConfigFromSpec(nil)
+	health := Update(nil, 1, v1alpha1.ProbeSample{Success: false}, errors.New("failed"), cfg)
+	health = Update(health, 1, v1alpha1.ProbeSample{Success: false}, errors.New("failed"), cfg)
+	if health.ConsecutiveFailures != 2 {
+		t.Fatalf("expected 2 consecutive failures, got %d", health.ConsecutiveFailures)
+	}
+	health = Update(health, 2, v1alpha1.ProbeSample{LatencyMilliseconds: 10, Success: true}, nil, cfg)
+	if health.ObservedGeneration != 2 || len(health.Samples) != 1 || health.ErrorRate != 0 {
+		t.Errorf("expected statistics reset for new generation, got %+v", health)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	sample, err := Run(context.Background(), 50*time.Millisecond, func(ctx context.Context) error {
+		// ignore the context like some clients do
+		time.Sleep(time.Second)
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || sample.Success {
+		t.Errorf("expected probe timeout, got %v", err)
+	}
+	if sample.LatencyMilliseconds >= 1000 {
+		t.Errorf("expected probe abandoned after timeout, latency %dms", sample.LatencyMilliseconds)
+	}
+}
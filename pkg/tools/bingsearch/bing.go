@@ -19,6 +19,7 @@ package bingsearch
 import (
 	"context"
 	"strconv"
+	"sync"
 
 	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/tools"
@@ -37,17 +38,20 @@ const (
 type Tool struct {
 	client           *BingClient
 	CallbacksHandler callbacks.Handler
+
+	mu    sync.Mutex
+	pages []WebPage
 }
 
-var _ tools.Tool = Tool{}
+var _ tools.Tool = &Tool{}
 
 // New creates a new bing search tool to search on internet
-func New(tool *v1alpha1.Tool) (*Tool, error) {
+func New(tool *v1alpha1.AllowedTool) (*Tool, error) {
 	client, err := NewFromToolSpec(tool)
 	return &Tool{client: client}, err
 }
 
-func NewFromToolSpec(tool *v1alpha1.Tool) (client *BingClient, err error) {
+func NewFromToolSpec(tool *v1alpha1.AllowedTool) (client *BingClient, err error) {
 	var countVal int
 	apikey := tool.Params[ParamAPIKey]
 	count, ok := tool.Params[ParamCount]
@@ -69,32 +73,45 @@ func NewFromToolSpec(tool *v1alpha1.Tool) (client *BingClient, err error) {
 	return NewBingClient(WithAPIKey(apikey), WithCount(countVal), WithScraperPage(scraperPage)), nil
 }
 
-func (t Tool) Name() string {
+func (t *Tool) Name() string {
 	return ToolName
 }
 
-func (t Tool) Description() string {
+func (t *Tool) Description() string {
 	return "Invoke API to get the realtime bing search data."
 }
 
-func (t Tool) Call(ctx context.Context, input string) (string, error) {
+func (t *Tool) Call(ctx context.Context, input string) (string, error) {
 	klog.Infof("running tool %s", ToolName)
 	if t.CallbacksHandler != nil {
 		t.CallbacksHandler.HandleToolStart(ctx, input)
 	}
-	result, err := t.client.Search(ctx, input)
+	pages, result, err := t.client.SearchGetDetailData(ctx, input)
 	if err != nil {
 		if t.CallbacksHandler != nil {
 			t.CallbacksHandler.HandleToolError(ctx, err)
 		}
 		return "", err
 	}
+	if len(pages) > 0 {
+		t.mu.Lock()
+		t.pages = append(t.pages, pages...)
+		t.mu.Unlock()
+		result = FormatResults(pages)
+	}
 	if t.CallbacksHandler != nil {
 		t.CallbacksHandler.HandleToolEnd(ctx, input)
 	}
 	return result, nil
 }
 
+// Pages returns the web pages found by all calls
+func (t *Tool) Pages() []WebPage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]WebPage{}, t.pages...)
+}
+
 type WebPage struct {
 	Title       string
 	Description string
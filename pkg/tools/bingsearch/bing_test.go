@@ -32,7 +32,7 @@ func TestBingSearchTool(t *testing.T) {
 	if apikey == "" {
 		t.Skip("Must set BING_KEY to run TestBingSearchTool")
 	}
-	rightTool := &v1alpha1.Tool{
+	rightTool := &v1alpha1.AllowedTool{
 		Params: map[string]string{
 			"apiKey": apikey,
 		},
@@ -0,0 +1,486 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package codeinterpreter runs the code generated by llm in an isolated kubernetes job.
+// The sandbox pod has no network, no service account token, limited cpu/memory/time,
+// and the uploaded files of the conversation are mounted read only in /data.
+// Files written to the working directory are stored in the system datasource.
+package codeinterpreter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/tools"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	pkgconfig "github.com/kubeagi/arcadia/pkg/config"
+)
+
+// Permissions required to run code in sandbox
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=create
+//+kubebuilder:rbac:groups="",resources=pods,verbs=list
+//+kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;create
+
+const (
+	ToolName = "Code Interpreter"
+
+	ParamLanguage    = "language"
+	ParamPythonImage = "pythonImage"
+	ParamNodeImage   = "nodeImage"
+	ParamCPU         = "cpu"
+	ParamMemory      = "memory"
+	ParamTimeout     = "timeout"
+
+	DefaultPythonImage = "python:3.11-slim"
+	DefaultNodeImage   = "node:20-slim"
+	DefaultCPU         = "1"
+	DefaultMemory      = "512Mi"
+	DefaultTimeout     = 60 * time.Second
+
+	// maxStdoutLength limits the stdout returned to llm
+	maxStdoutLength = 4096
+	// maxInputFilesSize limits the total size of input files, as they are stored in a ConfigMap
+	maxInputFilesSize = 900 * 1024
+	pollInterval      = time.Second
+)
+
+var (
+	ErrEmptyCode           = errors.New("no code to run")
+	ErrUnsupportedLanguage = errors.New("unsupported language, only python and javascript are supported")
+)
+
+// Options are the limits and images of the sandbox
+type Options struct {
+	Language Language
+	Images   map[Language]string
+	CPU      resource.Quantity
+	Memory   resource.Quantity
+	Timeout  time.Duration
+}
+
+// GeneratedFile is a file generated by the code and stored in the system datasource
+type GeneratedFile struct {
+	Name   string
+	Bucket string
+	Object string
+}
+
+// Tool runs python or javascript code in sandbox
+type Tool struct {
+	cli       client.Client
+	clientset kubernetes.Interface
+	namespace string
+	options   *Options
+
+	// files in InputBucket under InputPrefix, like the uploaded files of the conversation, are mounted in /data of the sandbox
+	InputBucket string
+	InputPrefix string
+	// OutputBucket and OutputPrefix are where the generated files are stored
+	OutputBucket string
+	OutputPrefix string
+	// Progress receives the progress of running code if set
+	Progress func(ctx context.Context, message string)
+
+	mu        sync.Mutex
+	generated []GeneratedFile
+
+	CallbacksHandler callbacks.Handler
+}
+
+var _ tools.Tool = &Tool{}
+
+// New creates a code interpreter tool which runs sandboxes in the namespace
+func New(cli client.Client, namespace string, tool *v1alpha1.AllowedTool) (*Tool, error) {
+	options, err := OptionsFromParams(tool.Params)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithClientset(cli, clientset, namespace, options), nil
+}
+
+// NewWithClientset creates a code interpreter tool with the clientset which is used to read logs of pods
+func NewWithClientset(cli client.Client, clientset kubernetes.Interface, namespace string, options *Options) *Tool {
+	return &Tool{
+		cli:          cli,
+		clientset:    clientset,
+		namespace:    namespace,
+		options:      options,
+		OutputBucket: namespace,
+		OutputPrefix: "code-interpreter/",
+	}
+}
+
+// OptionsFromParams parses the params of the tool spec
+func OptionsFromParams(params map[string]string) (options *Options, err error) {
+	options = &Options{
+		Language: Python,
+		Images:   map[Language]string{Python: DefaultPythonImage, JavaScript: DefaultNodeImage},
+		Timeout:  DefaultTimeout,
+	}
+	if v := params[ParamLanguage]; v != "" {
+		if options.Language, err = parseLanguage(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := params[ParamPythonImage]; v != "" {
+		options.Images[Python] = v
+	}
+	if v := params[ParamNodeImage]; v != "" {
+		options.Images[JavaScript] = v
+	}
+	cpu, memory := DefaultCPU, DefaultMemory
+	if v := params[ParamCPU]; v != "" {
+		cpu = v
+	}
+	if v := params[ParamMemory]; v != "" {
+		memory = v
+	}
+	if options.CPU, err = resource.ParseQuantity(cpu); err != nil {
+		return nil, fmt.Errorf("invalid cpu %s: %w", cpu, err)
+	}
+	if options.Memory, err = resource.ParseQuantity(memory); err != nil {
+		return nil, fmt.Errorf("invalid memory %s: %w", memory, err)
+	}
+	if v := params[ParamTimeout]; v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 1 {
+			return nil, fmt.Errorf("invalid timeout %s", v)
+		}
+		options.Timeout = time.Duration(seconds) * time.Second
+	}
+	return options, nil
+}
+
+func parseLanguage(v string) (Language, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "python", "py", "python3":
+		return Python, nil
+	case "javascript", "js", "node", "nodejs":
+		return JavaScript, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedLanguage, v)
+}
+
+func (t *Tool) Name() string {
+	// function names of llms only allow letters, digits, underscores and dashes
+	return "code_interpreter"
+}
+
+func (t *Tool) Description() string {
+	return fmt.Sprintf(`Run %s code in a sandbox without network access and return its stdout, useful for calculation and data analysis.
+Uploaded files are in the read only directory %s, files written to the current directory are saved and returned.
+Input should be the code, print the result to stdout.`, t.options.Language, dataDir)
+}
+
+// Parameters returns the json schema of the arguments for the tool calling agent
+func (t *Tool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"language": map[string]any{
+				"type":        "string",
+				"enum":        []string{string(Python), string(JavaScript)},
+				"description": "the programming language of the code, default is " + string(t.options.Language),
+			},
+			"code": map[string]any{
+				"type":        "string",
+				"description": "the code to run, print the result to stdout",
+			},
+		},
+		"required": []string{"code"},
+	}
+}
+
+func (t *Tool) Call(ctx context.Context, input string) (string, error) {
+	klog.FromContext(ctx).V(3).Info(fmt.Sprintf("running tool %s", ToolName))
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolStart(ctx, input)
+	}
+	result, err := t.call(ctx, input)
+	if err != nil {
+		if t.CallbacksHandler != nil {
+			t.CallbacksHandler.HandleToolError(ctx, err)
+		}
+		return "", err
+	}
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolEnd(ctx, result)
+	}
+	return result, nil
+}
+
+// GeneratedFiles returns the files generated by all calls
+func (t *Tool) GeneratedFiles() []GeneratedFile {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]GeneratedFile{}, t.generated...)
+}
+
+var codeBlock = regexp.MustCompile("(?s)```([a-zA-Z]*)\\s*\\n(.*?)```")
+
+// ParseInput gets the language and code from the input, which can be the arguments in json, a markdown code block or the code
+func ParseInput(input string, defaultLanguage Language) (Language, string, error) {
+	language := defaultLanguage
+	code := strings.TrimSpace(input)
+	args := struct {
+		Language string `json:"language"`
+		Code     string `json:"code"`
+	}{}
+	if err := json.Unmarshal([]byte(code), &args); err == nil && args.Code != "" {
+		code = args.Code
+		if args.Language != "" {
+			var err error
+			if language, err = parseLanguage(args.Language); err != nil {
+				return "", "", err
+			}
+		}
+	}
+	if m := codeBlock.FindStringSubmatch(code); len(m) == 3 {
+		code = m[2]
+		if m[1] != "" {
+			var err error
+			if language, err = parseLanguage(m[1]); err != nil {
+				return "", "", err
+			}
+		}
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", "", ErrEmptyCode
+	}
+	return language, code, nil
+}
+
+func (t *Tool) call(ctx context.Context, input string) (string, error) {
+	language, code, err := ParseInput(input, t.options.Language)
+	if err != nil {
+		return "", err
+	}
+	oss, err := pkgconfig.GetSystemDatasourceOSS(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get system datasource: %w", err)
+	}
+	files, err := t.readInputFiles(ctx, oss.Client)
+	if err != nil {
+		return "", err
+	}
+	if err := t.ensureNetworkPolicy(ctx); err != nil {
+		return "", err
+	}
+
+	name := "code-interpreter-" + rand.String(8)
+	job := newJob(name, t.namespace, language, t.options, files)
+	if err := t.cli.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	defer func() {
+		// delete the job in background, its pod and ConfigMap are garbage collected
+		if err := t.cli.Delete(context.Background(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to delete sandbox job %s/%s: %s", job.Namespace, job.Name, err)
+		}
+	}()
+	if err := t.cli.Create(ctx, newConfigMap(job, language, code, files)); err != nil {
+		return "", fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	t.progress(ctx, fmt.Sprintf("Running %s code in sandbox %s\n", language, name))
+
+	if err := t.waitJob(ctx, job); err != nil {
+		return "", err
+	}
+	logs, err := t.logs(ctx, job)
+	if err != nil {
+		return "", err
+	}
+	result, err := ParseLogs(logs)
+	if err != nil {
+		return "", err
+	}
+	t.progress(ctx, fmt.Sprintf("Sandbox %s finished with exit code %d\n", name, result.ExitCode))
+
+	var sb strings.Builder
+	switch result.ExitCode {
+	case 0:
+	case -1:
+		sb.WriteString(fmt.Sprintf("The code was killed, it may exceed the time limit %s or the memory limit %s.\n", t.options.Timeout, t.options.Memory.String()))
+	default:
+		sb.WriteString(fmt.Sprintf("The code exited with code %d.\n", result.ExitCode))
+	}
+	stdout := result.Stdout
+	if runes := []rune(stdout); len(runes) > maxStdoutLength {
+		stdout = string(runes[len(runes)-maxStdoutLength:])
+		sb.WriteString("Output (truncated, only the end is kept):\n")
+	} else {
+		sb.WriteString("Output:\n")
+	}
+	sb.WriteString(stdout)
+	if len(result.Files) > 0 {
+		generated, err := t.saveFiles(ctx, oss.Client, name, result.Files)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("\nGenerated files:\n")
+		for _, f := range generated {
+			sb.WriteString(fmt.Sprintf("- %s\n", f.Name))
+		}
+	}
+	return sb.String(), nil
+}
+
+func (t *Tool) progress(ctx context.Context, message string) {
+	if t.Progress != nil {
+		t.Progress(ctx, message)
+	}
+}
+
+func (t *Tool) readInputFiles(ctx context.Context, oss *minio.Client) ([]File, error) {
+	files := make([]File, 0)
+	if t.InputBucket == "" || t.InputPrefix == "" {
+		return files, nil
+	}
+	total := int64(0)
+	for info := range oss.ListObjects(ctx, t.InputBucket, minio.ListObjectsOptions{Prefix: t.InputPrefix}) {
+		if info.Err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", info.Err)
+		}
+		// skip directories, like the generated files of previous runs
+		if strings.HasSuffix(info.Key, "/") {
+			continue
+		}
+		if total+info.Size > maxInputFilesSize {
+			klog.FromContext(ctx).Info(fmt.Sprintf("skip file %s as the total size of files exceeds %d bytes", info.Key, maxInputFilesSize))
+			continue
+		}
+		object, err := oss.GetObject(ctx, t.InputBucket, info.Key, minio.GetObjectOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", info.Key, err)
+		}
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(object)
+		object.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", info.Key, err)
+		}
+		total += int64(buf.Len())
+		files = append(files, File{Name: path.Base(info.Key), Content: buf.Bytes()})
+	}
+	return files, nil
+}
+
+func (t *Tool) ensureNetworkPolicy(ctx context.Context) error {
+	policy := &networkingv1.NetworkPolicy{}
+	err := t.cli.Get(ctx, client.ObjectKey{Namespace: t.namespace, Name: NetworkPolicyName}, policy)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := t.cli.Create(ctx, newNetworkPolicy(t.namespace)); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create network policy for sandbox: %w", err)
+	}
+	return nil
+}
+
+// waitJob waits until the job finishes, the job is killed by kubernetes after the timeout
+func (t *Tool) waitJob(ctx context.Context, job *batchv1.Job) error {
+	// wait some more time for pulling image and scheduling
+	ctx, cancel := context.WithTimeout(ctx, t.options.Timeout+2*time.Minute)
+	defer cancel()
+	running := false
+	err := wait.PollImmediateUntilWithContext(ctx, pollInterval, func(ctx context.Context) (bool, error) {
+		if err := t.cli.Get(ctx, client.ObjectKeyFromObject(job), job); err != nil {
+			return false, err
+		}
+		if job.Status.Active > 0 && !running {
+			running = true
+			t.progress(ctx, "Sandbox is running\n")
+		}
+		for _, c := range job.Status.Conditions {
+			if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for sandbox %s: %w", job.Name, err)
+	}
+	return nil
+}
+
+func (t *Tool) logs(ctx context.Context, job *batchv1.Job) ([]byte, error) {
+	pods, err := t.clientset.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{LabelSelector: "job-name=" + job.Name})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pod found for sandbox %s", job.Name)
+	}
+	return t.clientset.CoreV1().Pods(job.Namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{Container: containerName}).DoRaw(ctx)
+}
+
+func (t *Tool) saveFiles(ctx context.Context, oss *minio.Client, name string, files []File) ([]GeneratedFile, error) {
+	generated := make([]GeneratedFile, 0, len(files))
+	for _, f := range files {
+		object, err := OutputObject(t.OutputPrefix, name, f.Name)
+		if err != nil {
+			klog.FromContext(ctx).Info(fmt.Sprintf("skip generated file: %s", err))
+			continue
+		}
+		f.Name = path.Base(object)
+		if _, err := oss.PutObject(ctx, t.OutputBucket, object, bytes.NewReader(f.Content), int64(len(f.Content)), minio.PutObjectOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to save generated file %s: %w", f.Name, err)
+		}
+		generated = append(generated, GeneratedFile{Name: f.Name, Bucket: t.OutputBucket, Object: object})
+	}
+	t.mu.Lock()
+	t.generated = append(t.generated, generated...)
+	t.mu.Unlock()
+	return generated, nil
+}
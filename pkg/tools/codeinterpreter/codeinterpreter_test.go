@@ -0,0 +1,130 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codeinterpreter
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInput(t *testing.T) {
+	language, code, err := ParseInput(`{"language": "javascript", "code": "console.log(1)"}`, Python)
+	require.NoError(t, err)
+	require.Equal(t, JavaScript, language)
+	require.Equal(t, "console.log(1)", code)
+
+	language, code, err = ParseInput("Run it:\n```python\nprint(1)\n```", JavaScript)
+	require.NoError(t, err)
+	require.Equal(t, Python, language)
+	require.Equal(t, "print(1)", code)
+
+	language, code, err = ParseInput("print(1)\n", Python)
+	require.NoError(t, err)
+	require.Equal(t, Python, language)
+	require.Equal(t, "print(1)", code)
+
+	_, _, err = ParseInput(" ", Python)
+	require.ErrorIs(t, err, ErrEmptyCode)
+	_, _, err = ParseInput(`{"language": "ruby", "code": "puts 1"}`, Python)
+	require.ErrorIs(t, err, ErrUnsupportedLanguage)
+}
+
+func TestOptionsFromParams(t *testing.T) {
+	options, err := OptionsFromParams(nil)
+	require.NoError(t, err)
+	require.Equal(t, Python, options.Language)
+	require.Equal(t, DefaultTimeout, options.Timeout)
+	require.Equal(t, "512Mi", options.Memory.String())
+
+	options, err = OptionsFromParams(map[string]string{ParamLanguage: "js", ParamCPU: "500m", ParamTimeout: "10", ParamNodeImage: "node:18"})
+	require.NoError(t, err)
+	require.Equal(t, JavaScript, options.Language)
+	require.Equal(t, "500m", options.CPU.String())
+	require.Equal(t, 10*time.Second, options.Timeout)
+	require.Equal(t, "node:18", options.Images[JavaScript])
+
+	_, err = OptionsFromParams(map[string]string{ParamTimeout: "0"})
+	require.Error(t, err)
+	_, err = OptionsFromParams(map[string]string{ParamMemory: "a lot"})
+	require.Error(t, err)
+}
+
+func TestNewJob(t *testing.T) {
+	options, err := OptionsFromParams(nil)
+	require.NoError(t, err)
+	job := newJob("sandbox", "default", Python, options, []File{{Name: "sales data.csv", Content: []byte("a,b")}})
+	require.Equal(t, int64(60), *job.Spec.ActiveDeadlineSeconds)
+	require.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	pod := job.Spec.Template.Spec
+	require.False(t, *pod.AutomountServiceAccountToken)
+	require.True(t, *pod.SecurityContext.RunAsNonRoot)
+	require.Equal(t, "true", job.Spec.Template.Labels[LabelSandbox])
+	container := pod.Containers[0]
+	require.Equal(t, DefaultPythonImage, container.Image)
+	require.True(t, *container.SecurityContext.ReadOnlyRootFilesystem)
+	require.Equal(t, options.Memory, container.Resources.Limits.Memory().DeepCopy())
+	require.Equal(t, "sales_data.csv", pod.Volumes[1].ConfigMap.Items[0].Path)
+
+	cm := newConfigMap(job, Python, "print(1)", []File{{Name: "sales data.csv", Content: []byte("a,b")}})
+	require.Equal(t, "print(1)", cm.Data["main.py"])
+	require.Equal(t, []byte("a,b"), cm.BinaryData["data-sales_data.csv"])
+
+	job = newJob("sandbox", "default", Python, options, nil)
+	require.NotNil(t, job.Spec.Template.Spec.Volumes[1].EmptyDir)
+}
+
+func TestConfigMapKey(t *testing.T) {
+	require.Equal(t, "report.csv", ConfigMapKey("report.csv"))
+	require.Equal(t, "file", ConfigMapKey(".."))
+	require.Equal(t, "a_b.txt", ConfigMapKey("dir/a b.txt"))
+}
+
+func TestParseLogs(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0o755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "./chart.png", Typeflag: tar.TypeReg, Mode: 0o644, Size: 3}))
+	_, err := tw.Write([]byte("png"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	logs := "hello\nworld\n\n" + outputMarker + " 0\n" + encoded[:10] + "\n" + encoded[10:] + "\n"
+	result, err := ParseLogs([]byte(logs))
+	require.NoError(t, err)
+	require.Equal(t, "hello\nworld\n", result.Stdout)
+	require.Equal(t, 0, result.ExitCode)
+	require.Equal(t, []File{{Name: "chart.png", Content: []byte("png")}}, result.Files)
+
+	result, err = ParseLogs([]byte("Traceback\n\n" + outputMarker + " 1\n"))
+	require.NoError(t, err)
+	require.Equal(t, 1, result.ExitCode)
+	require.Empty(t, result.Files)
+
+	result, err = ParseLogs([]byte("partial output"))
+	require.NoError(t, err)
+	require.Equal(t, -1, result.ExitCode)
+	require.Equal(t, "partial output", result.Stdout)
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codeinterpreter
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// LabelSandbox is the label of sandbox pods, the network policy denies all traffic of them
+	LabelSandbox = "arcadia.kubeagi.k8s.com.cn/code-interpreter"
+	// NetworkPolicyName is the name of the network policy which isolates sandbox pods
+	NetworkPolicyName = "arcadia-code-interpreter"
+
+	containerName = "sandbox"
+	codeDir       = "/code"
+	dataDir       = "/data"
+	outputDir     = "/output"
+	// outputMarker separates the stdout of the code and the generated files in the logs of sandbox
+	outputMarker = "----ARCADIA-CODE-INTERPRETER-OUTPUT----"
+
+	// maxOutputFiles limits the size of files generated by the code
+	maxOutputFiles = "16Mi"
+	// ttlAfterFinished cleans up jobs which are not deleted by the tool, like the apiserver restarts during execution
+	ttlAfterFinished = 300
+)
+
+var invalidKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]+`)
+
+// Language is the programming language of the code
+type Language string
+
+const (
+	Python     Language = "python"
+	JavaScript Language = "javascript"
+)
+
+func (l Language) fileName() string {
+	if l == JavaScript {
+		return "main.js"
+	}
+	return "main.py"
+}
+
+func (l Language) command() string {
+	if l == JavaScript {
+		return "node"
+	}
+	return "python"
+}
+
+// File is a file in the sandbox
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// sandboxScript prints the marker with a random nonce, runs the code in /output, kills the processes left by the code,
+// then prints the marker with the nonce and the exit code, and the generated files as base64 tar.gz.
+// The nonce is only in the memory of the shell and printed before the code starts, so the code can't forge the end marker.
+func sandboxScript(language Language) string {
+	return fmt.Sprintf(`nonce=$(od -An -N16 -tx1 /dev/urandom | tr -d ' \n'); echo "%[5]s $nonce"; `+
+		`cd %[1]s && %[2]s %[3]s/%[4]s; code=$?; kill -9 -1 2>/dev/null; echo; echo "%[5]s $nonce $code"; tar czf - . | base64; exit 0`,
+		outputDir, language.command(), codeDir, language.fileName(), outputMarker)
+}
+
+// ConfigMapKey returns a valid key of ConfigMap for the file name
+func ConfigMapKey(name string) string {
+	key := strings.Trim(invalidKeyChars.ReplaceAllString(path.Base(name), "_"), ".")
+	if key == "" {
+		key = "file"
+	}
+	return key
+}
+
+// newJob returns the job which runs the code in an isolated pod:
+// no service account token, no network, non-root user, read only root filesystem and limited resources.
+func newJob(name, namespace string, language Language, options *Options, files []File) *batchv1.Job {
+	dataItems := make([]corev1.KeyToPath, 0, len(files))
+	for _, f := range files {
+		dataItems = append(dataItems, corev1.KeyToPath{Key: "data-" + ConfigMapKey(f.Name), Path: ConfigMapKey(f.Name)})
+	}
+	dataVolume := corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+		LocalObjectReference: corev1.LocalObjectReference{Name: name},
+		Items:                dataItems,
+	}}
+	if len(dataItems) == 0 {
+		// a ConfigMap volume without items contains all keys
+		dataVolume = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	}
+	outputLimit := resource.MustParse(maxOutputFiles)
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    options.CPU,
+		corev1.ResourceMemory: options.Memory,
+	}
+	labels := map[string]string{LabelSandbox: "true"}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            pointer.Int32(0),
+			ActiveDeadlineSeconds:   pointer.Int64(int64(options.Timeout.Seconds())),
+			TTLSecondsAfterFinished: pointer.Int32(ttlAfterFinished),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:                corev1.RestartPolicyNever,
+					AutomountServiceAccountToken: pointer.Bool(false),
+					EnableServiceLinks:           pointer.Bool(false),
+					// no dns, the network policy denies all traffic
+					DNSPolicy: corev1.DNSNone,
+					DNSConfig: &corev1.PodDNSConfig{Nameservers: []string{"127.0.0.1"}},
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot: pointer.Bool(true),
+						RunAsUser:    pointer.Int64(65534),
+						RunAsGroup:   pointer.Int64(65534),
+						FSGroup:      pointer.Int64(65534),
+					},
+					Containers: []corev1.Container{{
+						Name:    containerName,
+						Image:   options.Images[language],
+						Command: []string{"sh", "-c", sandboxScript(language)},
+						Env: []corev1.EnvVar{
+							{Name: "HOME", Value: "/tmp"},
+							{Name: "PYTHONDONTWRITEBYTECODE", Value: "1"},
+							{Name: "MPLCONFIGDIR", Value: "/tmp"},
+						},
+						Resources: corev1.ResourceRequirements{Limits: resources, Requests: resources},
+						SecurityContext: &corev1.SecurityContext{
+							AllowPrivilegeEscalation: pointer.Bool(false),
+							ReadOnlyRootFilesystem:   pointer.Bool(true),
+							Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+						},
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "code", MountPath: codeDir, ReadOnly: true},
+							{Name: "data", MountPath: dataDir, ReadOnly: true},
+							{Name: "output", MountPath: outputDir},
+							{Name: "tmp", MountPath: "/tmp"},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "code", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: name},
+							Items:                []corev1.KeyToPath{{Key: language.fileName(), Path: language.fileName()}},
+						}}},
+						{Name: "data", VolumeSource: dataVolume},
+						{Name: "output", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &outputLimit}}},
+						{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &outputLimit}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newConfigMap returns the ConfigMap which holds the code and the input files, it is owned by the job
+func newConfigMap(job *batchv1.Job, language Language, code string, files []File) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name,
+			Namespace: job.Namespace,
+			Labels:    job.Labels,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: batchv1.SchemeGroupVersion.String(),
+				Kind:       "Job",
+				Name:       job.Name,
+				UID:        job.UID,
+			}},
+		},
+		Data:       map[string]string{language.fileName(): code},
+		BinaryData: make(map[string][]byte, len(files)),
+	}
+	for _, f := range files {
+		cm.BinaryData["data-"+ConfigMapKey(f.Name)] = f.Content
+	}
+	return cm
+}
+
+// newNetworkPolicy denies all ingress and egress traffic of sandbox pods
+func newNetworkPolicy(namespace string) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      NetworkPolicyName,
+			Namespace: namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{LabelSandbox: "true"}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+}
+
+// Result is the result of running code in sandbox
+type Result struct {
+	Stdout   string
+	ExitCode int
+	Files    []File
+}
+
+// ParseLogs parses the logs of sandbox into stdout, exit code and generated files.
+// The first line is the marker with the nonce of this run, and the generated files follow the marker with the same nonce.
+func ParseLogs(logs []byte) (*Result, error) {
+	first, rest, _ := bytes.Cut(logs, []byte("\n"))
+	nonce, ok := strings.CutPrefix(string(first), outputMarker+" ")
+	if !ok || strings.TrimSpace(nonce) == "" {
+		// the sandbox didn't start the code
+		return &Result{Stdout: string(logs), ExitCode: -1}, nil
+	}
+	end := []byte(outputMarker + " " + strings.TrimSpace(nonce) + " ")
+	i := bytes.Index(rest, end)
+	if i < 0 {
+		// the code is killed, like timeout or out of memory
+		return &Result{Stdout: string(rest), ExitCode: -1}, nil
+	}
+	result := &Result{Stdout: strings.TrimSuffix(string(rest[:i]), "\n")}
+	rest = rest[i+len(end):]
+	line, archive, _ := bytes.Cut(rest, []byte("\n"))
+	code, err := strconv.Atoi(strings.TrimSpace(string(line)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid exit code %q", line)
+	}
+	result.ExitCode = code
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(archive)), ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode output files: %w", err)
+	}
+	if len(data) == 0 {
+		return result, nil
+	}
+	result.Files, err = untar(data)
+	return result, err
+}
+
+// OutputObject returns the object of the generated file under the prefix of this run.
+// The name comes from the sandbox, so the names which may escape the prefix are rejected.
+func OutputObject(prefix, run, name string) (string, error) {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "..") || strings.Contains(name, "\\") {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	dir := path.Join(prefix, run) + "/"
+	object := path.Join(dir, path.Base(name))
+	if !strings.HasPrefix(object, dir) {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	return object, nil
+}
+
+func untar(data []byte) ([]File, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	files := make([]File, 0)
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: path.Clean(strings.TrimPrefix(header.Name, "./")), Content: content})
+	}
+	return files, nil
+}
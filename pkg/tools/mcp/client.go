@@ -0,0 +1,215 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mcp is a client of the Model Context Protocol, it lists the tools of MCP servers and calls them.
+// The streamable HTTP transport and the stdio transport are supported.
+// See https://modelcontextprotocol.io/specification for the protocol.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	ProtocolVersion = "2025-03-26"
+
+	methodInitialize  = "initialize"
+	methodInitialized = "notifications/initialized"
+	methodListTools   = "tools/list"
+	methodCallTool    = "tools/call"
+
+	// maxListPages avoids endless pagination of a broken server
+	maxListPages = 100
+)
+
+var ErrClosed = errors.New("mcp client is closed")
+
+// Request is a JSON-RPC request, it is a notification if ID is nil
+type Request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      *int64 `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC response
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is the error of JSON-RPC response
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// Transport sends messages to the MCP server
+type Transport interface {
+	// Send sends the request and waits for the response with the same id
+	Send(ctx context.Context, request *Request) (*Response, error)
+	// Notify sends the notification without waiting for response
+	Notify(ctx context.Context, notification *Request) error
+	Close() error
+}
+
+// ToolInfo is the definition of a tool provided by MCP server
+type ToolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema,omitempty"`
+}
+
+// Content is an item of the result of tool call
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	// Resource is set for embedded resources
+	Resource *struct {
+		URI  string `json:"uri"`
+		Text string `json:"text,omitempty"`
+	} `json:"resource,omitempty"`
+}
+
+// CallToolResult is the result of tool call
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text returns the text of all contents, non text contents are described by their types
+func (r *CallToolResult) Text() string {
+	parts := make([]string, 0, len(r.Content))
+	for _, c := range r.Content {
+		switch {
+		case c.Type == "text":
+			parts = append(parts, c.Text)
+		case c.Resource != nil && c.Resource.Text != "":
+			parts = append(parts, c.Resource.Text)
+		case c.Resource != nil:
+			parts = append(parts, fmt.Sprintf("[resource %s]", c.Resource.URI))
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content %s]", c.Type, c.MimeType))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ServerInfo is the result of initialize
+type ServerInfo struct {
+	ProtocolVersion string `json:"protocolVersion"`
+	ServerInfo      struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"serverInfo"`
+}
+
+// Client is a MCP client over a transport
+type Client struct {
+	transport Transport
+	nextID    atomic.Int64
+}
+
+// NewClient returns a client over the transport, Initialize must be called before other methods
+func NewClient(transport Transport) *Client {
+	return &Client{transport: transport}
+}
+
+func (c *Client) call(ctx context.Context, method string, params any, result any) error {
+	id := c.nextID.Add(1)
+	resp, err := c.transport.Send(ctx, &Request{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("invalid result of %s: %w", method, err)
+	}
+	return nil
+}
+
+// Initialize negotiates the protocol version with the server
+func (c *Client) Initialize(ctx context.Context) (*ServerInfo, error) {
+	info := &ServerInfo{}
+	err := c.call(ctx, methodInitialize, map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "arcadia", "version": "v1alpha1"},
+	}, info)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.transport.Notify(ctx, &Request{JSONRPC: "2.0", Method: methodInitialized}); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ListTools returns all tools of the server
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	tools := make([]ToolInfo, 0)
+	cursor := ""
+	for i := 0; i < maxListPages; i++ {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		page := struct {
+			Tools      []ToolInfo `json:"tools"`
+			NextCursor string     `json:"nextCursor,omitempty"`
+		}{}
+		if err := c.call(ctx, methodListTools, params, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+	return nil, fmt.Errorf("too many pages of tools, more than %d", maxListPages)
+}
+
+// CallTool calls the tool with the arguments
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]any) (*CallToolResult, error) {
+	result := &CallToolResult{}
+	if err := c.call(ctx, methodCallTool, map[string]any{"name": name, "arguments": arguments}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Close closes the transport
+func (c *Client) Close() error {
+	return c.transport.Close()
+}
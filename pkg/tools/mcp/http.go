@@ -0,0 +1,184 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	headerSessionID = "Mcp-Session-Id"
+	// maxErrorBodyLength limits the body of error responses in errors
+	maxErrorBodyLength = 512
+)
+
+// HTTPTransport is the streamable HTTP transport, each message is POSTed to the endpoint,
+// the server responds a JSON message or a stream of server-sent events.
+type HTTPTransport struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+var _ Transport = &HTTPTransport{}
+
+// NewHTTPTransport returns a streamable HTTP transport, headers are sent in every request, like the Authorization header
+func NewHTTPTransport(endpoint string, headers map[string]string, client *http.Client) *HTTPTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPTransport{endpoint: endpoint, headers: headers, client: client}
+}
+
+func (t *HTTPTransport) post(ctx context.Context, message *Request) (*http.Response, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(headerSessionID, t.sessionID)
+	}
+	t.mu.Unlock()
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return nil, fmt.Errorf("mcp server responded %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if id := resp.Header.Get(headerSessionID); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (t *HTTPTransport) Send(ctx context.Context, request *Request) (*Response, error) {
+	resp, err := t.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return readEventStream(resp.Body, *request.ID)
+	}
+	response := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return response, nil
+}
+
+// readEventStream reads the server-sent events until the response of the request,
+// requests and notifications from the server are ignored.
+func readEventStream(body io.Reader, id int64) (*Response, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			if data.Len() > 0 {
+				data.WriteString("\n")
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+		// an empty line dispatches the event
+		response := &Response{}
+		err := json.Unmarshal([]byte(data.String()), response)
+		data.Reset()
+		if err != nil {
+			continue
+		}
+		if response.ID != nil && *response.ID == id {
+			return response, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// the last event may not end with an empty line
+	response := &Response{}
+	if err := json.Unmarshal([]byte(data.String()), response); err == nil && response.ID != nil && *response.ID == id {
+		return response, nil
+	}
+	return nil, errors.New("event stream closed without response")
+}
+
+func (t *HTTPTransport) Notify(ctx context.Context, notification *Request) error {
+	resp, err := t.post(ctx, notification)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Close terminates the session if the server assigned one
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.endpoint, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set(headerSessionID, sessionID)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
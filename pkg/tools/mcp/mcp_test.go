@@ -0,0 +1,176 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// handle is a fake MCP server with an echo tool and a failing tool, the tools are listed in two pages
+func handle(request *Request) *Response {
+	if request.ID == nil {
+		return nil
+	}
+	var result any
+	switch request.Method {
+	case methodInitialize:
+		result = map[string]any{"protocolVersion": ProtocolVersion, "serverInfo": map[string]any{"name": "fake", "version": "1.0"}}
+	case methodListTools:
+		params, _ := request.Params.(map[string]any)
+		if params["cursor"] == "2" {
+			result = map[string]any{"tools": []any{map[string]any{"name": "fail", "description": "always fails"}}}
+		} else {
+			result = map[string]any{"tools": []any{map[string]any{
+				"name":        "echo",
+				"description": "echo the text",
+				"inputSchema": map[string]any{"type": "object", "properties": map[string]any{"text": map[string]any{"type": "string"}}},
+			}}, "nextCursor": "2"}
+		}
+	case methodCallTool:
+		params, _ := request.Params.(map[string]any)
+		args, _ := params["arguments"].(map[string]any)
+		if params["name"] == "fail" {
+			result = map[string]any{"content": []any{map[string]any{"type": "text", "text": "boom"}}, "isError": true}
+		} else {
+			result = map[string]any{"content": []any{map[string]any{"type": "text", "text": fmt.Sprint(args["text"])}}}
+		}
+	default:
+		return &Response{JSONRPC: "2.0", ID: request.ID, Error: &RPCError{Code: -32601, Message: "method not found"}}
+	}
+	data, _ := json.Marshal(result)
+	return &Response{JSONRPC: "2.0", ID: request.ID, Result: data}
+}
+
+func testTools(t *testing.T, client *Client) {
+	ctx := context.Background()
+	info, err := client.Initialize(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "fake", info.ServerInfo.Name)
+
+	tools, err := NewTools(ctx, client, "fake server", nil, time.Second)
+	require.NoError(t, err)
+	require.Len(t, tools, 2)
+	require.Equal(t, "fake_server_echo", tools[0].Name())
+	require.Contains(t, tools[0].Description(), "echo the text")
+
+	out, err := tools[0].Call(ctx, `{"text": "hello"}`)
+	require.NoError(t, err)
+	require.Equal(t, "hello", out)
+	// the input is passed as the only string property
+	out, err = tools[0].Call(ctx, "plain text")
+	require.NoError(t, err)
+	require.Equal(t, "plain text", out)
+
+	out, err = tools[1].Call(ctx, "{}")
+	require.NoError(t, err)
+	require.Equal(t, "The tool returned an error: boom", out)
+
+	tools, err = NewTools(ctx, client, "fake", []string{"fail"}, time.Second)
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+}
+
+func TestHTTPTransport(t *testing.T) {
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if r.Method == http.MethodDelete {
+			require.Equal(t, "session-1", r.Header.Get(headerSessionID))
+			deleted = true
+			return
+		}
+		request := &Request{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(request))
+		if request.Method == methodInitialize {
+			w.Header().Set(headerSessionID, "session-1")
+		} else {
+			require.Equal(t, "session-1", r.Header.Get(headerSessionID))
+		}
+		response := handle(request)
+		if response == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		data, _ := json.Marshal(response)
+		// respond tool calls with event stream, other requests with json
+		if request.Method == methodCallTool {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	client := NewClient(NewHTTPTransport(server.URL, map[string]string{"Authorization": "Bearer token"}, nil))
+	testTools(t, client)
+	require.NoError(t, client.Close())
+	require.True(t, deleted)
+}
+
+func TestHTTPTransportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	_, err := NewClient(NewHTTPTransport(server.URL, nil, nil)).Initialize(context.Background())
+	require.ErrorContains(t, err, "401")
+}
+
+// TestHelperProcess is the fake MCP server started by TestStdioTransport
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		request := &Request{}
+		if err := json.Unmarshal(scanner.Bytes(), request); err != nil {
+			continue
+		}
+		// logs in stdout should be ignored by client
+		fmt.Println("handling", request.Method)
+		if response := handle(request); response != nil {
+			data, _ := json.Marshal(response)
+			fmt.Println(string(data))
+		}
+	}
+	os.Exit(0)
+}
+
+func TestStdioTransport(t *testing.T) {
+	transport, err := NewStdioTransport([]string{os.Args[0], "-test.run=TestHelperProcess"}, map[string]string{"GO_WANT_HELPER_PROCESS": "1"})
+	require.NoError(t, err)
+	client := NewClient(transport)
+	testTools(t, client)
+	require.NoError(t, client.Close())
+
+	_, err = client.CallTool(context.Background(), "echo", nil)
+	require.Error(t, err)
+}
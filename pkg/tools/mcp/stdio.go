@@ -0,0 +1,168 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// StdioTransport runs the server as a subprocess and exchanges newline delimited messages over its stdin and stdout.
+// In kubernetes the binary of server is usually provided by a sidecar or an init container through a shared volume.
+type StdioTransport struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[int64]chan *Response
+	err     error
+	done    chan struct{}
+}
+
+var _ Transport = &StdioTransport{}
+
+// NewStdioTransport starts the command, env is added to the environment of current process
+func NewStdioTransport(command []string, env map[string]string) (*StdioTransport, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no command of mcp server")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start mcp server: %w", err)
+	}
+	t := &StdioTransport{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan *Response),
+		done:    make(chan struct{}),
+	}
+	go t.read(stdout)
+	return t, nil
+}
+
+// read dispatches the responses to the pending requests until stdout is closed
+func (t *StdioTransport) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		response := &Response{}
+		if err := json.Unmarshal(scanner.Bytes(), response); err != nil || response.ID == nil {
+			// logs printed to stdout, requests and notifications from the server are ignored
+			continue
+		}
+		t.mu.Lock()
+		ch, ok := t.pending[*response.ID]
+		delete(t.pending, *response.ID)
+		t.mu.Unlock()
+		if ok {
+			ch <- response
+		}
+	}
+	t.mu.Lock()
+	t.err = scanner.Err()
+	if t.err == nil {
+		t.err = ErrClosed
+	}
+	t.mu.Unlock()
+	close(t.done)
+}
+
+func (t *StdioTransport) write(message *Request) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *StdioTransport) Send(ctx context.Context, request *Request) (*Response, error) {
+	ch := make(chan *Response, 1)
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return nil, t.err
+	}
+	t.pending[*request.ID] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, *request.ID)
+		t.mu.Unlock()
+	}()
+	if err := t.write(request); err != nil {
+		return nil, err
+	}
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-t.done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return nil, t.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *StdioTransport) Notify(ctx context.Context, notification *Request) error {
+	return t.write(notification)
+}
+
+// Close closes stdin of the server and kills it if it doesn't exit in time
+func (t *StdioTransport) Close() error {
+	_ = t.stdin.Close()
+	exited := make(chan error, 1)
+	go func() {
+		exited <- t.cmd.Wait()
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		if err := t.cmd.Process.Kill(); err != nil {
+			klog.Errorf("failed to kill mcp server %s: %s", t.cmd.Path, err)
+		}
+		<-exited
+	}
+	return nil
+}
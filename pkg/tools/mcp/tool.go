@@ -0,0 +1,138 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/tools"
+	"k8s.io/klog/v2"
+)
+
+const maxToolNameLength = 64
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Tool calls a tool of MCP server
+type Tool struct {
+	client  *Client
+	server  string
+	info    ToolInfo
+	timeout time.Duration
+
+	CallbacksHandler callbacks.Handler
+}
+
+var _ tools.Tool = &Tool{}
+
+// NewTools lists the tools of the server and returns the allowed ones, all tools are returned if allowed is empty.
+// timeout limits each call of the tools.
+func NewTools(ctx context.Context, client *Client, server string, allowed []string, timeout time.Duration) ([]*Tool, error) {
+	infos, err := client.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allowlist := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		allowlist[name] = true
+	}
+	result := make([]*Tool, 0, len(infos))
+	for _, info := range infos {
+		if len(allowlist) > 0 && !allowlist[info.Name] {
+			continue
+		}
+		result = append(result, &Tool{client: client, server: server, info: info, timeout: timeout})
+	}
+	return result, nil
+}
+
+// Name is prefixed with the server name to avoid conflicts between servers
+func (t *Tool) Name() string {
+	name := invalidToolNameChars.ReplaceAllString(t.server+"_"+t.info.Name, "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
+
+func (t *Tool) Description() string {
+	schema, _ := json.Marshal(t.Parameters())
+	return fmt.Sprintf("%s Input should be a JSON object matching the schema: %s", t.info.Description, schema)
+}
+
+// Parameters returns the input schema of the tool
+func (t *Tool) Parameters() map[string]any {
+	if len(t.info.InputSchema) == 0 {
+		return map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return t.info.InputSchema
+}
+
+func (t *Tool) Call(ctx context.Context, input string) (string, error) {
+	klog.FromContext(ctx).V(3).Info(fmt.Sprintf("running mcp tool %s of server %s", t.info.Name, t.server))
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolStart(ctx, input)
+	}
+	result, err := t.call(ctx, input)
+	if err != nil {
+		if t.CallbacksHandler != nil {
+			t.CallbacksHandler.HandleToolError(ctx, err)
+		}
+		return "", err
+	}
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolEnd(ctx, result)
+	}
+	return result, nil
+}
+
+func (t *Tool) call(ctx context.Context, input string) (string, error) {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	result, err := t.client.CallTool(ctx, t.info.Name, t.arguments(input))
+	if err != nil {
+		return "", err
+	}
+	if result.IsError {
+		// let llm know the error, it may retry with other arguments
+		return "The tool returned an error: " + result.Text(), nil
+	}
+	return result.Text(), nil
+}
+
+// arguments parses the input as a JSON object, or passes it as the only string property of the schema
+func (t *Tool) arguments(input string) map[string]any {
+	args := make(map[string]any)
+	if err := json.Unmarshal([]byte(input), &args); err == nil {
+		return args
+	}
+	properties, _ := t.Parameters()["properties"].(map[string]any)
+	for name, property := range properties {
+		if p, ok := property.(map[string]any); ok && p["type"] == "string" && len(properties) == 1 {
+			return map[string]any{name: input}
+		}
+	}
+	return map[string]any{"input": input}
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+
+Package openapi builds agent tools from an OpenAPI 3 document, every operation in the document becomes a
+`github.com/tmc/langchaingo/tools.Tool` whose input is a JSON object of the operation's parameters.
+
+The document can be stored in a ConfigMap or in the system datasource, it is configured by the params of the tool:
+
+  - configMap: name of the ConfigMap in the namespace of the agent which holds the document
+  - configMapKey: key of the document in the ConfigMap, default is openapi.yaml
+  - bucket, object: location of the document in the system datasource, bucket must be the namespace of the agent and defaults to it
+  - server: base url of the api, default is the first server in the document
+  - allowedHosts: comma separated hosts which can be requested, default is the host of the server
+  - authSecret: name of the Secret in the namespace of the agent, each key/value in it is sent as a http header
+  - operations: comma separated operationIds to expose, default is all operations
+  - timeout: timeout of each request in seconds, default is 30
+  - maxResponseLength: responses longer than it are truncated, default is 4096
+*/
+
+package openapi
@@ -0,0 +1,416 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/tools"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	basev1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/config"
+)
+
+const (
+	ToolName = "OpenAPI"
+
+	ParamConfigMap         = "configMap"
+	ParamConfigMapKey      = "configMapKey"
+	ParamBucket            = "bucket"
+	ParamObject            = "object"
+	ParamServer            = "server"
+	ParamAllowedHosts      = "allowedHosts"
+	ParamAuthSecret        = "authSecret"
+	ParamOperations        = "operations"
+	ParamTimeout           = "timeout"
+	ParamMaxResponseLength = "maxResponseLength"
+
+	DefaultConfigMapKey      = "openapi.yaml"
+	DefaultTimeout           = 30 * time.Second
+	DefaultMaxResponseLength = 4096
+
+	// bodyArgument is the argument which holds the request body
+	bodyArgument = "body"
+	// maxDocumentSize is the max size of openapi documents read from the system datasource
+	maxDocumentSize = 10 << 20
+)
+
+var (
+	ErrNoDocument       = errors.New("one of configMap or object is required for the openapi tool")
+	ErrHostNotAllowed   = errors.New("host is not allowed")
+	ErrBucketNotAllowed = errors.New("bucket is not allowed")
+)
+
+// Options are the options to call the operations
+type Options struct {
+	// Server is the base url of the api
+	Server string
+	// AllowedHosts are the hosts which can be requested
+	AllowedHosts []string
+	// Headers are sent with every request, usually for authentication
+	Headers map[string]string
+	// Operations limits the operations exposed as tools, empty means all
+	Operations []string
+	// Timeout of each request
+	Timeout time.Duration
+	// MaxResponseLength is the max length of the response returned to llm
+	MaxResponseLength int
+	// HTTPClient is used to send requests, a new client is created if nil
+	HTTPClient *http.Client
+}
+
+// Tool calls an operation of the api
+type Tool struct {
+	endpoint         Endpoint
+	options          *Options
+	client           *http.Client
+	CallbacksHandler callbacks.Handler
+}
+
+var _ tools.Tool = &Tool{}
+
+// New loads the openapi document and the auth headers configured by the tool spec, and creates a tool for each operation
+func New(ctx context.Context, cli client.Client, namespace string, tool *v1alpha1.AllowedTool) ([]*Tool, error) {
+	data, err := loadDocument(ctx, cli, namespace, tool.Params)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	options := &Options{
+		Server:            tool.Params[ParamServer],
+		AllowedHosts:      splitParam(tool.Params[ParamAllowedHosts]),
+		Operations:        splitParam(tool.Params[ParamOperations]),
+		Timeout:           DefaultTimeout,
+		MaxResponseLength: DefaultMaxResponseLength,
+	}
+	if v := tool.Params[ParamTimeout]; v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %s: %w", v, err)
+		}
+		options.Timeout = time.Duration(seconds) * time.Second
+	}
+	if v := tool.Params[ParamMaxResponseLength]; v != "" {
+		options.MaxResponseLength, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxResponseLength %s: %w", v, err)
+		}
+	}
+	if name := tool.Params[ParamAuthSecret]; name != "" {
+		secret := &corev1.Secret{}
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get auth secret %s: %w", name, err)
+		}
+		options.Headers = make(map[string]string, len(secret.Data))
+		for k, v := range secret.Data {
+			options.Headers[k] = string(v)
+		}
+	}
+	return NewFromDocument(doc, options)
+}
+
+// NewFromDocument creates a tool for each operation in the document
+func NewFromDocument(doc *Document, options *Options) ([]*Tool, error) {
+	if options.Server == "" {
+		if len(doc.Servers) == 0 {
+			return nil, errors.New("no server in openapi document, the server param is required")
+		}
+		options.Server = doc.Servers[0].URL
+	}
+	server, err := url.Parse(options.Server)
+	if err != nil || server.Host == "" {
+		return nil, fmt.Errorf("invalid server %q, it must be an absolute url", options.Server)
+	}
+	if len(options.AllowedHosts) == 0 {
+		options.AllowedHosts = []string{server.Host}
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.MaxResponseLength <= 0 {
+		options.MaxResponseLength = DefaultMaxResponseLength
+	}
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	// copy the client to check the hosts of redirects without changing the shared one
+	c := *httpClient
+	c.Timeout = options.Timeout
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return options.checkHost(req.URL)
+	}
+
+	endpoints, err := doc.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(options.Operations))
+	for _, op := range options.Operations {
+		wanted[op] = true
+	}
+	result := make([]*Tool, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if len(wanted) > 0 && !wanted[endpoint.Name] {
+			continue
+		}
+		result = append(result, &Tool{endpoint: endpoint, options: options, client: &c})
+	}
+	if len(result) == 0 {
+		return nil, errors.New("no operation found in openapi document")
+	}
+	return result, nil
+}
+
+func (t *Tool) Name() string {
+	return t.endpoint.Name
+}
+
+func (t *Tool) Description() string {
+	return fmt.Sprintf("%s\nThe input must be a JSON object matching the JSON schema: %s", t.endpoint.Description, t.endpoint.schemaString())
+}
+
+// Parameters returns the json schema of the arguments, so the tool calling agent can pass them directly
+func (t *Tool) Parameters() map[string]any {
+	return t.endpoint.JSONSchema()
+}
+
+func (t *Tool) Call(ctx context.Context, input string) (string, error) {
+	klog.Infof("running tool %s", t.Name())
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolStart(ctx, input)
+	}
+	result, err := t.call(ctx, input)
+	if err != nil {
+		if t.CallbacksHandler != nil {
+			t.CallbacksHandler.HandleToolError(ctx, err)
+		}
+		return "", err
+	}
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolEnd(ctx, result)
+	}
+	return result, nil
+}
+
+func (t *Tool) call(ctx context.Context, input string) (string, error) {
+	args, err := t.parseInput(input)
+	if err != nil {
+		return "", err
+	}
+	req, err := t.newRequest(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	if err := t.options.checkHost(req.URL); err != nil {
+		return "", err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	// read a little more than the limit, as the limit is in characters
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(t.options.MaxResponseLength)*4+1))
+	if err != nil {
+		return "", err
+	}
+	body := truncate(string(data), t.options.MaxResponseLength)
+	// errors of the api are returned as the observation, so llm can correct the arguments
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Sprintf("request failed with status code %d: %s", resp.StatusCode, body), nil
+	}
+	return body, nil
+}
+
+// parseInput parses the input as a json object, a plain string is accepted if the operation has only one argument
+func (t *Tool) parseInput(input string) (map[string]any, error) {
+	input = strings.TrimSpace(input)
+	args := make(map[string]any)
+	if input == "" {
+		return args, nil
+	}
+	if err := json.Unmarshal([]byte(input), &args); err == nil {
+		return args, nil
+	}
+	if len(t.endpoint.Parameters) == 1 && t.endpoint.Body == nil {
+		args[t.endpoint.Parameters[0].Name] = strings.Trim(input, `"'`)
+		return args, nil
+	}
+	return nil, fmt.Errorf("input of %s must be a JSON object matching the schema %s", t.Name(), t.endpoint.schemaString())
+}
+
+func (t *Tool) newRequest(ctx context.Context, args map[string]any) (*http.Request, error) {
+	path := t.endpoint.Path
+	query := url.Values{}
+	headers := make(map[string]string)
+	for _, p := range t.endpoint.Parameters {
+		v, ok := args[p.Name]
+		if !ok || v == nil {
+			if p.Required {
+				return nil, fmt.Errorf("missing required argument %s", p.Name)
+			}
+			continue
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(argString(v)))
+		case "query":
+			if list, ok := v.([]any); ok {
+				for _, item := range list {
+					query.Add(p.Name, argString(item))
+				}
+			} else {
+				query.Set(p.Name, argString(v))
+			}
+		case "header":
+			headers[p.Name] = argString(v)
+		}
+	}
+	u := strings.TrimSuffix(t.options.Server, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if t.endpoint.Body != nil {
+		if v, ok := args[bodyArgument]; ok && v != nil {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(data)
+		} else if t.endpoint.BodyRequired {
+			return nil, fmt.Errorf("missing required argument %s", bodyArgument)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, t.endpoint.Method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	// auth headers can't be overridden by llm
+	for k, v := range t.options.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+func (o *Options) checkHost(u *url.URL) error {
+	for _, host := range o.AllowedHosts {
+		if strings.EqualFold(u.Host, host) || strings.EqualFold(u.Hostname(), host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrHostNotAllowed, u.Host)
+}
+
+func loadDocument(ctx context.Context, cli client.Client, namespace string, params map[string]string) ([]byte, error) {
+	if name := params[ParamConfigMap]; name != "" {
+		key := params[ParamConfigMapKey]
+		if key == "" {
+			key = DefaultConfigMapKey
+		}
+		cm := &corev1.ConfigMap{}
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cm); err != nil {
+			return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		data, ok := cm.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("no key %s in configmap %s", key, name)
+		}
+		return []byte(data), nil
+	}
+	if object := params[ParamObject]; object != "" {
+		// the bucket of the system datasource is per namespace, an agent can only read the documents in its own namespace
+		bucket := params[ParamBucket]
+		if bucket == "" {
+			bucket = namespace
+		}
+		if bucket != namespace {
+			return nil, fmt.Errorf("%w: bucket %s is not the namespace %s of the agent", ErrBucketNotAllowed, bucket, namespace)
+		}
+		oss, err := config.GetSystemDatasourceOSS(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get system datasource: %w", err)
+		}
+		reader, err := oss.ReadFile(ctx, &basev1alpha1.OSS{Bucket: bucket, Object: object})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s/%s from system datasource: %w", bucket, object, err)
+		}
+		defer reader.Close()
+		return io.ReadAll(io.LimitReader(reader, maxDocumentSize))
+	}
+	return nil, ErrNoDocument
+}
+
+func splitParam(v string) []string {
+	result := make([]string, 0)
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func argString(v any) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "...(truncated)"
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+)
+
+const petstore = `
+openapi: 3.0.0
+info:
+  title: petstore
+servers:
+- url: %s/v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+      - $ref: '#/components/parameters/limit'
+      - name: tags
+        in: query
+        schema:
+          type: array
+          items:
+            type: string
+    post:
+      operationId: createPet
+      summary: Create a pet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+  /pets/{petId}:
+    parameters:
+    - name: petId
+      in: path
+      schema:
+        type: string
+    get:
+      summary: Get a pet by id
+components:
+  parameters:
+    limit:
+      name: limit
+      in: query
+      description: max number of pets
+      schema:
+        type: integer
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        owner:
+          $ref: '#/components/schemas/Owner'
+    Owner:
+      type: object
+      properties:
+        name:
+          type: string
+`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pets":
+			fmt.Fprintf(w, `{"limit":%q,"tags":%q}`, r.URL.Query().Get("limit"), strings.Join(r.URL.Query()["tags"], ","))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/pets":
+			data, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(data)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pets/a b":
+			fmt.Fprint(w, strings.Repeat("x", 100))
+		case r.URL.Path == "/redirect":
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestNew(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "arcadia", Name: "petstore"},
+			Data:       map[string]string{DefaultConfigMapKey: fmt.Sprintf(petstore, server.URL)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "arcadia", Name: "petstore-auth"},
+			Data:       map[string][]byte{"X-Api-Key": []byte("secret")},
+		},
+	).Build()
+	ctx := context.Background()
+
+	tools, err := New(ctx, cli, "arcadia", &v1alpha1.AllowedTool{
+		Name: ToolName,
+		Params: map[string]string{
+			ParamConfigMap:         "petstore",
+			ParamAuthSecret:        "petstore-auth",
+			ParamMaxResponseLength: "10",
+		},
+	})
+	require.NoError(t, err)
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name())
+	}
+	require.Equal(t, []string{"createPet", "get_pets_petId", "listPets"}, names)
+
+	// body schema is resolved and required
+	schema := tools[0].Parameters()
+	require.Equal(t, []string{bodyArgument}, schema["required"])
+	owner := schema["properties"].(map[string]any)[bodyArgument].(map[string]any)["properties"].(map[string]any)["owner"].(map[string]any)
+	require.Equal(t, "object", owner["type"])
+
+	out, err := tools[2].Call(ctx, `{"limit": 10, "tags": ["cat", "dog"]}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"limit":"`, out[:10])
+
+	out, err = tools[0].Call(ctx, `{"body": {"name": "kitty"}}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"k...(truncated)`, out)
+	_, err = tools[0].Call(ctx, `{}`)
+	require.ErrorContains(t, err, "missing required argument body")
+
+	// plain string input is accepted for operations with one argument, and the path is escaped
+	out, err = tools[1].Call(ctx, "a b")
+	require.NoError(t, err)
+	require.Equal(t, "xxxxxxxxxx...(truncated)", out)
+
+	// documents in the buckets of other namespaces can't be loaded
+	_, err = New(ctx, cli, "arcadia", &v1alpha1.AllowedTool{
+		Name:   ToolName,
+		Params: map[string]string{ParamBucket: "kube-system", ParamObject: "openapi.yaml"},
+	})
+	require.ErrorIs(t, err, ErrBucketNotAllowed)
+}
+
+func TestHostAllowlist(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	doc, err := ParseDocument([]byte(fmt.Sprintf(petstore, server.URL)))
+	require.NoError(t, err)
+	tools, err := NewFromDocument(doc, &Options{
+		AllowedHosts: []string{"example.com"},
+		Operations:   []string{"listPets"},
+	})
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	_, err = tools[0].Call(context.Background(), "")
+	require.ErrorIs(t, err, ErrHostNotAllowed)
+
+	// redirects to other hosts are rejected
+	redirect, err := ParseDocument([]byte(`{"openapi":"3.0.1","paths":{"/redirect":{"get":{"operationId":"redirect"}}}}`))
+	require.NoError(t, err)
+	tools, err = NewFromDocument(redirect, &Options{Server: server.URL, Headers: map[string]string{"X-Api-Key": "secret"}})
+	require.NoError(t, err)
+	_, err = tools[0].Call(context.Background(), "")
+	require.ErrorIs(t, err, ErrHostNotAllowed)
+
+	// errors of the api are returned as observation
+	tools, err = NewFromDocument(doc, &Options{Operations: []string{"listPets"}})
+	require.NoError(t, err)
+	out, err := tools[0].Call(context.Background(), "")
+	require.NoError(t, err)
+	require.Contains(t, out, "status code 401")
+
+	data, err := json.Marshal(tools[0].Parameters())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"object","properties":{"limit":{"type":"integer","description":"max number of pets"},"tags":{"type":"array","items":{"type":"string"}}}}`, string(data))
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// maxRefDepth limits the depth of resolving $ref, to avoid endless loops on recursive schemas
+const maxRefDepth = 8
+
+// Document is the subset of OpenAPI 3 document used to build tools
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas       map[string]map[string]any `json:"schemas,omitempty"`
+	Parameters    map[string]Parameter      `json:"parameters,omitempty"`
+	RequestBodies map[string]RequestBody    `json:"requestBodies,omitempty"`
+}
+
+type PathItem struct {
+	Parameters []Parameter `json:"parameters,omitempty"`
+	Get        *Operation  `json:"get,omitempty"`
+	Put        *Operation  `json:"put,omitempty"`
+	Post       *Operation  `json:"post,omitempty"`
+	Delete     *Operation  `json:"delete,omitempty"`
+	Patch      *Operation  `json:"patch,omitempty"`
+}
+
+type Operation struct {
+	OperationID string       `json:"operationId,omitempty"`
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Parameters  []Parameter  `json:"parameters,omitempty"`
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+}
+
+type Parameter struct {
+	Ref         string         `json:"$ref,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	In          string         `json:"in,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      map[string]any `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema map[string]any `json:"schema,omitempty"`
+}
+
+// Endpoint is an operation with everything resolved, which is ready to be called
+type Endpoint struct {
+	Name        string
+	Description string
+	Method      string
+	Path        string
+	Parameters  []Parameter
+	// Body is the json schema of the request body, nil if the operation has no json body
+	Body         map[string]any
+	BodyRequired bool
+}
+
+// ParseDocument parses an OpenAPI 3 document in json or yaml
+func ParseDocument(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("failed to parse openapi document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q, only 3.x is supported", doc.OpenAPI)
+	}
+	if len(doc.Paths) == 0 {
+		return nil, fmt.Errorf("no paths in openapi document")
+	}
+	return doc, nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Endpoints returns the resolved operations sorted by name
+func (doc *Document) Endpoints() ([]Endpoint, error) {
+	endpoints := make([]Endpoint, 0)
+	for path, item := range doc.Paths {
+		for method, op := range map[string]*Operation{
+			http.MethodGet:    item.Get,
+			http.MethodPut:    item.Put,
+			http.MethodPost:   item.Post,
+			http.MethodDelete: item.Delete,
+			http.MethodPatch:  item.Patch,
+		} {
+			if op == nil {
+				continue
+			}
+			endpoint, err := doc.endpoint(method, path, item.Parameters, op)
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].Name < endpoints[j].Name
+	})
+	return endpoints, nil
+}
+
+func (doc *Document) endpoint(method, path string, common []Parameter, op *Operation) (Endpoint, error) {
+	name := op.OperationID
+	if name == "" {
+		name = strings.ToLower(method) + path
+	}
+	// function names of llms only allow letters, digits, underscores and dashes
+	name = strings.Trim(invalidNameChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	endpoint := Endpoint{
+		Name:        name,
+		Description: strings.TrimSpace(strings.Join([]string{op.Summary, op.Description}, "\n")),
+		Method:      method,
+		Path:        path,
+	}
+	if endpoint.Description == "" {
+		endpoint.Description = fmt.Sprintf("%s %s", method, path)
+	}
+
+	// parameters of the operation override the ones of the path with the same name and location
+	params := make(map[string]Parameter)
+	keys := make([]string, 0)
+	for _, p := range append(append([]Parameter{}, common...), op.Parameters...) {
+		resolved, err := doc.resolveParameter(p)
+		if err != nil {
+			return endpoint, fmt.Errorf("operation %s: %w", name, err)
+		}
+		if resolved.In == "cookie" {
+			continue
+		}
+		key := resolved.In + "/" + resolved.Name
+		if _, ok := params[key]; !ok {
+			keys = append(keys, key)
+		}
+		params[key] = resolved
+	}
+	for _, key := range keys {
+		endpoint.Parameters = append(endpoint.Parameters, params[key])
+	}
+
+	if op.RequestBody != nil {
+		body, err := doc.resolveRequestBody(*op.RequestBody)
+		if err != nil {
+			return endpoint, fmt.Errorf("operation %s: %w", name, err)
+		}
+		for contentType, media := range body.Content {
+			if strings.Contains(contentType, "json") {
+				endpoint.Body = doc.resolveSchema(media.Schema, 0)
+				if endpoint.Body == nil {
+					endpoint.Body = map[string]any{"type": "object"}
+				}
+				if body.Description != "" {
+					endpoint.Body["description"] = body.Description
+				}
+				endpoint.BodyRequired = body.Required
+				break
+			}
+		}
+	}
+	return endpoint, nil
+}
+
+func (doc *Document) resolveParameter(p Parameter) (Parameter, error) {
+	if p.Ref != "" {
+		name := strings.TrimPrefix(p.Ref, "#/components/parameters/")
+		ref, ok := doc.Components.Parameters[name]
+		if !ok || name == p.Ref {
+			return p, fmt.Errorf("can't resolve parameter %s", p.Ref)
+		}
+		p = ref
+	}
+	if p.Name == "" || p.In == "" {
+		return p, fmt.Errorf("parameter without name or location")
+	}
+	if p.In == "path" {
+		p.Required = true
+	}
+	p.Schema = doc.resolveSchema(p.Schema, 0)
+	return p, nil
+}
+
+func (doc *Document) resolveRequestBody(body RequestBody) (RequestBody, error) {
+	if body.Ref == "" {
+		return body, nil
+	}
+	name := strings.TrimPrefix(body.Ref, "#/components/requestBodies/")
+	ref, ok := doc.Components.RequestBodies[name]
+	if !ok || name == body.Ref {
+		return body, fmt.Errorf("can't resolve request body %s", body.Ref)
+	}
+	return ref, nil
+}
+
+// resolveSchema returns a copy of the schema with all local $ref replaced by the referenced schemas
+func (doc *Document) resolveSchema(schema map[string]any, depth int) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		target, found := doc.Components.Schemas[name]
+		if !found || depth >= maxRefDepth {
+			return map[string]any{"type": "object"}
+		}
+		return doc.resolveSchema(target, depth+1)
+	}
+	resolved := make(map[string]any, len(schema))
+	for k, v := range schema {
+		resolved[k] = doc.resolveValue(v, depth)
+	}
+	return resolved
+}
+
+func (doc *Document) resolveValue(v any, depth int) any {
+	switch value := v.(type) {
+	case map[string]any:
+		return doc.resolveSchema(value, depth)
+	case []any:
+		list := make([]any, len(value))
+		for i := range value {
+			list[i] = doc.resolveValue(value[i], depth)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// JSONSchema returns the json schema of the arguments of the endpoint,
+// parameters are properties of the root object and the request body is the property "body".
+func (e *Endpoint) JSONSchema() map[string]any {
+	properties := make(map[string]any)
+	required := make([]string, 0)
+	for _, p := range e.Parameters {
+		schema := map[string]any{"type": "string"}
+		if p.Schema != nil {
+			schema = make(map[string]any, len(p.Schema)+1)
+			for k, v := range p.Schema {
+				schema[k] = v
+			}
+		}
+		if p.Description != "" {
+			schema["description"] = p.Description
+		}
+		properties[p.Name] = schema
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	if e.Body != nil {
+		properties[bodyArgument] = e.Body
+		if e.BodyRequired {
+			required = append(required, bodyArgument)
+		}
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (e *Endpoint) schemaString() string {
+	data, _ := json.Marshal(e.JSONSchema())
+	return string(data)
+}
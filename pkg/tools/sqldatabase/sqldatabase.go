@@ -0,0 +1,521 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sqldatabase answers questions by querying a PostgreSQL datasource with the SQL generated by llm.
+// The query runs in a read only transaction with a statement timeout and a row limit,
+// and only the tables in the allowlist can be used, they are checked against the query plan before running.
+// Views are expanded in the plan, so the tables used by a view must be allowed too.
+// The allowlist is not a replacement of the database permissions, the datasource should use a role which can only read the allowed tables.
+package sqldatabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/tmc/langchaingo/callbacks"
+	langchainllms "github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+	basev1alpha1 "github.com/kubeagi/arcadia/api/base/v1alpha1"
+	"github.com/kubeagi/arcadia/pkg/datasource"
+)
+
+const (
+	ToolName = "SQL Database"
+
+	ParamDatasource = "datasource"
+	ParamNamespace  = "namespace"
+	ParamTables     = "tables"
+	ParamMaxRows    = "maxRows"
+	ParamTimeout    = "timeout"
+
+	DefaultMaxRows = 100
+	DefaultTimeout = 10 * time.Second
+
+	// maxCellLength limits the length of each value in the result table
+	maxCellLength = 256
+)
+
+var (
+	ErrNotReadOnly        = errors.New("only a single SELECT statement is allowed")
+	ErrTableNotAllowed    = errors.New("table is not allowed")
+	ErrNoTableAvailable   = errors.New("no table available in the datasource")
+	ErrFromListNotAllowed = errors.New("comma separated tables in FROM are not allowed, use JOIN instead")
+	ErrFunctionNotAllowed = errors.New("function is not allowed")
+)
+
+const sqlPromptTemplate = `You are a PostgreSQL expert. Given the database schema below, write one read-only PostgreSQL SELECT query to answer the question.
+Only use the tables and columns in the schema, query at most %d rows, and return the SQL only without any explanation.
+
+Schema:
+%s
+Question: %s
+SQL:`
+
+// Tool generates SQL for the input question with llm and returns the query result as a markdown table
+type Tool struct {
+	pool    *pgxpool.Pool
+	llm     langchainllms.Model
+	name    string
+	tables  []string
+	maxRows int
+	timeout time.Duration
+
+	schemaOnce sync.Once
+	schema     string
+	schemaErr  error
+
+	mu      sync.Mutex
+	queries []string
+
+	CallbacksHandler callbacks.Handler
+}
+
+var _ tools.Tool = &Tool{}
+
+// New creates a sql tool for the PostgreSQL datasource in the params, the datasource must be in the namespace of the agent
+func New(ctx context.Context, cli client.Client, llm langchainllms.Model, namespace string, tool *v1alpha1.AllowedTool) (*Tool, error) {
+	if llm == nil {
+		return nil, errors.New("llm is required by the sql tool")
+	}
+	name := tool.Params[ParamDatasource]
+	if name == "" {
+		return nil, errors.New("datasource is required by the sql tool")
+	}
+	if ns := tool.Params[ParamNamespace]; ns != "" && ns != namespace {
+		return nil, fmt.Errorf("datasource %s/%s is not in the namespace %s of the agent", ns, name, namespace)
+	}
+	ds := &basev1alpha1.Datasource{}
+	if err := cli.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, ds); err != nil {
+		return nil, fmt.Errorf("can't find the datasource in cluster: %w", err)
+	}
+	if ds.Spec.Type() != basev1alpha1.DatasourceTypePostgreSQL {
+		return nil, fmt.Errorf("datasource %s is not a PostgreSQL datasource", name)
+	}
+	if ds.Spec.Endpoint.AuthSecret != nil && ds.Spec.Endpoint.AuthSecret.Namespace == nil {
+		ds.Spec.Endpoint.AuthSecret.WithNameSpace(ds.Namespace)
+	}
+	pg, err := datasource.GetPostgreSQLPool(ctx, cli, ds)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tool{
+		pool:    pg.Pool,
+		llm:     llm,
+		name:    name,
+		maxRows: DefaultMaxRows,
+		timeout: DefaultTimeout,
+	}
+	for _, table := range strings.Split(tool.Params[ParamTables], ",") {
+		if table = strings.ToLower(strings.TrimSpace(table)); table != "" {
+			t.tables = append(t.tables, table)
+		}
+	}
+	if v := tool.Params[ParamMaxRows]; v != "" {
+		if t.maxRows, err = strconv.Atoi(v); err != nil || t.maxRows < 1 {
+			return nil, fmt.Errorf("invalid maxRows %s", v)
+		}
+	}
+	if v := tool.Params[ParamTimeout]; v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 1 {
+			return nil, fmt.Errorf("invalid timeout %s", v)
+		}
+		t.timeout = time.Duration(seconds) * time.Second
+	}
+	return t, nil
+}
+
+func (t *Tool) Name() string {
+	return ToolName
+}
+
+func (t *Tool) Description() string {
+	return fmt.Sprintf("Query the PostgreSQL database %s to answer questions about its data, like statistics and records. Input should be a question in natural language.", t.name)
+}
+
+func (t *Tool) Call(ctx context.Context, input string) (string, error) {
+	klog.FromContext(ctx).V(3).Info(fmt.Sprintf("running tool %s", ToolName))
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolStart(ctx, input)
+	}
+	result, err := t.call(ctx, input)
+	if err != nil {
+		if t.CallbacksHandler != nil {
+			t.CallbacksHandler.HandleToolError(ctx, err)
+		}
+		return "", err
+	}
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolEnd(ctx, result)
+	}
+	return result, nil
+}
+
+func (t *Tool) call(ctx context.Context, question string) (string, error) {
+	schema, err := t.getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	generated, err := langchainllms.GenerateFromSinglePrompt(ctx, t.llm, fmt.Sprintf(sqlPromptTemplate, t.maxRows, schema, question), langchainllms.WithTemperature(0.01))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate sql: %w", err)
+	}
+	query := ExtractSQL(generated)
+	klog.FromContext(ctx).V(3).Info(fmt.Sprintf("sql generated: %s", query))
+	if err := ValidateSQL(query, t.tables); err != nil {
+		// return the error to the agent, so it can rephrase the question
+		return fmt.Sprintf("The generated SQL %q is rejected: %s", query, err), nil
+	}
+	columns, rows, truncated, err := t.query(ctx, query)
+	if err != nil {
+		return fmt.Sprintf("The SQL %q failed: %s", query, err), nil
+	}
+	t.mu.Lock()
+	t.queries = append(t.queries, query)
+	t.mu.Unlock()
+	return fmt.Sprintf("SQL: %s\nResult:\n%s", query, FormatTable(columns, rows, truncated)), nil
+}
+
+// Datasource returns the name of the datasource
+func (t *Tool) Datasource() string {
+	return t.name
+}
+
+// Queries returns the sql executed successfully
+func (t *Tool) Queries() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.queries...)
+}
+
+// query runs the sql in a read only transaction with statement timeout, and returns at most maxRows rows
+func (t *Tool) query(ctx context.Context, query string) (columns []string, rows [][]any, truncated bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout+time.Second)
+	defer cancel()
+	tx, err := t.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer func() {
+		_ = tx.Rollback(context.Background())
+	}()
+	if _, err = tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", t.timeout.Milliseconds())); err != nil {
+		return nil, nil, false, err
+	}
+	query = fmt.Sprintf("SELECT * FROM (%s) AS q LIMIT %d", query, t.maxRows+1)
+	if len(t.tables) > 0 {
+		// the relations really scanned are in the plan, including the ones the regular expressions miss
+		var plan []byte
+		if err = tx.QueryRow(ctx, "EXPLAIN (VERBOSE, FORMAT JSON) "+query).Scan(&plan); err != nil {
+			return nil, nil, false, err
+		}
+		if err = ValidatePlan(plan, t.tables); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	result, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer result.Close()
+	for _, f := range result.FieldDescriptions() {
+		columns = append(columns, f.Name)
+	}
+	for result.Next() {
+		if len(rows) == t.maxRows {
+			truncated = true
+			break
+		}
+		values, err := result.Values()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		rows = append(rows, values)
+	}
+	return columns, rows, truncated, result.Err()
+}
+
+// getSchema returns the columns of allowed tables, it is loaded once for each tool
+func (t *Tool) getSchema(ctx context.Context) (string, error) {
+	t.schemaOnce.Do(func() {
+		rows, err := t.pool.Query(ctx, `SELECT table_schema, table_name, column_name, data_type FROM information_schema.columns
+WHERE table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY table_schema, table_name, ordinal_position`)
+		if err != nil {
+			t.schemaErr = fmt.Errorf("failed to get schema of datasource %s: %w", t.name, err)
+			return
+		}
+		defer rows.Close()
+		columns := make([]Column, 0)
+		for rows.Next() {
+			c := Column{}
+			if err := rows.Scan(&c.Schema, &c.Table, &c.Name, &c.Type); err != nil {
+				t.schemaErr = err
+				return
+			}
+			columns = append(columns, c)
+		}
+		if err := rows.Err(); err != nil {
+			t.schemaErr = err
+			return
+		}
+		t.schema = FormatSchema(columns, t.tables)
+		if t.schema == "" {
+			t.schemaErr = ErrNoTableAvailable
+		}
+	})
+	return t.schema, t.schemaErr
+}
+
+// Column is a column of a table
+type Column struct {
+	Schema string
+	Table  string
+	Name   string
+	Type   string
+}
+
+// FormatSchema formats the columns of allowed tables like "table(column type, ...)", all tables are allowed if allowlist is empty
+func FormatSchema(columns []Column, allowlist []string) string {
+	var sb strings.Builder
+	var current string
+	for _, c := range columns {
+		table := c.Table
+		if c.Schema != "public" {
+			table = c.Schema + "." + c.Table
+		}
+		if len(allowlist) > 0 && !tableAllowed(c.Schema, c.Table, allowlist) {
+			continue
+		}
+		if table != current {
+			if current != "" {
+				sb.WriteString(")\n")
+			}
+			sb.WriteString(table + "(")
+			current = table
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(c.Name + " " + c.Type)
+	}
+	if current != "" {
+		sb.WriteString(")\n")
+	}
+	return sb.String()
+}
+
+var (
+	codeBlock      = regexp.MustCompile("(?s)```(?:sql|SQL)?\\s*(.*?)```")
+	sqlComment     = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	tableReference = regexp.MustCompile(`(?i)\b(?:from|join)\s+((?:"?[a-z_][a-z0-9_$]*"?\.)?"?[a-z_][a-z0-9_$]*"?)`)
+	cteName        = regexp.MustCompile(`(?i)(?:\bwith(?:\s+recursive)?|,)\s*"?([a-z_][a-z0-9_]*)"?\s*(?:\([^)]*\)\s*)?as\s*\(`)
+	writeKeywords  = regexp.MustCompile(`(?i)\b(insert|update|delete|merge|create|alter|drop|truncate|grant|revoke|copy|call|do|vacuum|lock|set|reset|into)\b`)
+	// unsafeFunctions run the sql in their arguments, or read files and objects outside of the tables
+	unsafeFunctions = regexp.MustCompile(`(?i)\b(query_to_xml\w*|cursor_to_xml\w*|table_to_xml\w*|schema_to_xml\w*|database_to_xml\w*|dblink\w*|pg_read_\w+|pg_ls_\w+|pg_stat_file|lo_\w+|set_config|current_setting)\s*\(`)
+	// fromListEnd are the keywords ending a FROM clause
+	fromListEnd = map[string]bool{"where": true, "group": true, "having": true, "order": true, "limit": true, "offset": true, "union": true,
+		"intersect": true, "except": true, "window": true, "fetch": true, "for": true}
+)
+
+// ExtractSQL gets the sql from the output of llm, which may be in a markdown code block
+func ExtractSQL(output string) string {
+	if m := codeBlock.FindStringSubmatch(output); len(m) == 2 {
+		output = m[1]
+	}
+	output = strings.TrimSpace(output)
+	output = strings.TrimPrefix(output, "SQL:")
+	return strings.TrimSuffix(strings.TrimSpace(output), ";")
+}
+
+// ValidateSQL checks the sql is a single SELECT statement which only uses the allowed tables.
+// It is a best effort check, the read only transaction is the real protection.
+func ValidateSQL(query string, allowlist []string) error {
+	stripped := strings.TrimSpace(stringLiteral.ReplaceAllString(sqlComment.ReplaceAllString(query, " "), "''"))
+	lower := strings.ToLower(stripped)
+	if !strings.HasPrefix(lower, "select") && !strings.HasPrefix(lower, "with") {
+		return ErrNotReadOnly
+	}
+	if strings.Contains(stripped, ";") || writeKeywords.MatchString(stripped) {
+		return ErrNotReadOnly
+	}
+	if len(allowlist) == 0 {
+		return nil
+	}
+	if m := unsafeFunctions.FindStringSubmatch(stripped); m != nil {
+		return fmt.Errorf("%w: %s", ErrFunctionNotAllowed, m[1])
+	}
+	// only the first table after FROM is matched, so the others in a comma separated list can't be checked
+	if hasFromList(stripped) {
+		return ErrFromListNotAllowed
+	}
+	ctes := make(map[string]bool)
+	for _, m := range cteName.FindAllStringSubmatch(stripped, -1) {
+		ctes[strings.ToLower(m[1])] = true
+	}
+	for _, m := range tableReference.FindAllStringSubmatch(stripped, -1) {
+		name := strings.ToLower(strings.ReplaceAll(m[1], `"`, ""))
+		schema, table := "public", name
+		if i := strings.Index(name, "."); i > 0 {
+			schema, table = name[:i], name[i+1:]
+		} else if ctes[name] {
+			continue
+		}
+		if !tableAllowed(schema, table, allowlist) {
+			return fmt.Errorf("%w: %s", ErrTableNotAllowed, name)
+		}
+	}
+	return nil
+}
+
+// hasFromList returns whether there is a comma in a FROM clause, out of the parentheses in it
+func hasFromList(query string) bool {
+	query = strings.ToLower(query)
+	// whether each level of parentheses is in a FROM clause
+	inFrom := []bool{false}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '"':
+			// skip the quoted identifier
+			if end := strings.IndexByte(query[i+1:], '"'); end >= 0 {
+				i += end + 1
+			}
+		case c == '(':
+			inFrom = append(inFrom, false)
+		case c == ')':
+			if len(inFrom) > 1 {
+				inFrom = inFrom[:len(inFrom)-1]
+			}
+		case c == ',':
+			if inFrom[len(inFrom)-1] {
+				return true
+			}
+		case c == '_' || (c >= 'a' && c <= 'z'):
+			j := i + 1
+			for j < len(query) && (query[j] == '_' || query[j] == '$' || (query[j] >= 'a' && query[j] <= 'z') || (query[j] >= '0' && query[j] <= '9')) {
+				j++
+			}
+			if word := query[i:j]; word == "from" {
+				inFrom[len(inFrom)-1] = true
+			} else if fromListEnd[word] {
+				inFrom[len(inFrom)-1] = false
+			}
+			i = j - 1
+		}
+	}
+	return false
+}
+
+// ValidatePlan checks all relations scanned in the plan of EXPLAIN (VERBOSE, FORMAT JSON) are allowed
+func ValidatePlan(plan []byte, allowlist []string) error {
+	var explained []struct {
+		Plan map[string]any `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil {
+		return fmt.Errorf("failed to parse the query plan: %w", err)
+	}
+	for _, e := range explained {
+		if err := validatePlanNode(e.Plan, allowlist); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePlanNode(node map[string]any, allowlist []string) error {
+	if relation, ok := node["Relation Name"].(string); ok {
+		schema, _ := node["Schema"].(string)
+		if !tableAllowed(schema, relation, allowlist) {
+			return fmt.Errorf("%w: %s.%s", ErrTableNotAllowed, schema, relation)
+		}
+	}
+	if function, ok := node["Function Name"].(string); ok {
+		return fmt.Errorf("%w: %s", ErrFunctionNotAllowed, function)
+	}
+	children, _ := node["Plans"].([]any)
+	for _, child := range children {
+		if c, ok := child.(map[string]any); ok {
+			if err := validatePlanNode(c, allowlist); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func tableAllowed(schema, table string, allowlist []string) bool {
+	schema, table = strings.ToLower(schema), strings.ToLower(table)
+	for _, allowed := range allowlist {
+		if allowed == schema+"."+table || (schema == "public" && allowed == table) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatTable formats the query result as a markdown table
+func FormatTable(columns []string, rows [][]any, truncated bool) string {
+	if len(rows) == 0 {
+		return "no rows"
+	}
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = formatValue(v)
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	if truncated {
+		sb.WriteString(fmt.Sprintf("(only the first %d rows are returned)\n", len(rows)))
+	}
+	return sb.String()
+}
+
+func formatValue(v any) string {
+	var s string
+	switch value := v.(type) {
+	case nil:
+		return "NULL"
+	case time.Time:
+		s = value.Format(time.RFC3339)
+	case []byte:
+		s = string(value)
+	default:
+		s = fmt.Sprint(value)
+	}
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\n", " "), "|", "\\|")
+	if runes := []rune(s); len(runes) > maxCellLength {
+		s = string(runes[:maxCellLength]) + "..."
+	}
+	return s
+}
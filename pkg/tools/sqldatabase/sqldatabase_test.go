@@ -0,0 +1,101 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqldatabase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSQL(t *testing.T) {
+	require.Equal(t, "SELECT count(*) FROM orders", ExtractSQL("```sql\nSELECT count(*) FROM orders;\n```"))
+	require.Equal(t, "SELECT 1", ExtractSQL("SQL: SELECT 1;"))
+	require.Equal(t, "SELECT 1", ExtractSQL("Here it is:\n```\nSELECT 1\n```\nDone"))
+}
+
+func TestValidateSQL(t *testing.T) {
+	allowlist := []string{"orders", "sales.customers"}
+	cases := []struct {
+		query string
+		err   error
+	}{
+		{query: "SELECT count(*) FROM orders WHERE status = 'update'"},
+		{query: `SELECT o.id FROM public.orders o JOIN "sales"."customers" c ON o.customer_id = c.id`},
+		{query: "WITH recent AS (SELECT * FROM orders) SELECT count(*) FROM recent"},
+		{query: "-- comment\nselect deleted_at from orders"},
+		{query: "SELECT * FROM users", err: ErrTableNotAllowed},
+		{query: "SELECT * FROM orders JOIN customers ON true", err: ErrTableNotAllowed},
+		{query: "DELETE FROM orders", err: ErrNotReadOnly},
+		{query: "SELECT 1; DROP TABLE orders", err: ErrNotReadOnly},
+		{query: "SELECT * INTO backup FROM orders", err: ErrNotReadOnly},
+		{query: "WITH d AS (DELETE FROM orders RETURNING *) SELECT * FROM d", err: ErrNotReadOnly},
+		{query: "SELECT * FROM orders, secret_table", err: ErrFromListNotAllowed},
+		{query: "SELECT * FROM orders a, pg_catalog.pg_authid", err: ErrFromListNotAllowed},
+		{query: "SELECT * FROM orders o JOIN sales.customers c ON o.customer_id = c.id, secret_table", err: ErrFromListNotAllowed},
+		{query: "SELECT * FROM (SELECT id FROM orders) a, users", err: ErrFromListNotAllowed},
+		{query: "SELECT id, status FROM (SELECT id, status FROM orders) AS o WHERE id IN (1, 2) ORDER BY id, status"},
+		{query: "SELECT * FROM orders WHERE id IN (SELECT id FROM users)", err: ErrTableNotAllowed},
+		{query: "SELECT query_to_xml('select * from users', true, true, '')", err: ErrFunctionNotAllowed},
+		{query: "SELECT * FROM generate_series(1, 10)", err: ErrTableNotAllowed},
+	}
+	for _, c := range cases {
+		err := ValidateSQL(c.query, allowlist)
+		if c.err == nil {
+			require.NoError(t, err, c.query)
+		} else {
+			require.ErrorIs(t, err, c.err, c.query)
+		}
+	}
+	require.NoError(t, ValidateSQL("SELECT * FROM users", nil))
+}
+
+func TestFormatSchema(t *testing.T) {
+	columns := []Column{
+		{Schema: "public", Table: "orders", Name: "id", Type: "integer"},
+		{Schema: "public", Table: "orders", Name: "status", Type: "text"},
+		{Schema: "public", Table: "users", Name: "password", Type: "text"},
+		{Schema: "sales", Table: "customers", Name: "name", Type: "text"},
+	}
+	require.Equal(t, "orders(id integer, status text)\nsales.customers(name text)\n", FormatSchema(columns, []string{"orders", "sales.customers"}))
+	require.Equal(t, "", FormatSchema(columns, []string{"missing"}))
+}
+
+func TestFormatTable(t *testing.T) {
+	require.Equal(t, "no rows", FormatTable([]string{"id"}, nil, false))
+	require.Equal(t, "| id | name |\n| --- | --- |\n| 1 | a\\|b |\n| 2 | NULL |\n(only the first 2 rows are returned)\n",
+		FormatTable([]string{"id", "name"}, [][]any{{1, "a|b"}, {2, nil}}, true))
+}
+
+func TestValidatePlan(t *testing.T) {
+	allowlist := []string{"orders", "sales.customers"}
+	plan := func(nodes string) []byte {
+		return []byte(`[{"Plan": {"Node Type": "Limit", "Plans": [` + nodes + `]}}]`)
+	}
+	require.NoError(t, ValidatePlan(plan(`{"Node Type": "Hash Join", "Plans": [
+		{"Node Type": "Seq Scan", "Relation Name": "orders", "Schema": "public", "Alias": "o"},
+		{"Node Type": "Hash", "Plans": [{"Node Type": "Index Scan", "Relation Name": "customers", "Schema": "sales", "Alias": "c"}]}
+	]}`), allowlist))
+	require.ErrorIs(t, ValidatePlan(plan(`{"Node Type": "Nested Loop", "Plans": [
+		{"Node Type": "Seq Scan", "Relation Name": "orders", "Schema": "public", "Alias": "a"},
+		{"Node Type": "Seq Scan", "Relation Name": "pg_authid", "Schema": "pg_catalog", "Alias": "pg_authid"}
+	]}`), allowlist), ErrTableNotAllowed)
+	// the customers in public is not the allowed one
+	require.ErrorIs(t, ValidatePlan(plan(`{"Node Type": "Seq Scan", "Relation Name": "customers", "Schema": "public"}`), allowlist), ErrTableNotAllowed)
+	require.ErrorIs(t, ValidatePlan(plan(`{"Node Type": "Function Scan", "Function Name": "pg_ls_dir", "Schema": "pg_catalog"}`), allowlist), ErrFunctionNotAllowed)
+	require.Error(t, ValidatePlan([]byte("not json"), allowlist))
+}
@@ -39,7 +39,7 @@ type Tool struct {
 var _ tools.Tool = Tool{}
 
 // New creates a new weather tool to search on internet
-func New(tool *v1alpha1.Tool) (*Tool, error) {
+func New(tool *v1alpha1.AllowedTool) (*Tool, error) {
 	apikey := tool.Params["apiKey"]
 	return &Tool{
 		client: internal.New(apikey),
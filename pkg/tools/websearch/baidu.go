@@ -0,0 +1,109 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+)
+
+const (
+	BaiduToolName = "Baidu Search"
+	BaiduEndpoint = "https://qianfan.baidubce.com/v2/ai_search/web_search"
+)
+
+// Baidu searches with the web search API of Baidu AI Search on Qianfan
+type Baidu struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+var _ Searcher = &Baidu{}
+
+// NewBaidu creates a Baidu search tool, the apiKey of Qianfan is required
+func NewBaidu(tool *v1alpha1.AllowedTool) (*Tool, error) {
+	options, err := OptionsFromParams(tool, BaiduEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	if options.APIKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	return NewTool(BaiduToolName, &Baidu{endpoint: options.Endpoint, apiKey: options.APIKey, client: options.Client}, options.Count), nil
+}
+
+type baiduRequest struct {
+	Messages           []baiduMessage       `json:"messages"`
+	SearchSource       string               `json:"search_source"`
+	ResourceTypeFilter []baiduResourceLimit `json:"resource_type_filter"`
+}
+
+type baiduMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type baiduResourceLimit struct {
+	Type string `json:"type"`
+	TopK int    `json:"top_k"`
+}
+
+type baiduResponse struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	References []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"references"`
+}
+
+func (b *Baidu) Search(ctx context.Context, query string, count int) ([]Result, error) {
+	body, err := json.Marshal(baiduRequest{
+		Messages:           []baiduMessage{{Role: "user", Content: query}},
+		SearchSource:       "baidu_search_v2",
+		ResourceTypeFilter: []baiduResourceLimit{{Type: "web", TopK: count}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	resp := &baiduResponse{}
+	if err := do(b.client, req, resp); err != nil {
+		return nil, err
+	}
+	// errors are returned with http status 200
+	if resp.Code != "" && resp.Code != "0" {
+		return nil, fmt.Errorf("baidu search failed: %s %s", resp.Code, resp.Message)
+	}
+	results := make([]Result, 0, len(resp.References))
+	for _, r := range resp.References {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return limit(results, count), nil
+}
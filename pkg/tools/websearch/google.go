@@ -0,0 +1,103 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websearch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+)
+
+const (
+	GoogleToolName = "Google Search"
+	GoogleEndpoint = "https://www.googleapis.com/customsearch/v1"
+	// ParamSearchEngineID is the id(cx) of the Programmable Search Engine
+	ParamSearchEngineID = "searchEngineID"
+
+	// googleMaxNum is the max number of results in a request of Custom Search JSON API
+	googleMaxNum = 10
+)
+
+// Google searches with the Custom Search JSON API of Google Programmable Search Engine
+type Google struct {
+	endpoint       string
+	apiKey         string
+	searchEngineID string
+	client         *http.Client
+}
+
+var _ Searcher = &Google{}
+
+// NewGoogle creates a Google search tool, both apiKey and searchEngineID are required
+func NewGoogle(tool *v1alpha1.AllowedTool) (*Tool, error) {
+	options, err := OptionsFromParams(tool, GoogleEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	if options.APIKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	searcher := &Google{endpoint: options.Endpoint, apiKey: options.APIKey, searchEngineID: tool.Params[ParamSearchEngineID], client: options.Client}
+	if searcher.searchEngineID == "" {
+		return nil, errors.New("searchEngineID is required")
+	}
+	return NewTool(GoogleToolName, searcher, options.Count), nil
+}
+
+type googleResponse struct {
+	Items []struct {
+		Title   string `json:"title"`
+		Link    string `json:"link"`
+		Snippet string `json:"snippet"`
+	} `json:"items"`
+}
+
+func (g *Google) Search(ctx context.Context, query string, count int) ([]Result, error) {
+	results := make([]Result, 0, count)
+	// the results are paginated by 10
+	for start := 1; len(results) < count; start += googleMaxNum {
+		num := count - len(results)
+		if num > googleMaxNum {
+			num = googleMaxNum
+		}
+		params := url.Values{}
+		params.Set("key", g.apiKey)
+		params.Set("cx", g.searchEngineID)
+		params.Set("q", query)
+		params.Set("num", strconv.Itoa(num))
+		params.Set("start", strconv.Itoa(start))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.endpoint+"?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp := &googleResponse{}
+		if err := do(g.client, req, resp); err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Items {
+			results = append(results, Result{Title: item.Title, URL: item.Link, Snippet: item.Snippet})
+		}
+		if len(resp.Items) < num {
+			break
+		}
+	}
+	return limit(results, count), nil
+}
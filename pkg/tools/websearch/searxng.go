@@ -0,0 +1,80 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websearch
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+)
+
+const (
+	SearxNGToolName = "SearxNG Search"
+	// ParamLanguage is the search language of SearxNG, like zh-CN
+	ParamLanguage = "language"
+)
+
+// SearxNG searches with a self-hosted SearxNG instance, the json format must be enabled in its settings
+type SearxNG struct {
+	endpoint string
+	language string
+	client   *http.Client
+}
+
+var _ Searcher = &SearxNG{}
+
+// NewSearxNG creates a SearxNG tool, the endpoint is required, like http://searxng.default:8080
+func NewSearxNG(tool *v1alpha1.AllowedTool) (*Tool, error) {
+	options, err := OptionsFromParams(tool, "")
+	if err != nil {
+		return nil, err
+	}
+	searcher := &SearxNG{endpoint: options.Endpoint, language: tool.Params[ParamLanguage], client: options.Client}
+	return NewTool(SearxNGToolName, searcher, options.Count), nil
+}
+
+type searxngResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+func (s *SearxNG) Search(ctx context.Context, query string, count int) ([]Result, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "json")
+	if s.language != "" {
+		params.Set("language", s.language)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := &searxngResponse{}
+	if err := do(s.client, req, resp); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return limit(results, count), nil
+}
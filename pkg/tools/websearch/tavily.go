@@ -0,0 +1,88 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+)
+
+const (
+	TavilyToolName = "Tavily Search"
+	TavilyEndpoint = "https://api.tavily.com/search"
+)
+
+// Tavily searches with the Tavily search API which is built for llm
+type Tavily struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+var _ Searcher = &Tavily{}
+
+// NewTavily creates a Tavily search tool, the apiKey is required
+func NewTavily(tool *v1alpha1.AllowedTool) (*Tool, error) {
+	options, err := OptionsFromParams(tool, TavilyEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	if options.APIKey == "" {
+		return nil, ErrNoAPIKey
+	}
+	return NewTool(TavilyToolName, &Tavily{endpoint: options.Endpoint, apiKey: options.APIKey, client: options.Client}, options.Count), nil
+}
+
+type tavilyRequest struct {
+	Query       string `json:"query"`
+	MaxResults  int    `json:"max_results"`
+	SearchDepth string `json:"search_depth"`
+}
+
+type tavilyResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+func (t *Tavily) Search(ctx context.Context, query string, count int) ([]Result, error) {
+	body, err := json.Marshal(tavilyRequest{Query: query, MaxResults: count, SearchDepth: "basic"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	resp := &tavilyResponse{}
+	if err := do(t.client, req, resp); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, Result{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return limit(results, count), nil
+}
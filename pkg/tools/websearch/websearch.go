@@ -0,0 +1,209 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/tools"
+	"k8s.io/klog/v2"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+)
+
+const (
+	ParamAPIKey   = "apiKey"
+	ParamEndpoint = "endpoint"
+	ParamCount    = "count"
+	ParamTimeout  = "timeout"
+
+	defaultCount   = 5
+	maxCount       = 20
+	defaultTimeout = 10 * time.Second
+	// maxErrorBodyLength limits the body of error responses in errors
+	maxErrorBodyLength = 512
+)
+
+var ErrNoAPIKey = errors.New("apiKey is required")
+
+// Result is a result of web search, it is shown to llm and added to the chat references
+type Result struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// Searcher searches the web with a search engine
+type Searcher interface {
+	Search(ctx context.Context, query string, count int) ([]Result, error)
+}
+
+// Tool searches the web with a Searcher, the results of all calls are recorded
+type Tool struct {
+	name     string
+	searcher Searcher
+	count    int
+
+	mu      sync.Mutex
+	results []Result
+
+	CallbacksHandler callbacks.Handler
+}
+
+var _ tools.Tool = &Tool{}
+
+// NewTool returns a tool with the name, count is the max number of results of each search
+func NewTool(name string, searcher Searcher, count int) *Tool {
+	return &Tool{name: name, searcher: searcher, count: count}
+}
+
+func (t *Tool) Name() string {
+	return t.name
+}
+
+func (t *Tool) Description() string {
+	return "Search the internet for the latest information. Input should be a search query."
+}
+
+func (t *Tool) Call(ctx context.Context, input string) (string, error) {
+	klog.FromContext(ctx).V(3).Info("running tool " + t.name)
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolStart(ctx, input)
+	}
+	results, err := t.searcher.Search(ctx, strings.TrimSpace(input), t.count)
+	if err != nil {
+		if t.CallbacksHandler != nil {
+			t.CallbacksHandler.HandleToolError(ctx, err)
+		}
+		return "", err
+	}
+	t.record(results)
+	output := FormatResults(results)
+	if output == "" {
+		output = "No results found."
+	}
+	if t.CallbacksHandler != nil {
+		t.CallbacksHandler.HandleToolEnd(ctx, output)
+	}
+	return output, nil
+}
+
+func (t *Tool) record(results []Result) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range results {
+		duplicated := false
+		for _, existing := range t.results {
+			if existing.URL == r.URL {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			t.results = append(t.results, r)
+		}
+	}
+}
+
+// Results returns the results of all calls without duplicated URLs
+func (t *Tool) Results() []Result {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Result{}, t.results...)
+}
+
+// FormatResults formats the results for llm
+func FormatResults(results []Result) string {
+	var b strings.Builder
+	for _, r := range results {
+		fmt.Fprintf(&b, "Title: %s\nURL: %s\nSnippet: %s\n\n", r.Title, r.URL, r.Snippet)
+	}
+	return b.String()
+}
+
+// Options are the common options of search engines in the params of tool
+type Options struct {
+	APIKey   string
+	Endpoint string
+	Count    int
+	Client   *http.Client
+}
+
+// OptionsFromParams parses the common params, the endpoint of engine is used if the endpoint is not set
+func OptionsFromParams(tool *v1alpha1.AllowedTool, endpoint string) (Options, error) {
+	options := Options{
+		APIKey:   tool.Params[ParamAPIKey],
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Count:    defaultCount,
+		Client:   &http.Client{Timeout: defaultTimeout},
+	}
+	if v := tool.Params[ParamEndpoint]; v != "" {
+		options.Endpoint = strings.TrimSuffix(v, "/")
+	}
+	if v := tool.Params[ParamCount]; v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil || count < 1 || count > maxCount {
+			return options, fmt.Errorf("invalid count %s, should be between 1 and %d", v, maxCount)
+		}
+		options.Count = count
+	}
+	if v := tool.Params[ParamTimeout]; v != "" {
+		timeout, err := strconv.Atoi(v)
+		if err != nil || timeout < 1 {
+			return options, fmt.Errorf("invalid timeout %s", v)
+		}
+		options.Client.Timeout = time.Duration(timeout) * time.Second
+	}
+	if options.Endpoint == "" {
+		return options, errors.New("endpoint is required")
+	}
+	return options, nil
+}
+
+// do sends the request and decodes the JSON response into result
+func do(client *http.Client, req *http.Request, result any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return fmt.Errorf("search engine responded %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response of search engine: %w", err)
+	}
+	return nil
+}
+
+func limit(results []Result, count int) []Result {
+	if count > 0 && len(results) > count {
+		return results[:count]
+	}
+	return results
+}
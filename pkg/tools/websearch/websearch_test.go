@@ -0,0 +1,168 @@
+/*
+Copyright 2024 KubeAGI.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubeagi/arcadia/api/app-node/agent/v1alpha1"
+)
+
+func spec(params map[string]string) *v1alpha1.AllowedTool {
+	return &v1alpha1.AllowedTool{Params: params}
+}
+
+func TestSearxNG(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/search", r.URL.Path)
+		require.Equal(t, "kubeagi", r.URL.Query().Get("q"))
+		require.Equal(t, "json", r.URL.Query().Get("format"))
+		require.Equal(t, "zh-CN", r.URL.Query().Get("language"))
+		_, _ = w.Write([]byte(`{"results":[
+			{"title":"KubeAGI","url":"https://kubeagi.github.io","content":"a platform"},
+			{"title":"Arcadia","url":"https://github.com/kubeagi/arcadia","content":"the repo"},
+			{"title":"Other","url":"https://example.com","content":"other"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewSearxNG(spec(map[string]string{}))
+	require.Error(t, err)
+
+	tool, err := NewSearxNG(spec(map[string]string{ParamEndpoint: server.URL + "/", ParamLanguage: "zh-CN", ParamCount: "2"}))
+	require.NoError(t, err)
+	out, err := tool.Call(context.Background(), " kubeagi ")
+	require.NoError(t, err)
+	require.Contains(t, out, "Title: KubeAGI\nURL: https://kubeagi.github.io\nSnippet: a platform")
+	require.NotContains(t, out, "example.com")
+
+	// the results of later calls are recorded without duplicated urls
+	_, err = tool.Call(context.Background(), "kubeagi")
+	require.NoError(t, err)
+	require.Equal(t, []Result{
+		{Title: "KubeAGI", URL: "https://kubeagi.github.io", Snippet: "a platform"},
+		{Title: "Arcadia", URL: "https://github.com/kubeagi/arcadia", Snippet: "the repo"},
+	}, tool.Results())
+}
+
+func TestGoogle(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		if query.Get("key") != "key" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":400,"message":"API key not valid"}}`))
+			return
+		}
+		require.Equal(t, "cx", query.Get("cx"))
+		require.Equal(t, "kubeagi", query.Get("q"))
+		items := make([]map[string]string, 0)
+		num := 10
+		if query.Get("start") == "11" {
+			require.Equal(t, "2", query.Get("num"))
+			num = 1
+		} else {
+			require.Equal(t, "1", query.Get("start"))
+			require.Equal(t, "10", query.Get("num"))
+		}
+		for i := 0; i < num; i++ {
+			items = append(items, map[string]string{"title": "title", "link": "https://example.com/" + query.Get("start") + "/" + string(rune('a'+i)), "snippet": "snippet"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+	}))
+	defer server.Close()
+
+	_, err := NewGoogle(spec(map[string]string{ParamAPIKey: "key"}))
+	require.Error(t, err)
+
+	searcher := &Google{endpoint: server.URL, apiKey: "key", searchEngineID: "cx", client: http.DefaultClient}
+	results, err := searcher.Search(context.Background(), "kubeagi", 12)
+	require.NoError(t, err)
+	require.Len(t, results, 11)
+	require.Equal(t, 2, requests)
+
+	tool, err := NewGoogle(spec(map[string]string{ParamAPIKey: "wrong", ParamSearchEngineID: "cx", ParamEndpoint: server.URL}))
+	require.NoError(t, err)
+	_, err = tool.Call(context.Background(), "kubeagi")
+	require.ErrorContains(t, err, "API key not valid")
+}
+
+func TestBaidu(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		if r.Header.Get("Authorization") != "Bearer key" {
+			_, _ = w.Write([]byte(`{"code":"216003","message":"authentication error"}`))
+			return
+		}
+		req := baiduRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "kubeagi", req.Messages[0].Content)
+		require.Equal(t, []baiduResourceLimit{{Type: "web", TopK: 3}}, req.ResourceTypeFilter)
+		_, _ = w.Write([]byte(`{"references":[{"title":"KubeAGI","url":"https://kubeagi.github.io","content":"a platform"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewBaidu(spec(map[string]string{}))
+	require.ErrorIs(t, err, ErrNoAPIKey)
+
+	tool, err := NewBaidu(spec(map[string]string{ParamAPIKey: "key", ParamEndpoint: server.URL, ParamCount: "3"}))
+	require.NoError(t, err)
+	out, err := tool.Call(context.Background(), "kubeagi")
+	require.NoError(t, err)
+	require.Contains(t, out, "https://kubeagi.github.io")
+
+	tool, err = NewBaidu(spec(map[string]string{ParamAPIKey: "wrong", ParamEndpoint: server.URL}))
+	require.NoError(t, err)
+	_, err = tool.Call(context.Background(), "kubeagi")
+	require.ErrorContains(t, err, "authentication error")
+}
+
+func TestTavily(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"detail":{"error":"Unauthorized"}}`))
+			return
+		}
+		req := tavilyRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, tavilyRequest{Query: "kubeagi", MaxResults: defaultCount, SearchDepth: "basic"}, req)
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewTavily(spec(map[string]string{ParamAPIKey: "key", ParamCount: "100"}))
+	require.Error(t, err)
+
+	tool, err := NewTavily(spec(map[string]string{ParamAPIKey: "key", ParamEndpoint: server.URL}))
+	require.NoError(t, err)
+	out, err := tool.Call(context.Background(), "kubeagi")
+	require.NoError(t, err)
+	require.Equal(t, "No results found.", out)
+	require.Empty(t, tool.Results())
+
+	tool, err = NewTavily(spec(map[string]string{ParamAPIKey: "wrong", ParamEndpoint: server.URL}))
+	require.NoError(t, err)
+	_, err = tool.Call(context.Background(), "kubeagi")
+	require.ErrorContains(t, err, "401 Unauthorized")
+}